@@ -180,6 +180,7 @@ func (b *classBuilder) additionalFields(classProperties graphql.Fields, class *m
 	additionalProperties["score"] = b.additionalScoreField()
 	additionalProperties["explainScore"] = b.additionalExplainScoreField()
 	additionalProperties["group"] = b.additionalGroupField(classProperties, class)
+	additionalProperties["collection"] = b.additionalCollectionField()
 	if replicationEnabled(class) {
 		additionalProperties["isConsistent"] = b.isConsistentField()
 	}
@@ -282,6 +283,14 @@ func (b *classBuilder) additionalLastUpdateTimeUnix() *graphql.Field {
 	}
 }
 
+// additionalCollectionField holds the collection a result originates from,
+// it is only set on searches spanning multiple collections
+func (b *classBuilder) additionalCollectionField() *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.String,
+	}
+}
+
 func (b *classBuilder) isConsistentField() *graphql.Field {
 	return &graphql.Field{
 		Type: graphql.Boolean,
@@ -369,7 +369,7 @@ func (r *resolver) resolveGet(p graphql.ResolveParams, className string) (interf
 	selectionsOfClass := p.Info.FieldASTs[0].SelectionSet
 
 	properties, addlProps, groupByProperties, err := extractProperties(
-		className, selectionsOfClass, p.Info.Fragments, r.modulesProvider)
+		className, selectionsOfClass, p.Info.Fragments, p.Info.VariableValues, r.modulesProvider)
 	if err != nil {
 		return nil, err
 	}
@@ -589,7 +589,7 @@ func (ac *additionalCheck) isAdditional(parentName, name string) bool {
 			name == "distance" || name == "id" || name == "vector" || name == "vectors" ||
 			name == "creationTimeUnix" || name == "lastUpdateTimeUnix" ||
 			name == "score" || name == "explainScore" || name == "isConsistent" ||
-			name == "group" {
+			name == "group" || name == "collection" {
 			return true
 		}
 		if ac.isModuleAdditional(name) {
@@ -627,7 +627,7 @@ func fieldNameIsOfObjectButNonReferenceType(field string) bool {
 }
 
 func extractProperties(className string, selections *ast.SelectionSet,
-	fragments map[string]ast.Definition,
+	fragments map[string]ast.Definition, variables map[string]interface{},
 	modulesProvider ModulesProvider,
 ) ([]search.SelectProperty, additional.Properties, []search.SelectProperty, error) {
 	var properties []search.SelectProperty
@@ -707,7 +707,7 @@ func extractProperties(className string, selections *ast.SelectionSet,
 						if additionalProperty == "group" {
 							additionalProps.Group = true
 							var err error
-							additionalGroupHitProperties, err = extractGroupHitProperties(className, additionalProps, subSelection, fragments, modulesProvider)
+							additionalGroupHitProperties, err = extractGroupHitProperties(className, additionalProps, subSelection, fragments, variables, modulesProvider)
 							if err != nil {
 								return nil, additionalProps, nil, err
 							}
@@ -716,7 +716,8 @@ func extractProperties(className string, selections *ast.SelectionSet,
 						if modulesProvider != nil {
 							if additionalCheck.isModuleAdditional(additionalProperty) {
 								additionalProps.ModuleParams = getModuleParams(additionalProps.ModuleParams)
-								extracted := modulesProvider.ExtractAdditionalField(className, additionalProperty, s.Arguments)
+								extracted := modulesProvider.ExtractAdditionalField(className, additionalProperty,
+									inlineVariables(s.Arguments, variables))
 								if extractor, ok := extracted.(moduleadditional.PropertyExtractor); ok {
 									extractedProperties := extractor.GetPropertiesToExtract()
 									for _, extractedProperty := range extractedProperties {
@@ -733,7 +734,7 @@ func extractProperties(className string, selections *ast.SelectionSet,
 					}
 
 				case *ast.FragmentSpread:
-					ref, err := extractFragmentSpread(className, s, fragments, modulesProvider)
+					ref, err := extractFragmentSpread(className, s, fragments, variables, modulesProvider)
 					if err != nil {
 						return nil, additionalProps, nil, err
 					}
@@ -741,7 +742,7 @@ func extractProperties(className string, selections *ast.SelectionSet,
 					property.Refs = append(property.Refs, ref)
 
 				case *ast.InlineFragment:
-					ref, err := extractInlineFragment(className, s, fragments, modulesProvider)
+					ref, err := extractInlineFragment(className, s, fragments, variables, modulesProvider)
 					if err != nil {
 						return nil, additionalProps, nil, err
 					}
@@ -769,6 +770,7 @@ func extractGroupHitProperties(
 	additionalProps additional.Properties,
 	subSelection ast.Selection,
 	fragments map[string]ast.Definition,
+	variables map[string]interface{},
 	modulesProvider ModulesProvider,
 ) ([]search.SelectProperty, error) {
 	additionalGroupProperties := []search.SelectProperty{}
@@ -783,7 +785,7 @@ func extractGroupHitProperties(
 									if hf.SelectionSet != nil {
 										for _, ss := range hf.SelectionSet.Selections {
 											if inlineFrag, ok := ss.(*ast.InlineFragment); ok {
-												ref, err := extractInlineFragment(className, inlineFrag, fragments, modulesProvider)
+												ref, err := extractInlineFragment(className, inlineFrag, fragments, variables, modulesProvider)
 												if err != nil {
 													return nil, err
 												}
@@ -815,7 +817,7 @@ func getModuleParams(moduleParams map[string]interface{}) map[string]interface{}
 }
 
 func extractInlineFragment(class string, fragment *ast.InlineFragment,
-	fragments map[string]ast.Definition,
+	fragments map[string]ast.Definition, variables map[string]interface{},
 	modulesProvider ModulesProvider,
 ) (search.SelectClass, error) {
 	var className schema.ClassName
@@ -837,7 +839,7 @@ func extractInlineFragment(class string, fragment *ast.InlineFragment,
 		return result, fmt.Errorf("retrieving cross-refs by beacon is not supported yet - coming soon!")
 	}
 
-	subProperties, additionalProperties, _, err := extractProperties(class, fragment.SelectionSet, fragments, modulesProvider)
+	subProperties, additionalProperties, _, err := extractProperties(class, fragment.SelectionSet, fragments, variables, modulesProvider)
 	if err != nil {
 		return result, err
 	}
@@ -849,7 +851,7 @@ func extractInlineFragment(class string, fragment *ast.InlineFragment,
 }
 
 func extractFragmentSpread(class string, spread *ast.FragmentSpread,
-	fragments map[string]ast.Definition,
+	fragments map[string]ast.Definition, variables map[string]interface{},
 	modulesProvider ModulesProvider,
 ) (search.SelectClass, error) {
 	var result search.SelectClass
@@ -865,7 +867,7 @@ func extractFragmentSpread(class string, spread *ast.FragmentSpread,
 		return result, err
 	}
 
-	subProperties, additionalProperties, _, err := extractProperties(class, def.GetSelectionSet(), fragments, modulesProvider)
+	subProperties, additionalProperties, _, err := extractProperties(class, def.GetSelectionSet(), fragments, variables, modulesProvider)
 	if err != nil {
 		return result, err
 	}
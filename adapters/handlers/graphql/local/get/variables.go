@@ -0,0 +1,127 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package get
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/tailor-inc/graphql/language/ast"
+)
+
+// inlineVariables returns args with all variables replaced by literals of
+// their values. Modules extract the arguments of their additional properties
+// from the AST, which holds the variables unresolved. Variables without a
+// value, or with a value that has no literal, are kept. args is not modified.
+func inlineVariables(args []*ast.Argument, variables map[string]interface{}) []*ast.Argument {
+	if len(variables) == 0 {
+		return args
+	}
+
+	out := make([]*ast.Argument, len(args))
+	for i, arg := range args {
+		out[i] = ast.NewArgument(&ast.Argument{
+			Loc:   arg.Loc,
+			Name:  arg.Name,
+			Value: inlineVariablesOfValue(arg.Value, variables),
+		})
+	}
+	return out
+}
+
+func inlineVariablesOfValue(value ast.Value, variables map[string]interface{}) ast.Value {
+	switch v := value.(type) {
+	case *ast.Variable:
+		if literal := literalOf(variables[v.Name.Value]); literal != nil {
+			return literal
+		}
+		return v
+	case *ast.ObjectValue:
+		fields := make([]*ast.ObjectField, len(v.Fields))
+		for i, field := range v.Fields {
+			fields[i] = ast.NewObjectField(&ast.ObjectField{
+				Loc:   field.Loc,
+				Name:  field.Name,
+				Value: inlineVariablesOfValue(field.Value, variables),
+			})
+		}
+		return ast.NewObjectValue(&ast.ObjectValue{Loc: v.Loc, Fields: fields})
+	case *ast.ListValue:
+		values := make([]ast.Value, len(v.Values))
+		for i, item := range v.Values {
+			values[i] = inlineVariablesOfValue(item, variables)
+		}
+		return ast.NewListValue(&ast.ListValue{Loc: v.Loc, Values: values})
+	default:
+		return value
+	}
+}
+
+// literalOf converts the value of a variable, as decoded from the JSON
+// request, to a literal. It returns nil for values without a literal, e.g.
+// null.
+func literalOf(value interface{}) ast.Value {
+	switch v := value.(type) {
+	case string:
+		return ast.NewStringValue(&ast.StringValue{Value: v})
+	case bool:
+		return ast.NewBooleanValue(&ast.BooleanValue{Value: v})
+	case int:
+		return ast.NewIntValue(&ast.IntValue{Value: strconv.Itoa(v)})
+	case int64:
+		return ast.NewIntValue(&ast.IntValue{Value: strconv.FormatInt(v, 10)})
+	case float64:
+		// JSON doesn't distinguish integers, integral numbers are passed as
+		// ints so that they can be used for Int arguments
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return ast.NewIntValue(&ast.IntValue{Value: strconv.FormatInt(int64(v), 10)})
+		}
+		return ast.NewFloatValue(&ast.FloatValue{Value: strconv.FormatFloat(v, 'g', -1, 64)})
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return ast.NewIntValue(&ast.IntValue{Value: v.String()})
+		}
+		return ast.NewFloatValue(&ast.FloatValue{Value: v.String()})
+	case []interface{}:
+		values := make([]ast.Value, len(v))
+		for i, item := range v {
+			if values[i] = literalOf(item); values[i] == nil {
+				return nil
+			}
+		}
+		return ast.NewListValue(&ast.ListValue{Values: values})
+	case map[string]interface{}:
+		// sorted, so that the literal doesn't depend on the map order
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fields := make([]*ast.ObjectField, len(names))
+		for i, name := range names {
+			literal := literalOf(v[name])
+			if literal == nil {
+				return nil
+			}
+			fields[i] = ast.NewObjectField(&ast.ObjectField{
+				Name:  ast.NewName(&ast.Name{Value: name}),
+				Value: literal,
+			})
+		}
+		return ast.NewObjectValue(&ast.ObjectValue{Fields: fields})
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,70 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package get
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tailor-inc/graphql/language/ast"
+	"github.com/tailor-inc/graphql/language/parser"
+)
+
+func TestInlineVariables(t *testing.T) {
+	doc, err := parser.Parse(parser.ParseParams{Source: `query($prompt: String, $options: OllamaOptions, $topK: Int, $missing: Float) {
+		generate(singleResult: {prompt: $prompt, ollama: {options: $options, topK: $topK, temperature: $missing, model: "llama3"}})
+	}`})
+	require.Nil(t, err)
+	args := doc.Definitions[0].(*ast.OperationDefinition).SelectionSet.Selections[0].(*ast.Field).Arguments
+
+	variables := map[string]interface{}{
+		"prompt":  "What is {name}?",
+		"options": map[string]interface{}{"num_ctx": 4096.0, "stop": []interface{}{"\n"}, "mirostat_tau": 5.5},
+		"topK":    40.0,
+	}
+	inlined := inlineVariables(args, variables)
+
+	field := func(value ast.Value, name string) ast.Value {
+		for _, f := range value.(*ast.ObjectValue).Fields {
+			if f.Name.Value == name {
+				return f.Value
+			}
+		}
+		t.Fatalf("field %s not found", name)
+		return nil
+	}
+
+	singleResult := inlined[0].Value
+	assert.Equal(t, "What is {name}?", field(singleResult, "prompt").(*ast.StringValue).Value)
+
+	ollama := field(singleResult, "ollama")
+	assert.Equal(t, "40", field(ollama, "topK").(*ast.IntValue).Value)
+	assert.Equal(t, "llama3", field(ollama, "model").(*ast.StringValue).Value)
+	// variables without a value are kept
+	assert.IsType(t, &ast.Variable{}, field(ollama, "temperature"))
+
+	options := field(ollama, "options")
+	assert.Equal(t, "5.5", field(options, "mirostat_tau").(*ast.FloatValue).Value)
+	assert.Equal(t, "4096", field(options, "num_ctx").(*ast.IntValue).Value)
+	stop := field(options, "stop").(*ast.ListValue)
+	require.Len(t, stop.Values, 1)
+	assert.Equal(t, "\n", stop.Values[0].(*ast.StringValue).Value)
+
+	t.Run("arguments are not modified", func(t *testing.T) {
+		assert.IsType(t, &ast.Variable{}, field(args[0].Value, "prompt"))
+	})
+
+	t.Run("without variables", func(t *testing.T) {
+		assert.Equal(t, args, inlineVariables(args, nil))
+	})
+}
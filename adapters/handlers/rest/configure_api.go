@@ -742,6 +742,11 @@ func configureAPI(api *operations.WeaviateAPI) http.Handler {
 		// gracefully stop gRPC server
 		grpcServer.GracefulStop()
 
+		if err := appState.Modules.Close(); err != nil {
+			appState.Logger.WithField("action", "shutdown").WithError(err).
+				Error("failed to close modules")
+		}
+
 		if appState.ServerConfig.Config.Sentry.Enabled {
 			sentry.Flush(2 * time.Second)
 		}
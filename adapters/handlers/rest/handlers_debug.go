@@ -13,6 +13,7 @@ package rest
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
 	"os"
@@ -23,6 +24,7 @@ import (
 	"github.com/weaviate/weaviate/adapters/repos/db"
 	"github.com/weaviate/weaviate/entities/config"
 	"github.com/weaviate/weaviate/entities/schema"
+	"github.com/weaviate/weaviate/usecases/cluster"
 )
 
 func setupDebugHandlers(appState *state.State) {
@@ -240,6 +242,88 @@ func setupDebugHandlers(appState *state.State) {
 		w.Write(jsonBytes)
 	}))
 
+	// Returns the stats and the measured bloom filter false positive rate of the
+	// segment group of a single bucket. Call via something like:
+	// curl -u user:pass localhost:6060/debug/lsmkv/MyCollection/myShard/objects/segment-group
+	// The credentials are the cluster API basic auth credentials, if configured.
+	// The collection only holds the read side of the segment group's maintenance
+	// lock and gives up after 5s, e.g. while a compaction switches segments.
+	http.HandleFunc("/debug/lsmkv/", debugBasicAuth(appState.ServerConfig.Config.Cluster.AuthConfig.BasicAuth,
+		func(w http.ResponseWriter, r *http.Request) {
+			path := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/debug/lsmkv/"))
+			parts := strings.Split(path, "/")
+			if len(parts) != 4 || parts[3] != "segment-group" {
+				logger.WithField("parts", parts).Info("invalid path")
+				http.Error(w, "invalid path", http.StatusNotFound)
+				return
+			}
+
+			colName, shardName, bucketName := parts[0], parts[1], parts[2]
+
+			idx := appState.DB.GetIndex(schema.ClassName(colName))
+			if idx == nil {
+				logger.WithField("collection", colName).Error("collection not found")
+				http.Error(w, "collection not found", http.StatusNotFound)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+			defer cancel()
+
+			shard, release, err := idx.GetShard(ctx, shardName)
+			if err != nil {
+				logger.WithField("shard", shardName).Error(err)
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if shard == nil {
+				logger.WithField("shard", shardName).Error("shard not found")
+				http.Error(w, "shard not found", http.StatusNotFound)
+				return
+			}
+			defer release()
+
+			bucket := shard.Store().Bucket(bucketName)
+			if bucket == nil {
+				logger.WithField("bucket", bucketName).Error("bucket not found")
+				http.Error(w, "bucket not found", http.StatusNotFound)
+				return
+			}
+
+			info, err := bucket.SegmentGroupDebugInfo(ctx, 1000)
+			if err != nil {
+				logger.WithField("bucket", bucketName).WithError(err).Error("segment group debug info failed")
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+
+			jsonBytes, err := json.Marshal(info)
+			if err != nil {
+				logger.WithError(err).Error("marshal failed on segment group debug info")
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(jsonBytes)
+		}))
+
+	// Call via something like: curl -X GET localhost:6060/debug/modules/health
+	// The port is Weaviate's configured Go profiling port (defaults to 6060).
+	// It is not part of the readiness probe, so that a failing module
+	// dependency doesn't make the node unready.
+	http.HandleFunc("/debug/modules/health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if appState.Modules == nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if err := appState.Modules.Health(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
 	// Call via something like: curl -X GET localhost:6060/debug/config/maintenance_mode (can replace GET w/ POST or DELETE)
 	// The port is Weaviate's configured Go profiling port (defaults to 6060)
 	http.HandleFunc("/debug/config/maintenance_mode", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -267,6 +351,26 @@ func setupDebugHandlers(appState *state.State) {
 	}))
 }
 
+// debugBasicAuth protects handler with the given basic auth credentials. If
+// they are not configured, handler is returned as is.
+func debugBasicAuth(basicAuth cluster.BasicAuth, handler http.HandlerFunc) http.HandlerFunc {
+	if !basicAuth.Enabled() {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		// both are compared in constant time, so the response time doesn't
+		// reveal how much of the credentials matched
+		usernameMatches := subtle.ConstantTimeCompare([]byte(u), []byte(basicAuth.Username)) == 1
+		passwordMatches := subtle.ConstantTimeCompare([]byte(p), []byte(basicAuth.Password)) == 1
+		if ok && usernameMatches && passwordMatches {
+			handler(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+}
+
 type MaintenanceMode struct {
 	Enabled bool `json:"enabled"`
 }
@@ -0,0 +1,64 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weaviate/weaviate/usecases/cluster"
+)
+
+func TestDebugBasicAuth(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	tcs := map[string]struct {
+		basicAuth      cluster.BasicAuth
+		user, password string
+		expectedStatus int
+	}{
+		"auth disabled": {
+			expectedStatus: http.StatusOK,
+		},
+		"valid credentials": {
+			basicAuth:      cluster.BasicAuth{Username: "user", Password: "pass"},
+			user:           "user",
+			password:       "pass",
+			expectedStatus: http.StatusOK,
+		},
+		"invalid credentials": {
+			basicAuth:      cluster.BasicAuth{Username: "user", Password: "pass"},
+			user:           "user",
+			password:       "wrong",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"missing credentials": {
+			basicAuth:      cluster.BasicAuth{Username: "user", Password: "pass"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/debug/lsmkv/", nil)
+			if tc.user != "" {
+				r.SetBasicAuth(tc.user, tc.password)
+			}
+			w := httptest.NewRecorder()
+
+			debugBasicAuth(tc.basicAuth, ok)(w, r)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
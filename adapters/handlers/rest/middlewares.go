@@ -237,6 +237,9 @@ func addLiveAndReadyness(state *state.State, next http.Handler) http.Handler {
 			} else if !state.ClusterService.Ready() || state.Cluster.ClusterHealthScore() != 0 {
 				code = http.StatusServiceUnavailable
 			} else if state.Modules != nil {
+				// module health checks are diagnostics only, see
+				// /debug/modules/health. A failing module dependency must not
+				// take the whole node out of rotation.
 				_, err := state.Modules.GetMeta()
 				if err != nil {
 					code = http.StatusServiceUnavailable
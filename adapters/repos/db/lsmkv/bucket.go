@@ -22,11 +22,13 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/weaviate/sroar"
 	"github.com/weaviate/weaviate/adapters/repos/db/helpers"
@@ -87,6 +89,8 @@ type Bucket struct {
 
 	status     storagestate.Status
 	statusLock sync.RWMutex
+	// readOnly mirrors status, so that writes don't need the statusLock
+	readOnly atomic.Bool
 
 	metrics *Metrics
 
@@ -136,10 +140,79 @@ type Bucket struct {
 	// (currently supported only in buckets of REPLACE strategy)
 	segmentsCleanupInterval time.Duration
 
+	// optional bound of the number of newer segments a single cleanup checks
+	// a segment against, so that cleanups are spread over multiple cycles
+	segmentsCleanupBatchSize int
+
 	// optional validation of segment file checksums. Enabling this option
 	// introduces latency of segment availability, for the tradeoff of
 	// ensuring segment files have integrity before reading them.
 	enableChecksumValidation bool
+
+	// optional limit of segment files held open at the same time. If exceeded,
+	// the least recently read segments are closed and lazily reopened on their
+	// next read.
+	maxOpenSegmentFiles int
+
+	// retries of failed segment reads when using pread, to ride out transient
+	// storage errors
+	maxReadRetries int
+	readRetryDelay time.Duration
+
+	// reads slower than this threshold are logged at debug level
+	slowPathThreshold time.Duration
+
+	// reads give up waiting for the maintenance lock after this timeout, 0
+	// waits indefinitely
+	lockTimeout time.Duration
+
+	// what to do with segment files too small to be mounted, quarantine by
+	// default
+	invalidSegmentPolicy InvalidSegmentPolicy
+
+	// what collection reads do when a segment fails, the zero value is strict
+	collectionReadErrorPolicy CollectionReadErrorPolicy
+
+	// ranks pairs of segments for compaction, DefaultCompactionScorer if nil
+	compactionScorer CompactionScorer
+
+	// compaction starts once no segment was added for idleCompactionDelay and
+	// there are more than idleCompactionThreshold segments, disabled if 0
+	idleCompactionThreshold int
+	idleCompactionDelay     time.Duration
+
+	// flushes take precedence over compactions when contending for the
+	// flushVsCompactLock of the segment group
+	prioritizeFlush bool
+
+	// optional hook invoked after each successful compaction
+	onCompactionComplete func(CompactionResult)
+
+	// in-flight compactions are aborted on shutdown after this timeout, 0
+	// waits for them as long as the shutdown context allows
+	compactionShutdownTimeout time.Duration
+
+	// read metrics are labeled by the hex encoded first keyPrefixMetricsLen
+	// bytes of the key, disabled if 0
+	keyPrefixMetricsLen int
+
+	// limits the write throughput of compactions, unlimited if 0
+	compactionBytesPerSecond int64
+
+	// compactions are paused for this delay after memory pressure, the
+	// default is used if 0
+	compactionMemoryBackoff time.Duration
+
+	// number of keys the negative cache of the disk segments holds, disabled
+	// if 0
+	negativeCacheSize int
+
+	// see WithCompactionProgress
+	compactionProgress func() chan<- CompactionProgress
+	progressInterval   time.Duration
+
+	// concurrent SegmentGroupDebugInfo calls share a single collection
+	debugInfoFlight singleflight.Group
 }
 
 func NewBucketCreator() *Bucket { return &Bucket{} }
@@ -176,6 +249,10 @@ func (*Bucket) NewBucket(ctx context.Context, dir, rootDir string, logger logrus
 		metrics:               metrics,
 		useBloomFilter:        true,
 		calcCountNetAdditions: true,
+		maxReadRetries:        defaultMaxReadRetries,
+		readRetryDelay:        defaultReadRetryDelay,
+		slowPathThreshold:     defaultSlowPathThreshold,
+		invalidSegmentPolicy:  InvalidSegmentPolicyQuarantine,
 		haltedFlushTimer:      interval.NewBackoffTimer(),
 	}
 
@@ -189,20 +266,44 @@ func (*Bucket) NewBucket(ctx context.Context, dir, rootDir string, logger logrus
 		b.memtableThreshold = uint64(b.memtableResizer.Initial())
 	}
 
+	if b.mmapContents {
+		b.disableMmapOnNetworkFilesystem()
+	}
+
 	sg, err := newSegmentGroup(logger, metrics, compactionCallbacks,
 		sgConfig{
-			dir:                      dir,
-			strategy:                 b.strategy,
-			mapRequiresSorting:       b.legacyMapSortingBeforeCompaction,
-			monitorCount:             b.monitorCount,
-			mmapContents:             b.mmapContents,
-			keepTombstones:           b.keepTombstones,
-			forceCompaction:          b.forceCompaction,
-			useBloomFilter:           b.useBloomFilter,
-			calcCountNetAdditions:    b.calcCountNetAdditions,
-			maxSegmentSize:           b.maxSegmentSize,
-			cleanupInterval:          b.segmentsCleanupInterval,
-			enableChecksumValidation: b.enableChecksumValidation,
+			dir:                       dir,
+			strategy:                  b.strategy,
+			mapRequiresSorting:        b.legacyMapSortingBeforeCompaction,
+			monitorCount:              b.monitorCount,
+			mmapContents:              b.mmapContents,
+			keepTombstones:            b.keepTombstones,
+			forceCompaction:           b.forceCompaction,
+			useBloomFilter:            b.useBloomFilter,
+			calcCountNetAdditions:     b.calcCountNetAdditions,
+			maxSegmentSize:            b.maxSegmentSize,
+			cleanupInterval:           b.segmentsCleanupInterval,
+			cleanupBatchSize:          b.segmentsCleanupBatchSize,
+			enableChecksumValidation:  b.enableChecksumValidation,
+			maxOpenSegmentFiles:       b.maxOpenSegmentFiles,
+			maxReadRetries:            b.maxReadRetries,
+			readRetryDelay:            b.readRetryDelay,
+			slowPathThreshold:         b.slowPathThreshold,
+			lockTimeout:               b.lockTimeout,
+			invalidSegmentPolicy:      b.invalidSegmentPolicy,
+			collectionReadErrorPolicy: b.collectionReadErrorPolicy,
+			compactionScorer:          b.compactionScorer,
+			idleCompactionThreshold:   b.idleCompactionThreshold,
+			idleCompactionDelay:       b.idleCompactionDelay,
+			prioritizeFlush:           b.prioritizeFlush,
+			onCompactionComplete:      b.onCompactionComplete,
+			compactionShutdownTimeout: b.compactionShutdownTimeout,
+			keyPrefixMetricsLen:       b.keyPrefixMetricsLen,
+			compactionBytesPerSecond:  b.compactionBytesPerSecond,
+			compactionMemoryBackoff:   b.compactionMemoryBackoff,
+			negativeCacheSize:         b.negativeCacheSize,
+			compactionProgress:        b.compactionProgress,
+			progressInterval:          b.progressInterval,
 		}, b.allocChecker)
 	if err != nil {
 		return nil, fmt.Errorf("init disk segments: %w", err)
@@ -430,6 +531,51 @@ func (b *Bucket) get(key []byte) ([]byte, error) {
 	return b.disk.get(key)
 }
 
+// GetMany is the bulk variant of [Bucket.Get]. values[i] is the value of
+// keys[i], nil if the key does not exist or was deleted. Keys which are not
+// found in the memtables are looked up in the disk segments together, which
+// is cheaper than one Get per key for large batches.
+func (b *Bucket) GetMany(keys [][]byte) ([][]byte, error) {
+	b.flushLock.RLock()
+	defer b.flushLock.RUnlock()
+
+	values := make([][]byte, len(keys))
+
+	var diskKeys [][]byte
+	var diskPos []int
+	for i, key := range keys {
+		v, err := b.active.get(key)
+		if errors.Is(err, lsmkv.NotFound) && b.flushing != nil {
+			v, err = b.flushing.get(key)
+		}
+
+		switch {
+		case err == nil:
+			values[i] = v
+		case errors.Is(err, lsmkv.Deleted):
+			// deleted in a memtable, which is newer than all segments
+		case errors.Is(err, lsmkv.NotFound):
+			diskKeys = append(diskKeys, key)
+			diskPos = append(diskPos, i)
+		default:
+			return nil, fmt.Errorf("get from memtable: %w", err)
+		}
+	}
+
+	if len(diskKeys) == 0 {
+		return values, nil
+	}
+
+	diskValues, err := b.disk.GetMany(diskKeys)
+	if err != nil {
+		return nil, err
+	}
+	for i, pos := range diskPos {
+		values[pos] = diskValues[i]
+	}
+	return values, nil
+}
+
 func (b *Bucket) GetErrDeleted(key []byte) ([]byte, error) {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
@@ -568,21 +714,31 @@ func (b *Bucket) GetBySecondaryIntoMemory(pos int, key []byte, buffer []byte) ([
 // SetList is specific to the Set Strategy, for Map use [Bucket.MapList], and
 // for Replace use [Bucket.Get].
 func (b *Bucket) SetList(key []byte) ([][]byte, error) {
+	out, _, err := b.SetListPartial(key)
+	return out, err
+}
+
+// SetListPartial is like [Bucket.SetList], but additionally reports whether
+// disk segments which could not be read were skipped. This can only be the
+// case with CollectionReadErrorPolicyTolerant, see
+// [WithCollectionReadErrorPolicy]. Entries which were deleted in a skipped
+// segment may then be part of the result.
+func (b *Bucket) SetListPartial(key []byte) ([][]byte, bool, error) {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
 	var out []value
 
-	v, err := b.disk.getCollection(key)
+	v, partial, err := b.disk.getCollection(key)
 	if err != nil && !errors.Is(err, lsmkv.NotFound) {
-		return nil, err
+		return nil, false, err
 	}
 	out = v
 
 	if b.flushing != nil {
 		v, err = b.flushing.getCollection(key)
 		if err != nil && !errors.Is(err, lsmkv.NotFound) {
-			return nil, err
+			return nil, false, err
 		}
 		out = append(out, v...)
 
@@ -590,14 +746,14 @@ func (b *Bucket) SetList(key []byte) ([][]byte, error) {
 
 	v, err = b.active.getCollection(key)
 	if err != nil && !errors.Is(err, lsmkv.NotFound) {
-		return nil, err
+		return nil, false, err
 	}
 	if len(v) > 0 {
 		// skip the expensive append operation if there was no memtable
 		out = append(out, v...)
 	}
 
-	return newSetDecoder().Do(out), nil
+	return newSetDecoder().Do(out), partial, nil
 }
 
 // Put creates or replaces a single value for a given key.
@@ -623,6 +779,10 @@ func (b *Bucket) Put(key, value []byte, opts ...SecondaryKeyOption) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	return b.active.put(key, value, opts...)
 }
 
@@ -645,6 +805,10 @@ func (b *Bucket) SetAdd(key []byte, values [][]byte) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	return b.active.append(key, newSetEncoder().Do(values))
 }
 
@@ -664,6 +828,10 @@ func (b *Bucket) SetDeleteSingle(key []byte, valueToDelete []byte) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	return b.active.append(key, []value{
 		{
 			value:     valueToDelete,
@@ -928,6 +1096,10 @@ func (b *Bucket) MapSet(rowKey []byte, kv MapPair) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	return b.active.appendMapSorted(rowKey, kv)
 }
 
@@ -937,6 +1109,10 @@ func (b *Bucket) MapSetMulti(rowKey []byte, kvs []MapPair) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	for _, kv := range kvs {
 		if err := b.active.appendMapSorted(rowKey, kv); err != nil {
 			return err
@@ -961,6 +1137,10 @@ func (b *Bucket) MapDeleteKey(rowKey, mapKey []byte) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	pair := MapPair{
 		Key:       mapKey,
 		Tombstone: true,
@@ -984,13 +1164,63 @@ func (b *Bucket) Delete(key []byte, opts ...SecondaryKeyOption) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	return b.active.setTombstone(key, opts...)
 }
 
+// SoftDelete deletes the key like Delete, but additionally reclaims the disk
+// space of its value in the existing segments right away instead of waiting
+// for compaction. The value bytes are replaced with a sparse region of zeros,
+// so the segment layout is unchanged and the tombstone keeps read semantics
+// intact. This is meant for large values, for small values the savings are
+// limited by the file system's block size.
+//
+// The tombstone is fsynced before any segment is modified, as otherwise a
+// crash could surface the zeroed value. Reclaiming is skipped for segments
+// with checksums and on platforms or file systems which do not support
+// sparse writes. Only available for the "replace" strategy.
+func (b *Bucket) SoftDelete(key []byte, opts ...SecondaryKeyOption) error {
+	if b.strategy != StrategyReplace {
+		return fmt.Errorf("soft delete only possible for strategy %q", StrategyReplace)
+	}
+
+	if err := b.softDeleteTombstone(key, opts...); err != nil {
+		return err
+	}
+
+	if _, err := b.disk.reclaimValue(key); err != nil {
+		return fmt.Errorf("reclaim value of soft deleted key: %w", err)
+	}
+
+	return nil
+}
+
+func (b *Bucket) softDeleteTombstone(key []byte, opts ...SecondaryKeyOption) error {
+	b.flushLock.RLock()
+	defer b.flushLock.RUnlock()
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	if err := b.active.setTombstone(key, opts...); err != nil {
+		return err
+	}
+
+	return b.active.syncWAL()
+}
+
 func (b *Bucket) DeleteWith(key []byte, deletionTime time.Time, opts ...SecondaryKeyOption) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	if !b.keepTombstones {
 		return fmt.Errorf("bucket requires option `keepTombstones` set to delete keys at a given timestamp")
 	}
@@ -1003,7 +1233,7 @@ func (b *Bucket) DeleteWith(key []byte, deletionTime time.Time, opts ...Secondar
 func (b *Bucket) setNewActiveMemtable() error {
 	path := filepath.Join(b.dir, fmt.Sprintf("segment-%d", time.Now().UnixNano()))
 
-	cl, err := newCommitLogger(path)
+	cl, err := newCommitLogger(path, b.logger)
 	if err != nil {
 		return errors.Wrap(err, "init commit logger")
 	}
@@ -1188,9 +1418,20 @@ func (b *Bucket) UpdateStatus(status storagestate.Status) {
 	defer b.statusLock.Unlock()
 
 	b.status = status
+	b.readOnly.Store(status == storagestate.StatusReadOnly)
 	b.disk.UpdateStatus(status)
 }
 
+// checkWritable returns an error if the bucket is read-only. Writes call it
+// while holding the flushLock, so that no write ends up in a memtable after it
+// was flushed by MarkReadOnlyAndFlush.
+func (b *Bucket) checkWritable() error {
+	if b.readOnly.Load() {
+		return storagestate.ErrStatusReadOnly
+	}
+	return nil
+}
+
 func (b *Bucket) isReadOnly() bool {
 	b.statusLock.Lock()
 	defer b.statusLock.Unlock()
@@ -1317,10 +1558,10 @@ func (b *Bucket) atomicallyAddDiskSegmentAndRemoveFlushing(seg *segment) error {
 	}
 	b.flushing = nil
 
-	if b.strategy == StrategyReplace && b.monitorCount {
+	if b.monitorCount {
 		// having just flushed the memtable we now have the most up2date count which
 		// is a good place to update the metric
-		b.metrics.ObjectCount(b.disk.count())
+		b.disk.observeCount()
 	}
 
 	return nil
@@ -1594,3 +1835,26 @@ func addDataToTerm(mem []MapPair, filterDocIds helpers.AllowList, term *terms.Te
 	term.SetIdPointer(term.Data[0].Id)
 	return n, nil
 }
+
+// disableMmapOnNetworkFilesystem switches the bucket to pread if its directory
+// is located on a network filesystem, where accessing a memory-mapped segment
+// can crash the process with SIGBUS, see WithPread.
+func (b *Bucket) disableMmapOnNetworkFilesystem() {
+	fs, err := detectNetworkFilesystem(b.dir)
+	if err != nil {
+		b.logger.WithField("action", "lsm_detect_filesystem").
+			WithField("path", b.dir).
+			WithError(err).
+			Warn("failed to detect filesystem type, keeping mmap enabled")
+		return
+	}
+	if fs == "" {
+		return
+	}
+
+	b.logger.WithField("action", "lsm_detect_filesystem").
+		WithField("path", b.dir).
+		WithField("filesystem", fs).
+		Warnf("bucket is located on a network filesystem (%s), using pread instead of mmap", fs)
+	b.mmapContents = false
+}
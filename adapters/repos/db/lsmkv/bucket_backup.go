@@ -33,6 +33,11 @@ func (b *Bucket) FlushMemtable() error {
 		return errors.Wrap(storagestate.ErrStatusReadOnly, "flush memtable")
 	}
 
+	return b.flushMemtable()
+}
+
+// flushMemtable is FlushMemtable without the read-only check
+func (b *Bucket) flushMemtable() error {
 	// this lock does not currently _need_ to be
 	// obtained, as the only other place that
 	// grabs this lock is the flush cycle, which
@@ -83,6 +88,19 @@ func (b *Bucket) ListFiles(ctx context.Context, basePath string) ([]string, erro
 		if filepath.Ext(currPath) == ".wal" {
 			return nil
 		}
+		// ignore the segment manifest, it is rewritten on every flush and
+		// regenerated from the segment names after a restore
+		if path.Base(currPath) == SegmentManifestFile {
+			return nil
+		}
+		// ignore the lock file, it is only meaningful to the running process
+		if path.Base(currPath) == SegmentGroupLockFile {
+			return nil
+		}
+		// ignore quarantined segments, they are not part of the bucket's state
+		if filepath.Ext(currPath) == QuarantineSuffix {
+			return nil
+		}
 		files = append(files, path.Join(basePath, path.Base(currPath)))
 		return nil
 	})
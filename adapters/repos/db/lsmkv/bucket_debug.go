@@ -0,0 +1,89 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// SegmentGroupDebugInfo is the runtime state of the disk segments of a bucket
+// as exposed by the debug server
+type SegmentGroupDebugInfo struct {
+	Stats GroupStats `json:"stats"`
+	// BloomFilterFPR is the false positive rate measured by
+	// BloomFilterAccuracyTest. If the test fails, e.g. because no segment has a
+	// bloom filter, BloomFilterError is set instead.
+	BloomFilterFPR   *float64 `json:"bloomFilterFalsePositiveRate,omitempty"`
+	BloomFilterError string   `json:"bloomFilterError,omitempty"`
+}
+
+// SegmentGroupDebugInfo collects the Stats of the segment group of the bucket
+// and runs a BloomFilterAccuracyTest with the given sample size. Neither
+// holds the maintenance lock for longer than taking a snapshot of the
+// segments, so reads, writes, flushes and compactions continue meanwhile.
+//
+// Concurrent calls with the same sample size share a single collection. It
+// stops scanning segments once the ctx of the call which started it is done,
+// calls which are still waiting then start a new one. Segments scanned until
+// then keep their stats, so the new collection continues where the aborted
+// one stopped. If ctx is done first, ctx.Err() is returned.
+func (b *Bucket) SegmentGroupDebugInfo(ctx context.Context, sampleSize int,
+) (SegmentGroupDebugInfo, error) {
+	if b.disk == nil {
+		return SegmentGroupDebugInfo{}, fmt.Errorf("bucket %q has no segment group", b.dir)
+	}
+
+	for {
+		done := b.debugInfoFlight.DoChan(strconv.Itoa(sampleSize), func() (interface{}, error) {
+			return b.segmentGroupDebugInfo(ctx, sampleSize)
+		})
+
+		select {
+		case res := <-done:
+			if res.Err != nil {
+				if ctx.Err() == nil && isContextErr(res.Err) {
+					// the call which started the collection gave up, start
+					// another one
+					continue
+				}
+				return SegmentGroupDebugInfo{}, res.Err
+			}
+			return res.Val.(SegmentGroupDebugInfo), nil
+		case <-ctx.Done():
+			return SegmentGroupDebugInfo{}, ctx.Err()
+		}
+	}
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (b *Bucket) segmentGroupDebugInfo(ctx context.Context, sampleSize int,
+) (SegmentGroupDebugInfo, error) {
+	stats, err := b.disk.StatsContext(ctx)
+	if err != nil {
+		return SegmentGroupDebugInfo{}, err
+	}
+
+	info := SegmentGroupDebugInfo{Stats: stats}
+	fpr, err := BloomFilterAccuracyTest(b.disk, sampleSize)
+	if err != nil {
+		info.BloomFilterError = err.Error()
+	} else {
+		info.BloomFilterFPR = &fpr
+	}
+	return info, nil
+}
@@ -0,0 +1,123 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestBucket_SegmentGroupDebugInfo(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	t.Run("without segments", func(t *testing.T) {
+		info, err := b.SegmentGroupDebugInfo(ctx, 100)
+		require.Nil(t, err)
+		assert.Equal(t, 0, info.Stats.SegmentCount)
+		assert.Nil(t, info.BloomFilterFPR)
+		assert.NotEmpty(t, info.BloomFilterError)
+	})
+
+	for i := 0; i < 10; i++ {
+		require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+	}
+	require.Nil(t, b.FlushAndSwitch())
+
+	t.Run("with segments", func(t *testing.T) {
+		info, err := b.SegmentGroupDebugInfo(ctx, 100)
+		require.Nil(t, err)
+		assert.Equal(t, 1, info.Stats.SegmentCount)
+		require.NotNil(t, info.BloomFilterFPR)
+		assert.Empty(t, info.BloomFilterError)
+		assert.GreaterOrEqual(t, *info.BloomFilterFPR, 0.0)
+		assert.LessOrEqual(t, *info.BloomFilterFPR, 1.0)
+	})
+
+	t.Run("blocked by segment switch", func(t *testing.T) {
+		b.disk.maintenanceLock.Lock()
+		defer b.disk.maintenanceLock.Unlock()
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		_, err := b.SegmentGroupDebugInfo(ctx, 100)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("waiting call outlives the one which started the collection", func(t *testing.T) {
+		b.disk.maintenanceLock.Lock()
+
+		shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := b.SegmentGroupDebugInfo(shortCtx, 100)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := b.SegmentGroupDebugInfo(ctx, 100)
+			done <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+		b.disk.maintenanceLock.Unlock()
+
+		select {
+		case err := <-done:
+			assert.Nil(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("debug info was not collected")
+		}
+	})
+
+	t.Run("tombstone scans stop once ctx is done", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+			require.Nil(t, b.FlushAndSwitch())
+		}
+		for _, seg := range b.disk.segments {
+			seg.statsKnown.Store(false)
+		}
+
+		canceledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := b.SegmentGroupDebugInfo(canceledCtx, 100)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = b.disk.StatsContext(canceledCtx)
+		assert.ErrorIs(t, err, context.Canceled)
+		for _, seg := range b.disk.segments {
+			assert.False(t, seg.statsKnown.Load())
+			assert.False(t, seg.isAcquired())
+		}
+
+		info, err := b.SegmentGroupDebugInfo(ctx, 100)
+		require.Nil(t, err)
+		assert.Equal(t, 4, info.Stats.SegmentCount)
+		keys := make([]int, len(info.Stats.Segments))
+		for i, seg := range info.Stats.Segments {
+			keys[i] = seg.Keys
+		}
+		assert.Equal(t, []int{10, 1, 1, 1}, keys)
+	})
+}
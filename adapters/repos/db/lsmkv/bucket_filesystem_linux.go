@@ -0,0 +1,45 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+//go:build linux
+
+package lsmkv
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// networkFilesystems maps the statfs magic numbers of network and FUSE based
+// filesystems, on which mmap is unreliable, to their names
+var networkFilesystems = map[int64]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x65735546: "fuse",
+	0x00c36400: "ceph",
+	0x5346414f: "afs",
+	0x01021997: "9p",
+	0x47504653: "gpfs",
+	0x0bd00bd0: "lustre",
+}
+
+// detectNetworkFilesystem returns the name of the filesystem dir is located on
+// if it is a network filesystem, or an empty string otherwise.
+func detectNetworkFilesystem(dir string) (string, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return "", fmt.Errorf("statfs %s: %w", dir, err)
+	}
+	return networkFilesystems[int64(stat.Type)], nil
+}
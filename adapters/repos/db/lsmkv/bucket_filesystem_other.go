@@ -0,0 +1,20 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+//go:build !linux
+
+package lsmkv
+
+// detectNetworkFilesystem is only supported on linux, on all other platforms
+// no filesystem is reported as a network filesystem
+func detectNetworkFilesystem(dir string) (string, error) {
+	return "", nil
+}
@@ -66,6 +66,13 @@ func WithLegacyMapSorting() BucketOption {
 	}
 }
 
+// WithPread reads segments with pread instead of accessing their memory-mapped
+// contents. It is recommended for network filesystems, such as NFS or FUSE
+// based cloud volumes, on which a truncated or unavailable mapping crashes the
+// process with SIGBUS instead of returning an error. Buckets located on a
+// detected network filesystem always use pread.
+//
+// With pread, the segment indexes are held in memory, see segment_pread.go.
 func WithPread(with bool) BucketOption {
 	return func(b *Bucket) error {
 		b.mmapContents = !with
@@ -166,6 +173,98 @@ func WithSegmentsChecksumValidationEnabled(enable bool) BucketOption {
 	}
 }
 
+// WithMaxOpenSegmentFiles limits the number of pread segments which keep
+// their file open. Memory-mapped segments don't hold a file descriptor, so
+// they are not affected.
+func WithMaxOpenSegmentFiles(maxOpenSegmentFiles int) BucketOption {
+	return func(b *Bucket) error {
+		b.maxOpenSegmentFiles = maxOpenSegmentFiles
+		return nil
+	}
+}
+
+// WithSegmentReadRetries configures how often a failed segment read is
+// retried, starting with delay and doubling it on every attempt. Retries only
+// apply to pread, as mmap'ed segments do not surface I/O errors.
+func WithSegmentReadRetries(maxRetries int, delay time.Duration) BucketOption {
+	return func(b *Bucket) error {
+		if maxRetries < 0 {
+			return errors.Errorf("max read retries must not be negative, got %d", maxRetries)
+		}
+		b.maxReadRetries = maxRetries
+		b.readRetryDelay = delay
+		return nil
+	}
+}
+
+// WithSlowPathThreshold sets the duration after which waiting for the
+// maintenance lock or reading from an individual segment is considered slow
+// and logged at debug level. Defaults to 100ms.
+func WithSlowPathThreshold(threshold time.Duration) BucketOption {
+	return func(b *Bucket) error {
+		b.slowPathThreshold = threshold
+		return nil
+	}
+}
+
+// WithInvalidSegmentPolicy sets what happens to segment files which are too
+// small to hold a segment header when the bucket is loaded. Instead of failing
+// to load the bucket, such files are quarantined (default) or deleted.
+func WithInvalidSegmentPolicy(policy InvalidSegmentPolicy) BucketOption {
+	return func(b *Bucket) error {
+		switch policy {
+		case InvalidSegmentPolicyQuarantine, InvalidSegmentPolicyDelete:
+			b.invalidSegmentPolicy = policy
+			return nil
+		default:
+			return errors.Errorf("unknown invalid segment policy %q", policy)
+		}
+	}
+}
+
+// WithCompactionScorer replaces the scorer which decides which pair of segments
+// is compacted next. Defaults to DefaultCompactionScorer.
+func WithCompactionScorer(scorer CompactionScorer) BucketOption {
+	return func(b *Bucket) error {
+		if scorer == nil {
+			return errors.Errorf("compaction scorer must not be nil")
+		}
+		b.compactionScorer = scorer
+		return nil
+	}
+}
+
+// WithIdleCompaction starts a compaction as soon as no segment was added for
+// delay, if the bucket has more than threshold segments, instead of waiting
+// for the next run of the compaction cycle. This keeps the number of segments,
+// and with it the cost of reads, low right after a bulk load. A threshold of
+// 0 disables idle compaction, which is the default.
+func WithIdleCompaction(threshold int, delay time.Duration) BucketOption {
+	return func(b *Bucket) error {
+		if threshold < 0 {
+			return errors.Errorf("idle compaction threshold must not be negative, got %d", threshold)
+		}
+		if threshold > 0 && delay <= 0 {
+			return errors.Errorf("idle compaction delay must be positive, got %s", delay)
+		}
+		b.idleCompactionThreshold = threshold
+		b.idleCompactionDelay = delay
+		return nil
+	}
+}
+
+// WithPrioritizeFlush lets flushes take precedence over compactions for the
+// parts of both cycles that can't run concurrently. A compaction then waits
+// for pending flushes before it swaps in a compacted segment, which reduces
+// write stalls under heavy ingest at the cost of slower compactions. Disabled
+// by default.
+func WithPrioritizeFlush(prioritize bool) BucketOption {
+	return func(b *Bucket) error {
+		b.prioritizeFlush = prioritize
+		return nil
+	}
+}
+
 /*
 Background for this option:
 
@@ -185,3 +284,150 @@ func WithForceCompaction(opt bool) BucketOption {
 		return nil
 	}
 }
+
+// WithOnCompactionComplete registers a hook which is invoked after each
+// successful compaction, once the compacted segment was swapped in. The hook
+// runs outside the maintenance lock, but synchronously on the compaction
+// cycle: it must not block, otherwise further compactions are delayed.
+// Expensive work should be handed off to a separate goroutine.
+func WithOnCompactionComplete(hook func(CompactionResult)) BucketOption {
+	return func(b *Bucket) error {
+		b.onCompactionComplete = hook
+		return nil
+	}
+}
+
+// WithCompactionShutdownTimeout limits how long a shutdown waits for an
+// in-flight compaction. Once the timeout, or the deadline of the shutdown
+// context, has passed, the compaction is aborted and the original segments are
+// kept. The partially written segment is removed on the next startup. By
+// default the shutdown fails instead if the compaction doesn't finish in time.
+func WithCompactionShutdownTimeout(timeout time.Duration) BucketOption {
+	return func(b *Bucket) error {
+		if timeout < 0 {
+			return errors.Errorf("compaction shutdown timeout must not be negative, got %s", timeout)
+		}
+		b.compactionShutdownTimeout = timeout
+		return nil
+	}
+}
+
+// WithKeyPrefixMetricsLen labels the read metrics of the bucket with the hex
+// encoded first n bytes of the read key. This breaks down the read latency by
+// namespace for buckets which encode namespaces in their key prefixes. At most
+// maxKeyPrefixLabels distinct prefixes are labeled across all buckets, further
+// ones share the label "other". Disabled (0) by default.
+func WithKeyPrefixMetricsLen(n int) BucketOption {
+	return func(b *Bucket) error {
+		if n < 0 {
+			return errors.Errorf("key prefix metrics length must not be negative, got %d", n)
+		}
+		b.keyPrefixMetricsLen = n
+		return nil
+	}
+}
+
+// WithCompactionBytesPerSecond limits the rate at which compactions write the
+// compacted segment. As a compaction reads about as much as it writes, this
+// smooths its I/O so it doesn't saturate the disk bandwidth needed by queries.
+// 0 means unlimited, which is the default.
+func WithCompactionBytesPerSecond(bytesPerSecond int64) BucketOption {
+	return func(b *Bucket) error {
+		if bytesPerSecond < 0 {
+			return errors.Errorf("compaction bytes per second must not be negative, got %d", bytesPerSecond)
+		}
+		b.compactionBytesPerSecond = bytesPerSecond
+		return nil
+	}
+}
+
+// WithSegmentsCleanupBatchSize bounds the number of newer segments a single
+// cleanup checks a segment against. The cleanup continues in the next cycles
+// until all newer segments were checked. 0 means unbounded.
+func WithSegmentsCleanupBatchSize(batchSize int) BucketOption {
+	return func(b *Bucket) error {
+		if batchSize < 0 {
+			return errors.Errorf("segments cleanup batch size must not be negative, got %d", batchSize)
+		}
+		b.segmentsCleanupBatchSize = batchSize
+		return nil
+	}
+}
+
+// WithCompactionMemoryPressureBackoff sets the delay for which compactions
+// are paused after one was skipped or stopped because the memory checker
+// reported insufficient memory. 0 uses the default of 30s.
+func WithCompactionMemoryPressureBackoff(delay time.Duration) BucketOption {
+	return func(b *Bucket) error {
+		if delay < 0 {
+			return errors.Errorf("compaction memory pressure backoff must not be negative, got %v", delay)
+		}
+		b.compactionMemoryBackoff = delay
+		return nil
+	}
+}
+
+// WithReadLockTimeout bounds how long a read waits for the maintenance lock
+// of the segment group, e.g. while a stalled compaction switches segments.
+// Reads exceeding it fail with ErrLockTimeout. 0 (the default) waits
+// indefinitely.
+func WithReadLockTimeout(timeout time.Duration) BucketOption {
+	return func(b *Bucket) error {
+		if timeout < 0 {
+			return errors.Errorf("read lock timeout must not be negative, got %v", timeout)
+		}
+		b.lockTimeout = timeout
+		return nil
+	}
+}
+
+// WithCollectionReadErrorPolicy decides what collection reads, e.g.
+// [Bucket.SetList], do when a segment fails with an error other than
+// lsmkv.NotFound. Defaults to CollectionReadErrorPolicyStrict.
+func WithCollectionReadErrorPolicy(policy CollectionReadErrorPolicy) BucketOption {
+	return func(b *Bucket) error {
+		switch policy {
+		case CollectionReadErrorPolicyStrict, CollectionReadErrorPolicyTolerant:
+			b.collectionReadErrorPolicy = policy
+			return nil
+		default:
+			return errors.Errorf("unknown collection read error policy %q", policy)
+		}
+	}
+}
+
+// WithNegativeCacheSize enables a cache of up to size keys which a read of the
+// disk segments resolved as deleted or not found. Repeated reads of these
+// keys skip the scan of all segments, which benefits delete-heavy workloads.
+// The cache is cleared whenever a segment is flushed, compacted or cleaned
+// up. It only applies to Get of the "replace" strategy. 0 (the default)
+// disables it.
+func WithNegativeCacheSize(size int) BucketOption {
+	return func(b *Bucket) error {
+		if size < 0 {
+			return errors.Errorf("negative cache size must not be negative, got %d", size)
+		}
+		b.negativeCacheSize = size
+		return nil
+	}
+}
+
+// WithCompactionProgress reports the progress of long running compactions.
+// newProgress is called when a compaction of two segments starts and returns
+// the channel the progress of that compaction is sent to, or nil to skip it.
+// Updates are sent at most once per interval, the default of 1s is used if
+// interval is 0. Sends never block: updates are dropped while the consumer is
+// not ready to receive them. Once the compaction completed, a final update
+// with Done set is sent if it succeeded, then the channel is closed.
+func WithCompactionProgress(interval time.Duration,
+	newProgress func() chan<- CompactionProgress,
+) BucketOption {
+	return func(b *Bucket) error {
+		if interval < 0 {
+			return errors.Errorf("compaction progress interval must not be negative, got %v", interval)
+		}
+		b.compactionProgress = newProgress
+		b.progressInterval = interval
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/weaviate/weaviate/entities/storagestate"
+)
+
+// MarkReadOnlyAndFlush prepares the bucket for a snapshot of its files. It sets
+// the status to read-only, which rejects further writes and prevents flushes,
+// compactions and cleanups. It then flushes the active memtable, waits for an
+// in-flight compaction to finish and fsyncs the segment directory. Once it
+// returns, the files on disk are stable until the status is changed again.
+//
+// If the flush fails or ctx expires before the in-flight compaction finished,
+// the previous status is restored and the error is returned.
+func (b *Bucket) MarkReadOnlyAndFlush(ctx context.Context) error {
+	b.statusLock.RLock()
+	prevStatus := b.status
+	b.statusLock.RUnlock()
+
+	b.UpdateStatus(storagestate.StatusReadOnly)
+
+	if err := b.flushMemtable(); err != nil {
+		b.UpdateStatus(prevStatus)
+		return fmt.Errorf("flush memtable: %w", err)
+	}
+
+	if err := b.disk.waitForInFlightCompaction(ctx); err != nil {
+		b.UpdateStatus(prevStatus)
+		return fmt.Errorf("wait for in-flight compaction: %w", err)
+	}
+
+	if err := fsync(b.disk.dir); err != nil {
+		return fmt.Errorf("fsync segment group dir: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+	"github.com/weaviate/weaviate/entities/storagestate"
+)
+
+func TestBucket_MarkReadOnlyAndFlush(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	require.Nil(t, b.Put([]byte("key1"), []byte("value1")))
+	require.Nil(t, b.FlushAndSwitch())
+	require.Nil(t, b.Put([]byte("key2"), []byte("value2")))
+	require.Nil(t, b.FlushAndSwitch())
+
+	listFiles := func() []string {
+		entries, err := os.ReadDir(b.disk.dir)
+		require.Nil(t, err)
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+		return names
+	}
+
+	t.Run("in-flight compaction outlasts the context", func(t *testing.T) {
+		b.disk.compactionLock.Lock()
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		err := b.MarkReadOnlyAndFlush(timeoutCtx)
+		b.disk.compactionLock.Unlock()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		// the previous status is restored
+		assert.False(t, b.isReadOnly())
+		assert.False(t, b.disk.isReadyOnly())
+	})
+
+	t.Run("waits for in-flight compaction and flushes", func(t *testing.T) {
+		require.Nil(t, b.Put([]byte("key3"), []byte("value3")))
+		segmentsBefore := b.disk.Len()
+
+		b.disk.compactionLock.Lock()
+		released := make(chan struct{})
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			close(released)
+			b.disk.compactionLock.Unlock()
+		}()
+
+		require.Nil(t, b.MarkReadOnlyAndFlush(ctx))
+		select {
+		case <-released:
+		default:
+			t.Fatal("returned before the in-flight compaction finished")
+		}
+		assert.True(t, b.isReadOnly())
+		assert.True(t, b.disk.isReadyOnly())
+		assert.Equal(t, segmentsBefore+1, b.disk.Len())
+		assert.Equal(t, uint64(0), b.active.Size())
+	})
+
+	t.Run("writes are rejected", func(t *testing.T) {
+		assert.ErrorIs(t, b.Put([]byte("key4"), []byte("value4")), storagestate.ErrStatusReadOnly)
+		assert.ErrorIs(t, b.Delete([]byte("key1")), storagestate.ErrStatusReadOnly)
+	})
+
+	t.Run("state is stable afterwards", func(t *testing.T) {
+		before := listFiles()
+		require.Equal(t, 3, b.disk.Len())
+
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		assert.False(t, compacted)
+		assert.Empty(t, b.disk.CompactionCandidates())
+		require.Nil(t, b.disk.WaitForCompaction(ctx))
+
+		assert.Equal(t, 3, b.disk.Len())
+		assert.Equal(t, before, listFiles())
+	})
+
+	t.Run("compactions resume once ready again", func(t *testing.T) {
+		b.UpdateStatus(storagestate.StatusReady)
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		assert.True(t, compacted)
+		require.Nil(t, b.Put([]byte("key4"), []byte("value4")))
+
+		value, err := b.Get([]byte("key3"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value3"), value)
+	})
+}
@@ -59,7 +59,7 @@ func (b *Bucket) mayRecoverFromCommitLogs(ctx context.Context) error {
 	for _, fname := range walFileNames {
 		path := filepath.Join(b.dir, strings.TrimSuffix(fname, ".wal"))
 
-		cl, err := newCommitLogger(path)
+		cl, err := newCommitLogger(path, b.logger)
 		if err != nil {
 			return errors.Wrap(err, "init commit logger")
 		}
@@ -97,10 +97,17 @@ func (b *Bucket) mayRecoverFromCommitLogs(ctx context.Context) error {
 
 		meteredReader := diskio.NewMeteredReader(bufio.NewReader(cl.file), b.metrics.TrackStartupReadWALDiskIO)
 
-		err = newCommitLoggerParser(b.strategy, meteredReader, mt).Do()
+		parser := newCommitLoggerParser(b.strategy, meteredReader, mt, b.logger)
+		err = parser.Do()
 		if err != nil {
+			if errors.Is(err, ErrInvalidChecksum) {
+				b.metrics.WALReplayError()
+			}
+			// wal_seq_no is the last entry which could be recovered
 			b.logger.WithField("action", "lsm_recover_from_active_wal_corruption").
 				WithField("path", filepath.Join(b.dir, fname)).
+				WithField("wal_seq_no", parser.seqNo).
+				WithField("wal_position", parser.reader.pos).
 				Error(errors.Wrap(err, "write-ahead-log ended abruptly, some elements may not have been recovered"))
 		}
 
@@ -128,6 +135,7 @@ func (b *Bucket) mayRecoverFromCommitLogs(ctx context.Context) error {
 
 		b.logger.WithField("action", "lsm_recover_from_active_wal_success").
 			WithField("path", filepath.Join(b.dir, fname)).
+			WithField("wal_seq_no", parser.seqNo).
 			Info("successfully recovered from write-ahead-log")
 	}
 
@@ -26,6 +26,10 @@ func (b *Bucket) RoaringSetAddOne(key []byte, value uint64) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	return b.active.roaringSetAddOne(key, value)
 }
 
@@ -37,6 +41,10 @@ func (b *Bucket) RoaringSetRemoveOne(key []byte, value uint64) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	return b.active.roaringSetRemoveOne(key, value)
 }
 
@@ -48,6 +56,10 @@ func (b *Bucket) RoaringSetAddList(key []byte, values []uint64) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	return b.active.roaringSetAddList(key, values)
 }
 
@@ -59,6 +71,10 @@ func (b *Bucket) RoaringSetAddBitmap(key []byte, bm *sroar.Bitmap) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	return b.active.roaringSetAddBitmap(key, bm)
 }
 
@@ -28,6 +28,10 @@ func (b *Bucket) RoaringSetRangeAdd(key uint64, values ...uint64) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	return b.active.roaringSetRangeAdd(key, values...)
 }
 
@@ -39,6 +43,10 @@ func (b *Bucket) RoaringSetRangeRemove(key uint64, values ...uint64) error {
 	b.flushLock.RLock()
 	defer b.flushLock.RUnlock()
 
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
 	return b.active.roaringSetRangeRemove(key, values...)
 }
 
@@ -52,7 +60,11 @@ func (b *Bucket) ReaderRoaringSetRange() ReaderRoaringSetRange {
 
 	b.flushLock.RLock()
 
-	readers, releaseSegmentGroup := b.disk.newRoaringSetRangeReaders()
+	readers, releaseSegmentGroup, err := b.disk.newRoaringSetRangeReaders()
+	if err != nil {
+		b.flushLock.RUnlock()
+		return &failedReaderRoaringSetRange{err: b.cursorOpenFailed(err)}
+	}
 
 	// we have a flush-RLock, so we have the guarantee that the flushing state
 	// will not change for the lifetime of the cursor, thus there can only be two
@@ -67,3 +79,15 @@ func (b *Bucket) ReaderRoaringSetRange() ReaderRoaringSetRange {
 		b.flushLock.RUnlock()
 	}, concurrency.SROAR_MERGE, b.logger)
 }
+
+// failedReaderRoaringSetRange is returned if the segments of the bucket can't
+// be read, every Read fails with the error
+type failedReaderRoaringSetRange struct {
+	err error
+}
+
+func (r *failedReaderRoaringSetRange) Read(ctx context.Context, value uint64, operator filters.Operator) (*sroar.Bitmap, error) {
+	return nil, r.err
+}
+
+func (r *failedReaderRoaringSetRange) Close() {}
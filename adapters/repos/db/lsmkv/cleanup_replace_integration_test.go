@@ -228,11 +228,11 @@ func cleanupReplaceStrategy(ctx context.Context, t *testing.T, opts []BucketOpti
 	t.Run("verify segments' contents", func(t *testing.T) {
 		assertContents := func(t *testing.T, segIdx int, expected []*kvt) {
 			seg := bucket.disk.segments[segIdx]
-			cur := seg.newCursor()
+			cur, err := seg.newCursor()
+			require.Nil(t, err)
 
 			i := 0
 			var k, v []byte
-			var err error
 			for k, v, err = cur.first(); k != nil && i < len(expected); k, v, err = cur.next() {
 				assert.Equal(t, []byte(expected[i].pkey), k)
 				if expected[i].tomb {
@@ -615,11 +615,11 @@ func cleanupReplaceStrategy_WithSecondaryKeys(ctx context.Context, t *testing.T,
 	t.Run("verify segments' contents", func(t *testing.T) {
 		assertContents := func(t *testing.T, segIdx int, expected []*kvt) {
 			seg := bucket.disk.segments[segIdx]
-			cur := seg.newCursor()
+			cur, err := seg.newCursor()
+			require.Nil(t, err)
 
 			i := 0
 			var n segmentReplaceNode
-			var err error
 
 			for n, err = cur.firstWithAllKeys(); !errors.Is(err, lsmkv.NotFound) && i < len(expected); n, err = cur.nextWithAllKeys() {
 				assert.Equal(t, uint16(2), n.secondaryIndexCount)
@@ -18,7 +18,9 @@ import (
 	"fmt"
 	"os"
 	"sync/atomic"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/weaviate/weaviate/adapters/repos/db/roaringset"
 	"github.com/weaviate/weaviate/usecases/integrity"
 )
@@ -28,6 +30,11 @@ type commitLogger struct {
 	writer *bufio.Writer
 	n      atomic.Int64
 	path   string
+	logger logrus.FieldLogger
+
+	// seqNo is the number of entries written since the commit logger was
+	// initialized, it is only used for logging
+	seqNo uint64
 
 	checksumWriter integrity.ChecksumWriter
 
@@ -87,9 +94,10 @@ func (ct CommitType) Is(checkedCommitType CommitType) bool {
 	return ct == checkedCommitType
 }
 
-func newCommitLogger(path string) (*commitLogger, error) {
+func newCommitLogger(path string, logger logrus.FieldLogger) (*commitLogger, error) {
 	out := &commitLogger{
-		path: path + ".wal",
+		path:   path + ".wal",
+		logger: logger,
 	}
 
 	f, err := os.OpenFile(out.path, os.O_CREATE|os.O_RDWR, 0o666)
@@ -138,10 +146,52 @@ func (cl *commitLogger) writeEntry(commitType CommitType, nodeBytes []byte) erro
 	}
 
 	cl.n.Add(int64(1 + 1 + 4 + len(nodeBytes) + checksumSize))
+	cl.seqNo++
 
 	return nil
 }
 
+// logAppend traces the entry which was just written. The level is checked
+// by the callers, so that the fields are not built on every write.
+func (cl *commitLogger) logAppend(commitType CommitType, keyLen, valueLen int) {
+	cl.logger.WithFields(walEntryFields(commitType, cl.seqNo, keyLen, valueLen)).
+		WithField("action", "lsm_wal_append").
+		WithField("path", cl.path).
+		Trace("appended entry to write-ahead-log")
+}
+
+func (cl *commitLogger) traceEnabled() bool {
+	return logLevelEnabled(cl.logger, logrus.TraceLevel)
+}
+
+// walEntryFields are the fields logged for an entry of the write-ahead-log.
+// wal_value_len is the length of the value for the replace strategy, the sum
+// of the value lengths for collections and the size of the serialized
+// additions and deletions for roaring sets.
+func walEntryFields(commitType CommitType, seqNo uint64, keyLen, valueLen int) logrus.Fields {
+	return logrus.Fields{
+		"wal_entry_type": commitType.String(),
+		"wal_seq_no":     seqNo,
+		"wal_key_len":    keyLen,
+		"wal_value_len":  valueLen,
+	}
+}
+
+// roaringSetValueLen is the size of the additions and deletions of a
+// serialized roaring set node. Apart from those, nodes hold their own length,
+// the length indicators of the additions, deletions and key, and the key.
+func roaringSetValueLen(nodeLen, keyLen int) int {
+	return nodeLen - 8 - 8 - 8 - 4 - keyLen
+}
+
+func collectionValueLen(values []value) int {
+	n := 0
+	for _, v := range values {
+		n += len(v.value)
+	}
+	return n
+}
+
 func (cl *commitLogger) put(node segmentReplaceNode) error {
 	if cl.paused {
 		return nil
@@ -157,7 +207,14 @@ func (cl *commitLogger) put(node segmentReplaceNode) error {
 		return fmt.Errorf("unexpected error, node size mismatch")
 	}
 
-	return cl.writeEntry(CommitTypeReplace, cl.bufNode.Bytes())
+	if err := cl.writeEntry(CommitTypeReplace, cl.bufNode.Bytes()); err != nil {
+		return err
+	}
+
+	if cl.traceEnabled() {
+		cl.logAppend(CommitTypeReplace, len(node.primaryKey), len(node.value))
+	}
+	return nil
 }
 
 func (cl *commitLogger) append(node segmentCollectionNode) error {
@@ -175,7 +232,14 @@ func (cl *commitLogger) append(node segmentCollectionNode) error {
 		return fmt.Errorf("unexpected error, node size mismatch")
 	}
 
-	return cl.writeEntry(CommitTypeCollection, cl.bufNode.Bytes())
+	if err := cl.writeEntry(CommitTypeCollection, cl.bufNode.Bytes()); err != nil {
+		return err
+	}
+
+	if cl.traceEnabled() {
+		cl.logAppend(CommitTypeCollection, len(node.primaryKey), collectionValueLen(node.values))
+	}
+	return nil
 }
 
 func (cl *commitLogger) add(node *roaringset.SegmentNodeList) error {
@@ -193,7 +257,15 @@ func (cl *commitLogger) add(node *roaringset.SegmentNodeList) error {
 		return fmt.Errorf("unexpected error, node size mismatch")
 	}
 
-	return cl.writeEntry(CommitTypeRoaringSetList, cl.bufNode.Bytes())
+	if err := cl.writeEntry(CommitTypeRoaringSetList, cl.bufNode.Bytes()); err != nil {
+		return err
+	}
+
+	if cl.traceEnabled() {
+		keyLen := len(node.PrimaryKey())
+		cl.logAppend(CommitTypeRoaringSetList, keyLen, roaringSetValueLen(cl.bufNode.Len(), keyLen))
+	}
+	return nil
 }
 
 // Size returns the amount of data that has been written since the commit
@@ -237,3 +309,26 @@ func (cl *commitLogger) flushBuffers() error {
 
 	return nil
 }
+
+// sync flushes the buffers and fsyncs the WAL, so that all entries written so
+// far survive a crash of the machine
+func (cl *commitLogger) sync() error {
+	before := time.Now()
+
+	if err := cl.flushBuffers(); err != nil {
+		return err
+	}
+
+	if err := cl.file.Sync(); err != nil {
+		return fmt.Errorf("fsync WAL %q: %w", cl.path, err)
+	}
+
+	cl.logger.WithFields(logrus.Fields{
+		"action":     "lsm_wal_sync",
+		"path":       cl.path,
+		"wal_seq_no": cl.seqNo,
+		"took":       time.Since(before),
+	}).Debug("synced write-ahead-log")
+
+	return nil
+}
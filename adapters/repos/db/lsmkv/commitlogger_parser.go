@@ -15,31 +15,81 @@ import (
 	"bytes"
 	"encoding/binary"
 	"io"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/weaviate/weaviate/usecases/integrity"
 )
 
+// walReplayProgressInterval is the number of replayed entries between two
+// progress logs
+const walReplayProgressInterval = 10_000
+
 type commitloggerParser struct {
 	strategy string
 
-	reader         io.Reader
+	reader         *positionReader
 	checksumReader integrity.ChecksumReader
 
 	bufNode *bytes.Buffer
 
 	memtable *Memtable
+
+	logger logrus.FieldLogger
+	seqNo  uint64
+	start  time.Time
 }
 
 func newCommitLoggerParser(strategy string, reader io.Reader, memtable *Memtable,
+	logger logrus.FieldLogger,
 ) *commitloggerParser {
+	pr := &positionReader{r: reader}
 	return &commitloggerParser{
 		strategy:       strategy,
-		reader:         reader,
-		checksumReader: integrity.NewCRC32Reader(reader),
+		reader:         pr,
+		checksumReader: integrity.NewCRC32Reader(pr),
 		bufNode:        bytes.NewBuffer(nil),
 		memtable:       memtable,
+		logger:         logger,
+		start:          time.Now(),
+	}
+}
+
+// entryReplayed counts an entry which was read from the log. Every
+// walReplayProgressInterval entries the progress is logged, see walEntryFields
+// for the entry fields.
+func (p *commitloggerParser) entryReplayed(commitType CommitType, keyLen, valueLen int) {
+	p.seqNo++
+	if p.seqNo%walReplayProgressInterval != 0 {
+		return
+	}
+
+	rate := float64(p.seqNo)
+	if took := time.Since(p.start).Seconds(); took > 0 {
+		rate /= took
 	}
+
+	p.logger.WithFields(walEntryFields(commitType, p.seqNo, keyLen, valueLen)).
+		WithFields(logrus.Fields{
+			"action":                 "lsm_recover_from_active_wal_progress",
+			"path":                   p.memtable.path,
+			"wal_position":           p.reader.pos,
+			"wal_entries_per_second": rate,
+		}).
+		Debug("replaying write-ahead-log")
+}
+
+// positionReader tracks how many bytes of the log were read so far
+type positionReader struct {
+	r   io.Reader
+	pos int64
+}
+
+func (r *positionReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.pos += int64(n)
+	return n, err
 }
 
 func (p *commitloggerParser) Do() error {
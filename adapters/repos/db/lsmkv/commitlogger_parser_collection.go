@@ -82,6 +82,7 @@ func (p *commitloggerParser) parseCollectionNode(reader io.Reader) error {
 	if err != nil {
 		return err
 	}
+	p.entryReplayed(CommitTypeCollection, len(n.primaryKey), collectionValueLen(n.values))
 
 	if p.strategy == StrategyMapCollection {
 		return p.parseMapNode(n)
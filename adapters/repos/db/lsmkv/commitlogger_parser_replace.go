@@ -11,62 +11,16 @@
 
 package lsmkv
 
-import (
-	"encoding/binary"
-	"fmt"
-	"io"
-
-	"github.com/pkg/errors"
-)
-
 // doReplace parsers all entries into a cache for deduplication first and only
-// imports unique entries into the actual memtable as a final step.
+// imports unique entries into the actual memtable as a final step. Entries are
+// streamed from the log one at a time.
 func (p *commitloggerParser) doReplace() error {
 	nodeCache := make(map[string]segmentReplaceNode)
 
-	var errWhileParsing error
-
-	for {
-		var commitType CommitType
-
-		err := binary.Read(p.checksumReader, binary.LittleEndian, &commitType)
-		if errors.Is(err, io.EOF) {
-			break
-		}
-		if err != nil {
-			errWhileParsing = errors.Wrap(err, "read commit type")
-			break
-		}
-		if !CommitTypeReplace.Is(commitType) {
-			return errors.Errorf("found a %s commit on a replace bucket", commitType.String())
-		}
-
-		var version uint8
-
-		err = binary.Read(p.checksumReader, binary.LittleEndian, &version)
-		if err != nil {
-			errWhileParsing = errors.Wrap(err, "read commit version")
-			break
-		}
-
-		switch version {
-		case 0:
-			{
-				err = p.doReplaceRecordV0(nodeCache)
-			}
-		case 1:
-			{
-				err = p.doReplaceRecordV1(nodeCache)
-			}
-		default:
-			{
-				return fmt.Errorf("unsupported commit version %d", version)
-			}
-		}
-		if err != nil {
-			errWhileParsing = err
-			break
-		}
+	it := newWALIteratorFromReader(p.reader, p.memtable.secondaryIndices)
+	for it.Next() {
+		p.entryReplayed(CommitTypeReplace, len(it.node.primaryKey), len(it.node.value))
+		cacheReplaceNode(it.node, nodeCache)
 	}
 
 	for _, node := range nodeCache {
@@ -83,31 +37,13 @@ func (p *commitloggerParser) doReplace() error {
 		}
 	}
 
-	return errWhileParsing
-}
-
-func (p *commitloggerParser) doReplaceRecordV0(nodeCache map[string]segmentReplaceNode) error {
-	return p.parseReplaceNode(p.reader, nodeCache)
-}
-
-func (p *commitloggerParser) doReplaceRecordV1(nodeCache map[string]segmentReplaceNode) error {
-	reader, err := p.doRecord()
-	if err != nil {
-		return err
-	}
-
-	return p.parseReplaceNode(reader, nodeCache)
+	return it.Err()
 }
 
-// parseReplaceNode only parses into the deduplication cache, not into the
+// cacheReplaceNode only parses into the deduplication cache, not into the
 // final memtable yet. A second step is required to parse from the cache into
 // the actual memtable.
-func (p *commitloggerParser) parseReplaceNode(r io.Reader, nodeCache map[string]segmentReplaceNode) error {
-	n, err := ParseReplaceNode(r, p.memtable.secondaryIndices)
-	if err != nil {
-		return err
-	}
-
+func cacheReplaceNode(n segmentReplaceNode, nodeCache map[string]segmentReplaceNode) {
 	if !n.tombstone {
 		nodeCache[string(n.primaryKey)] = n
 	} else {
@@ -118,6 +54,4 @@ func (p *commitloggerParser) parseReplaceNode(r io.Reader, nodeCache map[string]
 			nodeCache[string(n.primaryKey)] = n
 		}
 	}
-
-	return nil
 }
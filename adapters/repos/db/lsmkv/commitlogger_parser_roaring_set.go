@@ -110,6 +110,7 @@ func (prs *commitlogParserRoaringSet) parseNode(reader io.Reader) error {
 
 	segment := roaringset.NewSegmentNodeFromBuffer(segBuf)
 	key := segment.PrimaryKey()
+	prs.parser.entryReplayed(CommitTypeRoaringSet, len(key), roaringSetValueLen(len(segBuf), len(key)))
 
 	if err := prs.consume(key, segment.Additions().ToArray(), segment.Deletions().ToArray()); err != nil {
 		return fmt.Errorf("consume segment additions/deletions: %w", err)
@@ -133,6 +134,7 @@ func (prs *commitlogParserRoaringSet) parseNodeList(reader io.Reader) error {
 
 	segment := roaringset.NewSegmentNodeListFromBuffer(segBuf)
 	key := segment.PrimaryKey()
+	prs.parser.entryReplayed(CommitTypeRoaringSetList, len(key), roaringSetValueLen(len(segBuf), len(key)))
 	if err := prs.consume(key, segment.Additions(), segment.Deletions()); err != nil {
 		return errors.Wrap(err, "add/remove bitmaps")
 	}
@@ -0,0 +1,118 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitLoggerStructuredLogging(t *testing.T) {
+	const entries = 2*walReplayProgressInterval + 1
+
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.TraceLevel)
+
+	dir := t.TempDir()
+	cl, err := newCommitLogger(path.Join(dir, "memtable"), logger)
+	require.NoError(t, err)
+
+	for i := 0; i < entries; i++ {
+		require.NoError(t, cl.put(segmentReplaceNode{
+			primaryKey: []byte(fmt.Sprintf("key-%05d", i)),
+			value:      []byte("value"),
+		}))
+	}
+	require.NoError(t, cl.sync())
+	require.NoError(t, cl.close())
+
+	t.Run("append and sync", func(t *testing.T) {
+		appends := entriesWithAction(hook, "lsm_wal_append")
+		require.Len(t, appends, entries)
+		assert.Equal(t, logrus.TraceLevel, appends[0].Level)
+		assert.Equal(t, "replace", appends[0].Data["wal_entry_type"])
+		assert.Equal(t, uint64(1), appends[0].Data["wal_seq_no"])
+		assert.Equal(t, 9, appends[0].Data["wal_key_len"])
+		assert.Equal(t, 5, appends[0].Data["wal_value_len"])
+
+		syncs := entriesWithAction(hook, "lsm_wal_sync")
+		require.Len(t, syncs, 1)
+		assert.Equal(t, uint64(entries), syncs[0].Data["wal_seq_no"])
+	})
+
+	replay := func(t *testing.T) (*commitloggerParser, error) {
+		f, err := os.Open(cl.path)
+		require.NoError(t, err)
+		defer f.Close()
+
+		// like on recovery, the replayed entries are not logged again
+		replayedCl, err := newCommitLogger(path.Join(t.TempDir(), "replayed"), logger)
+		require.NoError(t, err)
+		replayedCl.pause()
+		defer replayedCl.close()
+
+		mt, err := newMemtable(replayedCl.path, StrategyReplace, 0, replayedCl, nil, logger, false)
+		require.NoError(t, err)
+
+		hook.Reset()
+		parser := newCommitLoggerParser(StrategyReplace, bufio.NewReader(f), mt, logger)
+		return parser, parser.Do()
+	}
+
+	t.Run("replay progress", func(t *testing.T) {
+		parser, err := replay(t)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(entries), parser.seqNo)
+
+		progress := entriesWithAction(hook, "lsm_recover_from_active_wal_progress")
+		require.Len(t, progress, 2)
+		assert.Equal(t, logrus.DebugLevel, progress[0].Level)
+		assert.Equal(t, uint64(walReplayProgressInterval), progress[0].Data["wal_seq_no"])
+		assert.Equal(t, uint64(2*walReplayProgressInterval), progress[1].Data["wal_seq_no"])
+		assert.Equal(t, "replace", progress[0].Data["wal_entry_type"])
+		assert.Equal(t, 9, progress[0].Data["wal_key_len"])
+		assert.Equal(t, 5, progress[0].Data["wal_value_len"])
+		assert.Greater(t, progress[0].Data["wal_position"], int64(0))
+		assert.Contains(t, progress[0].Data, "wal_entries_per_second")
+	})
+
+	t.Run("replay stops at invalid checksum", func(t *testing.T) {
+		contents, err := os.ReadFile(cl.path)
+		require.NoError(t, err)
+		// flip a byte of the last entry
+		contents[len(contents)-6] ^= 0xFF
+		require.NoError(t, os.WriteFile(cl.path, contents, 0o666))
+
+		parser, err := replay(t)
+		assert.True(t, errors.Is(err, ErrInvalidChecksum))
+		assert.Equal(t, uint64(entries-1), parser.seqNo)
+	})
+}
+
+func entriesWithAction(hook *test.Hook, action string) []logrus.Entry {
+	var out []logrus.Entry
+	for _, e := range hook.AllEntries() {
+		if e.Data["action"] == action {
+			out = append(out, *e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+// BenchmarkReadLatencyDuringCompaction reads at a rate of 100 reads/s while
+// segments are being compacted and reports the P99 read latency.
+func BenchmarkReadLatencyDuringCompaction(b *testing.B) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	bucket, err := NewBucketCreator().NewBucket(ctx, b.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace), WithCalcCountNetAdditions(true))
+	require.Nil(b, err)
+	defer bucket.Shutdown(ctx)
+
+	keysPerSegment := 10000
+	key := func(i int) []byte { return []byte(fmt.Sprintf("key-%09d", i)) }
+
+	var latencies []time.Duration
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		for seg := 0; seg < 2; seg++ {
+			for k := 0; k < keysPerSegment; k++ {
+				require.Nil(b, bucket.Put(key(seg*keysPerSegment+k), make([]byte, 128)))
+			}
+			require.Nil(b, bucket.FlushAndSwitch())
+		}
+		b.StartTimer()
+
+		done := make(chan struct{})
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(10 * time.Millisecond)
+			defer ticker.Stop()
+			for k := 0; ; k++ {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					start := time.Now()
+					_, err := bucket.Get(key(k % (2 * keysPerSegment)))
+					latencies = append(latencies, time.Since(start))
+					require.Nil(b, err)
+				}
+			}
+		}()
+
+		for {
+			compacted, err := bucket.disk.compactOnce()
+			require.Nil(b, err)
+			if !compacted {
+				break
+			}
+		}
+		close(done)
+		wg.Wait()
+	}
+
+	b.StopTimer()
+	if len(latencies) == 0 {
+		return
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	p99 := latencies[len(latencies)*99/100]
+	b.ReportMetric(float64(p99.Microseconds()), "p99-us/read")
+}
@@ -0,0 +1,68 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// newCompactionLimiter returns a token bucket limiting compaction writes to
+// bytesPerSecond, or nil if bytesPerSecond is not positive. The bucket holds
+// 100ms worth of tokens, so the I/O is smoothed instead of bursty.
+func newCompactionLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	burst := int(bytesPerSecond / 10)
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+}
+
+// throttledWriteSeeker delays writes to w according to limiter. Writes larger
+// than the burst of the limiter are split up. A write waiting for the limiter
+// fails with errCompactionAborted once ctx is cancelled.
+type throttledWriteSeeker struct {
+	ctx     context.Context
+	w       io.WriteSeeker
+	limiter *rate.Limiter
+}
+
+func (t *throttledWriteSeeker) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := min(len(p), t.limiter.Burst())
+		if err := t.limiter.WaitN(t.ctx, chunk); err != nil {
+			if t.ctx.Err() != nil {
+				return written, errCompactionAborted
+			}
+			return written, err
+		}
+
+		n, err := t.w.Write(p[:chunk])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[chunk:]
+	}
+	return written, nil
+}
+
+func (t *throttledWriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	return t.w.Seek(offset, whence)
+}
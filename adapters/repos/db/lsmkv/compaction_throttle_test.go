@@ -0,0 +1,127 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestThrottledWriteSeeker(t *testing.T) {
+	const (
+		bytesPerSecond = 1024 * 1024
+		total          = 512 * 1024
+	)
+
+	assert.Nil(t, newCompactionLimiter(0))
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.Nil(t, err)
+	defer f.Close()
+
+	limiter := newCompactionLimiter(bytesPerSecond)
+	w := &throttledWriteSeeker{ctx: context.Background(), w: f, limiter: limiter}
+
+	// a single write larger than the burst is split up
+	chunks := [][]byte{make([]byte, 4*1024), make([]byte, 256*1024)}
+	start := time.Now()
+	written := 0
+	for written < total {
+		chunk := chunks[(written/4096)%2]
+		chunk = chunk[:min(len(chunk), total-written)]
+		n, err := w.Write(chunk)
+		require.Nil(t, err)
+		require.Equal(t, len(chunk), n)
+		written += n
+	}
+	took := time.Since(start)
+
+	stat, err := f.Stat()
+	require.Nil(t, err)
+	assert.Equal(t, int64(total), stat.Size())
+
+	// the initial burst is free, the rest is written at the configured rate
+	expected := time.Duration(float64(total-limiter.Burst()) / bytesPerSecond * float64(time.Second))
+	assert.GreaterOrEqual(t, took, expected*9/10)
+	assert.LessOrEqual(t, took, expected*3/2)
+
+	t.Run("waiting write is aborted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		// the initial burst of 1 byte is free, the rest takes about 10s
+		w := &throttledWriteSeeker{ctx: ctx, w: f, limiter: newCompactionLimiter(1)}
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		start := time.Now()
+		n, err := w.Write(make([]byte, 10))
+		assert.ErrorIs(t, err, errCompactionAborted)
+		assert.Equal(t, 1, n)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestCompactionBytesPerSecond(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	compact := func(t *testing.T, opts ...BucketOption) (time.Duration, int64) {
+		opts = append([]BucketOption{WithStrategy(StrategyReplace)}, opts...)
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			opts...)
+		require.Nil(t, err)
+		defer b.Shutdown(ctx)
+
+		value := make([]byte, 1024)
+		for segment := 0; segment < 2; segment++ {
+			for i := 0; i < 128; i++ {
+				require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%d-%d", segment, i)), value))
+			}
+			require.Nil(t, b.FlushAndSwitch())
+		}
+
+		start := time.Now()
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		require.True(t, compacted)
+		return time.Since(start), b.disk.segmentAtPos(0).size
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		took, _ := compact(t)
+		assert.Less(t, took, 200*time.Millisecond)
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		const bytesPerSecond = 512 * 1024
+		took, size := compact(t, WithCompactionBytesPerSecond(bytesPerSecond))
+
+		burst := int64(bytesPerSecond / 10)
+		expected := time.Duration(float64(size-burst) / bytesPerSecond * float64(time.Second))
+		assert.GreaterOrEqual(t, took, expected*9/10)
+	})
+
+	t.Run("negative limit", func(t *testing.T) {
+		_, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithCompactionBytesPerSecond(-1))
+		assert.ErrorContains(t, err, "must not be negative")
+	})
+}
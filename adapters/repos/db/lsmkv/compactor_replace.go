@@ -41,6 +41,10 @@ type compactorReplace struct {
 	scratchSpacePath string
 
 	enableChecksumValidation bool
+
+	// number of keys and tombstones written to the compacted segment
+	keys       int
+	tombstones int
 }
 
 func newCompactorReplace(w io.WriteSeeker,
@@ -188,6 +192,11 @@ func (c *compactorReplace) writeIndividualNode(f *segmentindex.SegmentFile,
 		secondaryIndexCount: c.secondaryIndexCount,
 		secondaryKeys:       secondaryKeys,
 	}
+
+	c.keys++
+	if tombstone {
+		c.tombstones++
+	}
 	return segNode.KeyIndexAndWriteTo(f.BodyWriter())
 }
 
@@ -27,6 +27,7 @@ type CursorMap struct {
 	unlock       func()
 	listCfg      MapListOptionConfig
 	keyOnly      bool
+	err          error
 }
 
 type cursorStateMap struct {
@@ -49,7 +50,11 @@ func (b *Bucket) MapCursor(cfgs ...MapListOption) *CursorMap {
 		cfg(&c)
 	}
 
-	innerCursors, unlockSegmentGroup := b.disk.newMapCursors()
+	innerCursors, unlockSegmentGroup, err := b.disk.newMapCursors()
+	if err != nil {
+		b.flushLock.RUnlock()
+		return &CursorMap{err: b.cursorOpenFailed(err), unlock: func() {}}
+	}
 
 	// we have a flush-RLock, so we have the guarantee that the flushing state
 	// will not change for the lifetime of the cursor, thus there can only be two
@@ -96,6 +101,12 @@ func (c *CursorMap) First(ctx context.Context) ([]byte, []MapPair) {
 	return c.serveCurrentStateAndAdvance(ctx)
 }
 
+// Err returns the error which kept the cursor from reading the segments of
+// the bucket. Such a cursor holds no entries.
+func (c *CursorMap) Err() error {
+	return c.err
+}
+
 func (c *CursorMap) Close() {
 	c.unlock()
 }
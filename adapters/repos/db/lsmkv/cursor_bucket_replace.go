@@ -23,6 +23,7 @@ type CursorReplace struct {
 	state        []cursorStateReplace
 	unlock       func()
 	serveCache   cursorStateReplace
+	err          error
 
 	reusableIDList []int
 }
@@ -48,7 +49,11 @@ func (b *Bucket) Cursor() *CursorReplace {
 		panic("Cursor() called on strategy other than 'replace'")
 	}
 
-	innerCursors, unlockSegmentGroup := b.disk.newCursors()
+	innerCursors, unlockSegmentGroup, err := b.disk.newCursors()
+	if err != nil {
+		b.flushLock.RUnlock()
+		return &CursorReplace{err: b.cursorOpenFailed(err), unlock: func() {}}
+	}
 
 	// we have a flush-RLock, so we have the guarantee that the flushing state
 	// will not change for the lifetime of the cursor, thus there can only be two
@@ -83,7 +88,11 @@ func (b *Bucket) CursorWith(desiredSecondaryIndexCount int) *CursorReplace {
 		panic("CursorWith(desiredSecondaryIndexCount) called on a bucket with a different secondary index count")
 	}
 
-	innerCursors, unlockSegmentGroup := b.disk.newCursorsWith(desiredSecondaryIndexCount)
+	innerCursors, unlockSegmentGroup, err := b.disk.newCursorsWith(desiredSecondaryIndexCount)
+	if err != nil {
+		b.flushLock.RUnlock()
+		return &CursorReplace{err: b.cursorOpenFailed(err), unlock: func() {}}
+	}
 
 	// we have a flush-RLock, so we have the guarantee that the flushing state
 	// will not change for the lifetime of the cursor, thus there can only be two
@@ -118,7 +127,11 @@ func (b *Bucket) CursorWithSecondaryIndex(pos int) *CursorReplace {
 		panic("CursorWithSecondaryIndex() called on a bucket without enough secondary indexes")
 	}
 
-	innerCursors, unlockSegmentGroup := b.disk.newCursorsWithSecondaryIndex(pos)
+	innerCursors, unlockSegmentGroup, err := b.disk.newCursorsWithSecondaryIndex(pos)
+	if err != nil {
+		b.flushLock.RUnlock()
+		return &CursorReplace{err: b.cursorOpenFailed(err), unlock: func() {}}
+	}
 
 	// we have a flush-RLock, so we have the guarantee that the flushing state
 	// will not change for the lifetime of the cursor, thus there can only be two
@@ -140,6 +153,12 @@ func (b *Bucket) CursorWithSecondaryIndex(pos int) *CursorReplace {
 	}
 }
 
+// Err returns the error which kept the cursor from reading the segments of
+// the bucket. Such a cursor holds no entries.
+func (c *CursorReplace) Err() error {
+	return c.err
+}
+
 func (c *CursorReplace) Close() {
 	c.unlock()
 }
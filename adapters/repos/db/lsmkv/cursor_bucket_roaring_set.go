@@ -57,7 +57,15 @@ func (b *Bucket) cursorRoaringSet(keyOnly bool) CursorRoaringSet {
 
 	b.flushLock.RLock()
 
-	innerCursors, unlockSegmentGroup := b.disk.newRoaringSetCursors()
+	innerCursors, unlockSegmentGroup, err := b.disk.newRoaringSetCursors()
+	if err != nil {
+		b.cursorOpenFailed(err)
+		b.flushLock.RUnlock()
+		return &cursorRoaringSet{
+			combinedCursor: roaringset.NewCombinedCursor(nil, keyOnly),
+			unlock:         func() {},
+		}
+	}
 
 	// we have a flush-RLock, so we have the guarantee that the flushing state
 	// will not change for the lifetime of the cursor, thus there can only be two
@@ -24,6 +24,7 @@ type CursorSet struct {
 	state        []cursorStateCollection
 	unlock       func()
 	keyOnly      bool
+	err          error
 }
 
 type innerCursorCollection interface {
@@ -47,7 +48,11 @@ func (b *Bucket) SetCursor() *CursorSet {
 		panic("SetCursor() called on strategy other than 'set'")
 	}
 
-	innerCursors, unlockSegmentGroup := b.disk.newCollectionCursors()
+	innerCursors, unlockSegmentGroup, err := b.disk.newCollectionCursors()
+	if err != nil {
+		b.flushLock.RUnlock()
+		return &CursorSet{err: b.cursorOpenFailed(err), unlock: func() {}}
+	}
 
 	// we have a flush-RLock, so we have the guarantee that the flushing state
 	// will not change for the lifetime of the cursor, thus there can only be two
@@ -95,6 +100,12 @@ func (c *CursorSet) First() ([]byte, [][]byte) {
 	return c.serveCurrentStateAndAdvance()
 }
 
+// Err returns the error which kept the cursor from reading the segments of
+// the bucket. Such a cursor holds no entries.
+func (c *CursorSet) Err() error {
+	return c.err
+}
+
 func (c *CursorSet) Close() {
 	c.unlock()
 }
@@ -20,21 +20,29 @@ type segmentCursorCollection struct {
 	nextOffset uint64
 }
 
-func (s *segment) newCollectionCursor() *segmentCursorCollection {
+func (s *segment) newCollectionCursor() (*segmentCursorCollection, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, err
+	}
 	return &segmentCursorCollection{
 		segment: s,
-	}
+	}, nil
 }
 
-func (sg *SegmentGroup) newCollectionCursors() ([]innerCursorCollection, func()) {
+func (sg *SegmentGroup) newCollectionCursors() ([]innerCursorCollection, func(), error) {
 	sg.maintenanceLock.RLock()
 	out := make([]innerCursorCollection, len(sg.segments))
 
 	for i, segment := range sg.segments {
-		out[i] = segment.newCollectionCursor()
+		cursor, err := segment.newCollectionCursor()
+		if err != nil {
+			sg.maintenanceLock.RUnlock()
+			return nil, nil, err
+		}
+		out[i] = cursor
 	}
 
-	return out, sg.maintenanceLock.RUnlock
+	return out, sg.maintenanceLock.RUnlock, nil
 }
 
 func (s *segmentCursorCollection) seek(key []byte) ([]byte, []value, error) {
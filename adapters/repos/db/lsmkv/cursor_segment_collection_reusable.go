@@ -21,10 +21,13 @@ type segmentCursorCollectionReusable struct {
 	nodeBuf    segmentCollectionNode
 }
 
-func (s *segment) newCollectionCursorReusable() *segmentCursorCollectionReusable {
+func (s *segment) newCollectionCursorReusable() (*segmentCursorCollectionReusable, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, err
+	}
 	return &segmentCursorCollectionReusable{
 		segment: s,
-	}
+	}, nil
 }
 
 func (s *segmentCursorCollectionReusable) seek(key []byte) ([]byte, []value, error) {
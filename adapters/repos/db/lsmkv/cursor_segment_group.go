@@ -0,0 +1,93 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"fmt"
+)
+
+// Cursor iterates all live keys of a segment group in sorted order. It is
+// meant for tools which export a bucket or build derived indexes from its
+// contents. The segments are merged lazily, so memory usage does not grow
+// with the size of the segment group: for duplicate keys only the value of
+// the newest segment is returned and deleted keys are skipped.
+//
+// The cursor iterates a snapshot of the segments taken when it is opened. It
+// does not block compactions or cleanups, segments replaced by them are kept
+// open until the cursor is closed. It needs to be closed using Close or
+// otherwise the segments of the snapshot will never be closed.
+type Cursor struct {
+	inner   *CursorReplace
+	started bool
+	closed  bool
+}
+
+// NewCursor returns a cursor over the segments of the segment group, see
+// Cursor. Entries in the memtables are not included. Only the replace
+// strategy is supported.
+func (sg *SegmentGroup) NewCursor() (*Cursor, error) {
+	if sg.strategy != StrategyReplace {
+		return nil, fmt.Errorf("cursor not supported for strategy %q", sg.strategy)
+	}
+
+	innerCursors, release, err := sg.newCursorsOfSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &Cursor{
+		inner: &CursorReplace{
+			// ordered from oldest to newest segment, so newer values win
+			innerCursors: innerCursors,
+			unlock:       release,
+		},
+	}, nil
+}
+
+// Next returns the next live key and its value. ok is false once all keys
+// were returned or the cursor was closed. The returned slices are only valid
+// until the next call and need to be copied to be retained.
+func (c *Cursor) Next() (key, value []byte, ok bool) {
+	if c.closed {
+		return nil, nil, false
+	}
+
+	if !c.started {
+		c.started = true
+		key, value = c.inner.First()
+	} else {
+		key, value = c.inner.Next()
+	}
+	return key, value, key != nil
+}
+
+// Seek moves the cursor to the first live key which is equal to or greater
+// than key and returns it, Next continues after it. ok is false if there is
+// no such key or the cursor was closed.
+func (c *Cursor) Seek(key []byte) (foundKey, value []byte, ok bool) {
+	if c.closed {
+		return nil, nil, false
+	}
+
+	c.started = true
+	foundKey, value = c.inner.Seek(key)
+	return foundKey, value, foundKey != nil
+}
+
+// Close releases the segments held by the cursor. It is safe to call Close
+// more than once.
+func (c *Cursor) Close() {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.inner.Close()
+}
@@ -0,0 +1,181 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroupCursor(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(t *testing.T, strategy string) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(strategy))
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+		return b
+	}
+
+	collect := func(t *testing.T, c *Cursor) map[string]string {
+		out := map[string]string{}
+		var keys []string
+		for k, v, ok := c.Next(); ok; k, v, ok = c.Next() {
+			keys = append(keys, string(k))
+			out[string(k)] = string(v)
+		}
+		assert.IsIncreasing(t, keys)
+		return out
+	}
+
+	t.Run("merges segments with deletes", func(t *testing.T) {
+		b := newBucket(t, StrategyReplace)
+
+		// segment 1
+		for i := 0; i < 10; i++ {
+			require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%02d", i)), []byte("v1")))
+		}
+		require.Nil(t, b.FlushAndSwitch())
+
+		// segment 2 updates the even keys and deletes key-03
+		for i := 0; i < 10; i += 2 {
+			require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%02d", i)), []byte("v2")))
+		}
+		require.Nil(t, b.Delete([]byte("key-03")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		// segment 3 deletes key-04, re-adds key-03 and adds a new key
+		require.Nil(t, b.Delete([]byte("key-04")))
+		require.Nil(t, b.Put([]byte("key-03"), []byte("v3")))
+		require.Nil(t, b.Put([]byte("key-10"), []byte("v3")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		// memtable entries are not part of the segment group
+		require.Nil(t, b.Put([]byte("key-11"), []byte("memtable")))
+		require.Nil(t, b.Delete([]byte("key-00")))
+
+		c, err := b.disk.NewCursor()
+		require.Nil(t, err)
+		defer c.Close()
+
+		expected := map[string]string{
+			"key-00": "v2", "key-01": "v1", "key-02": "v2", "key-03": "v3",
+			"key-05": "v1", "key-06": "v2", "key-07": "v1", "key-08": "v2",
+			"key-09": "v1", "key-10": "v3",
+		}
+		assert.Equal(t, expected, collect(t, c))
+
+		_, _, ok := c.Next()
+		assert.False(t, ok)
+	})
+
+	t.Run("empty segment group", func(t *testing.T) {
+		b := newBucket(t, StrategyReplace)
+
+		c, err := b.disk.NewCursor()
+		require.Nil(t, err)
+		defer c.Close()
+
+		_, _, ok := c.Next()
+		assert.False(t, ok)
+	})
+
+	t.Run("close releases the segments", func(t *testing.T) {
+		b := newBucket(t, StrategyReplace)
+		require.Nil(t, b.Put([]byte("key"), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		c, err := b.disk.NewCursor()
+		require.Nil(t, err)
+		c.Close()
+		c.Close()
+
+		_, _, ok := c.Next()
+		assert.False(t, ok)
+
+		locked := make(chan struct{})
+		go func() {
+			b.disk.maintenanceLock.Lock()
+			b.disk.maintenanceLock.Unlock()
+			close(locked)
+		}()
+		select {
+		case <-locked:
+		case <-time.After(time.Second):
+			t.Fatal("maintenance lock still held after closing the cursor")
+		}
+		for _, seg := range b.disk.segments {
+			assert.False(t, seg.isAcquired())
+		}
+	})
+
+	t.Run("compaction while open", func(t *testing.T) {
+		b := newBucket(t, StrategyReplace)
+		expected := map[string]string{}
+		for segment := 0; segment < 2; segment++ {
+			for i := 0; i < 100; i++ {
+				key := fmt.Sprintf("key-%03d", i*2+segment)
+				require.Nil(t, b.Put([]byte(key), []byte("value")))
+				expected[key] = "value"
+			}
+			require.Nil(t, b.FlushAndSwitch())
+		}
+		old := append([]*segment{}, b.disk.segments...)
+
+		c, err := b.disk.NewCursor()
+		require.Nil(t, err)
+		defer c.Close()
+
+		k, v, ok := c.Next()
+		require.True(t, ok)
+		actual := map[string]string{string(k): string(v)}
+
+		// the cursor does not block the segments from being replaced
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		require.True(t, compacted)
+		require.Equal(t, 1, b.disk.Len())
+		// the compacted segment took the name of the newer one
+		assert.NoFileExists(t, old[0].path)
+
+		for k, v, ok := c.Next(); ok; k, v, ok = c.Next() {
+			actual[string(k)] = string(v)
+		}
+		assert.Equal(t, expected, actual)
+
+		for _, seg := range old {
+			assert.True(t, seg.closePending)
+		}
+		c.Close()
+		for _, seg := range old {
+			assert.False(t, seg.isAcquired())
+			assert.False(t, seg.closePending)
+		}
+	})
+
+	t.Run("unsupported strategy", func(t *testing.T) {
+		b := newBucket(t, StrategySetCollection)
+
+		_, err := b.disk.NewCursor()
+		assert.NotNil(t, err)
+	})
+}
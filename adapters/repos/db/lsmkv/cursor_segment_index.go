@@ -0,0 +1,138 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+	"github.com/weaviate/weaviate/entities/lsmkv"
+)
+
+// segmentIndexCursor is a bidirectional cursor over the primary index of a
+// single segment. Unlike the data cursors (e.g. segmentCursorReplace) which
+// scan the data section sequentially, it navigates the index tree and can
+// therefore move in both directions.
+type segmentIndexCursor interface {
+	// First positions the cursor at the lowest key
+	First() bool
+	// SeekLast positions the cursor at the highest key
+	SeekLast() bool
+	// Next moves the cursor to the next higher key
+	Next() bool
+	// Prev moves the cursor to the next lower key
+	Prev() bool
+	// Key of the current position, only valid while the cursor is positioned
+	Key() []byte
+	// Value of the current position, returns lsmkv.Deleted for tombstones
+	Value() ([]byte, error)
+	// ValueInto is like Value, but reads into buf instead of allocating a new
+	// slice, if buf is large enough. It returns the (possibly grown) buffer for
+	// reuse. The value is only valid until buf is reused.
+	ValueInto(buf []byte) ([]byte, []byte, error)
+	// Err returns the first unexpected error the cursor ran into, if any
+	Err() error
+}
+
+type segmentIndexCursorReplace struct {
+	segment *segment
+	index   diskIndex
+	node    segmentindex.Node
+	valid   bool
+	err     error
+}
+
+func (s *segment) newIndexCursor() (*segmentIndexCursorReplace, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, err
+	}
+	return &segmentIndexCursorReplace{
+		segment: s,
+		index:   s.index,
+	}, nil
+}
+
+func (c *segmentIndexCursorReplace) First() bool {
+	return c.position(c.index.Seek(nil))
+}
+
+func (c *segmentIndexCursorReplace) SeekLast() bool {
+	return c.position(c.index.Last())
+}
+
+func (c *segmentIndexCursorReplace) Next() bool {
+	if !c.valid {
+		return false
+	}
+	return c.position(c.index.Next(c.node.Key))
+}
+
+func (c *segmentIndexCursorReplace) Prev() bool {
+	if !c.valid {
+		return false
+	}
+	return c.position(c.index.Prev(c.node.Key))
+}
+
+func (c *segmentIndexCursorReplace) position(node segmentindex.Node, err error) bool {
+	if err != nil {
+		if !errors.Is(err, lsmkv.NotFound) {
+			c.err = fmt.Errorf("segment index cursor of %q: %w", c.segment.path, err)
+		}
+		c.valid = false
+		return false
+	}
+
+	c.node = node
+	c.valid = true
+	return true
+}
+
+func (c *segmentIndexCursorReplace) Key() []byte {
+	return c.node.Key
+}
+
+func (c *segmentIndexCursorReplace) Value() ([]byte, error) {
+	if !c.valid {
+		return nil, lsmkv.NotFound
+	}
+
+	// the value is copied, so it remains valid after the cursor (and the lock
+	// protecting the segment) is released. See segment.get() for details.
+	v, _, err := c.ValueInto(nil)
+	return v, err
+}
+
+func (c *segmentIndexCursorReplace) ValueInto(buf []byte) ([]byte, []byte, error) {
+	if !c.valid {
+		return nil, buf, lsmkv.NotFound
+	}
+
+	size := int(c.node.End - c.node.Start)
+	if cap(buf) < size {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+	if err := c.segment.copyNode(buf, nodeOffset{c.node.Start, c.node.End}); err != nil {
+		return nil, buf, err
+	}
+
+	_, v, err := c.segment.replaceStratParseData(buf)
+	return v, buf, err
+}
+
+func (c *segmentIndexCursorReplace) Err() error {
+	return c.err
+}
+
+var _ segmentIndexCursor = (*segmentIndexCursorReplace)(nil)
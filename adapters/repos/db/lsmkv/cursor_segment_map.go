@@ -24,25 +24,34 @@ type segmentCursorMap struct {
 	nextOffset uint64
 }
 
-func (s *segment) newMapCursor() *segmentCursorMap {
+func (s *segment) newMapCursor() (*segmentCursorMap, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, err
+	}
 	return &segmentCursorMap{
 		segment: s,
-	}
+	}, nil
 }
 
-func (sg *SegmentGroup) newMapCursors() ([]innerCursorMap, func()) {
+func (sg *SegmentGroup) newMapCursors() ([]innerCursorMap, func(), error) {
 	sg.maintenanceLock.RLock()
 	out := make([]innerCursorMap, len(sg.segments))
 
 	for i, segment := range sg.segments {
 		if segment.strategy == segmentindex.StrategyInverted {
 			out[i] = segment.newInvertedCursorReusable()
-		} else {
-			out[i] = segment.newMapCursor()
+			continue
+		}
+
+		cursor, err := segment.newMapCursor()
+		if err != nil {
+			sg.maintenanceLock.RUnlock()
+			return nil, nil, err
 		}
+		out[i] = cursor
 	}
 
-	return out, sg.maintenanceLock.RUnlock
+	return out, sg.maintenanceLock.RUnlock, nil
 }
 
 func (s *segmentCursorMap) decode(parsed segmentCollectionNode) ([]MapPair, error) {
@@ -27,7 +27,10 @@ type segmentCursorReplace struct {
 	reusableBORW  byteops.ReadWriter
 }
 
-func (s *segment) newCursor() *segmentCursorReplace {
+func (s *segment) newCursor() (*segmentCursorReplace, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, err
+	}
 	cursor := &segmentCursorReplace{
 		segment: s,
 		index:   s.index,
@@ -49,13 +52,16 @@ func (s *segment) newCursor() *segmentCursorReplace {
 		return cursor.currOffset + uint64(n.offset), nil
 	}
 
-	return cursor
+	return cursor, nil
 }
 
 // Note: scanning over secondary keys is sub-optimal
 // i.e. no sequential scan is possible as when scanning over the primary key
 
-func (s *segment) newCursorWithSecondaryIndex(pos int) *segmentCursorReplace {
+func (s *segment) newCursorWithSecondaryIndex(pos int) (*segmentCursorReplace, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, err
+	}
 	return &segmentCursorReplace{
 		segment: s,
 		index:   s.secondaryIndices[pos],
@@ -80,21 +86,58 @@ func (s *segment) newCursorWithSecondaryIndex(pos int) *segmentCursorReplace {
 		},
 		reusableNode: &segmentReplaceNode{},
 		reusableBORW: byteops.NewReadWriter(nil),
-	}
+	}, nil
 }
 
-func (sg *SegmentGroup) newCursors() ([]innerCursorReplace, func()) {
+func (sg *SegmentGroup) newCursors() ([]innerCursorReplace, func(), error) {
 	sg.maintenanceLock.RLock()
 	out := make([]innerCursorReplace, len(sg.segments))
 
 	for i, segment := range sg.segments {
-		out[i] = segment.newCursor()
+		cursor, err := segment.newCursor()
+		if err != nil {
+			sg.maintenanceLock.RUnlock()
+			return nil, nil, err
+		}
+		out[i] = cursor
 	}
 
-	return out, sg.maintenanceLock.RUnlock
+	return out, sg.maintenanceLock.RUnlock, nil
 }
 
-func (sg *SegmentGroup) newCursorsWith(desiredSecondaryIndexCount int) ([]innerCursorReplace, func()) {
+// newCursorsOfSnapshot is like newCursors, but only holds the maintenanceLock
+// while creating the cursors. The segments are acquired instead, so the
+// cursors stay valid if the segments are replaced by compactions or cleanups
+// in the meantime. The returned func releases the segments and needs to be
+// called once the cursors are no longer used.
+func (sg *SegmentGroup) newCursorsOfSnapshot() ([]innerCursorReplace, func(), error) {
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	acquired := make([]*segment, 0, len(sg.segments))
+	release := func() {
+		for _, segment := range acquired {
+			segment.release()
+		}
+	}
+
+	out := make([]innerCursorReplace, len(sg.segments))
+	for i, segment := range sg.segments {
+		segment.acquire()
+		acquired = append(acquired, segment)
+
+		cursor, err := segment.newCursor()
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		out[i] = cursor
+	}
+
+	return out, release, nil
+}
+
+func (sg *SegmentGroup) newCursorsWith(desiredSecondaryIndexCount int) ([]innerCursorReplace, func(), error) {
 	sg.maintenanceLock.RLock()
 	out := make([]innerCursorReplace, 0, len(sg.segments))
 
@@ -102,13 +145,18 @@ func (sg *SegmentGroup) newCursorsWith(desiredSecondaryIndexCount int) ([]innerC
 		if int(segment.secondaryIndexCount) != desiredSecondaryIndexCount {
 			continue
 		}
-		out = append(out, segment.newCursor())
+		cursor, err := segment.newCursor()
+		if err != nil {
+			sg.maintenanceLock.RUnlock()
+			return nil, nil, err
+		}
+		out = append(out, cursor)
 	}
 
-	return out, sg.maintenanceLock.RUnlock
+	return out, sg.maintenanceLock.RUnlock, nil
 }
 
-func (sg *SegmentGroup) newCursorsWithSecondaryIndex(pos int) ([]innerCursorReplace, func()) {
+func (sg *SegmentGroup) newCursorsWithSecondaryIndex(pos int) ([]innerCursorReplace, func(), error) {
 	sg.maintenanceLock.RLock()
 	out := make([]innerCursorReplace, 0, len(sg.segments))
 
@@ -116,10 +164,15 @@ func (sg *SegmentGroup) newCursorsWithSecondaryIndex(pos int) ([]innerCursorRepl
 		if int(segment.secondaryIndexCount) <= pos {
 			continue
 		}
-		out = append(out, segment.newCursorWithSecondaryIndex(pos))
+		cursor, err := segment.newCursorWithSecondaryIndex(pos)
+		if err != nil {
+			sg.maintenanceLock.RUnlock()
+			return nil, nil, err
+		}
+		out = append(out, cursor)
 	}
 
-	return out, sg.maintenanceLock.RUnlock
+	return out, sg.maintenanceLock.RUnlock, nil
 }
 
 func (s *segmentCursorReplace) seek(key []byte) ([]byte, []byte, error) {
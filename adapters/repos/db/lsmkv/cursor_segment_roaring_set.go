@@ -16,20 +16,28 @@ import (
 	"github.com/weaviate/weaviate/adapters/repos/db/roaringset"
 )
 
-func (s *segment) newRoaringSetCursor() *roaringset.SegmentCursor {
+func (s *segment) newRoaringSetCursor() (*roaringset.SegmentCursor, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, err
+	}
 	return roaringset.NewSegmentCursor(s.contents[s.dataStartPos:s.dataEndPos],
-		&roaringSetSeeker{s.index})
+		&roaringSetSeeker{s.index}), nil
 }
 
-func (sg *SegmentGroup) newRoaringSetCursors() ([]roaringset.InnerCursor, func()) {
+func (sg *SegmentGroup) newRoaringSetCursors() ([]roaringset.InnerCursor, func(), error) {
 	sg.maintenanceLock.RLock()
 	out := make([]roaringset.InnerCursor, len(sg.segments))
 
 	for i, segment := range sg.segments {
-		out[i] = segment.newRoaringSetCursor()
+		cursor, err := segment.newRoaringSetCursor()
+		if err != nil {
+			sg.maintenanceLock.RUnlock()
+			return nil, nil, err
+		}
+		out[i] = cursor
 	}
 
-	return out, sg.maintenanceLock.RUnlock
+	return out, sg.maintenanceLock.RUnlock, nil
 }
 
 // diskIndex returns node's Start and End offsets
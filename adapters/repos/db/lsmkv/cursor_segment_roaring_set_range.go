@@ -18,23 +18,32 @@ import (
 	"github.com/weaviate/weaviate/entities/concurrency"
 )
 
-func (sg *SegmentGroup) newRoaringSetRangeReaders() ([]roaringsetrange.InnerReader, func()) {
+func (sg *SegmentGroup) newRoaringSetRangeReaders() ([]roaringsetrange.InnerReader, func(), error) {
 	sg.maintenanceLock.RLock()
 
 	readers := make([]roaringsetrange.InnerReader, len(sg.segments))
 	for i, segment := range sg.segments {
-		readers[i] = segment.newRoaringSetRangeReader()
+		reader, err := segment.newRoaringSetRangeReader()
+		if err != nil {
+			sg.maintenanceLock.RUnlock()
+			return nil, nil, err
+		}
+		readers[i] = reader
 	}
 
-	return readers, sg.maintenanceLock.RUnlock
+	return readers, sg.maintenanceLock.RUnlock, nil
 }
 
-func (s *segment) newRoaringSetRangeReader() *roaringsetrange.SegmentReader {
+func (s *segment) newRoaringSetRangeReader() (*roaringsetrange.SegmentReader, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, err
+	}
+
 	var segmentCursor roaringsetrange.SegmentCursor
 	if s.mmapContents {
 		segmentCursor = roaringsetrange.NewSegmentCursorMmap(s.contents[s.dataStartPos:s.dataEndPos])
 	} else {
-		sectionReader := io.NewSectionReader(s.contentFile, int64(s.dataStartPos), int64(s.dataEndPos))
+		sectionReader := io.NewSectionReader(s.readerAt(), int64(s.dataStartPos), int64(s.dataEndPos))
 		// since segment reader concurrenlty fetches next segment and merges bitmaps of previous segments
 		// at least 2 buffers needs to be used by cursor not to overwrite data before they are consumed.
 		segmentCursor = roaringsetrange.NewSegmentCursorPread(sectionReader, 2)
@@ -42,16 +51,20 @@ func (s *segment) newRoaringSetRangeReader() *roaringsetrange.SegmentReader {
 
 	return roaringsetrange.NewSegmentReaderConcurrent(
 		roaringsetrange.NewGaplessSegmentCursor(segmentCursor),
-		concurrency.SROAR_MERGE)
+		concurrency.SROAR_MERGE), nil
 }
 
-func (s *segment) newRoaringSetRangeCursor() roaringsetrange.SegmentCursor {
+func (s *segment) newRoaringSetRangeCursor() (roaringsetrange.SegmentCursor, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, err
+	}
+
 	if s.mmapContents {
-		return roaringsetrange.NewSegmentCursorMmap(s.contents[s.dataStartPos:s.dataEndPos])
+		return roaringsetrange.NewSegmentCursorMmap(s.contents[s.dataStartPos:s.dataEndPos]), nil
 	}
 
-	sectionReader := io.NewSectionReader(s.contentFile, int64(s.dataStartPos), int64(s.dataEndPos))
+	sectionReader := io.NewSectionReader(s.readerAt(), int64(s.dataStartPos), int64(s.dataEndPos))
 	// compactor does not work concurrently, next segment is fetched after previous one gets consumed,
 	// therefore just one buffer is sufficient.
-	return roaringsetrange.NewSegmentCursorPread(sectionReader, 1)
+	return roaringsetrange.NewSegmentCursorPread(sectionReader, 1), nil
 }
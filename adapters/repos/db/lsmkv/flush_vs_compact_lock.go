@@ -0,0 +1,69 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// flushVsCompactPollInterval is how often a compaction waiting for pending
+// flushes checks whether it may take the lock
+const flushVsCompactPollInterval = time.Millisecond
+
+// flushVsCompactMutex serializes the overlapping parts of the flush and
+// compaction cycles, see SegmentGroup.flushVsCompactLock. Flushes acquire it
+// using lockForFlush(), everything else using Lock().
+//
+// By default it behaves like a plain sync.Mutex. If prioritizeFlush is set, a
+// pending flush takes precedence: Lock() does not return while a flush is
+// waiting for the lock, so a compaction can't delay a flush, and with it
+// writes, by more than the critical section that is already running. The zero
+// value is an unlocked mutex without priorities.
+type flushVsCompactMutex struct {
+	mu              sync.Mutex
+	pendingFlushes  atomic.Int32
+	prioritizeFlush bool
+}
+
+// Lock acquires the mutex for anything but a flush, yielding to pending
+// flushes if they are prioritized
+func (m *flushVsCompactMutex) Lock() {
+	for {
+		if m.prioritizeFlush && m.pendingFlushes.Load() > 0 {
+			time.Sleep(flushVsCompactPollInterval)
+			continue
+		}
+
+		m.mu.Lock()
+		// a flush may have started waiting right before the lock was taken,
+		// the critical section hasn't started yet, so it is safe to step back
+		if m.prioritizeFlush && m.pendingFlushes.Load() > 0 {
+			m.mu.Unlock()
+			continue
+		}
+		return
+	}
+}
+
+// lockForFlush acquires the mutex for a flush
+func (m *flushVsCompactMutex) lockForFlush() {
+	m.pendingFlushes.Add(1)
+	defer m.pendingFlushes.Add(-1)
+
+	m.mu.Lock()
+}
+
+func (m *flushVsCompactMutex) Unlock() {
+	m.mu.Unlock()
+}
@@ -0,0 +1,111 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlushVsCompactMutex(t *testing.T) {
+	const (
+		compactors = 4
+		hold       = 20 * time.Millisecond
+	)
+
+	// compactors continuously contend for the lock while a single flush tries
+	// to acquire it, the time the flush had to wait is returned
+	flushWaitUnderContention := func(t *testing.T, m *flushVsCompactMutex) time.Duration {
+		stop := make(chan struct{})
+		wg := sync.WaitGroup{}
+		for i := 0; i < compactors; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					m.Lock()
+					time.Sleep(hold)
+					m.Unlock()
+				}
+			}()
+		}
+		defer func() {
+			close(stop)
+			wg.Wait()
+		}()
+
+		// make sure the compactors are queued up
+		time.Sleep(2 * hold)
+
+		start := time.Now()
+		m.lockForFlush()
+		wait := time.Since(start)
+		m.Unlock()
+		return wait
+	}
+
+	t.Run("flush waits for queued compactions by default", func(t *testing.T) {
+		wait := flushWaitUnderContention(t, &flushVsCompactMutex{})
+		t.Logf("flush waited %s", wait)
+	})
+
+	t.Run("prioritized flush waits for the running compaction only", func(t *testing.T) {
+		wait := flushWaitUnderContention(t, &flushVsCompactMutex{prioritizeFlush: true})
+		t.Logf("flush waited %s", wait)
+		assert.Less(t, wait, 2*hold)
+	})
+
+	t.Run("prioritized flush goes before waiting compactions", func(t *testing.T) {
+		m := &flushVsCompactMutex{prioritizeFlush: true}
+		m.Lock()
+
+		var order []string
+		orderLock := sync.Mutex{}
+		record := func(name string) {
+			orderLock.Lock()
+			defer orderLock.Unlock()
+			order = append(order, name)
+		}
+
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			m.lockForFlush()
+			record("flush")
+			m.Unlock()
+		}()
+		// wait for the flush to be pending before the second compaction queues up
+		assert.Eventually(t, func() bool { return m.pendingFlushes.Load() == 1 },
+			time.Second, time.Millisecond)
+		go func() {
+			defer wg.Done()
+			m.Lock()
+			record("compaction")
+			m.Unlock()
+		}()
+
+		time.Sleep(hold)
+		m.Unlock()
+		wg.Wait()
+
+		assert.Equal(t, []string{"flush", "compaction"}, order)
+	})
+}
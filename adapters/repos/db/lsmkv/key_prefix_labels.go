@@ -0,0 +1,130 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxKeyPrefixLabels bounds the cardinality of the key_prefix label across
+	// all buckets of the process
+	maxKeyPrefixLabels = 256
+	// keyPrefixLabelOther is shared by all prefixes beyond maxKeyPrefixLabels
+	keyPrefixLabelOther = "other"
+)
+
+// keyPrefixLabelBudget bounds the number of distinct key_prefix labels. It is
+// shared by all segment groups, as each label of each segment group is a
+// separate series.
+type keyPrefixLabelBudget struct {
+	limit    int32
+	count    atomic.Int32
+	warnOnce sync.Once
+}
+
+var globalKeyPrefixLabelBudget = &keyPrefixLabelBudget{limit: maxKeyPrefixLabels}
+
+// keyPrefixLabels maps the first length bytes of keys to the read observers of
+// their hex encoded metric label. Only as many distinct prefixes as the budget
+// allows get their own label, further ones share keyPrefixLabelOther. A nil
+// *keyPrefixLabels, used if metrics are disabled, returns nil observers.
+type keyPrefixLabels struct {
+	length  int
+	metrics *Metrics
+	logger  logrus.FieldLogger
+	budget  *keyPrefixLabelBudget
+
+	// unlabeled are the observers of all keys if length is not positive
+	unlabeled *keyPrefixReadObservers
+	// other is resolved once the budget is exhausted, so that no empty series
+	// is created before
+	other     *keyPrefixReadObservers
+	otherOnce sync.Once
+	observers sync.Map // string(prefix) -> *keyPrefixReadObservers
+	// count is the number of labels taken from the budget
+	count atomic.Int32
+}
+
+// newKeyPrefixLabels returns nil if metrics is nil. If length is not positive
+// keys are not labeled.
+func newKeyPrefixLabels(length int, metrics *Metrics, logger logrus.FieldLogger) *keyPrefixLabels {
+	if metrics == nil {
+		return nil
+	}
+
+	l := &keyPrefixLabels{
+		length:  length,
+		metrics: metrics,
+		logger:  logger,
+		budget:  globalKeyPrefixLabelBudget,
+	}
+	if length <= 0 {
+		l.unlabeled = metrics.keyPrefixReadObservers("")
+	}
+	return l
+}
+
+func (l *keyPrefixLabels) observersOf(key []byte) *keyPrefixReadObservers {
+	if l == nil {
+		return nil
+	}
+	if l.unlabeled != nil {
+		return l.unlabeled
+	}
+
+	prefix := key
+	if len(prefix) > l.length {
+		prefix = prefix[:l.length]
+	}
+
+	if observers, ok := l.observers.Load(string(prefix)); ok {
+		return observers.(*keyPrefixReadObservers)
+	}
+
+	if l.budget.count.Add(1) > l.budget.limit {
+		l.budget.count.Add(-1)
+		l.budget.warnOnce.Do(func() {
+			l.logger.WithField("action", "lsm_key_prefix_metrics").
+				WithField("limit", l.budget.limit).
+				Warnf("observed more than %d distinct key prefixes, further prefixes "+
+					"are labeled %q in the read metrics", l.budget.limit, keyPrefixLabelOther)
+		})
+		l.otherOnce.Do(func() {
+			l.other = l.metrics.keyPrefixReadObservers(keyPrefixLabelOther)
+		})
+		return l.other
+	}
+
+	observers, loaded := l.observers.LoadOrStore(string(prefix),
+		l.metrics.keyPrefixReadObservers(hex.EncodeToString(prefix)))
+	if loaded {
+		// a concurrent read stored the same prefix first
+		l.budget.count.Add(-1)
+	} else {
+		l.count.Add(1)
+	}
+	return observers.(*keyPrefixReadObservers)
+}
+
+// release returns the labels of a segment group which is shut down to the
+// budget
+func (l *keyPrefixLabels) release() {
+	if l == nil {
+		return
+	}
+	l.budget.count.Add(-l.count.Swap(0))
+}
@@ -0,0 +1,81 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+func TestKeyPrefixLabels(t *testing.T) {
+	metrics := NewMetrics(monitoring.GetMetrics(), "KeyPrefixLabels", "shard")
+	label := func(l *keyPrefixLabels, key []byte) string {
+		return l.observersOf(key).keyPrefix()
+	}
+
+	t.Run("metrics disabled", func(t *testing.T) {
+		l := newKeyPrefixLabels(2, nil, logrus.New())
+		assert.Nil(t, l)
+		assert.Nil(t, l.observersOf([]byte("key")))
+		assert.Equal(t, "", label(l, []byte("key")))
+	})
+
+	t.Run("not labeled", func(t *testing.T) {
+		l := newKeyPrefixLabels(0, metrics, logrus.New())
+		observers := l.observersOf([]byte("key"))
+		assert.Equal(t, "", observers.keyPrefix())
+		// resolved once
+		assert.Same(t, observers, l.observersOf([]byte("other-key")))
+	})
+
+	t.Run("hex encoded prefix", func(t *testing.T) {
+		l := newKeyPrefixLabels(2, metrics, logrus.New())
+		l.budget = &keyPrefixLabelBudget{limit: maxKeyPrefixLabels}
+		assert.Equal(t, "0102", label(l, []byte{1, 2, 3}))
+		assert.Same(t, l.observersOf([]byte{1, 2, 3}), l.observersOf([]byte{1, 2, 4}))
+		// shorter keys are labeled entirely
+		assert.Equal(t, "07", label(l, []byte{7}))
+	})
+
+	t.Run("bounded cardinality across segment groups", func(t *testing.T) {
+		logger, hook := test.NewNullLogger()
+		budget := &keyPrefixLabelBudget{limit: 4}
+		first := newKeyPrefixLabels(2, metrics, logger)
+		first.budget = budget
+		second := newKeyPrefixLabels(2, metrics, logger)
+		second.budget = budget
+
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, fmt.Sprintf("%04x", i), label(first, []byte{byte(i >> 8), byte(i)}))
+			assert.Equal(t, fmt.Sprintf("%04x", i), label(second, []byte{byte(i >> 8), byte(i)}))
+		}
+		assert.Empty(t, hook.AllEntries())
+
+		assert.Equal(t, keyPrefixLabelOther, label(first, []byte{0xff, 0xff}))
+		assert.Equal(t, keyPrefixLabelOther, label(second, []byte{0xff, 0xfe}))
+		// known prefixes keep their label
+		assert.Equal(t, "0001", label(first, []byte{0, 1}))
+
+		// warned once
+		assert.Len(t, hook.AllEntries(), 1)
+		assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+
+		// labels of shut down segment groups are returned to the budget
+		first.release()
+		assert.Equal(t, "fffe", label(second, []byte{0xff, 0xfe}))
+	})
+}
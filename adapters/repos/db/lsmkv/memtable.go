@@ -441,6 +441,14 @@ func (m *Memtable) writeWAL() error {
 	return m.commitlog.flushBuffers()
 }
 
+// syncWAL is like writeWAL, but additionally fsyncs the WAL
+func (m *Memtable) syncWAL() error {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.commitlog.sync()
+}
+
 func (m *Memtable) GetTombstones() (*sroar.Bitmap, error) {
 	if m.strategy != StrategyInverted && m.strategy != StrategyMapCollection {
 		return nil, errors.Errorf("tombstones only supported for inverted and map collection strategies")
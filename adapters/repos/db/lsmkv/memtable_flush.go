@@ -14,7 +14,6 @@ package lsmkv
 import (
 	"bufio"
 	"fmt"
-	"os"
 
 	"github.com/pkg/errors"
 	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
@@ -41,7 +40,7 @@ func (m *Memtable) flush() error {
 		return nil
 	}
 
-	f, err := os.OpenFile(m.path+".db", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o666)
+	f, err := openSegmentFileForWrite(m.path + ".db")
 	if err != nil {
 		return err
 	}
@@ -27,7 +27,7 @@ func Test_MemtableSecondaryKeyBug(t *testing.T) {
 	dir := t.TempDir()
 
 	logger, _ := test.NewNullLogger()
-	cl, err := newCommitLogger(dir)
+	cl, err := newCommitLogger(dir, logger)
 	require.NoError(t, err)
 
 	m, err := newMemtable(path.Join(dir, "will-never-flush"), StrategyReplace, 1, cl, nil, logger, false)
@@ -12,6 +12,7 @@
 package lsmkv
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -37,12 +38,22 @@ type Metrics struct {
 	SegmentObjects               *prometheus.GaugeVec
 	SegmentSize                  *prometheus.GaugeVec
 	SegmentCount                 *prometheus.GaugeVec
+	TierAmplification            *prometheus.GaugeVec
 	startupDurations             prometheus.ObserverVec
 	startupDiskIO                prometheus.ObserverVec
 	objectCount                  prometheus.Gauge
 	memtableDurations            prometheus.ObserverVec
 	memtableSize                 *prometheus.GaugeVec
 	DimensionSum                 *prometheus.GaugeVec
+	segmentReadRetryCount        prometheus.Counter
+	lockTimeoutCount             prometheus.Counter
+	walReplayErrorCount          prometheus.Counter
+	maintenanceLockWait          prometheus.ObserverVec
+	maintenanceLockWaitTime      prometheus.ObserverVec
+	maintenanceLockHeld          prometheus.ObserverVec
+	segmentRead                  prometheus.ObserverVec
+	segmentProbes                []segmentProbeCounters
+	segmentLevel                 prometheus.ObserverVec
 
 	groupClasses        bool
 	criticalBucketsOnly bool
@@ -122,6 +133,10 @@ func NewMetrics(promMetrics *monitoring.PrometheusMetrics, className,
 			"class_name": className,
 			"shard_name": shardName,
 		}),
+		TierAmplification: promMetrics.LSMTierAmplification.MustCurryWith(prometheus.Labels{
+			"class_name": className,
+			"shard_name": shardName,
+		}),
 		startupDiskIO: promMetrics.StartupDiskIO.MustCurryWith(prometheus.Labels{
 			"class_name": className,
 			"shard_name": shardName,
@@ -146,6 +161,39 @@ func NewMetrics(promMetrics *monitoring.PrometheusMetrics, className,
 			"class_name": className,
 			"shard_name": shardName,
 		}),
+		segmentReadRetryCount: promMetrics.LSMSegmentReadRetries.With(prometheus.Labels{
+			"class_name": className,
+			"shard_name": shardName,
+		}),
+		lockTimeoutCount: promMetrics.LSMMaintenanceLockTimeouts.With(prometheus.Labels{
+			"class_name": className,
+			"shard_name": shardName,
+		}),
+		walReplayErrorCount: promMetrics.LSMWALReplayErrors.With(prometheus.Labels{
+			"class_name": className,
+			"shard_name": shardName,
+		}),
+		maintenanceLockWait: promMetrics.LSMMaintenanceLockWaitDurations.MustCurryWith(prometheus.Labels{
+			"class_name": className,
+			"shard_name": shardName,
+		}),
+		maintenanceLockWaitTime: promMetrics.LSMMaintenanceLockWaitTime.MustCurryWith(prometheus.Labels{
+			"class_name": className,
+			"shard_name": shardName,
+		}),
+		maintenanceLockHeld: promMetrics.LSMMaintenanceLockHeldDuration.MustCurryWith(prometheus.Labels{
+			"class_name": className,
+			"shard_name": shardName,
+		}),
+		segmentRead: promMetrics.LSMSegmentReadDurations.MustCurryWith(prometheus.Labels{
+			"class_name": className,
+			"shard_name": shardName,
+		}),
+		segmentLevel: promMetrics.LSMSegmentLevel.MustCurryWith(prometheus.Labels{
+			"class_name": className,
+			"shard_name": shardName,
+		}),
+		segmentProbes: newSegmentProbeCounters(promMetrics, className, shardName),
 	}
 }
 
@@ -161,6 +209,181 @@ func noOpSetter(val uint64) {
 	// do nothing
 }
 
+func (m *Metrics) SegmentReadRetry() {
+	if m == nil {
+		return
+	}
+
+	m.segmentReadRetryCount.Inc()
+}
+
+// LockTimeout counts a read which gave up waiting for the maintenance lock,
+// see ErrLockTimeout
+func (m *Metrics) LockTimeout() {
+	if m == nil {
+		return
+	}
+
+	m.lockTimeoutCount.Inc()
+}
+
+// WALReplayError counts a write-ahead-log entry with an invalid checksum found
+// on recovery. The checksums are cumulative, so the entry and all entries
+// following it in the same log are not recovered.
+func (m *Metrics) WALReplayError() {
+	if m == nil {
+		return
+	}
+
+	m.walReplayErrorCount.Inc()
+}
+
+// keyPrefixReadObservers are the observers of reads of keys with the same
+// key_prefix label, see keyPrefixLabels. They are resolved once, so that reads
+// don't need to look up the label values. Nil observers record nothing.
+type keyPrefixReadObservers struct {
+	label       string
+	lockWait    prometheus.Observer
+	segmentRead prometheus.Observer
+}
+
+func (m *Metrics) keyPrefixReadObservers(label string) *keyPrefixReadObservers {
+	return &keyPrefixReadObservers{
+		label:       label,
+		lockWait:    m.maintenanceLockWait.WithLabelValues(label),
+		segmentRead: m.segmentRead.WithLabelValues(label),
+	}
+}
+
+// keyPrefix returns the key_prefix label, or an empty string for nil observers
+func (o *keyPrefixReadObservers) keyPrefix() string {
+	if o == nil {
+		return ""
+	}
+	return o.label
+}
+
+// observeMaintenanceLockWait records the lock wait of a read
+func (o *keyPrefixReadObservers) observeMaintenanceLockWait(took time.Duration) {
+	if o == nil {
+		return
+	}
+	o.lockWait.Observe(float64(took) / float64(time.Millisecond))
+}
+
+// observeSegmentRead records the read of a single segment
+func (o *keyPrefixReadObservers) observeSegmentRead(took time.Duration) {
+	if o == nil {
+		return
+	}
+	o.segmentRead.Observe(float64(took) / float64(time.Millisecond))
+}
+
+// RecordLockWait records how long op waited to acquire the maintenance lock.
+// keyPrefix is empty for operations which don't read an individual key.
+func (m *Metrics) RecordLockWait(op, keyPrefix string, took time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.maintenanceLockWaitTime.With(prometheus.Labels{
+		"operation":  op,
+		"key_prefix": keyPrefix,
+	}).Observe(took.Seconds())
+}
+
+// RecordLockHeld records how long op held the maintenance lock exclusively
+func (m *Metrics) RecordLockHeld(op string, took time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.maintenanceLockHeld.With(prometheus.Labels{
+		"operation": op,
+	}).Observe(took.Seconds())
+}
+
+// maxSegmentProbeDepth caps the depth label of segment probes, deeper probes
+// are all recorded as "16+"
+const maxSegmentProbeDepth = 16
+
+var segmentProbeDepthLabels = func() []string {
+	labels := make([]string, maxSegmentProbeDepth+1)
+	for depth := range labels {
+		labels[depth] = strconv.Itoa(depth)
+	}
+	labels[maxSegmentProbeDepth] += "+"
+	return labels
+}()
+
+// segmentProbeCounters are the counters of a single probe depth. They are
+// resolved once, as they are incremented for every segment a read probes.
+type segmentProbeCounters struct {
+	hit     prometheus.Counter
+	skip    prometheus.Counter
+	seconds prometheus.Counter
+}
+
+func newSegmentProbeCounters(promMetrics *monitoring.PrometheusMetrics,
+	className, shardName string,
+) []segmentProbeCounters {
+	probes := promMetrics.LSMSegmentProbes.MustCurryWith(prometheus.Labels{
+		"class_name": className,
+		"shard_name": shardName,
+	})
+	seconds := promMetrics.LSMSegmentProbeSeconds.MustCurryWith(prometheus.Labels{
+		"class_name": className,
+		"shard_name": shardName,
+	})
+
+	counters := make([]segmentProbeCounters, len(segmentProbeDepthLabels))
+	for depth, depthLabel := range segmentProbeDepthLabels {
+		counters[depth] = segmentProbeCounters{
+			hit:     probes.WithLabelValues(depthLabel, "hit"),
+			skip:    probes.WithLabelValues(depthLabel, "skip"),
+			seconds: seconds.WithLabelValues(depthLabel),
+		}
+	}
+	return counters
+}
+
+// ObserveSegmentProbe records a read probing a single segment. depth is the
+// position below the newest segment, 0 being the newest one. Reads
+// consistently probing deep segments indicate that compaction is behind.
+func (m *Metrics) ObserveSegmentProbe(depth int, hit bool) {
+	if m == nil {
+		return
+	}
+
+	counters := m.segmentProbes[min(depth, maxSegmentProbeDepth)]
+	if hit {
+		counters.hit.Inc()
+	} else {
+		counters.skip.Inc()
+	}
+}
+
+// ObserveSegmentProbeDuration adds the time spent probing a segment at depth.
+// Probes are only timed for a sample of the reads, so took is the estimated
+// total of all probes the sampled one stands for.
+func (m *Metrics) ObserveSegmentProbeDuration(depth int, took time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.segmentProbes[min(depth, maxSegmentProbeDepth)].seconds.Add(took.Seconds())
+}
+
+func (m *Metrics) ObserveSegmentLevel(strategy string, level uint16) {
+	if m == nil {
+		return
+	}
+
+	m.segmentLevel.With(prometheus.Labels{
+		"strategy": strategy,
+	}).Observe(float64(level))
+}
+
 func (m *Metrics) MemtableOpObserver(path, strategy, op string) NsObserver {
 	if m == nil {
 		return noOpNsObserver
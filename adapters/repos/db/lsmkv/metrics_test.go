@@ -0,0 +1,190 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+func TestSegmentGroupMaintenanceLockMetrics(t *testing.T) {
+	promMetrics := monitoring.GetMetrics()
+	sg := &SegmentGroup{
+		metrics: NewMetrics(promMetrics, "MaintenanceLockMetrics", "shard"),
+	}
+
+	waitSeries := testutil.CollectAndCount(promMetrics.LSMMaintenanceLockWaitTime)
+	heldSeries := testutil.CollectAndCount(promMetrics.LSMMaintenanceLockHeldDuration)
+
+	sg.rLock("get")
+	sg.maintenanceLock.RUnlock()
+
+	unlock := sg.lock("compaction")
+	unlock()
+
+	// one series per operation and lock mode
+	assert.Equal(t, waitSeries+2, testutil.CollectAndCount(promMetrics.LSMMaintenanceLockWaitTime))
+	assert.Equal(t, heldSeries+1, testutil.CollectAndCount(promMetrics.LSMMaintenanceLockHeldDuration))
+
+	t.Run("nil metrics are ignored", func(t *testing.T) {
+		sg := &SegmentGroup{}
+		sg.rLock("get")
+		sg.maintenanceLock.RUnlock()
+		sg.lock("compaction")()
+	})
+}
+
+func TestSegmentGroupKeyPrefixMetrics(t *testing.T) {
+	promMetrics := monitoring.GetMetrics()
+	metrics := NewMetrics(promMetrics, "KeyPrefixMetrics", "shard")
+	sg := &SegmentGroup{
+		metrics:         metrics,
+		keyPrefixLabels: newKeyPrefixLabels(2, metrics, logrus.New()),
+	}
+
+	readSeries := testutil.CollectAndCount(promMetrics.LSMSegmentReadDurations)
+	waitSeries := testutil.CollectAndCount(promMetrics.LSMMaintenanceLockWaitDurations)
+
+	for _, key := range []string{"ns1-a", "ns1-b", "ns2-a"} {
+		_, err := sg.get([]byte(key))
+		assert.Nil(t, err)
+	}
+
+	// all keys share the 2 byte prefix "ns"
+	assert.Equal(t, waitSeries+1, testutil.CollectAndCount(promMetrics.LSMMaintenanceLockWaitDurations))
+	// the observers of a label are resolved together, even though there are no
+	// segment reads without segments
+	assert.Equal(t, readSeries+1, testutil.CollectAndCount(promMetrics.LSMSegmentReadDurations))
+
+	waitSeries = testutil.CollectAndCount(promMetrics.LSMMaintenanceLockWaitDurations)
+	sg.keyPrefixLabels.release()
+	sg.keyPrefixLabels = newKeyPrefixLabels(3, metrics, logrus.New())
+	for _, key := range []string{"ns1-a", "ns2-a", "ns2-b"} {
+		_, err := sg.get([]byte(key))
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, waitSeries+2, testutil.CollectAndCount(promMetrics.LSMMaintenanceLockWaitDurations))
+}
+
+func TestSegmentGroupProbeMetrics(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+	promMetrics := monitoring.GetMetrics()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger,
+		NewMetrics(promMetrics, "ProbeMetrics", "shard"),
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	// time every read, so that the probe seconds are recorded
+	b.disk.readTimingSampleRate = 1
+
+	for i := 0; i < 3; i++ {
+		require.Nil(t, b.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+	}
+
+	probes := func(depth, result string) float64 {
+		return testutil.ToFloat64(promMetrics.LSMSegmentProbes.
+			WithLabelValues("ProbeMetrics", "shard", depth, result))
+	}
+	// get reads the key and returns the probes it added by depth and result
+	get := func(key string) map[string]float64 {
+		labels := [][2]string{
+			{"0", "hit"}, {"0", "skip"}, {"1", "hit"}, {"1", "skip"}, {"2", "hit"}, {"2", "skip"},
+		}
+		before := map[string]float64{}
+		for _, l := range labels {
+			before[l[0]+"/"+l[1]] = probes(l[0], l[1])
+		}
+		_, err := b.Get([]byte(key))
+		require.Nil(t, err)
+		added := map[string]float64{}
+		for _, l := range labels {
+			if delta := probes(l[0], l[1]) - before[l[0]+"/"+l[1]]; delta != 0 {
+				added[l[0]+"/"+l[1]] = delta
+			}
+		}
+		return added
+	}
+
+	// the oldest segment is two segments below the newest one
+	assert.Equal(t, map[string]float64{"0/skip": 1, "1/skip": 1, "2/hit": 1}, get("key0"))
+	assert.Equal(t, map[string]float64{"0/hit": 1}, get("key2"))
+	// a missing key probes all segments
+	assert.Equal(t, map[string]float64{"0/skip": 1, "1/skip": 1, "2/skip": 1}, get("missing"))
+
+	assert.Greater(t, testutil.ToFloat64(promMetrics.LSMSegmentProbeSeconds.
+		WithLabelValues("ProbeMetrics", "shard", "2")), 0.0)
+}
+
+func TestSegmentProbeDepthLabels(t *testing.T) {
+	assert.Equal(t, "0", segmentProbeDepthLabels[0])
+	assert.Equal(t, "15", segmentProbeDepthLabels[15])
+	assert.Equal(t, "16+", segmentProbeDepthLabels[min(100, maxSegmentProbeDepth)])
+}
+
+func TestSegmentGroupTimeSegmentReads(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.InfoLevel)
+
+	t.Run("without metrics and debug logs", func(t *testing.T) {
+		sg := &SegmentGroup{logger: logger, readTimingSampleRate: 1}
+		timed, sampled := sg.timeSegmentReads()
+		assert.False(t, timed)
+		assert.False(t, sampled)
+	})
+
+	t.Run("debug logs time every read", func(t *testing.T) {
+		debugLogger := logrus.New()
+		debugLogger.SetLevel(logrus.DebugLevel)
+		sg := &SegmentGroup{logger: debugLogger, readTimingSampleRate: 1}
+		timed, sampled := sg.timeSegmentReads()
+		assert.True(t, timed)
+		assert.False(t, sampled)
+	})
+
+	t.Run("metrics only time a sample of the reads", func(t *testing.T) {
+		sg := &SegmentGroup{
+			logger:               logger,
+			metrics:              NewMetrics(monitoring.GetMetrics(), "TimeSegmentReads", "shard"),
+			readTimingSampleRate: defaultReadTimingSampleRate,
+		}
+
+		timedReads := 0
+		for i := 0; i < 100*defaultReadTimingSampleRate; i++ {
+			timed, sampled := sg.timeSegmentReads()
+			assert.Equal(t, timed, sampled)
+			if timed {
+				timedReads++
+			}
+		}
+		assert.Greater(t, timedReads, 0)
+		assert.Less(t, timedReads, 10*defaultReadTimingSampleRate)
+
+		sg.readTimingSampleRate = 1
+		timed, sampled := sg.timeSegmentReads()
+		assert.True(t, timed)
+		assert.True(t, sampled)
+	})
+}
@@ -15,6 +15,8 @@ import (
 	"bytes"
 	"math"
 	"sort"
+
+	"github.com/sirupsen/logrus"
 )
 
 // QuantileKeys returns an approximation of the keys that make up the specified
@@ -56,7 +58,18 @@ func (sg *SegmentGroup) quantileKeys(q int) [][]byte {
 	}
 
 	for _, s := range sg.segments {
-		keys = append(keys, s.quantileKeys(q)...)
+		segmentKeys, err := s.quantileKeys(q)
+		if err != nil {
+			// the keys are only used as starting points of cursors, so the
+			// keys of the other segments are still good enough. The cursors
+			// report the error when reading from the segment.
+			sg.logger.WithError(err).WithFields(logrus.Fields{
+				"action": "lsm_segment_group_quantile_keys",
+				"path":   s.path,
+			}).Warn("skipping quantile keys of segment which can't be read")
+			continue
+		}
+		keys = append(keys, segmentKeys...)
 	}
 
 	// re-sort keys
@@ -77,8 +90,11 @@ func (sg *SegmentGroup) quantileKeys(q int) [][]byte {
 	return pickEvenlyDistributedKeys(uniqueKeys, q)
 }
 
-func (s *segment) quantileKeys(q int) [][]byte {
-	return s.index.QuantileKeys(q)
+func (s *segment) quantileKeys(q int) ([][]byte, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, err
+	}
+	return s.index.QuantileKeys(q), nil
 }
 
 // pickEvenlyDistributedKeys picks q keys from the input keys, trying to keep
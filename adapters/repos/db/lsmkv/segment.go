@@ -17,6 +17,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/edsrzf/mmap-go"
 	"github.com/pkg/errors"
@@ -45,6 +48,8 @@ type segment struct {
 	metrics             *Metrics
 	size                int64
 	mmapContents        bool
+	maxReadRetries      int
+	readRetryDelay      time.Duration
 
 	useBloomFilter        bool // see bucket for more datails
 	bloomFilter           *bloom.BloomFilter
@@ -53,10 +58,38 @@ type segment struct {
 
 	// the net addition this segment adds with respect to all previous segments
 	calcCountNetAdditions bool // see bucket for more datails
-	countNetAdditions     int
+	countNetAdditions     atomic.Int64
 
 	invertedHeader *segmentindex.HeaderInverted
 	invertedData   *segmentInvertedData
+
+	// contents (and the indexes pointing into them) can be released to free
+	// file descriptors and are reopened lazily on the next read, see
+	// SegmentGroup.closeLeastRecentlyReadSegments
+	contentsLock     sync.Mutex
+	contentsReleased atomic.Bool
+	lastRead         atomic.Int64
+
+	// key and tombstone counts are atomics, so they can be set and read
+	// without holding the maintenance lock, see tombstoneStats
+	statsKeys       atomic.Int64
+	statsTombstones atomic.Int64
+	statsKnown      atomic.Bool
+
+	// tombstonesCollected is set on segments written by the gc compaction, so
+	// they are not picked again, see SegmentGroup.gcCompactOnce. It is persisted
+	// in the segment manifest.
+	tombstonesCollected atomic.Bool
+
+	// pinned segments have their contents locked in memory, see
+	// SegmentGroup.PinSegment
+	pinned atomic.Bool
+
+	// readers holding a reference keep the contents open even if the segment
+	// is closed in the meantime, see acquire
+	refsLock     sync.Mutex
+	refs         int
+	closePending bool
 }
 
 type diskIndex interface {
@@ -70,9 +103,20 @@ type diskIndex interface {
 
 	Next(key []byte) (segmentindex.Node, error)
 
+	// Prev returns lsmkv.NotFound in case there is no value lower than the
+	// provided key, otherwise it returns the next lowest value
+	Prev(key []byte) (segmentindex.Node, error)
+
+	// Last returns the node with the highest key, or lsmkv.NotFound if the
+	// index is empty
+	Last() (segmentindex.Node, error)
+
 	// AllKeys in no specific order, e.g. for building a bloom filter
 	AllKeys() ([][]byte, error)
 
+	// KeyCount returns the number of keys in the index
+	KeyCount() (int, error)
+
 	// Size of the index in bytes
 	Size() int
 
@@ -85,6 +129,8 @@ type segmentConfig struct {
 	calcCountNetAdditions    bool
 	overwriteDerived         bool
 	enableChecksumValidation bool
+	maxReadRetries           int
+	readRetryDelay           time.Duration
 }
 
 // newSegment creates a new segment structure, representing an LSM disk segment.
@@ -110,6 +156,12 @@ func newSegment(path string, logger logrus.FieldLogger, metrics *Metrics,
 	if err != nil {
 		return nil, fmt.Errorf("open file: %w", err)
 	}
+	defer func() {
+		// only pread segments keep their file open, see below
+		if err != nil || cfg.mmapContents {
+			file.Close()
+		}
+	}()
 
 	fileInfo, err := file.Stat()
 	if err != nil {
@@ -122,7 +174,7 @@ func newSegment(path string, logger logrus.FieldLogger, metrics *Metrics,
 		return nil, fmt.Errorf("mmap file: %w", err)
 	}
 
-	header, err := segmentindex.ParseHeader(bytes.NewReader(contents[:segmentindex.HeaderSize]))
+	header, err := readSegmentHeader(file, contents, cfg.mmapContents)
 	if err != nil {
 		return nil, fmt.Errorf("parse header: %w", err)
 	}
@@ -138,13 +190,12 @@ func newSegment(path string, logger logrus.FieldLogger, metrics *Metrics,
 		}
 	}
 
-	primaryIndex, err := header.PrimaryIndex(contents)
+	primaryDiskIndex, secondaryIndices, err := loadSegmentIndexes(header, file,
+		contents, size, cfg.mmapContents)
 	if err != nil {
-		return nil, fmt.Errorf("extract primary index position: %w", err)
+		return nil, err
 	}
 
-	primaryDiskIndex := segmentindex.NewDiskTree(primaryIndex)
-
 	dataStartPos := uint64(segmentindex.HeaderSize)
 	dataEndPos := header.IndexStart
 
@@ -174,28 +225,24 @@ func newSegment(path string, logger logrus.FieldLogger, metrics *Metrics,
 		metrics:               metrics,
 		size:                  size,
 		mmapContents:          cfg.mmapContents,
+		maxReadRetries:        cfg.maxReadRetries,
+		readRetryDelay:        cfg.readRetryDelay,
 		useBloomFilter:        cfg.useBloomFilter,
 		calcCountNetAdditions: cfg.calcCountNetAdditions,
 		invertedHeader:        invertedHeader,
 		invertedData:          &segmentInvertedData{},
 	}
 
-	// Using pread strategy requires file to remain open for segment lifetime
-	if seg.mmapContents {
-		defer file.Close()
-	} else {
+	// Using pread strategy requires file to remain open for segment lifetime.
+	// Memory-mapped segments close it, so they don't hold a file descriptor
+	// each. Other processes are kept out by the lock of the segment group, see
+	// lockSegmentGroupDir.
+	if !seg.mmapContents {
 		seg.contentFile = file
 	}
 
 	if seg.secondaryIndexCount > 0 {
-		seg.secondaryIndices = make([]diskIndex, seg.secondaryIndexCount)
-		for i := range seg.secondaryIndices {
-			secondary, err := header.SecondaryIndex(contents, uint16(i))
-			if err != nil {
-				return nil, fmt.Errorf("get position for secondary index at %d: %w", i, err)
-			}
-			seg.secondaryIndices[i] = segmentindex.NewDiskTree(secondary)
-		}
+		seg.secondaryIndices = secondaryIndices
 	}
 
 	if seg.useBloomFilter {
@@ -213,6 +260,16 @@ func newSegment(path string, logger logrus.FieldLogger, metrics *Metrics,
 }
 
 func (s *segment) close() error {
+	if s.deferCloseWhileAcquired() {
+		return nil
+	}
+	if s.contentsReleased.Load() {
+		return nil
+	}
+	return s.closeContents()
+}
+
+func (s *segment) closeContents() error {
 	var munmapErr, fileCloseErr error
 
 	m := mmap.MMap(s.contents)
@@ -394,6 +451,6 @@ func (s *segment) bufferedReaderAt(offset uint64) (*bufio.Reader, error) {
 		return nil, fmt.Errorf("nil contentFile for segment at %s", s.path)
 	}
 
-	r := io.NewSectionReader(s.contentFile, int64(offset), s.size)
+	r := io.NewSectionReader(s.readerAt(), int64(offset), s.size)
 	return bufio.NewReader(r), nil
 }
@@ -25,6 +25,10 @@ import (
 	"github.com/willf/bloom"
 )
 
+// bloomFilterFalsePositiveRate is the false positive rate all primary and
+// secondary bloom filters are sized for
+const bloomFilterFalsePositiveRate = 0.001
+
 func (s *segment) bloomFilterPath() string {
 	extless := strings.TrimSuffix(s.path, filepath.Ext(s.path))
 	return fmt.Sprintf("%s.bloom", extless)
@@ -102,7 +106,7 @@ func (s *segment) computeAndStoreBloomFilter(path string) error {
 		return err
 	}
 
-	s.bloomFilter = bloom.NewWithEstimates(uint(len(keys)), 0.001)
+	s.bloomFilter = bloom.NewWithEstimates(uint(len(keys)), bloomFilterFalsePositiveRate)
 	for _, key := range keys {
 		s.bloomFilter.Add(key)
 	}
@@ -243,7 +247,7 @@ func (s *segment) computeAndStoreSecondaryBloomFilter(path string, pos int) erro
 		return err
 	}
 
-	s.secondaryBloomFilters[pos] = bloom.NewWithEstimates(uint(len(keys)), 0.001)
+	s.secondaryBloomFilters[pos] = bloom.NewWithEstimates(uint(len(keys)), bloomFilterFalsePositiveRate)
 	for _, key := range keys {
 		s.secondaryBloomFilters[pos].Add(key)
 	}
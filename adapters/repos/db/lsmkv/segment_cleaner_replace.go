@@ -32,6 +32,14 @@ type segmentCleanerReplace struct {
 	secondaryIndexCount      uint16
 	scratchSpacePath         string
 	enableChecksumValidation bool
+
+	// tombstoneKeyExistsFn is only set for the gc compaction, see
+	// newSegmentGCCompactorReplace. Tombstones of keys it does not find are
+	// dropped.
+	tombstoneKeyExistsFn keyExistsOnUpperSegmentsFunc
+	// keys and tombstones count the written nodes
+	keys       int
+	tombstones int
 }
 
 func newSegmentCleanerReplace(w io.WriteSeeker, cursor *segmentCursorReplace,
@@ -51,6 +59,19 @@ func newSegmentCleanerReplace(w io.WriteSeeker, cursor *segmentCursorReplace,
 	}
 }
 
+// newSegmentGCCompactorReplace rewrites a single segment without the
+// tombstones of keys for which keyExistsBelowFn does not find an entry in any
+// of the lower segments. Such tombstones have nothing left to shadow.
+func newSegmentGCCompactorReplace(w io.WriteSeeker, cursor *segmentCursorReplace,
+	keyExistsBelowFn keyExistsOnUpperSegmentsFunc, level, secondaryIndexCount uint16,
+	scratchSpacePath string, enableChecksumValidation bool,
+) *segmentCleanerReplace {
+	p := newSegmentCleanerReplace(w, cursor, nil, level, secondaryIndexCount,
+		scratchSpacePath, enableChecksumValidation)
+	p.tombstoneKeyExistsFn = keyExistsBelowFn
+	return p
+}
+
 func (p *segmentCleanerReplace) do(shouldAbort cyclemanager.ShouldAbortCallback) error {
 	if err := p.init(); err != nil {
 		return fmt.Errorf("init: %w", err)
@@ -121,12 +142,23 @@ func (p *segmentCleanerReplace) writeKeys(f *segmentindex.SegmentFile,
 			return nil, fmt.Errorf("should abort requested")
 		}
 
-		keyExists, err = p.keyExistsFn(node.primaryKey)
-		if err != nil {
-			break
+		if p.keyExistsFn != nil {
+			keyExists, err = p.keyExistsFn(node.primaryKey)
+			if err != nil {
+				break
+			}
+			if keyExists {
+				continue
+			}
 		}
-		if keyExists {
-			continue
+		if node.tombstone && p.tombstoneKeyExistsFn != nil {
+			keyExists, err = p.tombstoneKeyExistsFn(node.primaryKey)
+			if err != nil {
+				break
+			}
+			if !keyExists {
+				continue
+			}
 		}
 		nodeCopy := node
 		nodeCopy.offset = offset
@@ -136,6 +168,10 @@ func (p *segmentCleanerReplace) writeKeys(f *segmentindex.SegmentFile,
 		}
 		offset = indexKey.ValueEnd
 		indexKeys = append(indexKeys, indexKey)
+		p.keys++
+		if node.tombstone {
+			p.tombstones++
+		}
 	}
 
 	if !errors.Is(err, lsmkv.NotFound) {
@@ -31,6 +31,10 @@ func (s *segment) getCollection(key []byte) ([]value, error) {
 		return nil, lsmkv.NotFound
 	}
 
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, err
+	}
+
 	node, err := s.index.Get(key)
 	if err != nil {
 		return nil, err
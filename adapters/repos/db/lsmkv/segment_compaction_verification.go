@@ -0,0 +1,132 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/edsrzf/mmap-go"
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+	entsentry "github.com/weaviate/weaviate/entities/sentry"
+)
+
+// verifyCompactedSegment opens the freshly written compaction output at path
+// and checks that it can safely replace left and right. It validates the
+// header and, if enabled, the checksum and makes sure the number of keys in
+// the primary index is plausible given the inputs. It does not modify or
+// remove the file.
+func verifyCompactedSegment(path string, left, right *segment,
+	cleanupTombstones, enableChecksumValidation bool,
+) (err error) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		entsentry.Recover(p)
+		err = fmt.Errorf("unexpected error verifying segment %q: %v", path, p)
+	}()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat file: %w", err)
+	}
+	size := fileInfo.Size()
+	if size < segmentindex.HeaderSize {
+		return fmt.Errorf("segment of size %d is smaller than its header", size)
+	}
+
+	contents, err := mmap.MapRegion(file, int(size), mmap.RDONLY, 0, 0)
+	if err != nil {
+		return fmt.Errorf("mmap file: %w", err)
+	}
+	defer contents.Unmap()
+
+	header, err := segmentindex.ParseHeader(bytes.NewReader(contents[:segmentindex.HeaderSize]))
+	if err != nil {
+		return fmt.Errorf("parse header: %w", err)
+	}
+
+	if err := segmentindex.CheckExpectedStrategy(header.Strategy); err != nil {
+		return fmt.Errorf("unsupported strategy in segment: %w", err)
+	}
+	if header.Strategy != left.strategy {
+		return fmt.Errorf("strategy %d does not match input strategy %d",
+			header.Strategy, left.strategy)
+	}
+	if header.SecondaryIndices != left.secondaryIndexCount {
+		return fmt.Errorf("%d secondary indices, but inputs have %d",
+			header.SecondaryIndices, left.secondaryIndexCount)
+	}
+	if header.IndexStart < segmentindex.HeaderSize || header.IndexStart > uint64(size) {
+		return fmt.Errorf("index start %d out of bounds for segment of size %d",
+			header.IndexStart, size)
+	}
+
+	if header.Version >= segmentindex.SegmentV1 && enableChecksumValidation {
+		segmentFile := segmentindex.NewSegmentFile(segmentindex.WithReader(file))
+		if err := segmentFile.ValidateChecksum(fileInfo); err != nil {
+			return fmt.Errorf("validate checksum: %w", err)
+		}
+	}
+
+	primaryIndex, err := header.PrimaryIndex(contents)
+	if err != nil {
+		return fmt.Errorf("extract primary index position: %w", err)
+	}
+
+	keys, err := segmentindex.NewDiskTree(primaryIndex).KeyCount()
+	if err != nil {
+		return fmt.Errorf("count keys: %w", err)
+	}
+
+	leftKeys, err := left.keyCount()
+	if err != nil {
+		return fmt.Errorf("count keys of left segment: %w", err)
+	}
+
+	rightKeys, err := right.keyCount()
+	if err != nil {
+		return fmt.Errorf("count keys of right segment: %w", err)
+	}
+
+	if keys > leftKeys+rightKeys {
+		return fmt.Errorf("segment has %d keys, but inputs only have %d and %d",
+			keys, leftKeys, rightKeys)
+	}
+
+	// a replace compaction keeps a node for every key, including tombstones,
+	// unless it was explicitly asked to drop them. Other strategies may drop
+	// keys whose values cancel each other out.
+	if header.Strategy == segmentindex.StrategyReplace && !cleanupTombstones &&
+		keys < max(leftKeys, rightKeys) {
+		return fmt.Errorf("segment has %d keys, but inputs have %d and %d",
+			keys, leftKeys, rightKeys)
+	}
+
+	return nil
+}
+
+func (s *segment) keyCount() (int, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return 0, fmt.Errorf("ensure contents open: %w", err)
+	}
+	return s.index.KeyCount()
+}
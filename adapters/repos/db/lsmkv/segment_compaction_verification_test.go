@@ -0,0 +1,166 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestVerifyCompactedSegment(t *testing.T) {
+	ctx := context.Background()
+
+	newBucketWithTwoSegments := func(t *testing.T, keysPerSegment int) *Bucket {
+		logger, _ := test.NewNullLogger()
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+
+		// the second segment overlaps half of the keys of the first one
+		for seg := 0; seg < 2; seg++ {
+			for i := 0; i < keysPerSegment; i++ {
+				key := []byte(fmt.Sprintf("key-%05d", seg*keysPerSegment/2+i))
+				require.Nil(t, b.Put(key, []byte(fmt.Sprintf("value-%d", seg))))
+			}
+			require.Nil(t, b.FlushAndSwitch())
+		}
+		require.Equal(t, 2, b.disk.Len())
+		return b
+	}
+
+	compact := func(t *testing.T, b *Bucket, path string) {
+		left, right := b.disk.segmentAtPos(0), b.disk.segmentAtPos(1)
+
+		f, err := os.Create(path)
+		require.Nil(t, err)
+		defer f.Close()
+
+		leftCursor, err := left.newCursor()
+		require.Nil(t, err)
+		rightCursor, err := right.newCursor()
+		require.Nil(t, err)
+
+		c := newCompactorReplace(f, leftCursor, rightCursor, 1, 0,
+			path+".scratch.d", false, false)
+		require.Nil(t, c.do())
+		require.Nil(t, f.Sync())
+	}
+
+	verify := func(b *Bucket, path string) error {
+		return verifyCompactedSegment(path, b.disk.segmentAtPos(0),
+			b.disk.segmentAtPos(1), false, false)
+	}
+
+	t.Run("valid compaction output", func(t *testing.T) {
+		b := newBucketWithTwoSegments(t, 100)
+		path := filepath.Join(t.TempDir(), "segment-1_2.db.tmp")
+		compact(t, b, path)
+
+		assert.Nil(t, verify(b, path))
+	})
+
+	t.Run("compaction output truncated in the data section", func(t *testing.T) {
+		b := newBucketWithTwoSegments(t, 100)
+		path := filepath.Join(t.TempDir(), "segment-1_2.db.tmp")
+		compact(t, b, path)
+		require.Nil(t, os.Truncate(path, segmentindex.HeaderSize+10))
+
+		err := verify(b, path)
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "out of bounds")
+	})
+
+	t.Run("compaction output truncated in the index", func(t *testing.T) {
+		b := newBucketWithTwoSegments(t, 100)
+		path := filepath.Join(t.TempDir(), "segment-1_2.db.tmp")
+		compact(t, b, path)
+
+		info, err := os.Stat(path)
+		require.Nil(t, err)
+		require.Nil(t, os.Truncate(path, info.Size()-10))
+
+		err = verify(b, path)
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "count keys")
+	})
+
+	t.Run("compaction output smaller than a header", func(t *testing.T) {
+		b := newBucketWithTwoSegments(t, 100)
+		path := filepath.Join(t.TempDir(), "segment-1_2.db.tmp")
+		compact(t, b, path)
+		require.Nil(t, os.Truncate(path, segmentindex.HeaderSize-1))
+
+		err := verify(b, path)
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "smaller than its header")
+	})
+
+	t.Run("compaction output with corrupt index", func(t *testing.T) {
+		b := newBucketWithTwoSegments(t, 100)
+		path := filepath.Join(t.TempDir(), "segment-1_2.db.tmp")
+		compact(t, b, path)
+
+		contents, err := os.ReadFile(path)
+		require.Nil(t, err)
+		header, err := segmentindex.ParseHeader(bytes.NewReader(contents[:segmentindex.HeaderSize]))
+		require.Nil(t, err)
+
+		// make the length of the first key point way beyond the index
+		for i := header.IndexStart; i < header.IndexStart+4; i++ {
+			contents[i] = 0xff
+		}
+		require.Nil(t, os.WriteFile(path, contents, 0o666))
+
+		err = verify(b, path)
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "count keys")
+	})
+
+	t.Run("compaction output with more keys than its inputs", func(t *testing.T) {
+		b := newBucketWithTwoSegments(t, 100)
+		other := newBucketWithTwoSegments(t, 1000)
+		path := filepath.Join(t.TempDir(), "segment-1_2.db.tmp")
+		compact(t, other, path)
+
+		err := verify(b, path)
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "inputs only have")
+	})
+
+	t.Run("compaction output with fewer keys than its inputs", func(t *testing.T) {
+		b := newBucketWithTwoSegments(t, 1000)
+		other := newBucketWithTwoSegments(t, 100)
+		path := filepath.Join(t.TempDir(), "segment-1_2.db.tmp")
+		compact(t, other, path)
+
+		err := verify(b, path)
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "but inputs have")
+
+		// the same output is fine if tombstones were cleaned up, as keys may
+		// legitimately have been dropped
+		assert.Nil(t, verifyCompactedSegment(path, b.disk.segmentAtPos(0),
+			b.disk.segmentAtPos(1), true, false))
+	})
+}
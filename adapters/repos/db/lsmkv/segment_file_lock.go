@@ -0,0 +1,90 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SegmentGroupLockFile is locked exclusively by a segment group for its
+// whole lifetime, so that a second process using the same data directory
+// fails to open the bucket instead of corrupting it.
+const SegmentGroupLockFile = "segment_group.lock"
+
+// errSegmentFileLocked is returned if a file is locked by another process,
+// which usually means that two processes share the same data directory.
+//
+// Locks are advisory and never block: if a lock can't be acquired, the
+// operation fails immediately.
+var errSegmentFileLocked = errors.New("segment file is locked by another process")
+
+// errFileLockUnsupported is returned if the filesystem or platform doesn't
+// support advisory locks
+var errFileLockUnsupported = errors.New("file locks are not supported")
+
+// lockSegmentGroupDir creates the lock file of the segment group in dir and
+// locks it exclusively. The lock is held until the returned file is closed.
+// If locks are not supported, a warning is logged and the segment group is
+// opened without the protection.
+func lockSegmentGroupDir(dir string, logger logrus.FieldLogger) (*os.File, error) {
+	path := filepath.Join(dir, SegmentGroupLockFile)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o666)
+	if err != nil {
+		return nil, fmt.Errorf("open segment group lock file: %w", err)
+	}
+
+	err = lockFile(f, true)
+	switch {
+	case err == nil:
+		return f, nil
+	case errors.Is(err, errFileLockUnsupported):
+		logger.WithError(err).WithField("action", "lsm_segment_group_lock").
+			WithField("path", path).
+			Warn("can't lock segment group, another process using the same data directory won't be detected")
+		return f, nil
+	default:
+		f.Close()
+		return nil, fmt.Errorf("segment group %s: %w", dir, err)
+	}
+}
+
+// openSegmentFileForWrite creates or truncates the segment file at path for
+// writing and locks it exclusively. The lock is released when the file is
+// closed.
+//
+// Unlike os.Create, the file is only truncated once the lock is held, so a
+// file which is being written by another process is left untouched. Missing
+// lock support is reported once by lockSegmentGroupDir and is not repeated
+// for every file.
+func openSegmentFileForWrite(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o666)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(f, true); err != nil && !errors.Is(err, errFileLockUnsupported) {
+		f.Close()
+		return nil, err
+	}
+
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("truncate segment file %s: %w", path, err)
+	}
+
+	return f, nil
+}
@@ -0,0 +1,56 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+//go:build linux
+
+package lsmkv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockFile acquires a shared or exclusive lock on the whole file
+// without blocking. Open file description locks (F_OFD_SETLK) are used instead
+// of classic POSIX record locks, as the latter are owned by the process:
+// closing any descriptor of the file would release them, and they never
+// conflict within the same process.
+//
+// Filesystems which don't support locking return errFileLockUnsupported.
+func lockFile(f *os.File, exclusive bool) error {
+	lockType := int16(unix.F_RDLCK)
+	if exclusive {
+		lockType = unix.F_WRLCK
+	}
+
+	lock := unix.Flock_t{
+		Type:   lockType,
+		Whence: io.SeekStart,
+		Start:  0,
+		Len:    0, // the whole file
+	}
+
+	err := unix.FcntlFlock(f.Fd(), unix.F_OFD_SETLK, &lock)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, unix.EAGAIN), errors.Is(err, unix.EACCES):
+		return fmt.Errorf("%w: %s", errSegmentFileLocked, f.Name())
+	case errors.Is(err, unix.ENOLCK), errors.Is(err, unix.ENOTSUP), errors.Is(err, unix.EINVAL):
+		return fmt.Errorf("%w: %s: %w", errFileLockUnsupported, f.Name(), err)
+	default:
+		return fmt.Errorf("lock file %s: %w", f.Name(), err)
+	}
+}
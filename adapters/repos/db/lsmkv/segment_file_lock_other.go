@@ -0,0 +1,25 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+//go:build !unix
+
+package lsmkv
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockFile returns errFileLockUnsupported on platforms without advisory file
+// locks
+func lockFile(f *os.File, exclusive bool) error {
+	return fmt.Errorf("%w: %s", errFileLockUnsupported, f.Name())
+}
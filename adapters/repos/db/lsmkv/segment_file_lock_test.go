@@ -0,0 +1,114 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+//go:build unix
+
+package lsmkv
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+// Locks conflict between open files rather than processes, so a second
+// process sharing the data directory is simulated by opening the files again.
+func TestSegmentFileLocks(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(dir string) (*Bucket, error) {
+		return NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+	}
+
+	t.Run("a bucket directory can't be opened by another process", func(t *testing.T) {
+		dir := t.TempDir()
+		b, err := newBucket(dir)
+		require.Nil(t, err)
+
+		require.Nil(t, b.Put([]byte("key"), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		_, err = newBucket(dir)
+		require.ErrorIs(t, err, errSegmentFileLocked)
+
+		// the segments are left untouched
+		value, err := b.Get([]byte("key"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value"), value)
+
+		// the lock is released on shutdown
+		require.Nil(t, b.Shutdown(ctx))
+		b, err = newBucket(dir)
+		require.Nil(t, err)
+		defer b.Shutdown(ctx)
+
+		value, err = b.Get([]byte("key"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value"), value)
+	})
+
+	t.Run("the lock file is not backed up", func(t *testing.T) {
+		b, err := newBucket(t.TempDir())
+		require.Nil(t, err)
+		defer b.Shutdown(ctx)
+
+		files, err := b.ListFiles(ctx, "")
+		require.Nil(t, err)
+		assert.NotContains(t, files, SegmentGroupLockFile)
+	})
+
+	t.Run("mmapped segments don't keep their file open", func(t *testing.T) {
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		defer b.Shutdown(ctx)
+
+		require.Nil(t, b.Put([]byte("key"), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Len(t, b.disk.segments, 1)
+		assert.Nil(t, b.disk.segments[0].contentFile)
+
+		value, err := b.Get([]byte("key"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value"), value)
+	})
+
+	t.Run("segments being written can't be opened by another process", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "segment-123.db.tmp")
+		f, err := openSegmentFileForWrite(path)
+		require.Nil(t, err)
+		_, err = f.Write([]byte("partially written"))
+		require.Nil(t, err)
+
+		_, err = openSegmentFileForWrite(path)
+		require.ErrorIs(t, err, errSegmentFileLocked)
+
+		// the file is not truncated by the failed attempt
+		contents, err := os.ReadFile(path)
+		require.Nil(t, err)
+		assert.Equal(t, "partially written", string(contents))
+
+		require.Nil(t, f.Close())
+		f, err = openSegmentFileForWrite(path)
+		require.Nil(t, err)
+		defer f.Close()
+	})
+}
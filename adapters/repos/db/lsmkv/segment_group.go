@@ -16,19 +16,27 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"math"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
 	"github.com/weaviate/weaviate/adapters/repos/db/roaringset"
 	"github.com/weaviate/weaviate/entities/cyclemanager"
+	enterrors "github.com/weaviate/weaviate/entities/errors"
 	"github.com/weaviate/weaviate/entities/lsmkv"
 	"github.com/weaviate/weaviate/entities/storagestate"
 	"github.com/weaviate/weaviate/usecases/memwatch"
+	"golang.org/x/time/rate"
 )
 
 type SegmentGroup struct {
@@ -38,12 +46,48 @@ type SegmentGroup struct {
 	// operation
 	maintenanceLock sync.RWMutex
 	dir             string
+	manifestPath    string
+	// readTimingSampleRate is the share of reads, one in readTimingSampleRate,
+	// whose segment probes are timed for the metrics, see
+	// timeSegmentReads
+	readTimingSampleRate int
+
+	// dirLock holds the exclusive lock of the segment group directory until
+	// shutdown, see lockSegmentGroupDir
+	dirLock *os.File
+
+	// manifestLock serializes writes of the segment manifest, which happen
+	// after the maintenanceLock is released. manifestVersion is incremented
+	// for every new segment order while the maintenanceLock is held
+	// exclusively, manifestWritten is the version on disk and protected by the
+	// manifestLock, so an outdated order never replaces a newer one.
+	manifestLock    sync.Mutex
+	manifestVersion uint64
+	manifestWritten uint64
 
 	// flushVsCompactLock is a simple synchronization mechanism between the
 	// compaction and flush cycle. In general, those are independent, however,
 	// there are parts of it that are not. See the comments of the routines
-	// interacting with this lock for more details.
-	flushVsCompactLock sync.Mutex
+	// interacting with this lock for more details. Flushes may take precedence
+	// over compactions, see flushVsCompactMutex.
+	flushVsCompactLock flushVsCompactMutex
+
+	// compactionLock serializes all routines which replace existing segments,
+	// i.e. the regular compaction and cleanup cycle and the one-shot pass of
+	// CompactLeftOverSegments. Those routines rely on segments only being
+	// appended while they are running.
+	compactionLock sync.Mutex
+
+	// pinLock serializes pinning and unpinning of segments, see PinSegment.
+	// It is always obtained before the maintenanceLock. It also guards
+	// pinnedBytes, the total size of the pinned segments.
+	pinLock     sync.Mutex
+	pinnedBytes int64
+
+	// reportedTierPairs is the number of tier pairs of the last
+	// reportTierSizeAmplification call. It is only accessed from the
+	// compaction cycle.
+	reportedTierPairs int
 
 	strategy string
 
@@ -74,57 +118,199 @@ type SegmentGroup struct {
 	allocChecker   memwatch.AllocChecker
 	maxSegmentSize int64
 
+	// optional limit of segments holding their files open, see
+	// closeLeastRecentlyReadSegments
+	maxOpenSegmentFiles int
+
+	// retries of failed pread calls, see segment.readerAt
+	maxReadRetries int
+	readRetryDelay time.Duration
+
+	// reads waiting longer than this for the maintenance lock or for an
+	// individual segment are logged
+	slowPathThreshold time.Duration
+
+	// reads give up waiting for the maintenance lock after this timeout, see
+	// ErrLockTimeout. 0 waits indefinitely.
+	lockTimeout time.Duration
+
+	// what to do with segment files too small to be mounted
+	invalidSegmentPolicy InvalidSegmentPolicy
+
+	// whether getCollection fails or skips segments which can't be read
+	collectionReadErrorPolicy CollectionReadErrorPolicy
+
+	// ranks pairs of segments for compaction, see findCompactionCandidates
+	compactionScorer CompactionScorer
+
+	// compacts once no more segments are added, nil if disabled
+	idleCompaction *idleCompactionTrigger
+
 	segmentCleaner     segmentCleaner
 	cleanupInterval    time.Duration
 	lastCleanupCall    time.Time
 	lastCompactionCall time.Time
+
+	// unix nano timestamps of the last successful compaction and cleanup, 0 if
+	// there was none since the segment group was loaded
+	lastCompaction atomic.Int64
+	lastCleanup    atomic.Int64
+
+	// number of successful compactions since the segment group was loaded,
+	// used to periodically run BloomFilterAccuracyTest
+	compactionCount atomic.Int64
+
+	// optional, see WithOnCompactionComplete
+	onCompactionComplete func(CompactionResult)
+
+	// see RegisterSegmentAddedObserver
+	segmentAddedObservers     []SegmentAddedObserver
+	segmentAddedObserversLock sync.Mutex
+
+	// in-flight compactions are aborted if they don't finish within this
+	// timeout on shutdown, see stopCompactions
+	compactionShutdownTimeout time.Duration
+	abortCompaction           atomic.Bool
+	// compactionCtx is cancelled together with setting abortCompaction, so
+	// that a compaction waiting for compactionLimiter stops waiting
+	compactionCtx       context.Context
+	cancelCompactionCtx context.CancelFunc
+
+	// resolves the read observers by key prefix, nil if metrics are disabled
+	keyPrefixLabels *keyPrefixLabels
+
+	// limits the write throughput of compactions, nil if unlimited
+	compactionLimiter *rate.Limiter
+
+	// compactions are paused until compactionBackoffUntil after they were
+	// skipped or stopped due to memory pressure. compactionBackoffUntil is
+	// protected by the compactionLock.
+	compactionMemoryBackoff time.Duration
+	compactionBackoffUntil  time.Time
+
+	// keys resolved as deleted or not found by get, nil if disabled
+	negativeCache *negativeCache
+
+	// returns the channel the progress of a compaction is reported to, see
+	// WithCompactionProgress
+	compactionProgress func() chan<- CompactionProgress
+	progressInterval   time.Duration
 }
 
+// defaultSlowPathThreshold is used if sgConfig does not specify a threshold
+const defaultSlowPathThreshold = 100 * time.Millisecond
+
 type sgConfig struct {
-	dir                      string
-	strategy                 string
-	mapRequiresSorting       bool
-	monitorCount             bool
-	mmapContents             bool
-	keepTombstones           bool
-	useBloomFilter           bool
-	calcCountNetAdditions    bool
-	forceCompaction          bool
-	maxSegmentSize           int64
-	cleanupInterval          time.Duration
-	enableChecksumValidation bool
+	dir                       string
+	strategy                  string
+	mapRequiresSorting        bool
+	monitorCount              bool
+	mmapContents              bool
+	keepTombstones            bool
+	useBloomFilter            bool
+	calcCountNetAdditions     bool
+	forceCompaction           bool
+	maxSegmentSize            int64
+	cleanupInterval           time.Duration
+	cleanupBatchSize          int
+	enableChecksumValidation  bool
+	maxOpenSegmentFiles       int
+	maxReadRetries            int
+	readRetryDelay            time.Duration
+	slowPathThreshold         time.Duration
+	lockTimeout               time.Duration
+	invalidSegmentPolicy      InvalidSegmentPolicy
+	collectionReadErrorPolicy CollectionReadErrorPolicy
+	compactionScorer          CompactionScorer
+	idleCompactionThreshold   int
+	idleCompactionDelay       time.Duration
+	prioritizeFlush           bool
+	onCompactionComplete      func(CompactionResult)
+	compactionShutdownTimeout time.Duration
+	keyPrefixMetricsLen       int
+	compactionBytesPerSecond  int64
+	compactionMemoryBackoff   time.Duration
+	negativeCacheSize         int
+	compactionProgress        func() chan<- CompactionProgress
+	progressInterval          time.Duration
 }
 
 func newSegmentGroup(logger logrus.FieldLogger, metrics *Metrics,
 	compactionCallbacks cyclemanager.CycleCallbackGroup, cfg sgConfig,
 	allocChecker memwatch.AllocChecker,
-) (*SegmentGroup, error) {
+) (_ *SegmentGroup, err error) {
+	dirLock, err := lockSegmentGroupDir(cfg.dir, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			dirLock.Close()
+		}
+	}()
+
 	list, err := os.ReadDir(cfg.dir)
 	if err != nil {
 		return nil, err
 	}
 
+	manifest, err := loadSegmentManifest(cfg.dir)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	sg := &SegmentGroup{
-		segments:                 make([]*segment, len(list)),
-		dir:                      cfg.dir,
-		logger:                   logger,
-		metrics:                  metrics,
-		monitorCount:             cfg.monitorCount,
-		mapRequiresSorting:       cfg.mapRequiresSorting,
-		strategy:                 cfg.strategy,
-		mmapContents:             cfg.mmapContents,
-		keepTombstones:           cfg.keepTombstones,
-		useBloomFilter:           cfg.useBloomFilter,
-		calcCountNetAdditions:    cfg.calcCountNetAdditions,
-		compactLeftOverSegments:  cfg.forceCompaction,
-		maxSegmentSize:           cfg.maxSegmentSize,
-		cleanupInterval:          cfg.cleanupInterval,
-		enableChecksumValidation: cfg.enableChecksumValidation,
-		allocChecker:             allocChecker,
-		lastCompactionCall:       now,
-		lastCleanupCall:          now,
+		dir:                       cfg.dir,
+		manifestPath:              filepath.Join(cfg.dir, SegmentManifestFile),
+		dirLock:                   dirLock,
+		readTimingSampleRate:      defaultReadTimingSampleRate,
+		logger:                    logger,
+		metrics:                   metrics,
+		monitorCount:              cfg.monitorCount,
+		mapRequiresSorting:        cfg.mapRequiresSorting,
+		strategy:                  cfg.strategy,
+		mmapContents:              cfg.mmapContents,
+		keepTombstones:            cfg.keepTombstones,
+		useBloomFilter:            cfg.useBloomFilter,
+		calcCountNetAdditions:     cfg.calcCountNetAdditions,
+		compactLeftOverSegments:   cfg.forceCompaction,
+		maxSegmentSize:            cfg.maxSegmentSize,
+		cleanupInterval:           cfg.cleanupInterval,
+		enableChecksumValidation:  cfg.enableChecksumValidation,
+		maxOpenSegmentFiles:       cfg.maxOpenSegmentFiles,
+		maxReadRetries:            cfg.maxReadRetries,
+		readRetryDelay:            cfg.readRetryDelay,
+		slowPathThreshold:         cfg.slowPathThreshold,
+		lockTimeout:               cfg.lockTimeout,
+		invalidSegmentPolicy:      cfg.invalidSegmentPolicy,
+		collectionReadErrorPolicy: cfg.collectionReadErrorPolicy,
+		compactionScorer:          cfg.compactionScorer,
+		flushVsCompactLock:        flushVsCompactMutex{prioritizeFlush: cfg.prioritizeFlush},
+		onCompactionComplete:      cfg.onCompactionComplete,
+		compactionShutdownTimeout: cfg.compactionShutdownTimeout,
+		keyPrefixLabels:           newKeyPrefixLabels(cfg.keyPrefixMetricsLen, metrics, logger),
+		compactionLimiter:         newCompactionLimiter(cfg.compactionBytesPerSecond),
+		compactionMemoryBackoff:   cfg.compactionMemoryBackoff,
+		negativeCache:             newNegativeCache(cfg.negativeCacheSize),
+		compactionProgress:        cfg.compactionProgress,
+		progressInterval:          cfg.progressInterval,
+		allocChecker:              allocChecker,
+		lastCompactionCall:        now,
+		lastCleanupCall:           now,
 	}
+	sg.compactionCtx, sg.cancelCompactionCtx = context.WithCancel(context.Background())
+
+	if sg.compactionMemoryBackoff <= 0 {
+		sg.compactionMemoryBackoff = defaultCompactionMemoryBackoff
+	}
+
+	list, err = sg.quarantineDuplicateSegments(list, manifest)
+	if err != nil {
+		return nil, err
+	}
+	sortByManifest(list, manifest)
+	sg.segments = make([]*segment, len(list))
 
 	segmentIndex := 0
 
@@ -202,6 +388,8 @@ func newSegmentGroup(logger logrus.FieldLogger, metrics *Metrics,
 					calcCountNetAdditions:    sg.calcCountNetAdditions,
 					overwriteDerived:         false,
 					enableChecksumValidation: sg.enableChecksumValidation,
+					maxReadRetries:           sg.maxReadRetries,
+					readRetryDelay:           sg.readRetryDelay,
 				})
 			if err != nil {
 				return nil, fmt.Errorf("init already compacted right segment %s: %w", rightSegmentFilename, err)
@@ -250,6 +438,8 @@ func newSegmentGroup(logger logrus.FieldLogger, metrics *Metrics,
 				calcCountNetAdditions:    sg.calcCountNetAdditions,
 				overwriteDerived:         true,
 				enableChecksumValidation: sg.enableChecksumValidation,
+				maxReadRetries:           sg.maxReadRetries,
+				readRetryDelay:           sg.readRetryDelay,
 			},
 		)
 		if err != nil {
@@ -313,6 +503,17 @@ func newSegmentGroup(logger logrus.FieldLogger, metrics *Metrics,
 			continue
 		}
 
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("stat segment %s: %w", entry.Name(), err)
+		}
+		if isInvalidSegmentSize(info.Size()) {
+			if err := sg.handleInvalidSegment(filepath.Join(sg.dir, entry.Name()), info.Size()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		segment, err := newSegment(filepath.Join(sg.dir, entry.Name()), logger,
 			metrics, sg.makeExistsOnLower(segmentIndex),
 			segmentConfig{
@@ -321,6 +522,8 @@ func newSegmentGroup(logger logrus.FieldLogger, metrics *Metrics,
 				calcCountNetAdditions:    sg.calcCountNetAdditions,
 				overwriteDerived:         false,
 				enableChecksumValidation: sg.enableChecksumValidation,
+				maxReadRetries:           sg.maxReadRetries,
+				readRetryDelay:           sg.readRetryDelay,
 			})
 		if err != nil {
 			return nil, fmt.Errorf("init segment %s: %w", entry.Name(), err)
@@ -332,11 +535,31 @@ func newSegmentGroup(logger logrus.FieldLogger, metrics *Metrics,
 
 	sg.segments = sg.segments[:segmentIndex]
 
+	// segments recovered from a compaction are mounted before all others, so
+	// the mount order does not necessarily reflect the age of the segments.
+	// Readers rely on newer segments coming last. The segment IDs only decide
+	// the order if there is no manifest yet.
+	if manifest != nil {
+		sortSegmentsByManifest(sg.segments, manifest)
+	} else {
+		sortSegmentsByID(sg.segments)
+	}
+	manifest.restoreTombstonesCollected(sg.segments)
+	for _, seg := range sg.segments {
+		sg.metrics.ObserveSegmentLevel(sg.strategy, seg.level)
+	}
+
+	// generates the manifest on first startup and drops segments from it which
+	// were removed by a compaction that finished before the manifest was updated
+	if err := sg.writeManifest(sg.manifestSnapshotLocked()); err != nil {
+		return nil, err
+	}
+
 	if sg.monitorCount {
-		sg.metrics.ObjectCount(sg.count())
+		sg.observeCount()
 	}
 
-	sc, err := newSegmentCleaner(sg)
+	sc, err := newSegmentCleaner(sg, cfg.cleanupBatchSize)
 	if err != nil {
 		return nil, err
 	}
@@ -346,6 +569,11 @@ func newSegmentGroup(logger logrus.FieldLogger, metrics *Metrics,
 	id := "segmentgroup/compaction/" + sg.dir
 	sg.compactionCallbackCtrl = compactionCallbacks.Register(id, sg.compactOrCleanup)
 
+	if cfg.idleCompactionThreshold > 0 {
+		sg.idleCompaction = newIdleCompactionTrigger(cfg.idleCompactionThreshold,
+			cfg.idleCompactionDelay, sg.compactOrCleanup, sg.logger)
+	}
+
 	return sg, nil
 }
 
@@ -357,7 +585,8 @@ func (sg *SegmentGroup) makeExistsOnLower(nextSegmentIndex int) existsOnLowerSeg
 			return false, nil
 		}
 
-		v, err := sg.getWithUpperSegmentBoundary(key, nextSegmentIndex-1)
+		v, err := sg.getWithUpperSegmentBoundary(key, nextSegmentIndex-1,
+			sg.keyPrefixLabels.observersOf(key))
 		if err != nil {
 			return false, fmt.Errorf("check exists on segments lower than %d: %w",
 				nextSegmentIndex, err)
@@ -368,64 +597,267 @@ func (sg *SegmentGroup) makeExistsOnLower(nextSegmentIndex int) existsOnLowerSeg
 }
 
 func (sg *SegmentGroup) add(path string) error {
-	sg.maintenanceLock.Lock()
-	defer sg.maintenanceLock.Unlock()
+	return <-sg.addAsync(path)
+}
 
-	newSegmentIndex := len(sg.segments)
-	segment, err := newSegment(path, sg.logger,
-		sg.metrics, sg.makeExistsOnLower(newSegmentIndex),
-		segmentConfig{
-			mmapContents:             sg.mmapContents,
-			useBloomFilter:           sg.useBloomFilter,
-			calcCountNetAdditions:    sg.calcCountNetAdditions,
-			overwriteDerived:         true,
-			enableChecksumValidation: sg.enableChecksumValidation,
-		})
-	if err != nil {
-		return fmt.Errorf("init segment %s: %w", path, err)
+// addAsync initializes the segment at path in the background and adds it to
+// the segment group once it is ready. All I/O, such as opening the file,
+// loading the index and building bloom filters and net count additions,
+// happens before the maintenanceLock is obtained exclusively, so the lock is
+// only held for the final append to the segments slice.
+//
+// The returned channel receives exactly one value: nil on success or the error
+// that prevented the segment from being added.
+func (sg *SegmentGroup) addAsync(path string) <-chan error {
+	errC := make(chan error, 1)
+
+	enterrors.GoWrapper(func() {
+		// the deferred send makes sure the caller is never left waiting, even
+		// if something panics along the way
+		err := fmt.Errorf("add segment %s: aborted", path)
+		defer func() { errC <- err }()
+
+		segment, initErr := sg.initAndPrecomputeNewSegment(path)
+		if initErr != nil {
+			err = fmt.Errorf("init segment %s: %w", path, initErr)
+			return
+		}
+
+		err = sg.addInitializedSegment(segment)
+	}, sg.logger)
+
+	return errC
+}
+
+func (sg *SegmentGroup) addInitializedSegment(segment *segment) error {
+	// the segment is not visible yet, so it can be scanned without locks. A
+	// failure is not fatal, Stats retries the scan later.
+	if segment.strategy == segmentindex.StrategyReplace {
+		if _, _, err := segment.tombstoneStats(); err != nil {
+			sg.logger.WithField("action", "lsm_segment_group_add_segment").
+				WithField("path", segment.path).
+				WithError(err).
+				Warn("failed to count tombstones of segment")
+		}
 	}
 
+	unlock := sg.lock("flush")
 	sg.segments = append(sg.segments, segment)
+	sg.negativeCache.invalidate()
+	manifest := sg.manifestSnapshotLocked()
+	sg.metrics.ObserveSegmentLevel(sg.strategy, segment.level)
+	sg.idleCompaction.segmentAdded(len(sg.segments))
+	unlock()
+
+	sg.updateManifest(manifest)
+
+	sg.notifySegmentAdded(segment.path)
 	return nil
 }
 
-func (sg *SegmentGroup) addInitializedSegment(segment *segment) error {
+// rLock acquires the maintenanceLock for reading and records how long op
+// waited for it. The wait time is returned for further reporting.
+func (sg *SegmentGroup) rLock(op string) time.Duration {
+	return sg.rLockForKey(op, "")
+}
+
+// rLockForKey is like rLock for reads of an individual key, keyPrefix is the
+// label of the key, see keyPrefixLabels
+func (sg *SegmentGroup) rLockForKey(op, keyPrefix string) time.Duration {
+	before := time.Now()
+	sg.maintenanceLock.RLock()
+	took := time.Since(before)
+	sg.metrics.RecordLockWait(op, keyPrefix, took)
+	return took
+}
+
+// lock acquires the maintenanceLock exclusively and records how long op waited
+// for it. The returned func releases the lock and records how long it was held.
+func (sg *SegmentGroup) lock(op string) (unlock func()) {
+	before := time.Now()
 	sg.maintenanceLock.Lock()
-	defer sg.maintenanceLock.Unlock()
+	acquired := time.Now()
+	sg.metrics.RecordLockWait(op, "", acquired.Sub(before))
 
-	sg.segments = append(sg.segments, segment)
-	return nil
+	return func() {
+		sg.maintenanceLock.Unlock()
+		sg.metrics.RecordLockHeld(op, time.Since(acquired))
+	}
 }
 
 func (sg *SegmentGroup) get(key []byte) ([]byte, error) {
-	beforeMaintenanceLock := time.Now()
-	sg.maintenanceLock.RLock()
-	if time.Since(beforeMaintenanceLock) > 100*time.Millisecond {
-		sg.logger.WithField("duration", time.Since(beforeMaintenanceLock)).
+	readObservers := sg.keyPrefixLabels.observersOf(key)
+	tookLock, err := sg.rLockWithTimeout("get", readObservers.keyPrefix())
+	if err != nil {
+		return nil, err
+	}
+	readObservers.observeMaintenanceLockWait(tookLock)
+	if threshold := sg.getSlowPathThreshold(); tookLock > threshold {
+		sg.logger.WithField("duration", tookLock).
 			WithField("action", "lsm_segment_group_get_obtain_maintenance_lock").
-			Debug("waited over 100ms to obtain maintenance lock in segment group get()")
+			Debugf("waited over %s to obtain maintenance lock in segment group get()", threshold)
+	}
+	defer sg.maintenanceLock.RUnlock()
+
+	if sg.negativeCache.contains(key) {
+		return nil, nil
+	}
+
+	v, err := sg.getWithUpperSegmentBoundary(key, len(sg.segments)-1, readObservers)
+	if err == nil && v == nil {
+		// the segments can't change while the lock is held, so the key is
+		// known to be deleted or missing until the next segment change
+		sg.negativeCache.add(key)
+	}
+	return v, err
+}
+
+// getZeroCopy is an expert variant of get for latency-sensitive readers which
+// consume the value right away, e.g. to serialize it. For mmapped segments the
+// returned value is not copied, but points directly into the mmapped segment.
+//
+// WARNING: the value is only valid until release is called. It must not be
+// accessed, modified or retained afterwards, not even as a sub-slice. Until
+// release is called, the maintenance lock is held for reading, which blocks
+// compactions, cleanups and flushes from swapping segments and, as pending
+// writers take precedence, eventually all other readers. Release the lock as
+// soon as possible and never call back into the segment group while holding
+// it. Accessing the value after release, i.e. after the segment may have been
+// compacted and unmapped, can crash the process.
+//
+// release must be called exactly once, also if the key was not found or an
+// error is returned. Values of segments which are not mmapped are copied as in
+// get.
+func (sg *SegmentGroup) getZeroCopy(key []byte) (value []byte, release func(), err error) {
+	sg.rLock("get")
+
+	for i := len(sg.segments) - 1; i >= 0; i-- {
+		v, err := sg.segments[i].getZeroCopy(key)
+		if err != nil {
+			if errors.Is(err, lsmkv.NotFound) {
+				continue
+			}
+
+			// deleted keys are not an error, just like in get
+			sg.maintenanceLock.RUnlock()
+			if errors.Is(err, lsmkv.Deleted) {
+				return nil, func() {}, nil
+			}
+			return nil, func() {}, err
+		}
+
+		return v, sg.maintenanceLock.RUnlock, nil
 	}
+
+	sg.maintenanceLock.RUnlock()
+	return nil, func() {}, nil
+}
+
+func (sg *SegmentGroup) getSlowPathThreshold() time.Duration {
+	if sg.slowPathThreshold <= 0 {
+		return defaultSlowPathThreshold
+	}
+	return sg.slowPathThreshold
+}
+
+// defaultReadTimingSampleRate makes every 64th read time its segment probes
+const defaultReadTimingSampleRate = 64
+
+// timeSegmentReads reports whether the segment probes of a read are timed and
+// whether the timings are recorded in the metrics. Timing every read would
+// call time.Now twice per probed segment on the hot read path, so the
+// metrics are fed by a random sample of the reads, which keeps the latency
+// distribution unbiased. The slow path log needs every read to be timed, but
+// only if it is emitted at all.
+func (sg *SegmentGroup) timeSegmentReads() (timed, sampled bool) {
+	if sg.metrics != nil {
+		sampled = sg.readTimingSampleRate <= 1 || rand.IntN(sg.readTimingSampleRate) == 0
+	}
+	return sampled || debugLogEnabled(sg.logger), sampled
+}
+
+// debugLogEnabled reports whether logger emits debug logs. Loggers whose level
+// can't be determined are assumed to emit them.
+func debugLogEnabled(logger logrus.FieldLogger) bool {
+	return logLevelEnabled(logger, logrus.DebugLevel)
+}
+
+// logLevelEnabled is like debugLogEnabled for any level
+func logLevelEnabled(logger logrus.FieldLogger, level logrus.Level) bool {
+	switch l := logger.(type) {
+	case *logrus.Logger:
+		return l.IsLevelEnabled(level)
+	case *logrus.Entry:
+		return l.Logger.IsLevelEnabled(level)
+	default:
+		return true
+	}
+}
+
+// getWithSource behaves like get, but additionally returns the ID of the
+// segment the key was resolved in. If the key was deleted, the ID of the
+// segment holding the tombstone is returned along with a nil value. If the key
+// does not exist in any segment, the ID is empty.
+//
+// Segment IDs are the unix nano timestamps at which the memtable that was
+// flushed into the segment was created, compacted segments keep the ID of the
+// newer segment. This gives a rough idea of when the value was written.
+func (sg *SegmentGroup) getWithSource(key []byte) ([]byte, string, error) {
+	readObservers := sg.keyPrefixLabels.observersOf(key)
+	sg.rLockForKey("get", readObservers.keyPrefix())
 	defer sg.maintenanceLock.RUnlock()
 
-	return sg.getWithUpperSegmentBoundary(key, len(sg.segments)-1)
+	v, pos, err := sg.getWithUpperSegmentBoundaryAndPos(key, len(sg.segments)-1, readObservers)
+	if err != nil || pos < 0 {
+		return v, "", err
+	}
+
+	return v, segmentID(sg.segments[pos].path), nil
 }
 
 // not thread-safe on its own, as the assumption is that this is called from a
-// lockholder, e.g. within .get()
-func (sg *SegmentGroup) getWithUpperSegmentBoundary(key []byte, topMostSegment int) ([]byte, error) {
+// lockholder, e.g. within .get(). readObservers are the observers of the key,
+// they are resolved once by the caller, see keyPrefixLabels.observersOf.
+func (sg *SegmentGroup) getWithUpperSegmentBoundary(key []byte, topMostSegment int,
+	readObservers *keyPrefixReadObservers,
+) ([]byte, error) {
+	v, _, err := sg.getWithUpperSegmentBoundaryAndPos(key, topMostSegment, readObservers)
+	return v, err
+}
+
+// getWithUpperSegmentBoundaryAndPos additionally returns the position of the
+// segment the key was resolved in, or -1 if no segment contains the key. Same
+// as getWithUpperSegmentBoundary, it needs to be called from a lockholder.
+func (sg *SegmentGroup) getWithUpperSegmentBoundaryAndPos(key []byte, topMostSegment int,
+	readObservers *keyPrefixReadObservers,
+) ([]byte, int, error) {
 	// assumes "replace" strategy
 
+	timed, sampled := sg.timeSegmentReads()
+
 	// start with latest and exit as soon as something is found, thus making sure
 	// the latest takes presence
 	for i := topMostSegment; i >= 0; i-- {
-		beforeSegment := time.Now()
+		var beforeSegment time.Time
+		if timed {
+			beforeSegment = time.Now()
+		}
 		v, err := sg.segments[i].get(key)
-		if time.Since(beforeSegment) > 100*time.Millisecond {
-			sg.logger.WithField("duration", time.Since(beforeSegment)).
-				WithField("action", "lsm_segment_group_get_individual_segment").
-				WithError(err).
-				WithField("segment_pos", i).
-				Debug("waited over 100ms to get result from individual segment")
+		sg.metrics.ObserveSegmentProbe(topMostSegment-i, !errors.Is(err, lsmkv.NotFound))
+		if timed {
+			tookSegment := time.Since(beforeSegment)
+			if sampled {
+				readObservers.observeSegmentRead(tookSegment)
+				sg.metrics.ObserveSegmentProbeDuration(topMostSegment-i,
+					tookSegment*time.Duration(max(1, sg.readTimingSampleRate)))
+			}
+			if threshold := sg.getSlowPathThreshold(); tookSegment > threshold {
+				sg.logger.WithField("duration", tookSegment).
+					WithField("action", "lsm_segment_group_get_individual_segment").
+					WithError(err).
+					WithField("segment_pos", i).
+					Debugf("waited over %s to get result from individual segment", threshold)
+			}
 		}
 		if err != nil {
 			if errors.Is(err, lsmkv.NotFound) {
@@ -433,20 +865,20 @@ func (sg *SegmentGroup) getWithUpperSegmentBoundary(key []byte, topMostSegment i
 			}
 
 			if errors.Is(err, lsmkv.Deleted) {
-				return nil, nil
+				return nil, i, nil
 			}
 
-			panic(fmt.Sprintf("unsupported error in segmentGroup.get(): %v", err))
+			return nil, i, fmt.Errorf("get from segment %q: %w", sg.segments[i].path, err)
 		}
 
-		return v, nil
+		return v, i, nil
 	}
 
-	return nil, nil
+	return nil, -1, nil
 }
 
 func (sg *SegmentGroup) getErrDeleted(key []byte) ([]byte, error) {
-	sg.maintenanceLock.RLock()
+	sg.rLock("get")
 	defer sg.maintenanceLock.RUnlock()
 
 	return sg.getWithUpperSegmentBoundaryErrDeleted(key, len(sg.segments)-1)
@@ -468,7 +900,7 @@ func (sg *SegmentGroup) getWithUpperSegmentBoundaryErrDeleted(key []byte, topMos
 				return nil, err
 			}
 
-			panic(fmt.Sprintf("unsupported error in segmentGroup.get(): %v", err))
+			return nil, fmt.Errorf("get from segment %q: %w", sg.segments[i].path, err)
 		}
 
 		return v, nil
@@ -496,7 +928,7 @@ func (sg *SegmentGroup) getBySecondaryIntoMemory(pos int, key []byte, buffer []b
 				return nil, nil, nil, nil
 			}
 
-			panic(fmt.Sprintf("unsupported error in segmentGroup.get(): %v", err))
+			return nil, nil, nil, fmt.Errorf("get from segment %q: %w", sg.segments[i].path, err)
 		}
 
 		return k, v, allocatedBuff, nil
@@ -505,11 +937,16 @@ func (sg *SegmentGroup) getBySecondaryIntoMemory(pos int, key []byte, buffer []b
 	return nil, nil, nil, nil
 }
 
-func (sg *SegmentGroup) getCollection(key []byte) ([]value, error) {
-	sg.maintenanceLock.RLock()
+// getCollection gathers the values of key from all segments, oldest first.
+// Segments which fail with an error other than lsmkv.NotFound fail the read,
+// unless the policy is CollectionReadErrorPolicyTolerant. Then they are logged
+// and skipped, and the bool reports that the values are partial.
+func (sg *SegmentGroup) getCollection(key []byte) ([]value, bool, error) {
+	sg.rLockForKey("getCollection", sg.keyPrefixLabels.observersOf(key).keyPrefix())
 	defer sg.maintenanceLock.RUnlock()
 
 	var out []value
+	partial := false
 
 	// start with first and do not exit
 	for _, segment := range sg.segments {
@@ -518,8 +955,13 @@ func (sg *SegmentGroup) getCollection(key []byte) ([]value, error) {
 			if errors.Is(err, lsmkv.NotFound) {
 				continue
 			}
+			if sg.collectionReadErrorPolicy != CollectionReadErrorPolicyTolerant {
+				return nil, false, err
+			}
 
-			return nil, err
+			sg.logSkippedCollectionSegment(segment, err)
+			partial = true
+			continue
 		}
 
 		if len(out) == 0 {
@@ -529,11 +971,98 @@ func (sg *SegmentGroup) getCollection(key []byte) ([]value, error) {
 		}
 	}
 
-	return out, nil
+	return out, partial, nil
+}
+
+// getCollectionWithLimit behaves like getCollection, but gathers at most limit
+// values, which protects against pathological collection sizes. Segments and
+// their values are walked newest first, so that the most recent values are
+// kept. Values are identified by their key, see collectionValueKey: the newest
+// entry of a key decides whether it is present or deleted, and only present
+// values count towards the limit. The returned values contain no
+// tombstones and are ordered from oldest to newest like the ones of
+// getCollection. truncated reports whether present values were left out.
+//
+// Unreadable segments are handled according to the collectionReadErrorPolicy
+// like in getCollection, partial reports whether one was skipped.
+func (sg *SegmentGroup) getCollectionWithLimit(key []byte, limit int,
+) (out []value, truncated, partial bool, err error) {
+	if limit <= 0 {
+		return nil, false, false, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+
+	sg.rLockForKey("getCollectionWithLimit", sg.keyPrefixLabels.observersOf(key).keyPrefix())
+	defer sg.maintenanceLock.RUnlock()
+
+	seen := map[string]struct{}{}
+
+segments:
+	for i := len(sg.segments) - 1; i >= 0; i-- {
+		values, err := sg.segments[i].getCollection(key)
+		if err != nil {
+			if errors.Is(err, lsmkv.NotFound) {
+				continue
+			}
+			if sg.collectionReadErrorPolicy != CollectionReadErrorPolicyTolerant {
+				return nil, false, false, err
+			}
+
+			sg.logSkippedCollectionSegment(sg.segments[i], err)
+			partial = true
+			continue
+		}
+
+		for j := len(values) - 1; j >= 0; j-- {
+			valueKey, err := collectionValueKey(sg.segments[i].strategy, values[j])
+			if err != nil {
+				return nil, false, false, err
+			}
+			if _, ok := seen[string(valueKey)]; ok {
+				// shadowed by a newer entry
+				continue
+			}
+			seen[string(valueKey)] = struct{}{}
+			if values[j].tombstone {
+				continue
+			}
+			if len(out) == limit {
+				truncated = true
+				break segments
+			}
+			out = append(out, values[j])
+		}
+	}
+
+	slices.Reverse(out)
+	return out, truncated, partial, nil
+}
+
+// collectionValueKey returns the key that identifies v within a collection of
+// a segment with the given strategy. A newer entry with the same key replaces
+// an older one. For sets that is the value itself, for maps it is the key of
+// the map pair.
+func collectionValueKey(strategy segmentindex.Strategy, v value) ([]byte, error) {
+	switch strategy {
+	case segmentindex.StrategyMapCollection:
+		var mp MapPair
+		if err := mp.FromBytes(v.value, true); err != nil {
+			return nil, err
+		}
+		return mp.Key, nil
+	case segmentindex.StrategyInverted:
+		// values of inverted segments are converted to the 8 byte key followed
+		// by the value, see collectionStratParseDataInverted
+		if len(v.value) < 8 {
+			return nil, fmt.Errorf("inverted value must be at least 8 bytes, got %d", len(v.value))
+		}
+		return v.value[:8], nil
+	default:
+		return v.value, nil
+	}
 }
 
 func (sg *SegmentGroup) getCollectionAndSegments(key []byte) ([][]value, []*segment, error) {
-	sg.maintenanceLock.RLock()
+	sg.rLock("getCollection")
 	defer sg.maintenanceLock.RUnlock()
 
 	out := make([][]value, len(sg.segments))
@@ -591,15 +1120,95 @@ func (sg *SegmentGroup) count() int {
 
 	count := 0
 	for _, seg := range sg.segments {
-		count += seg.countNetAdditions
+		count += int(seg.countNetAdditions.Load())
 	}
 
 	return count
 }
 
+// RecomputeNetAdditions rescans all segments to recompute their net
+// additions, which count() is based on. It fixes counts which drifted, e.g.
+// due to a bug, without having to restart to recalculate them on mount. The
+// recomputed counts are persisted. It is a no-op if net additions are not
+// calculated for the segment group.
+//
+// Compactions and cleanups are blocked while the counts are recomputed, reads
+// and writes are not.
+func (sg *SegmentGroup) RecomputeNetAdditions(ctx context.Context) error {
+	if !sg.calcCountNetAdditions || sg.strategy != StrategyReplace {
+		return nil
+	}
+
+	// segments are swapped under the maintenanceLock, but only by routines
+	// which hold the compactionLock. While it is held, flushes can only append
+	// segments, so the segments below any given segment do not change.
+	sg.compactionLock.Lock()
+	defer sg.compactionLock.Unlock()
+
+	sg.maintenanceLock.RLock()
+	segments := make([]*segment, len(sg.segments))
+	copy(segments, sg.segments)
+	sg.maintenanceLock.RUnlock()
+
+	for i, seg := range segments {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("recompute net additions: %w", err)
+		}
+
+		if err := seg.ensureContentsOpen(); err != nil {
+			return fmt.Errorf("recompute net additions of segment %s: %w", seg.path, err)
+		}
+
+		countNet, err := seg.computeCountNetAdditions(existsOnSegments(segments[:i]))
+		if err != nil {
+			return fmt.Errorf("recompute net additions of segment %s: %w", seg.path, err)
+		}
+
+		if previous := seg.countNetAdditions.Swap(int64(countNet)); previous != int64(countNet) {
+			sg.logger.WithField("action", "lsm_recompute_net_additions").
+				WithField("path", seg.path).
+				WithField("previous", previous).
+				WithField("recomputed", countNet).
+				Warn("corrected drifted net additions of segment")
+		}
+
+		if err := seg.storeCountNetOnDisk(); err != nil {
+			return fmt.Errorf("store net additions of segment %s: %w", seg.path, err)
+		}
+	}
+
+	if sg.monitorCount {
+		sg.observeCount()
+	}
+
+	return nil
+}
+
+// existsOnSegments is like makeExistsOnLower, but resolves keys on a
+// snapshot of the segments instead of the segment group
+func existsOnSegments(segments []*segment) existsOnLowerSegmentsFn {
+	return func(key []byte) (bool, error) {
+		for i := len(segments) - 1; i >= 0; i-- {
+			_, err := segments[i].get(key)
+			switch {
+			case err == nil:
+				return true, nil
+			case errors.Is(err, lsmkv.NotFound):
+				continue
+			case errors.Is(err, lsmkv.Deleted):
+				return false, nil
+			default:
+				return false, fmt.Errorf("check exists on segment %s: %w", segments[i].path, err)
+			}
+		}
+		return false, nil
+	}
+}
+
 func (sg *SegmentGroup) shutdown(ctx context.Context) error {
-	if err := sg.compactionCallbackCtrl.Unregister(ctx); err != nil {
-		return fmt.Errorf("long-running compaction in progress: %w", ctx.Err())
+	sg.idleCompaction.close()
+	if err := sg.stopCompactions(ctx); err != nil {
+		return err
 	}
 	if err := sg.segmentCleaner.close(); err != nil {
 		return err
@@ -622,12 +1231,21 @@ func (sg *SegmentGroup) shutdown(ctx context.Context) error {
 
 		sg.segments[i] = nil
 	}
+	sg.keyPrefixLabels.release()
 
 	// make sure the segment list itself is set to nil. In case a memtable will
 	// still flush after closing, it might try to read from a disk segment list
 	// otherwise and run into nil-pointer problems.
 	sg.segments = nil
 
+	// releases the lock, another segment group may open the directory now
+	if sg.dirLock != nil {
+		if err := sg.dirLock.Close(); err != nil {
+			return fmt.Errorf("release segment group lock: %w", err)
+		}
+		sg.dirLock = nil
+	}
+
 	return nil
 }
 
@@ -645,6 +1263,34 @@ func (sg *SegmentGroup) isReadyOnly() bool {
 	return sg.status == storagestate.StatusReadOnly
 }
 
+// sortSegmentsByID orders segments from oldest to newest by their numeric
+// segment ID, which is the creation time of the memtable they were flushed
+// from. A compacted segment is identified by its right-hand, i.e. newer,
+// component. Segments with equal or non-numeric IDs keep their relative order,
+// the latter come last.
+func sortSegmentsByID(segments []*segment) {
+	sort.SliceStable(segments, func(a, b int) bool {
+		idA, okA := numericSegmentID(segments[a].path)
+		idB, okB := numericSegmentID(segments[b].path)
+		if okA != okB {
+			return okA
+		}
+		return okA && idA < idB
+	})
+}
+
+func numericSegmentID(path string) (int64, bool) {
+	id := segmentID(path)
+	if pos := strings.LastIndex(id, "_"); pos >= 0 {
+		id = id[pos+1:]
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
 func fileExists(path string) (bool, error) {
 	_, err := os.Stat(path)
 	if err == nil {
@@ -661,10 +1307,24 @@ func fileExists(path string) (bool, error) {
 func (sg *SegmentGroup) compactOrCleanup(shouldAbort cyclemanager.ShouldAbortCallback) bool {
 	sg.monitorSegments()
 
+	sg.compactionLock.Lock()
+	defer sg.compactionLock.Unlock()
+
+	if err := sg.closeLeastRecentlyReadSegments(); err != nil {
+		sg.logger.WithField("action", "lsm_segment_group_close_segment_files").
+			WithField("path", sg.dir).
+			WithError(err).
+			Errorf("closing segment files failed")
+	}
+
 	compact := func() bool {
 		sg.lastCompactionCall = time.Now()
 		compacted, err := sg.compactOnce()
-		if err != nil {
+		if errors.Is(err, errCompactionAborted) {
+			sg.logger.WithField("action", "lsm_compaction").
+				WithField("path", sg.dir).
+				Warn("compaction aborted on shutdown, original segments are kept")
+		} else if err != nil {
 			sg.logger.WithField("action", "lsm_compaction").
 				WithField("path", sg.dir).
 				WithError(err).
@@ -685,8 +1345,21 @@ func (sg *SegmentGroup) compactOrCleanup(shouldAbort cyclemanager.ShouldAbortCal
 				WithError(err).
 				Errorf("cleanup failed")
 		}
+		if cleaned {
+			sg.lastCleanup.Store(time.Now().UnixNano())
+		}
 		return cleaned
 	}
+	gcCompact := func() bool {
+		compacted, err := sg.gcCompactOnce(shouldAbort)
+		if err != nil {
+			sg.logger.WithField("action", "lsm_gc_compaction").
+				WithField("path", sg.dir).
+				WithError(err).
+				Errorf("gc compaction failed")
+		}
+		return compacted
+	}
 
 	// alternatively run compaction or cleanup first
 	// if 1st one called succeeds, 2nd one is skipped, otherwise 2nd one is called as well
@@ -695,12 +1368,14 @@ func (sg *SegmentGroup) compactOrCleanup(shouldAbort cyclemanager.ShouldAbortCal
 	// was not called for over [forceCleanupInterval], force at least one execution
 	// in between compactions.
 	// (ignore if compaction was not called within that time either)
+	//
+	// gc compaction only runs if neither found anything to do, see gcCompactOnce
 	forceCleanupInterval := time.Hour * 12
 
 	if time.Since(sg.lastCleanupCall) > forceCleanupInterval && sg.lastCleanupCall.Before(sg.lastCompactionCall) {
-		return cleanup() || compact()
+		return cleanup() || compact() || gcCompact()
 	}
-	return compact() || cleanup()
+	return compact() || cleanup() || gcCompact()
 }
 
 func (sg *SegmentGroup) Len() int {
@@ -709,3 +1384,24 @@ func (sg *SegmentGroup) Len() int {
 
 	return len(sg.segments)
 }
+
+// LenAtLevel returns the number of segments of the given compaction level.
+// Flushed segments are of level 0, compacting two segments of level N
+// produces a segment of level N+1. Levels are stored as uint16, so there are
+// no segments of a negative or larger level.
+func (sg *SegmentGroup) LenAtLevel(level int) int {
+	if level < 0 || level > math.MaxUint16 {
+		return 0
+	}
+
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	count := 0
+	for _, seg := range sg.segments {
+		if seg.level == uint16(level) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,101 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_AddAsync(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(t *testing.T, dir string) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+		return b
+	}
+
+	// build a segment in a separate bucket, so it can be added to the segment
+	// group under test
+	srcDir := t.TempDir()
+	src := newBucket(t, srcDir)
+	require.Nil(t, src.Put([]byte("hello"), []byte("world")))
+	require.Nil(t, src.FlushAndSwitch())
+	srcSegment := src.disk.segmentAtPos(0).path
+
+	dir := t.TempDir()
+	b := newBucket(t, dir)
+	require.Nil(t, b.Put([]byte("foo"), []byte("bar")))
+	require.Nil(t, b.FlushAndSwitch())
+	require.Equal(t, 1, b.disk.Len())
+
+	t.Run("segment is added", func(t *testing.T) {
+		contents, err := os.ReadFile(srcSegment)
+		require.Nil(t, err)
+		path := filepath.Join(dir, "segment-9999999999999999999.db")
+		require.Nil(t, os.WriteFile(path, contents, 0o666))
+
+		require.Nil(t, <-b.disk.addAsync(path))
+		assert.Equal(t, 2, b.disk.Len())
+
+		v, err := b.Get([]byte("hello"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("world"), v)
+
+		v, err = b.Get([]byte("foo"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("bar"), v)
+	})
+
+	t.Run("readers only block the final swap", func(t *testing.T) {
+		contents, err := os.ReadFile(srcSegment)
+		require.Nil(t, err)
+		path := filepath.Join(dir, "segment-9999999999999999998.db")
+		require.Nil(t, os.WriteFile(path, contents, 0o666))
+
+		// simulate a long-running reader
+		b.disk.maintenanceLock.RLock()
+		errC := b.disk.addAsync(path)
+
+		select {
+		case err := <-errC:
+			b.disk.maintenanceLock.RUnlock()
+			t.Fatalf("segment added while a reader held the lock: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		assert.Equal(t, 2, len(b.disk.segments))
+
+		b.disk.maintenanceLock.RUnlock()
+		require.Nil(t, <-errC)
+		assert.Equal(t, 3, b.disk.Len())
+	})
+
+	t.Run("segment does not exist", func(t *testing.T) {
+		err := <-b.disk.addAsync(filepath.Join(dir, "segment-123.db"))
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "init segment")
+		assert.Equal(t, 3, b.disk.Len())
+	})
+}
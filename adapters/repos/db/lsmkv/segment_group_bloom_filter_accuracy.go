@@ -0,0 +1,108 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// every n-th successful compaction of a segment group triggers a
+	// BloomFilterAccuracyTest
+	bloomFilterAccuracyInterval = 10
+
+	// with the configured rate of 0.1% this yields ~10 expected false positives
+	// per segment, enough to tell a healthy filter from a degraded one
+	bloomFilterAccuracySampleSize = 10_000
+)
+
+// BloomFilterAccuracyTest measures the actual false positive rate of the
+// primary bloom filters of all segments in the group. It generates sampleSize
+// random keys by hashing random bytes and tests each of them against the
+// bloom filter of every segment. As the keys are 32 byte hashes of random
+// input, they are not contained in any segment for all practical purposes, so
+// every positive test is a false positive.
+//
+// Segments without a loaded bloom filter are skipped. An error is returned if
+// no segment has a bloom filter at all.
+func BloomFilterAccuracyTest(sg *SegmentGroup, sampleSize int) (float64, error) {
+	if sampleSize <= 0 {
+		return 0, fmt.Errorf("sample size must be positive, got %d", sampleSize)
+	}
+
+	keys := make([][]byte, sampleSize)
+	random := make([]byte, 16)
+	for i := range keys {
+		if _, err := rand.Read(random); err != nil {
+			return 0, fmt.Errorf("generate random key: %w", err)
+		}
+		key := sha256.Sum256(random)
+		keys[i] = key[:]
+	}
+
+	sg.rLock("bloomFilterAccuracyTest")
+	defer sg.maintenanceLock.RUnlock()
+
+	tests, positives := 0, 0
+	for _, seg := range sg.segments {
+		if seg.bloomFilter == nil {
+			continue
+		}
+
+		for _, key := range keys {
+			tests++
+			if seg.bloomFilter.Test(key) {
+				positives++
+			}
+		}
+	}
+
+	if tests == 0 {
+		return 0, fmt.Errorf("none of %d segments has a bloom filter", len(sg.segments))
+	}
+
+	return float64(positives) / float64(tests), nil
+}
+
+// logBloomFilterAccuracy runs BloomFilterAccuracyTest and logs the result. If
+// the measured rate exceeds twice the configured one, a warning suggesting to
+// regenerate the bloom filters is logged instead.
+func (sg *SegmentGroup) logBloomFilterAccuracy() {
+	if !sg.useBloomFilter {
+		return
+	}
+
+	logger := sg.logger.WithFields(logrus.Fields{
+		"action":         "lsm_bloom_filter_accuracy",
+		"path":           sg.dir,
+		"sample_size":    bloomFilterAccuracySampleSize,
+		"configured_fpr": bloomFilterFalsePositiveRate,
+	})
+
+	actualFPR, err := BloomFilterAccuracyTest(sg, bloomFilterAccuracySampleSize)
+	if err != nil {
+		logger.WithError(err).Debug("skipped bloom filter accuracy test")
+		return
+	}
+
+	logger = logger.WithField("actual_fpr", actualFPR)
+	if actualFPR > 2*bloomFilterFalsePositiveRate {
+		logger.Warn("bloom filter false positive rate exceeds twice the configured " +
+			"rate, consider regenerating the bloom filters")
+		return
+	}
+	logger.Debug("measured bloom filter false positive rate")
+}
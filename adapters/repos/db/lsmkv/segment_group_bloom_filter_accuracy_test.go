@@ -0,0 +1,69 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestBloomFilterAccuracyTest(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(t *testing.T, opts ...BucketOption) *Bucket {
+		opts = append([]BucketOption{WithStrategy(StrategyReplace)}, opts...)
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			opts...)
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+		return b
+	}
+
+	t.Run("invalid sample size", func(t *testing.T) {
+		b := newBucket(t)
+		_, err := BloomFilterAccuracyTest(b.disk, 0)
+		assert.ErrorContains(t, err, "sample size must be positive")
+	})
+
+	t.Run("without bloom filters", func(t *testing.T) {
+		b := newBucket(t, WithUseBloomFilter(false))
+		require.Nil(t, b.Put([]byte("key"), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		_, err := BloomFilterAccuracyTest(b.disk, 100)
+		assert.ErrorContains(t, err, "none of 1 segments has a bloom filter")
+	})
+
+	t.Run("rate close to the configured one", func(t *testing.T) {
+		b := newBucket(t)
+		for seg := 0; seg < 3; seg++ {
+			for i := 0; i < 1000; i++ {
+				key := []byte(fmt.Sprintf("key-%d-%d", seg, i))
+				require.Nil(t, b.Put(key, key))
+			}
+			require.Nil(t, b.FlushAndSwitch())
+		}
+
+		fpr, err := BloomFilterAccuracyTest(b.disk, 20_000)
+		require.Nil(t, err)
+		assert.GreaterOrEqual(t, fpr, 0.0)
+		assert.LessOrEqual(t, fpr, 2*bloomFilterFalsePositiveRate)
+	})
+}
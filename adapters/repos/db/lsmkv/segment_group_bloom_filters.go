@@ -0,0 +1,131 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/willf/bloom"
+)
+
+// PreloadBloomFilters makes sure the bloom filters of all segments are
+// resident in memory, so that the first reads after a (cold) start do not have
+// to load them. Filters which are already loaded are left untouched, which
+// makes the call cheap when used on a warm node. It is a no-op for buckets
+// without bloom filters.
+//
+// Loading stops when ctx is cancelled or the allocChecker reports that there is
+// not enough memory left to hold the next segment's filters.
+//
+// The filters are loaded without holding the maintenanceLock, it is only held
+// exclusively to swap in the filters of a segment. Compactions wait until the
+// preload is done.
+func (sg *SegmentGroup) PreloadBloomFilters(ctx context.Context) error {
+	if !sg.useBloomFilter {
+		return nil
+	}
+
+	// segments are only replaced or closed by routines which hold the
+	// compactionLock, so none of the segments is closed while its filters are
+	// loaded
+	sg.compactionLock.Lock()
+	defer sg.compactionLock.Unlock()
+
+	sg.maintenanceLock.RLock()
+	segments := make([]*segment, len(sg.segments))
+	copy(segments, sg.segments)
+	sg.maintenanceLock.RUnlock()
+
+	for _, seg := range segments {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("preload bloom filters: %w", err)
+		}
+
+		if seg.bloomFiltersLoaded() {
+			continue
+		}
+
+		if sg.allocChecker != nil {
+			// allocChecker is optional
+			if err := sg.allocChecker.CheckAlloc(seg.bloomFiltersSizeOnDisk()); err != nil {
+				return fmt.Errorf("preload bloom filters of segment %s: %w", seg.path, err)
+			}
+		}
+
+		if err := seg.ensureContentsOpen(); err != nil {
+			return fmt.Errorf("preload bloom filters of segment %s: %w", seg.path, err)
+		}
+		primary, secondary, err := seg.loadBloomFilters(sg.metrics)
+		if err != nil {
+			return fmt.Errorf("preload bloom filters of segment %s: %w", seg.path, err)
+		}
+
+		// readers test the bloom filters without further synchronization, so
+		// they must not be swapped while reads are in progress
+		sg.maintenanceLock.Lock()
+		seg.bloomFilter = primary
+		seg.secondaryBloomFilters = secondary
+		seg.bloomFilterMetrics = newBloomFilterMetrics(sg.metrics)
+		sg.maintenanceLock.Unlock()
+	}
+
+	return nil
+}
+
+// loadBloomFilters loads the bloom filters of the segment, or builds them if
+// they are missing on disk, without assigning them to the segment. The
+// contents of the segment need to be open.
+func (s *segment) loadBloomFilters(metrics *Metrics) (*bloom.BloomFilter, []*bloom.BloomFilter, error) {
+	scratch := &segment{
+		path:                s.path,
+		logger:              s.logger,
+		index:               s.index,
+		secondaryIndices:    s.secondaryIndices,
+		secondaryIndexCount: s.secondaryIndexCount,
+	}
+	if err := scratch.initBloomFilters(metrics, false); err != nil {
+		return nil, nil, err
+	}
+	return scratch.bloomFilter, scratch.secondaryBloomFilters, nil
+}
+
+func (s *segment) bloomFiltersLoaded() bool {
+	if s.bloomFilter == nil || len(s.secondaryBloomFilters) != int(s.secondaryIndexCount) {
+		return false
+	}
+	for _, filter := range s.secondaryBloomFilters {
+		if filter == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomFiltersSizeOnDisk estimates the memory required to hold the bloom
+// filters of the segment by the size of their files. Missing files are
+// ignored.
+func (s *segment) bloomFiltersSizeOnDisk() int64 {
+	paths := []string{s.bloomFilterPath()}
+	for i := 0; i < int(s.secondaryIndexCount); i++ {
+		paths = append(paths, s.bloomFilterSecondaryPath(i))
+	}
+
+	var size int64
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
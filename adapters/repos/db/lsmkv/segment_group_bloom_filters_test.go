@@ -0,0 +1,125 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+	"github.com/weaviate/weaviate/usecases/memwatch"
+)
+
+func TestSegmentGroup_PreloadBloomFilters(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(t *testing.T, opts ...BucketOption) *Bucket {
+		opts = append([]BucketOption{WithStrategy(StrategyReplace), WithSecondaryIndices(1)}, opts...)
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(), opts...)
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+
+		for _, key := range []string{"key1", "key2"} {
+			require.Nil(t, b.Put([]byte(key), []byte("value"), WithSecondaryKey(0, []byte("s"+key))))
+			require.Nil(t, b.FlushAndSwitch())
+		}
+		require.Len(t, b.disk.segments, 2)
+		return b
+	}
+
+	// simulates segments whose bloom filters are not resident yet
+	dropBloomFilters := func(b *Bucket) {
+		for _, seg := range b.disk.segments {
+			seg.bloomFilter = nil
+			seg.secondaryBloomFilters = nil
+		}
+	}
+
+	t.Run("loads missing bloom filters", func(t *testing.T) {
+		b := newBucket(t)
+		dropBloomFilters(b)
+
+		require.Nil(t, b.disk.PreloadBloomFilters(ctx))
+
+		for _, seg := range b.disk.segments {
+			require.NotNil(t, seg.bloomFilter)
+			require.Len(t, seg.secondaryBloomFilters, 1)
+			assert.NotNil(t, seg.secondaryBloomFilters[0])
+		}
+		value, err := b.Get([]byte("key1"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value"), value)
+		value, err = b.GetBySecondary(0, []byte("skey2"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value"), value)
+	})
+
+	t.Run("keeps loaded bloom filters", func(t *testing.T) {
+		b := newBucket(t)
+		filter := b.disk.segments[0].bloomFilter
+
+		require.Nil(t, b.disk.PreloadBloomFilters(ctx))
+		assert.Same(t, filter, b.disk.segments[0].bloomFilter)
+	})
+
+	t.Run("no-op without bloom filters", func(t *testing.T) {
+		b := newBucket(t, WithUseBloomFilter(false))
+
+		require.Nil(t, b.disk.PreloadBloomFilters(ctx))
+		for _, seg := range b.disk.segments {
+			assert.Nil(t, seg.bloomFilter)
+		}
+	})
+
+	t.Run("stops on cancelled context", func(t *testing.T) {
+		b := newBucket(t)
+		dropBloomFilters(b)
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := b.disk.PreloadBloomFilters(cancelled)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, b.disk.segments[0].bloomFilter)
+	})
+
+	t.Run("stops when out of memory", func(t *testing.T) {
+		b := newBucket(t, WithAllocChecker(&fakeAllocChecker{err: errors.New("not enough memory")}))
+		dropBloomFilters(b)
+
+		err := b.disk.PreloadBloomFilters(ctx)
+		require.ErrorContains(t, err, "not enough memory")
+		assert.Nil(t, b.disk.segments[0].bloomFilter)
+	})
+}
+
+type fakeAllocChecker struct {
+	err error
+}
+
+func (f *fakeAllocChecker) CheckAlloc(sizeInBytes int64) error {
+	return f.err
+}
+
+func (f *fakeAllocChecker) CheckMappingAndReserve(numberMappings int64, reservationTimeInS int) error {
+	return nil
+}
+
+func (f *fakeAllocChecker) Refresh(updateMappings bool) {}
+
+var _ memwatch.AllocChecker = (*fakeAllocChecker)(nil)
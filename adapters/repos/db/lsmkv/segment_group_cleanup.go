@@ -14,7 +14,6 @@ package lsmkv
 import (
 	"encoding/binary"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"time"
@@ -41,14 +40,17 @@ type segmentCleaner interface {
 	cleanupOnce(shouldAbort cyclemanager.ShouldAbortCallback) (cleaned bool, err error)
 }
 
-func newSegmentCleaner(sg *SegmentGroup) (segmentCleaner, error) {
+// newSegmentCleaner creates the cleaner for the strategy of the segment group.
+// batchSize bounds the number of newer segments a single cleanup checks the
+// keys of the cleaned segment against, 0 means unbounded.
+func newSegmentCleaner(sg *SegmentGroup, batchSize int) (segmentCleaner, error) {
 	if sg.cleanupInterval <= 0 {
 		return &segmentCleanerNoop{}, nil
 	}
 
 	switch sg.strategy {
 	case StrategyReplace:
-		cleaner := &segmentCleanerCommon{sg: sg}
+		cleaner := &segmentCleanerCommon{sg: sg, batchSize: batchSize}
 		if err := cleaner.init(); err != nil {
 			return nil, err
 		}
@@ -101,9 +103,15 @@ func (c *segmentCleanerNoop) cleanupOnce(shouldAbort cyclemanager.ShouldAbortCal
 // Additionally "global" earliest cleanup timestamp is stored ([cleanupDbKeyMetaNextAllowedTs])
 // or last execution timestamp of findCandiate method. This timeout is used to quickly exit
 // findCandidate method without necessity to verify if interval passed for each segment.
+//
+// If batchSize is set, a single cleanup checks the keys of the candidate against
+// at most batchSize newer segments. The cleanup of the remaining newer segments
+// continues in the next cycles. Until then the segment's cleanup timestamp is
+// stored as 0, so that it is picked again without waiting for cleanupInterval.
 type segmentCleanerCommon struct {
-	sg *SegmentGroup
-	db *bolt.DB
+	sg        *SegmentGroup
+	db        *bolt.DB
+	batchSize int
 }
 
 func (c *segmentCleanerCommon) init() error {
@@ -176,8 +184,16 @@ func (c *segmentCleanerCommon) findCandidate() (int, int, int, onCompletedFunc,
 		// candidate found and ready for cleanup
 		id := ids[candidateIdx]
 		lastProcessedId := ids[len(ids)-1]
+		cleanedTs := nowTs
+		if lo, hi := min(startIdx, lastIdx), max(startIdx, lastIdx); c.batchSize > 0 && hi-lo+1 > c.batchSize {
+			// clean against the oldest batch of newer segments only, in ascending
+			// order so that the next cycle can continue after the last one
+			startIdx, lastIdx = lo, lo+c.batchSize-1
+			lastProcessedId = ids[lastIdx]
+			cleanedTs = 0
+		}
 		onCompleted := func(size int64) error {
-			return c.storeSegmentMeta(id, lastProcessedId, size, nowTs)
+			return c.storeSegmentMeta(id, lastProcessedId, size, cleanedTs)
 		}
 		return candidateIdx, startIdx, lastIdx, onCompleted, nil
 	}
@@ -374,11 +390,12 @@ func (c *segmentCleanerCommon) readEarliestCleaned(ids, sizes []int64, nowTs int
 					// segment should be cleaned only if sum of sizes of segments to be cleaned
 					// with exceeds [minCleanupSizePercent] of its current size, to increase
 					// probability of redunand keys.
+					// An incomplete batched cleanup (timestamp 0) is always continued.
 					sumSize := int64(0)
 					for i := possibleStartIdx; i < count; i++ {
 						sumSize += sizes[i]
 					}
-					if size*minCleanupSizePercent/100 <= sumSize {
+					if size*minCleanupSizePercent/100 <= sumSize || storedCleanedTs == 0 {
 						earliestCleanedTs = storedCleanedTs
 						candidateIdx = idx
 						startIdx = possibleStartIdx
@@ -488,14 +505,18 @@ func (c *segmentCleanerCommon) cleanupOnce(shouldAbort cyclemanager.ShouldAbortC
 		}
 	}()
 
-	file, err := os.Create(tmpSegmentPath)
+	file, err := openSegmentFileForWrite(tmpSegmentPath)
 	if err != nil {
 		return false, err
 	}
 
 	switch c.sg.strategy {
 	case StrategyReplace:
-		c := newSegmentCleanerReplace(file, oldSegment.newCursor(),
+		var cursor *segmentCursorReplace
+		if cursor, err = oldSegment.newCursor(); err != nil {
+			return false, err
+		}
+		c := newSegmentCleanerReplace(file, cursor,
 			c.sg.makeKeyExistsOnUpperSegments(startIdx, lastIdx), oldSegment.level,
 			oldSegment.secondaryIndexCount, scratchSpacePath, c.sg.enableChecksumValidation)
 		if err = c.do(shouldAbort); err != nil {
@@ -515,7 +536,7 @@ func (c *segmentCleanerCommon) cleanupOnce(shouldAbort cyclemanager.ShouldAbortC
 		return false, err
 	}
 
-	segment, err := c.sg.replaceSegment(candidateIdx, tmpSegmentPath)
+	segment, err := c.sg.replaceSegment(candidateIdx, tmpSegmentPath, false)
 	if err != nil {
 		err = fmt.Errorf("replace compacted segments: %w", err)
 		return false, err
@@ -568,10 +589,14 @@ func (sg *SegmentGroup) makeKeyExistsOnUpperSegments(startIdx, lastIdx int) keyE
 	}
 }
 
+// replaceSegment replaces the segment at segmentIdx with the one written to
+// tmpSegmentPath. tombstonesCollected marks the new segment as written by the
+// gc compaction, which is persisted in the manifest.
 func (sg *SegmentGroup) replaceSegment(segmentIdx int, tmpSegmentPath string,
+	tombstonesCollected bool,
 ) (*segment, error) {
 	oldSegment := sg.segmentAtPos(segmentIdx)
-	countNetAdditions := oldSegment.countNetAdditions
+	countNetAdditions := int(oldSegment.countNetAdditions.Load())
 
 	precomputedFiles, err := preComputeSegmentMeta(tmpSegmentPath, countNetAdditions,
 		sg.logger, sg.useBloomFilter, sg.calcCountNetAdditions, sg.enableChecksumValidation)
@@ -579,10 +604,12 @@ func (sg *SegmentGroup) replaceSegment(segmentIdx int, tmpSegmentPath string,
 		return nil, fmt.Errorf("precompute segment meta: %w", err)
 	}
 
-	newSegment, err := sg.replaceSegmentBlocking(segmentIdx, oldSegment, precomputedFiles)
+	newSegment, err := sg.replaceSegmentBlocking(segmentIdx, oldSegment, precomputedFiles,
+		tombstonesCollected)
 	if err != nil {
 		return nil, fmt.Errorf("replace segment (blocking): %w", err)
 	}
+	sg.repinReplacedSegment(segmentIdx, oldSegment)
 
 	if err := sg.deleteOldSegmentsNonBlocking(oldSegment); err != nil {
 		// don't abort if the delete fails, we can still continue (albeit
@@ -599,9 +626,16 @@ func (sg *SegmentGroup) replaceSegment(segmentIdx int, tmpSegmentPath string,
 
 func (sg *SegmentGroup) replaceSegmentBlocking(
 	segmentIdx int, oldSegment *segment, precomputedFiles []string,
+	tombstonesCollected bool,
 ) (*segment, error) {
-	sg.maintenanceLock.Lock()
-	defer sg.maintenanceLock.Unlock()
+	unlock := sg.lock("cleanup")
+	var manifest *segmentManifest
+	defer func() {
+		unlock()
+		// the segment keeps its name, the manifest only changes if the marker
+		// of the gc compaction does
+		sg.updateManifest(manifest)
+	}()
 
 	start := time.Now()
 
@@ -638,12 +672,19 @@ func (sg *SegmentGroup) replaceSegmentBlocking(
 			calcCountNetAdditions:    sg.calcCountNetAdditions,
 			overwriteDerived:         false,
 			enableChecksumValidation: sg.enableChecksumValidation,
+			maxReadRetries:           sg.maxReadRetries,
+			readRetryDelay:           sg.readRetryDelay,
 		})
 	if err != nil {
 		return nil, fmt.Errorf("create new segment %q: %w", segmentPath, err)
 	}
 
+	newSegment.tombstonesCollected.Store(tombstonesCollected)
 	sg.segments[segmentIdx] = newSegment
+	sg.negativeCache.invalidate()
+	if tombstonesCollected || oldSegment.tombstonesCollected.Load() {
+		manifest = sg.manifestSnapshotLocked()
+	}
 
 	sg.observeReplaceDuration(start, segmentIdx, oldSegment, newSegment)
 	return newSegment, nil
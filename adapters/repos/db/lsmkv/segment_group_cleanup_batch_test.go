@@ -0,0 +1,91 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+	"github.com/weaviate/weaviate/entities/lsmkv"
+)
+
+func TestSegmentGroup_CleanupBatchSize(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace), WithSegmentsCleanupInterval(time.Hour),
+		WithSegmentsCleanupBatchSize(1))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	// the oldest segment holds 4 keys, 3 of them are overwritten by one newer
+	// segment each
+	for _, key := range []string{"key0", "key1", "key2", "key3"} {
+		require.Nil(t, b.Put([]byte(key), []byte("old")))
+	}
+	require.Nil(t, b.FlushAndSwitch())
+	for _, key := range []string{"key1", "key2", "key3"} {
+		require.Nil(t, b.Put([]byte(key), []byte("new")))
+		require.Nil(t, b.FlushAndSwitch())
+	}
+
+	keysInOldestSegment := func() []string {
+		var keys []string
+		c, err := b.disk.segmentAtPos(0).newCursor()
+		require.Nil(t, err)
+		for k, _, err := c.first(); !errors.Is(err, lsmkv.NotFound); k, _, err = c.next() {
+			keys = append(keys, string(k))
+		}
+		return keys
+	}
+	noAbort := func() bool { return false }
+
+	// every cycle checks the oldest segment against a single newer segment
+	expected := [][]string{
+		{"key0", "key2", "key3"},
+		{"key0", "key3"},
+		{"key0"},
+	}
+	for _, keys := range expected {
+		cleaned, err := b.disk.segmentCleaner.cleanupOnce(noAbort)
+		require.Nil(t, err)
+		require.True(t, cleaned)
+		assert.Equal(t, keys, keysInOldestSegment())
+	}
+
+	// the remaining segments don't overlap, but are cleaned in bounded cycles
+	// as well, until nothing is left to do
+	cycles := 0
+	for {
+		cleaned, err := b.disk.segmentCleaner.cleanupOnce(noAbort)
+		require.Nil(t, err)
+		if !cleaned {
+			break
+		}
+		cycles++
+	}
+	assert.Equal(t, 3, cycles)
+
+	for key, value := range map[string]string{"key0": "old", "key1": "new", "key2": "new", "key3": "new"} {
+		res, err := b.Get([]byte(key))
+		require.Nil(t, err)
+		assert.Equal(t, value, string(res))
+	}
+}
@@ -0,0 +1,38 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// CollectionReadErrorPolicy controls what collection reads do when a segment
+// fails with an error other than lsmkv.NotFound, e.g. because its file is
+// briefly unreadable.
+type CollectionReadErrorPolicy string
+
+const (
+	// CollectionReadErrorPolicyStrict fails the read. This is the default.
+	CollectionReadErrorPolicyStrict CollectionReadErrorPolicy = "strict"
+	// CollectionReadErrorPolicyTolerant logs and skips the segment and returns
+	// the values of all other segments, marked as partial.
+	CollectionReadErrorPolicyTolerant CollectionReadErrorPolicy = "tolerant"
+)
+
+func (sg *SegmentGroup) logSkippedCollectionSegment(seg *segment, err error) {
+	sg.logger.WithFields(logrus.Fields{
+		"action":  "lsm_segment_group_get_collection_skip_segment",
+		"path":    seg.path,
+		"segment": segmentID(seg.path),
+	}).WithError(err).
+		Warn("skipped segment which could not be read, returning partial collection")
+}
@@ -0,0 +1,124 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_CollectionReadErrorPolicy(t *testing.T) {
+	ctx := context.Background()
+	key := []byte("key")
+
+	// three segments holding one value each, the middle one can't be read
+	newBucket := func(t *testing.T, opts ...BucketOption) (*Bucket, *test.Hook) {
+		logger, hook := test.NewNullLogger()
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			append([]BucketOption{WithStrategy(StrategySetCollection), WithPread(true)}, opts...)...)
+		require.Nil(t, err)
+
+		for _, v := range []string{"a", "b", "c"} {
+			require.Nil(t, b.SetAdd(key, [][]byte{[]byte(v)}))
+			require.Nil(t, b.FlushAndSwitch())
+		}
+		require.Len(t, b.disk.segments, 3)
+		require.Nil(t, b.disk.segments[1].contentFile.Close())
+		t.Cleanup(func() { b.Shutdown(ctx) })
+
+		hook.Reset()
+		return b, hook
+	}
+
+	t.Run("strict by default", func(t *testing.T) {
+		b, _ := newBucket(t)
+
+		_, err := b.SetList(key)
+		require.NotNil(t, err)
+
+		_, partial, err := b.SetListPartial(key)
+		require.NotNil(t, err)
+		assert.False(t, partial)
+	})
+
+	t.Run("tolerant skips the unreadable segment", func(t *testing.T) {
+		b, hook := newBucket(t, WithCollectionReadErrorPolicy(CollectionReadErrorPolicyTolerant))
+
+		values, partial, err := b.SetListPartial(key)
+		require.Nil(t, err)
+		assert.True(t, partial)
+		assert.Equal(t, [][]byte{[]byte("a"), []byte("c")}, values)
+
+		values, err = b.SetList(key)
+		require.Nil(t, err)
+		assert.Equal(t, [][]byte{[]byte("a"), []byte("c")}, values)
+
+		entry := hook.LastEntry()
+		require.NotNil(t, entry)
+		assert.Equal(t, logrus.WarnLevel, entry.Level)
+		assert.Equal(t, "lsm_segment_group_get_collection_skip_segment", entry.Data["action"])
+		assert.Equal(t, b.disk.segments[1].path, entry.Data["path"])
+	})
+
+	t.Run("reads with limit follow the policy", func(t *testing.T) {
+		b, _ := newBucket(t)
+		_, _, _, err := b.disk.getCollectionWithLimit(key, 10)
+		require.NotNil(t, err)
+
+		b, hook := newBucket(t, WithCollectionReadErrorPolicy(CollectionReadErrorPolicyTolerant))
+		values, truncated, partial, err := b.disk.getCollectionWithLimit(key, 10)
+		require.Nil(t, err)
+		assert.False(t, truncated)
+		assert.True(t, partial)
+		require.Len(t, values, 2)
+		assert.Equal(t, []byte("a"), values[0].value)
+		assert.Equal(t, []byte("c"), values[1].value)
+
+		entry := hook.LastEntry()
+		require.NotNil(t, entry)
+		assert.Equal(t, "lsm_segment_group_get_collection_skip_segment", entry.Data["action"])
+	})
+
+	t.Run("tolerant without errors is not partial", func(t *testing.T) {
+		logger, _ := test.NewNullLogger()
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategySetCollection),
+			WithCollectionReadErrorPolicy(CollectionReadErrorPolicyTolerant))
+		require.Nil(t, err)
+		defer b.Shutdown(ctx)
+
+		require.Nil(t, b.SetAdd(key, [][]byte{[]byte("a")}))
+		require.Nil(t, b.FlushAndSwitch())
+
+		values, partial, err := b.SetListPartial(key)
+		require.Nil(t, err)
+		assert.False(t, partial)
+		assert.Equal(t, [][]byte{[]byte("a")}, values)
+	})
+
+	t.Run("unknown policy is rejected", func(t *testing.T) {
+		logger, _ := test.NewNullLogger()
+		_, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategySetCollection),
+			WithCollectionReadErrorPolicy("lenient"))
+		require.NotNil(t, err)
+	})
+}
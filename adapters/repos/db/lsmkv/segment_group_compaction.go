@@ -13,6 +13,7 @@ package lsmkv
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
@@ -63,6 +64,10 @@ import (
 // other to prevent merging large segments (GiB) with tiny one (KiB). Level of newly produced segment
 // will be the same as level of larger(left) segment.
 // maxSegmentSize ise respected for pair of leftover segments.
+//
+// Pairs of matching levels are ranked by the compactionScorer, which defaults
+// to DefaultCompactionScorer and implements the behaviour described above.
+// Leftover pairs are only considered if the scorer did not select any pair.
 func (sg *SegmentGroup) findCompactionCandidates() (pair []int, level uint16) {
 	// if true, the parent shard has indicated that it has
 	// entered an immutable state. During this time, the
@@ -72,64 +77,107 @@ func (sg *SegmentGroup) findCompactionCandidates() (pair []int, level uint16) {
 		return nil, 0
 	}
 
-	sg.maintenanceLock.RLock()
+	sg.rLock("compaction")
 	defer sg.maintenanceLock.RUnlock()
 
+	return sg.findCompactionCandidatesLocked()
+}
+
+// findCompactionCandidatesLocked is findCompactionCandidates without the
+// read-only check. Callers need to hold the maintenanceLock.
+func (sg *SegmentGroup) findCompactionCandidatesLocked() (pair []int, level uint16) {
 	// Nothing to compact
 	if len(sg.segments) < 2 {
 		return nil, 0
 	}
 
-	matchingPairFound := false
-	leftoverPairFound := false
-	var matchingLeftId, leftoverLeftId int
-	var matchingLevel, leftoverLevel uint16
+	scorer := sg.compactionScorer
+	if scorer == nil {
+		scorer = DefaultCompactionScorer{}
+	}
 
-	// as newest segments are prioritized, loop in reverse order
-	for leftId := len(sg.segments) - 2; leftId >= 0; leftId-- {
+	bestLeftId := -1
+	bestScore := 0.0
+	for leftId := 0; leftId < len(sg.segments)-1; leftId++ {
 		left, right := sg.segments[leftId], sg.segments[leftId+1]
 
 		if left.secondaryIndexCount != right.secondaryIndexCount {
 			// only pair of segments with the same secondary indexes are compacted
 			continue
 		}
+		if !sg.compactionFitsSizeLimit(left, right) {
+			continue
+		}
 
-		if left.level == right.level {
-			if sg.compactionFitsSizeLimit(left, right) {
-				// max size not exceeded
-				matchingPairFound = true
-				matchingLeftId = leftId
-				matchingLevel = left.level + 1
-			} else if matchingPairFound {
-				// older segment of same level as pair's level exist.
-				// keep unchanged level
-				matchingLevel = left.level
-			}
-		} else {
-			if matchingPairFound {
-				// moving to segments of higher level, but matching pair is already found.
-				// stop further search
-				break
+		// strictly greater, so the oldest pair wins ties
+		if score := scorer.Score(left, right); score > bestScore {
+			bestLeftId = leftId
+			bestScore = score
+		}
+	}
+
+	if bestLeftId >= 0 {
+		return []int{bestLeftId, bestLeftId + 1}, sg.compactedLevel(bestLeftId)
+	}
+
+	if sg.compactLeftOverSegments {
+		// as newest segments are prioritized, loop in reverse order
+		for leftId := len(sg.segments) - 2; leftId >= 0; leftId-- {
+			left, right := sg.segments[leftId], sg.segments[leftId+1]
+
+			if left.secondaryIndexCount != right.secondaryIndexCount || left.level == right.level {
+				continue
 			}
-			if sg.compactLeftOverSegments && !leftoverPairFound {
-				// eftover segments enabled, none leftover pair found yet
-				if sg.compactionFitsSizeLimit(left, right) && isSimilarSegmentSizes(left.size, right.size) {
-					// max size not exceeded, segment sizes similar despite different levels
-					leftoverPairFound = true
-					leftoverLeftId = leftId
-					leftoverLevel = left.level
-				}
+			if sg.compactionFitsSizeLimit(left, right) && isSimilarSegmentSizes(left.size, right.size) {
+				// max size not exceeded, segment sizes similar despite different levels
+				return []int{leftId, leftId + 1}, left.level
 			}
 		}
 	}
 
-	if matchingPairFound {
-		return []int{matchingLeftId, matchingLeftId + 1}, matchingLevel
+	return nil, 0
+}
+
+// CompactionCandidates returns the IDs of the segments which would be merged
+// by the next compaction, or an empty slice if there are none. It runs the same
+// selection as the compaction itself, but doesn't change any state, so it can
+// be used to diagnose why the number of segments doesn't shrink.
+func (sg *SegmentGroup) CompactionCandidates() []string {
+	if sg.isReadyOnly() {
+		return []string{}
+	}
+
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	pair, _ := sg.findCompactionCandidatesLocked()
+	ids := make([]string, 0, len(pair))
+	for _, pos := range pair {
+		ids = append(ids, segmentID(sg.segments[pos].path))
+	}
+	return ids
+}
+
+// compactedLevel returns the level of the segment produced by compacting the
+// segments at leftId and leftId+1. A pair of the same level is promoted to the
+// next level, unless an older segment of the same level exists. Otherwise the
+// higher level of the pair is kept, so newer segments never have a higher level
+// than older ones.
+func (sg *SegmentGroup) compactedLevel(leftId int) uint16 {
+	left, right := sg.segments[leftId], sg.segments[leftId+1]
+
+	if left.level != right.level {
+		if right.level > left.level {
+			return right.level
+		}
+		return left.level
 	}
-	if leftoverPairFound {
-		return []int{leftoverLeftId, leftoverLeftId + 1}, leftoverLevel
+	if leftId > 0 && sg.segments[leftId-1].level == left.level {
+		// older segment of same level as pair's level exist.
+		// keep unchanged level
+		return left.level
 	}
-	return nil, 0
+	return left.level + 1
 }
 
 func isSimilarSegmentSizes(leftSize, rightSize int64) bool {
@@ -193,15 +241,35 @@ func (sg *SegmentGroup) compactOnce() (bool, error) {
 	// compaction. We do however need to protect against a read-while-write (race
 	// condition) on the array. Thus any read from sg.segments need to protected
 
+	if sg.compactionsBackedOff() {
+		// memory pressure was detected recently, give the node time to recover
+		return false, nil
+	}
+
 	pair, level := sg.findCompactionCandidates()
 	if pair == nil {
 		// nothing to do
 		return false, nil
 	}
 
+	res, err := sg.compactPair(pair, level)
+	if err != nil || res == nil {
+		return false, err
+	}
+
+	// the maintenance lock was released when the compacted segment was
+	// swapped in, so the hook can't deadlock with the segment group
+	sg.notifyCompactionComplete(*res)
+	return true, nil
+}
+
+// compactPair compacts the two consecutive segments at pair into a single
+// segment of the given level. Callers need to hold the compactionLock. The
+// result is nil if the compaction was skipped.
+func (sg *SegmentGroup) compactPair(pair []int, level uint16) (res *CompactionResult, err error) {
 	if sg.allocChecker != nil {
 		// allocChecker is optional
-		if err := sg.allocChecker.CheckAlloc(100 * 1024 * 1024); err != nil {
+		if err := sg.allocChecker.CheckAlloc(compactionMemoryEstimate); err != nil {
 			// if we don't have at least 100MB to spare, don't start a compaction. A
 			// compaction does not actually need a 100MB, but it will create garbage
 			// that needs to be cleaned up. If we're so close to the memory limit, we
@@ -215,7 +283,8 @@ func (sg *SegmentGroup) compactOnce() (bool, error) {
 			}).WithError(err).
 				Warnf("skipping compaction due to memory pressure")
 
-			return false, nil
+			sg.backOffCompactions()
+			return nil, nil
 		}
 	}
 
@@ -224,9 +293,53 @@ func (sg *SegmentGroup) compactOnce() (bool, error) {
 
 	path := filepath.Join(sg.dir, "segment-"+segmentID(leftSegment.path)+"_"+segmentID(rightSegment.path)+".db.tmp")
 
-	f, err := os.Create(path)
+	f, err := openSegmentFileForWrite(path)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	// releases the lock if the compaction is aborted, the file is closed
+	// explicitly once written
+	defer f.Close()
+	defer func() {
+		if !errors.Is(err, errCompactionMemoryPressure) {
+			return
+		}
+		// the original segments are still intact, the partial result is of no
+		// use and would only take up disk space until the next startup
+		f.Close()
+		if rmErr := os.Remove(path); rmErr != nil && !os.IsNotExist(rmErr) {
+			sg.logger.WithField("action", "lsm_compaction").
+				WithField("path", path).
+				WithError(rmErr).
+				Error("failed to remove partially compacted segment")
+		}
+		sg.logger.WithFields(logrus.Fields{
+			"action":  "lsm_compaction",
+			"event":   "compaction_stopped_oom",
+			"path":    sg.dir,
+			"backoff": sg.compactionMemoryBackoff,
+		}).WithError(err).
+			Warnf("stopped compaction due to memory pressure")
+		sg.backOffCompactions()
+		// not an error of the segment group, the compaction is simply retried
+		// after the backoff
+		res, err = nil, nil
+	}()
+	// compactors write through w, so they stop early if the compaction is
+	// aborted on shutdown or due to memory pressure and respect the optional
+	// I/O limit
+	var out io.WriteSeeker = f
+	if sg.compactionLimiter != nil {
+		out = &throttledWriteSeeker{ctx: sg.compactionCtx, w: f, limiter: sg.compactionLimiter}
+	}
+	if sg.allocChecker != nil {
+		out = &memoryCheckedWriteSeeker{w: out, allocChecker: sg.allocChecker}
+	}
+	var w io.WriteSeeker = &abortableWriteSeeker{w: out, aborted: &sg.abortCompaction}
+	if progress := sg.newCompactionProgress(leftSegment, rightSegment, level); progress != nil {
+		pw := &progressWriteSeeker{w: w, progress: progress}
+		defer func() { progress.done(err == nil && res != nil, pw.written) }()
+		w = pw
 	}
 
 	scratchSpacePath := rightSegment.path + "compaction.scratch.d"
@@ -235,6 +348,10 @@ func (sg *SegmentGroup) compactOnce() (bool, error) {
 	secondaryIndices := leftSegment.secondaryIndexCount
 	cleanupTombstones := !sg.keepTombstones && pair[0] == 0
 
+	// set if the compactor knows the key and tombstone counts of the new
+	// segment, so they don't need to be computed later on
+	var keyStats *segmentKeyStats
+
 	pathLabel := "n/a"
 	if sg.metrics != nil && !sg.metrics.groupClasses {
 		pathLabel = sg.dir
@@ -244,8 +361,16 @@ func (sg *SegmentGroup) compactOnce() (bool, error) {
 	// TODO: call metrics just once with variable strategy label
 
 	case segmentindex.StrategyReplace:
-		c := newCompactorReplace(f, leftSegment.newCursor(),
-			rightSegment.newCursor(), level, secondaryIndices,
+		leftCursor, err := leftSegment.newCursor()
+		if err != nil {
+			return nil, err
+		}
+		rightCursor, err := rightSegment.newCursor()
+		if err != nil {
+			return nil, err
+		}
+
+		c := newCompactorReplace(w, leftCursor, rightCursor, level, secondaryIndices,
 			scratchSpacePath, cleanupTombstones, sg.enableChecksumValidation)
 
 		if sg.metrics != nil {
@@ -254,11 +379,20 @@ func (sg *SegmentGroup) compactOnce() (bool, error) {
 		}
 
 		if err := c.do(); err != nil {
-			return false, err
+			return nil, err
 		}
+		keyStats = &segmentKeyStats{keys: c.keys, tombstones: c.tombstones}
 	case segmentindex.StrategySetCollection:
-		c := newCompactorSetCollection(f, leftSegment.newCollectionCursor(),
-			rightSegment.newCollectionCursor(), level, secondaryIndices,
+		leftCursor, err := leftSegment.newCollectionCursor()
+		if err != nil {
+			return nil, err
+		}
+		rightCursor, err := rightSegment.newCollectionCursor()
+		if err != nil {
+			return nil, err
+		}
+
+		c := newCompactorSetCollection(w, leftCursor, rightCursor, level, secondaryIndices,
 			scratchSpacePath, cleanupTombstones, sg.enableChecksumValidation)
 
 		if sg.metrics != nil {
@@ -267,12 +401,19 @@ func (sg *SegmentGroup) compactOnce() (bool, error) {
 		}
 
 		if err := c.do(); err != nil {
-			return false, err
+			return nil, err
 		}
 	case segmentindex.StrategyMapCollection:
-		c := newCompactorMapCollection(f,
-			leftSegment.newCollectionCursorReusable(),
-			rightSegment.newCollectionCursorReusable(),
+		leftCursor, err := leftSegment.newCollectionCursorReusable()
+		if err != nil {
+			return nil, err
+		}
+		rightCursor, err := rightSegment.newCollectionCursorReusable()
+		if err != nil {
+			return nil, err
+		}
+
+		c := newCompactorMapCollection(w, leftCursor, rightCursor,
 			level, secondaryIndices, scratchSpacePath,
 			sg.mapRequiresSorting, cleanupTombstones,
 			sg.enableChecksumValidation)
@@ -283,13 +424,19 @@ func (sg *SegmentGroup) compactOnce() (bool, error) {
 		}
 
 		if err := c.do(); err != nil {
-			return false, err
+			return nil, err
 		}
 	case segmentindex.StrategyRoaringSet:
-		leftCursor := leftSegment.newRoaringSetCursor()
-		rightCursor := rightSegment.newRoaringSetCursor()
+		leftCursor, err := leftSegment.newRoaringSetCursor()
+		if err != nil {
+			return nil, err
+		}
+		rightCursor, err := rightSegment.newRoaringSetCursor()
+		if err != nil {
+			return nil, err
+		}
 
-		c := roaringset.NewCompactor(f, leftCursor, rightCursor,
+		c := roaringset.NewCompactor(w, leftCursor, rightCursor,
 			level, scratchSpacePath, cleanupTombstones,
 			sg.enableChecksumValidation)
 
@@ -299,14 +446,20 @@ func (sg *SegmentGroup) compactOnce() (bool, error) {
 		}
 
 		if err := c.Do(); err != nil {
-			return false, err
+			return nil, err
 		}
 
 	case segmentindex.StrategyRoaringSetRange:
-		leftCursor := leftSegment.newRoaringSetRangeCursor()
-		rightCursor := rightSegment.newRoaringSetRangeCursor()
+		leftCursor, err := leftSegment.newRoaringSetRangeCursor()
+		if err != nil {
+			return nil, err
+		}
+		rightCursor, err := rightSegment.newRoaringSetRangeCursor()
+		if err != nil {
+			return nil, err
+		}
 
-		c := roaringsetrange.NewCompactor(f, leftCursor, rightCursor,
+		c := roaringsetrange.NewCompactor(w, leftCursor, rightCursor,
 			level, cleanupTombstones, sg.enableChecksumValidation)
 
 		if sg.metrics != nil {
@@ -315,10 +468,10 @@ func (sg *SegmentGroup) compactOnce() (bool, error) {
 		}
 
 		if err := c.Do(); err != nil {
-			return false, err
+			return nil, err
 		}
 	case segmentindex.StrategyInverted:
-		c := newCompactorInverted(f,
+		c := newCompactorInverted(w,
 			leftSegment.newInvertedCursorReusable(),
 			rightSegment.newInvertedCursorReusable(),
 			level, secondaryIndices, scratchSpacePath, cleanupTombstones)
@@ -329,47 +482,106 @@ func (sg *SegmentGroup) compactOnce() (bool, error) {
 		}
 
 		if err := c.do(); err != nil {
-			return false, err
+			return nil, err
 		}
 	default:
-		return false, errors.Errorf("unrecognized strategy %v", strategy)
+		return nil, errors.Errorf("unrecognized strategy %v", strategy)
 	}
 
 	if err := f.Sync(); err != nil {
-		return false, errors.Wrap(err, "fsync compacted segment file")
+		return nil, errors.Wrap(err, "fsync compacted segment file")
 	}
 
 	if err := f.Close(); err != nil {
-		return false, errors.Wrap(err, "close compacted segment file")
-	}
-
-	if err := sg.replaceCompactedSegments(pair[0], pair[1], path); err != nil {
-		return false, errors.Wrap(err, "replace compacted segments")
-	}
+		return nil, errors.Wrap(err, "close compacted segment file")
+	}
+
+	if err := verifyCompactedSegment(path, leftSegment, rightSegment,
+		cleanupTombstones, sg.enableChecksumValidation); err != nil {
+		// the original segments are still intact, so we can abort without any
+		// data loss. The .tmp file is deliberately left on disk for inspection
+		// and recovery. It is only overwritten by the next attempt to compact
+		// the same pair or removed on the next startup, as both inputs are
+		// still present.
+		sg.logger.WithFields(logrus.Fields{
+			"action":     "lsm_compaction_verification",
+			"path":       path,
+			"file_left":  leftSegment.path,
+			"file_right": rightSegment.path,
+			"strategy":   strategy,
+			"level":      level,
+		}).WithError(err).
+			Error("compacted segment failed verification, keeping original segments")
+		return nil, fmt.Errorf("verify compacted segment: %w", err)
+	}
+
+	if sg.abortCompaction.Load() {
+		// don't swap in the compacted segment while shutting down
+		return nil, errCompactionAborted
+	}
+
+	if err := sg.replaceCompactedSegments(pair[0], pair[1], leftSegment,
+		rightSegment, path, keyStats); err != nil {
+		return nil, errors.Wrap(err, "replace compacted segments")
+	}
+
+	// the compactionLock is still held, so no other routine replaced segments
+	// since the swap and the compacted segment is at the position of the left
+	// one. Flushes only append segments.
+	compacted := sg.segmentAtPos(pair[0])
+
+	sg.lastCompaction.Store(time.Now().UnixNano())
+	if sg.compactionCount.Add(1)%bloomFilterAccuracyInterval == 0 {
+		sg.logBloomFilterAccuracy()
+	}
+	return &CompactionResult{
+		LeftSegmentID:  segmentID(leftSegment.path),
+		RightSegmentID: segmentID(rightSegment.path),
+		SegmentID:      segmentID(compacted.path),
+		Level:          level,
+		BytesWritten:   compacted.size,
+	}, nil
+}
 
-	return true, nil
+// segmentKeyStats are the key and tombstone counts of a segment, see
+// segment.tombstoneStats
+type segmentKeyStats struct {
+	keys       int
+	tombstones int
 }
 
+// replaceCompactedSegments replaces the segments at old1 and old2 with the
+// compacted segment at newPathTmp. All statistics of the new segment are
+// gathered before obtaining the maintenance lock, so the lock is only held to
+// switch the segments. keyStats is optional.
 func (sg *SegmentGroup) replaceCompactedSegments(old1, old2 int,
-	newPathTmp string,
+	left, right *segment, newPathTmp string, keyStats *segmentKeyStats,
 ) error {
-	sg.maintenanceLock.RLock()
-	updatedCountNetAdditions := sg.segments[old1].countNetAdditions +
-		sg.segments[old2].countNetAdditions
-	sg.maintenanceLock.RUnlock()
+	// the net additions are atomics and left and right are only replaced by
+	// this compaction, which holds the compactionLock, so there is no need for
+	// the maintenance lock
+	updatedCountNetAdditions := int(left.countNetAdditions.Load() +
+		right.countNetAdditions.Load())
 
 	// WIP: we could add a random suffix to the tmp file to avoid conflicts
+	//
+	// the checksum was already validated in verifyCompactedSegment, no need to
+	// read the entire segment a second time
 	precomputedFiles, err := preComputeSegmentMeta(newPathTmp,
 		updatedCountNetAdditions, sg.logger, sg.useBloomFilter,
-		sg.calcCountNetAdditions, sg.enableChecksumValidation)
+		sg.calcCountNetAdditions, false)
 	if err != nil {
 		return fmt.Errorf("precompute segment meta: %w", err)
 	}
 
-	oldL, oldR, err := sg.replaceCompactedSegmentsBlocking(old1, old2, precomputedFiles)
+	start := time.Now()
+	oldL, oldR, err := sg.replaceCompactedSegmentsBlocking(old1, old2,
+		precomputedFiles, keyStats)
 	if err != nil {
 		return fmt.Errorf("replace compacted segments (blocking): %w", err)
 	}
+	sg.observeReplaceCompactedDuration(start, old1, oldL, oldR)
+	sg.repinReplacedSegment(old1, oldL, oldR)
 
 	if err := sg.deleteOldSegmentsNonBlocking(oldL, oldR); err != nil {
 		// don't abort if the delete fails, we can still continue (albeit
@@ -388,7 +600,7 @@ func (sg *SegmentGroup) replaceCompactedSegments(old1, old2 int,
 const replaceSegmentWarnThreshold = 300 * time.Millisecond
 
 func (sg *SegmentGroup) replaceCompactedSegmentsBlocking(
-	old1, old2 int, precomputedFiles []string,
+	old1, old2 int, precomputedFiles []string, keyStats *segmentKeyStats,
 ) (*segment, *segment, error) {
 	// We need a maintenanceLock.Lock() to switch segments, however, we can't
 	// simply call Lock(). Due to the write-preferring nature of the RWMutex this
@@ -406,14 +618,19 @@ func (sg *SegmentGroup) replaceCompactedSegmentsBlocking(
 	sg.flushVsCompactLock.Lock()
 	defer sg.flushVsCompactLock.Unlock()
 
-	start := time.Now()
 	beforeMaintenanceLock := time.Now()
-	sg.maintenanceLock.Lock()
+	unlock := sg.lock("compaction")
 	if time.Since(beforeMaintenanceLock) > 100*time.Millisecond {
 		sg.logger.WithField("duration", time.Since(beforeMaintenanceLock)).
 			Debug("compaction took more than 100ms to acquire maintenance lock")
 	}
-	defer sg.maintenanceLock.Unlock()
+	var manifest *segmentManifest
+	defer func() {
+		unlock()
+		// the manifest is written after releasing the lock, so readers don't
+		// wait for the fsync
+		sg.updateManifest(manifest)
+	}()
 
 	leftSegment := sg.segments[old1]
 	rightSegment := sg.segments[old2]
@@ -465,16 +682,24 @@ func (sg *SegmentGroup) replaceCompactedSegmentsBlocking(
 			calcCountNetAdditions:    sg.calcCountNetAdditions,
 			overwriteDerived:         false,
 			enableChecksumValidation: sg.enableChecksumValidation,
+			maxReadRetries:           sg.maxReadRetries,
+			readRetryDelay:           sg.readRetryDelay,
 		})
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "create new segment")
 	}
+	if keyStats != nil {
+		// the segment is not visible to readers yet
+		seg.setTombstoneStats(keyStats.keys, keyStats.tombstones)
+	}
 
 	sg.segments[old2] = seg
 
 	sg.segments = append(sg.segments[:old1], sg.segments[old1+1:]...)
+	sg.negativeCache.invalidate()
+	manifest = sg.manifestSnapshotLocked()
+	sg.metrics.ObserveSegmentLevel(sg.strategy, seg.level)
 
-	sg.observeReplaceCompactedDuration(start, old1, leftSegment, rightSegment)
 	return leftSegment, rightSegment, nil
 }
 
@@ -567,6 +792,7 @@ func (sg *SegmentGroup) reportSegmentStats() {
 	stats := sg.segmentLevelStats()
 	stats.fillMissingLevels()
 	stats.report(sg.metrics, sg.strategy, sg.dir)
+	sg.reportTierSizeAmplification()
 }
 
 type segmentLevelStats struct {
@@ -0,0 +1,98 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// errCompactionAborted is returned by a compaction which was forcibly aborted
+// on shutdown. The original segments are untouched, the partially written
+// .tmp file is removed on the next startup.
+var errCompactionAborted = errors.New("compaction aborted")
+
+// compactionAbortTimeout is the time an in-flight compaction is given to
+// notice that it was aborted, see stopCompactions
+const compactionAbortTimeout = 5 * time.Second
+
+// abortableWriteSeeker fails all writes and seeks once aborted is set, which
+// makes the compactor writing to it return early
+type abortableWriteSeeker struct {
+	w       io.WriteSeeker
+	aborted *atomic.Bool
+}
+
+func (a *abortableWriteSeeker) Write(p []byte) (int, error) {
+	if a.aborted.Load() {
+		return 0, errCompactionAborted
+	}
+	return a.w.Write(p)
+}
+
+func (a *abortableWriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	if a.aborted.Load() {
+		return 0, errCompactionAborted
+	}
+	return a.w.Seek(offset, whence)
+}
+
+// abortCompactions makes an in-flight compaction fail with
+// errCompactionAborted, including one waiting for the compaction limiter
+func (sg *SegmentGroup) abortCompactions() {
+	sg.abortCompaction.Store(true)
+	if sg.cancelCompactionCtx != nil {
+		sg.cancelCompactionCtx()
+	}
+}
+
+// stopCompactions unregisters the compaction callback, waiting for an
+// in-flight compaction or cleanup to finish. Without a shutdown timeout it
+// waits as long as ctx allows and fails afterwards.
+//
+// With a shutdown timeout, an in-flight compaction which takes longer than the
+// timeout (or the deadline of ctx, whichever comes first) is forcibly aborted
+// instead, so a single slow compaction can't block the shutdown indefinitely.
+func (sg *SegmentGroup) stopCompactions(ctx context.Context) error {
+	if sg.compactionShutdownTimeout <= 0 {
+		if err := sg.compactionCallbackCtrl.Unregister(ctx); err != nil {
+			return fmt.Errorf("long-running compaction in progress: %w", ctx.Err())
+		}
+		return nil
+	}
+
+	gracefulCtx, cancel := context.WithTimeout(ctx, sg.compactionShutdownTimeout)
+	err := sg.compactionCallbackCtrl.Unregister(gracefulCtx)
+	cancel()
+	if err == nil {
+		return nil
+	}
+
+	sg.logger.WithField("action", "lsm_compaction_abort").
+		WithField("path", sg.dir).
+		WithField("timeout", sg.compactionShutdownTimeout).
+		Warn("long-running compaction in progress on shutdown, aborting it")
+	sg.abortCompactions()
+
+	// ctx may already be expired at this point, the abort is bounded by its
+	// own timeout instead
+	abortCtx, cancel := context.WithTimeout(context.Background(), compactionAbortTimeout)
+	defer cancel()
+	if err := sg.compactionCallbackCtrl.Unregister(abortCtx); err != nil {
+		return fmt.Errorf("abort long-running compaction: %w", err)
+	}
+	return nil
+}
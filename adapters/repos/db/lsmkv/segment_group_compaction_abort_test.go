@@ -0,0 +1,186 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+// stuckAllocChecker blocks the first compaction until released
+type stuckAllocChecker struct {
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newStuckAllocChecker() *stuckAllocChecker {
+	return &stuckAllocChecker{
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (c *stuckAllocChecker) CheckAlloc(sizeInBytes int64) error {
+	c.once.Do(func() {
+		close(c.entered)
+		<-c.release
+	})
+	return nil
+}
+
+func (c *stuckAllocChecker) CheckMappingAndReserve(numberMappings int64, reservationTimeInS int) error {
+	return nil
+}
+
+func (c *stuckAllocChecker) Refresh(updateMappings bool) {}
+
+func TestSegmentGroup_ShutdownWithStuckCompaction(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	setup := func(t *testing.T, dir string, opts ...BucketOption) (*Bucket, *stuckAllocChecker) {
+		checker := newStuckAllocChecker()
+		compactionCallbacks := cyclemanager.NewCallbackGroup("compaction", logger, 1)
+		cycle := cyclemanager.NewManager(cyclemanager.NewFixedTicker(5*time.Millisecond),
+			compactionCallbacks.CycleCallback, logger)
+
+		opts = append([]BucketOption{
+			WithStrategy(StrategyReplace), WithAllocChecker(checker),
+		}, opts...)
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			compactionCallbacks, cyclemanager.NewCallbackGroupNoop(), opts...)
+		require.Nil(t, err)
+
+		require.Nil(t, b.Put([]byte("key1"), []byte("value1")))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.Put([]byte("key2"), []byte("value2")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		cycle.Start()
+		t.Cleanup(func() { cycle.StopAndWait(ctx) })
+
+		select {
+		case <-checker.entered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("compaction did not start")
+		}
+		return b, checker
+	}
+
+	t.Run("without timeout the shutdown fails", func(t *testing.T) {
+		b, checker := setup(t, t.TempDir())
+		defer close(checker.release)
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		err := b.disk.shutdown(shutdownCtx)
+		assert.ErrorContains(t, err, "long-running compaction in progress")
+	})
+
+	t.Run("with timeout the compaction is aborted", func(t *testing.T) {
+		dir := t.TempDir()
+		b, checker := setup(t, dir, WithCompactionShutdownTimeout(50*time.Millisecond))
+
+		// the stuck compaction only continues once it was aborted
+		go func() {
+			for !b.disk.abortCompaction.Load() {
+				time.Sleep(time.Millisecond)
+			}
+			close(checker.release)
+		}()
+
+		start := time.Now()
+		require.Nil(t, b.Shutdown(ctx))
+		assert.Less(t, time.Since(start), compactionAbortTimeout)
+
+		entries, err := os.ReadDir(dir)
+		require.Nil(t, err)
+		segments, tmp := 0, 0
+		for _, e := range entries {
+			switch {
+			case strings.HasSuffix(e.Name(), ".db"):
+				segments++
+			case strings.HasSuffix(e.Name(), ".tmp"):
+				tmp++
+			}
+		}
+		assert.Equal(t, 2, segments, "original segments are kept")
+		assert.Equal(t, 1, tmp, "partially written segment is left")
+
+		// the partially written segment is recovered on startup
+		b, err = NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		defer b.Shutdown(ctx)
+
+		for key, value := range map[string]string{"key1": "value1", "key2": "value2"} {
+			got, err := b.Get([]byte(key))
+			require.Nil(t, err)
+			assert.Equal(t, value, string(got))
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+		require.Nil(t, err)
+		assert.Empty(t, matches)
+	})
+}
+
+func TestSegmentGroup_ShutdownWithThrottledCompaction(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+	dir := t.TempDir()
+
+	compactionCallbacks := cyclemanager.NewCallbackGroup("compaction", logger, 1)
+	cycle := cyclemanager.NewManager(cyclemanager.NewFixedTicker(5*time.Millisecond),
+		compactionCallbacks.CycleCallback, logger)
+
+	// compacting the segments takes minutes at this rate
+	b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+		compactionCallbacks, cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace), WithCompactionBytesPerSecond(1024),
+		WithCompactionShutdownTimeout(50*time.Millisecond))
+	require.Nil(t, err)
+
+	value := make([]byte, 1024)
+	for segment := 0; segment < 2; segment++ {
+		for i := 0; i < 128; i++ {
+			require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%d-%d", segment, i)), value))
+		}
+		require.Nil(t, b.FlushAndSwitch())
+	}
+
+	cycle.Start()
+	defer cycle.StopAndWait(ctx)
+
+	// wait for the compaction to write the first tokens of the limiter
+	assert.Eventually(t, func() bool {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+		require.Nil(t, err)
+		return len(matches) > 0
+	}, 5*time.Second, time.Millisecond)
+
+	start := time.Now()
+	require.Nil(t, b.Shutdown(ctx))
+	assert.Less(t, time.Since(start), compactionAbortTimeout)
+}
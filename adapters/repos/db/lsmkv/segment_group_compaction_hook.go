@@ -0,0 +1,38 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+// CompactionResult describes a successful compaction of two segments into a
+// single one.
+type CompactionResult struct {
+	// IDs of the compacted (input) segments, the left one is the older one
+	LeftSegmentID  string
+	RightSegmentID string
+	// ID of the segment that replaced both input segments
+	SegmentID string
+	Level     uint16
+	// size of the new segment on disk
+	BytesWritten int64
+}
+
+// notifyCompactionComplete invokes the optional onCompactionComplete hook. It
+// must be called without holding the maintenance lock. The hook runs
+// synchronously on the compaction cycle, so a blocking hook delays all further
+// compactions of the segment group.
+func (sg *SegmentGroup) notifyCompactionComplete(res CompactionResult) {
+	if sg.onCompactionComplete == nil {
+		// hook is optional
+		return
+	}
+
+	sg.onCompactionComplete(res)
+}
@@ -0,0 +1,75 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_OnCompactionComplete(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	var results []CompactionResult
+	var b *Bucket
+	hook := func(res CompactionResult) {
+		// the hook must be invoked outside the maintenance lock
+		require.True(t, b.disk.maintenanceLock.TryLock())
+		b.disk.maintenanceLock.Unlock()
+		results = append(results, res)
+	}
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace), WithOnCompactionComplete(hook))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	require.Nil(t, b.Put([]byte("key1"), []byte("value1")))
+	require.Nil(t, b.FlushAndSwitch())
+	require.Nil(t, b.Put([]byte("key2"), []byte("value2")))
+	require.Nil(t, b.FlushAndSwitch())
+
+	leftID := segmentID(b.disk.segmentAtPos(0).path)
+	rightID := segmentID(b.disk.segmentAtPos(1).path)
+
+	compacted, err := b.disk.compactOnce()
+	require.Nil(t, err)
+	require.True(t, compacted)
+
+	require.Len(t, results, 1)
+	res := results[0]
+	assert.Equal(t, leftID, res.LeftSegmentID)
+	assert.Equal(t, rightID, res.RightSegmentID)
+	assert.Equal(t, uint16(1), res.Level)
+
+	newSegment := b.disk.segmentAtPos(0)
+	assert.Equal(t, segmentID(newSegment.path), res.SegmentID)
+	assert.NotEqual(t, leftID, res.SegmentID)
+	stat, err := os.Stat(newSegment.path)
+	require.Nil(t, err)
+	assert.Equal(t, stat.Size(), res.BytesWritten)
+
+	t.Run("no further compaction, no invocation", func(t *testing.T) {
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		require.False(t, compacted)
+		assert.Len(t, results, 1)
+	})
+}
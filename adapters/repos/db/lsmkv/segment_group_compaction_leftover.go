@@ -0,0 +1,84 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import "fmt"
+
+// SetCompactLeftOverSegments toggles whether the regular compaction cycle may
+// pick pairs of segments with different levels, see findCompactionCandidates.
+func (sg *SegmentGroup) SetCompactLeftOverSegments(enabled bool) {
+	sg.maintenanceLock.Lock()
+	defer sg.maintenanceLock.Unlock()
+
+	sg.compactLeftOverSegments = enabled
+}
+
+// CompactLeftOverSegments runs a one-shot pass which compacts consecutive
+// segments of different levels, such as the ones left behind by a compaction
+// that was interrupted by an ungraceful shutdown. Like the regular compaction
+// cycle, it only merges segments of similar size within maxSegmentSize, so a
+// small leftover segment is never rewritten together with a huge one.
+//
+// The pass waits for a running compaction or cleanup to finish and blocks the
+// regular cycle until it is done. It returns the number of compactions.
+func (sg *SegmentGroup) CompactLeftOverSegments() (int, error) {
+	sg.compactionLock.Lock()
+	defer sg.compactionLock.Unlock()
+
+	compactions := 0
+	for {
+		pair, level := sg.findLeftOverCompactionCandidates()
+		if pair == nil {
+			return compactions, nil
+		}
+
+		res, err := sg.compactPair(pair, level)
+		if err != nil {
+			return compactions, fmt.Errorf("compact leftover segments: %w", err)
+		}
+		if res == nil {
+			// compaction was skipped, e.g. due to memory pressure
+			return compactions, nil
+		}
+		sg.notifyCompactionComplete(*res)
+		compactions++
+	}
+}
+
+// findLeftOverCompactionCandidates returns the newest pair of consecutive
+// segments with different levels and similar sizes. The compacted segment keeps the level of the
+// older (left) segment, which is always the higher one.
+func (sg *SegmentGroup) findLeftOverCompactionCandidates() (pair []int, level uint16) {
+	if sg.isReadyOnly() {
+		return nil, 0
+	}
+
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	for leftId := len(sg.segments) - 2; leftId >= 0; leftId-- {
+		left, right := sg.segments[leftId], sg.segments[leftId+1]
+
+		if left.secondaryIndexCount != right.secondaryIndexCount {
+			continue
+		}
+		if left.level == right.level {
+			continue
+		}
+		if !sg.compactionFitsSizeLimit(left, right) || !isSimilarSegmentSizes(left.size, right.size) {
+			continue
+		}
+		return []int{leftId, leftId + 1}, left.level
+	}
+
+	return nil, 0
+}
@@ -0,0 +1,147 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_LeftOverCompactionCandidates(t *testing.T) {
+	t.Run("segments of same level are ignored", func(t *testing.T) {
+		sg := &SegmentGroup{
+			segments: []*segment{
+				{path: "seg_01", level: 3, size: 100 * MiB},
+				{path: "seg_02", level: 3, size: 100 * MiB},
+			},
+		}
+
+		pair, _ := sg.findLeftOverCompactionCandidates()
+		assert.Nil(t, pair)
+	})
+
+	t.Run("newest pair of different levels is picked", func(t *testing.T) {
+		sg := &SegmentGroup{
+			segments: []*segment{
+				{path: "seg_01", level: 5, size: 8 * MiB},
+				{path: "seg_02", level: 2, size: 5 * MiB},
+				{path: "seg_03", level: 0, size: 1 * KiB},
+			},
+		}
+
+		pair, level := sg.findLeftOverCompactionCandidates()
+		assert.Equal(t, []int{1, 2}, pair)
+		assert.Equal(t, uint16(2), level)
+	})
+
+	t.Run("segments of dissimilar size are ignored", func(t *testing.T) {
+		sg := &SegmentGroup{
+			segments: []*segment{
+				{path: "seg_01", level: 5, size: 100 * GiB},
+				{path: "seg_02", level: 2, size: 10 * MiB},
+				{path: "seg_03", level: 0, size: 1 * KiB},
+			},
+		}
+
+		pair, level := sg.findLeftOverCompactionCandidates()
+		assert.Equal(t, []int{1, 2}, pair)
+		assert.Equal(t, uint16(2), level)
+
+		sg.segments = sg.segments[:2]
+		pair, _ = sg.findLeftOverCompactionCandidates()
+		assert.Nil(t, pair)
+	})
+
+	t.Run("max segment size is respected", func(t *testing.T) {
+		sg := &SegmentGroup{
+			maxSegmentSize: 50 * GiB,
+			segments: []*segment{
+				{path: "seg_01", level: 5, size: 40 * GiB},
+				{path: "seg_02", level: 2, size: 20 * GiB},
+				{path: "seg_03", level: 2, size: 20 * GiB},
+			},
+		}
+
+		pair, _ := sg.findLeftOverCompactionCandidates()
+		assert.Nil(t, pair)
+	})
+}
+
+func TestSegmentGroup_CompactLeftOverSegments(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	put := func(i int) {
+		require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))))
+		require.Nil(t, b.FlushAndSwitch())
+	}
+
+	// two level-0 segments compact into a single level-1 segment, the
+	// following flush leaves a level-0 segment behind
+	put(0)
+	put(1)
+	compacted, err := b.disk.compactOnce()
+	require.Nil(t, err)
+	require.True(t, compacted)
+	put(2)
+	require.Equal(t, 2, b.disk.Len())
+
+	t.Run("regular compaction ignores leftover segments", func(t *testing.T) {
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		assert.False(t, compacted)
+		assert.Equal(t, 2, b.disk.Len())
+	})
+
+	t.Run("one-shot pass compacts leftover segments", func(t *testing.T) {
+		compactions, err := b.disk.CompactLeftOverSegments()
+		require.Nil(t, err)
+		assert.Equal(t, 1, compactions)
+		require.Equal(t, 1, b.disk.Len())
+		assert.Equal(t, uint16(1), b.disk.segmentAtPos(0).level)
+
+		for i := 0; i < 3; i++ {
+			v, err := b.Get([]byte(fmt.Sprintf("key-%d", i)))
+			require.Nil(t, err)
+			assert.Equal(t, []byte(fmt.Sprintf("value-%d", i)), v)
+		}
+	})
+
+	t.Run("nothing left to compact", func(t *testing.T) {
+		compactions, err := b.disk.CompactLeftOverSegments()
+		require.Nil(t, err)
+		assert.Equal(t, 0, compactions)
+	})
+
+	t.Run("toggle enables leftover compaction in the regular cycle", func(t *testing.T) {
+		put(3)
+		b.disk.SetCompactLeftOverSegments(true)
+		defer b.disk.SetCompactLeftOverSegments(false)
+
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		assert.True(t, compacted)
+		assert.Equal(t, 1, b.disk.Len())
+	})
+}
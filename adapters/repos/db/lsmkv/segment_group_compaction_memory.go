@@ -0,0 +1,86 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/weaviate/weaviate/usecases/memwatch"
+)
+
+// errCompactionMemoryPressure is returned by a compaction which was stopped
+// because the allocChecker reported insufficient memory. The original
+// segments are untouched, the partially written .tmp file is removed.
+var errCompactionMemoryPressure = errors.New("compaction stopped due to memory pressure")
+
+const (
+	// compactionMemoryEstimate is the memory a compaction is assumed to need,
+	// it is checked before a compaction starts and periodically while merging
+	compactionMemoryEstimate = 100 * 1024 * 1024
+
+	// compactionMemoryCheckInterval is the number of bytes written between two
+	// memory checks during a merge
+	compactionMemoryCheckInterval = 16 * 1024 * 1024
+
+	// defaultCompactionMemoryBackoff is used if sgConfig does not specify a
+	// backoff, see WithCompactionMemoryPressureBackoff
+	defaultCompactionMemoryBackoff = 30 * time.Second
+)
+
+// memoryCheckedWriteSeeker consults allocChecker every
+// compactionMemoryCheckInterval written bytes and fails all writes once it
+// reports insufficient memory, which makes the compactor writing to it return
+// early
+type memoryCheckedWriteSeeker struct {
+	w            io.WriteSeeker
+	allocChecker memwatch.AllocChecker
+	sinceCheck   int
+	err          error
+}
+
+func (m *memoryCheckedWriteSeeker) Write(p []byte) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+
+	m.sinceCheck += len(p)
+	if m.sinceCheck >= compactionMemoryCheckInterval {
+		m.sinceCheck = 0
+		if err := m.allocChecker.CheckAlloc(compactionMemoryEstimate); err != nil {
+			m.err = fmt.Errorf("%w: %w", errCompactionMemoryPressure, err)
+			return 0, m.err
+		}
+	}
+	return m.w.Write(p)
+}
+
+func (m *memoryCheckedWriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	return m.w.Seek(offset, whence)
+}
+
+// backOffCompactions pauses compactions for the configured backoff, so they
+// don't retry right away while the memory pressure persists
+func (sg *SegmentGroup) backOffCompactions() {
+	sg.compactionBackoffUntil = time.Now().Add(sg.compactionMemoryBackoff)
+}
+
+// compactionsBackedOff reports whether compactions are paused after memory
+// pressure. Callers need to hold the compactionLock.
+func (sg *SegmentGroup) compactionsBackedOff() bool {
+	return time.Now().Before(sg.compactionBackoffUntil)
+}
@@ -0,0 +1,157 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+// failingAllocChecker reports insufficient memory for all checks after the
+// first passAllocs ones
+type failingAllocChecker struct {
+	passAllocs int64
+	calls      atomic.Int64
+}
+
+func (c *failingAllocChecker) CheckAlloc(sizeInBytes int64) error {
+	if c.calls.Add(1) > c.passAllocs {
+		return errors.New("not enough memory")
+	}
+	return nil
+}
+
+func (c *failingAllocChecker) CheckMappingAndReserve(numberMappings int64, reservationTimeInS int) error {
+	return nil
+}
+
+func (c *failingAllocChecker) Refresh(updateMappings bool) {}
+
+func TestSegmentGroup_CompactionMemoryPressure(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	setup := func(t *testing.T, checker *failingAllocChecker, valueSize int) *Bucket {
+		dir := t.TempDir()
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace), WithAllocChecker(checker),
+			WithCompactionMemoryPressureBackoff(time.Hour))
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+
+		value := make([]byte, valueSize)
+		for seg := 0; seg < 2; seg++ {
+			for i := 0; i < 100; i++ {
+				key := []byte(fmt.Sprintf("key-%d-%03d", seg, i))
+				require.Nil(t, b.Put(key, value))
+			}
+			require.Nil(t, b.FlushAndSwitch())
+		}
+		return b
+	}
+
+	compactOnce := func(b *Bucket) (bool, error) {
+		b.disk.compactionLock.Lock()
+		defer b.disk.compactionLock.Unlock()
+		return b.disk.compactOnce()
+	}
+
+	assertNoTmpFiles := func(t *testing.T, dir string) {
+		entries, err := os.ReadDir(dir)
+		require.Nil(t, err)
+		for _, e := range entries {
+			assert.False(t, strings.HasSuffix(e.Name(), ".tmp"), e.Name())
+		}
+	}
+
+	t.Run("skipped before the merge", func(t *testing.T) {
+		checker := &failingAllocChecker{passAllocs: 0}
+		b := setup(t, checker, 16)
+
+		compacted, err := compactOnce(b)
+		require.Nil(t, err)
+		assert.False(t, compacted)
+		assert.Equal(t, 2, b.disk.Len())
+		assert.True(t, b.disk.compactionsBackedOff())
+
+		// backed off, the allocChecker is not consulted again
+		calls := checker.calls.Load()
+		compacted, err = compactOnce(b)
+		require.Nil(t, err)
+		assert.False(t, compacted)
+		assert.Equal(t, calls, checker.calls.Load())
+	})
+
+	t.Run("stopped during the merge", func(t *testing.T) {
+		// the check before the merge passes, the periodic one fails. The
+		// segments are large enough to be checked during the merge.
+		checker := &failingAllocChecker{passAllocs: 1}
+		b := setup(t, checker, 128*1024)
+
+		compacted, err := compactOnce(b)
+		require.Nil(t, err)
+		assert.False(t, compacted)
+		assert.Greater(t, checker.calls.Load(), int64(1))
+		assert.Equal(t, 2, b.disk.Len())
+		assert.True(t, b.disk.compactionsBackedOff())
+		assertNoTmpFiles(t, b.disk.dir)
+
+		v, err := b.Get([]byte("key-1-099"))
+		require.Nil(t, err)
+		assert.Len(t, v, 128*1024)
+
+		// compacts again once the backoff passed and memory is available
+		b.disk.compactionBackoffUntil = time.Time{}
+		checker.passAllocs = 1 << 30
+		compacted, err = compactOnce(b)
+		require.Nil(t, err)
+		assert.True(t, compacted)
+		assert.Equal(t, 1, b.disk.Len())
+	})
+
+	t.Run("memory checks are periodic", func(t *testing.T) {
+		checker := &failingAllocChecker{passAllocs: 1 << 30}
+		f, err := os.Create(filepath.Join(t.TempDir(), "segment.db.tmp"))
+		require.Nil(t, err)
+		defer f.Close()
+		w := &memoryCheckedWriteSeeker{w: f, allocChecker: checker}
+
+		chunk := make([]byte, 1024*1024)
+		for i := 0; i < 2*compactionMemoryCheckInterval/len(chunk); i++ {
+			_, err := w.Write(chunk)
+			require.Nil(t, err)
+		}
+		assert.Equal(t, int64(2), checker.calls.Load())
+
+		checker.passAllocs = 0
+		for i := 0; i < compactionMemoryCheckInterval/len(chunk); i++ {
+			w.Write(chunk)
+		}
+		_, err = w.Write(chunk)
+		assert.ErrorIs(t, err, errCompactionMemoryPressure)
+		_, err = w.Seek(0, 0)
+		assert.ErrorIs(t, err, errCompactionMemoryPressure)
+	})
+}
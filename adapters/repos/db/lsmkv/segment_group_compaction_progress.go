@@ -0,0 +1,157 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"io"
+	"math"
+	"time"
+)
+
+// defaultCompactionProgressInterval is used if no interval is configured
+const defaultCompactionProgressInterval = time.Second
+
+// CompactionProgress is a progress update of a running compaction of two
+// segments, see WithCompactionProgress.
+type CompactionProgress struct {
+	// IDs of the segments being compacted, the left one is the older one
+	LeftSegmentID  string
+	RightSegmentID string
+	Level          uint16
+	// BytesProcessed is the number of bytes written to the new segment so far.
+	// TotalBytes is the combined size of both input segments. The new segment
+	// is smaller if the compaction drops overwritten values or tombstones, so
+	// Percent and ETA are estimates that tend to be pessimistic.
+	BytesProcessed int64
+	TotalBytes     int64
+	Percent        float64
+	// ETA is the estimated remaining duration based on the throughput so far,
+	// 0 if unknown
+	ETA time.Duration
+	// Done is set on the last update, sent once the compacted segment was
+	// written successfully
+	Done bool
+}
+
+// compactionProgress sends the progress of a single compaction to ch. Updates
+// are sent at most once per interval and are dropped if ch is not ready to
+// receive, so a slow consumer never delays the compaction.
+type compactionProgress struct {
+	ch       chan<- CompactionProgress
+	interval time.Duration
+	base     CompactionProgress
+	started  time.Time
+	lastSent time.Time
+}
+
+// newCompactionProgress returns nil if no progress reporting is configured or
+// the configured func doesn't return a channel
+func (sg *SegmentGroup) newCompactionProgress(left, right *segment,
+	level uint16,
+) *compactionProgress {
+	if sg.compactionProgress == nil {
+		return nil
+	}
+	ch := sg.compactionProgress()
+	if ch == nil {
+		return nil
+	}
+
+	interval := sg.progressInterval
+	if interval <= 0 {
+		interval = defaultCompactionProgressInterval
+	}
+
+	now := time.Now()
+	return &compactionProgress{
+		ch:       ch,
+		interval: interval,
+		base: CompactionProgress{
+			LeftSegmentID:  segmentID(left.path),
+			RightSegmentID: segmentID(right.path),
+			Level:          level,
+			TotalBytes:     left.size + right.size,
+		},
+		started:  now,
+		lastSent: now,
+	}
+}
+
+// observe records that processed bytes were written in total and sends an
+// update if the interval has passed
+func (p *compactionProgress) observe(processed int64) {
+	now := time.Now()
+	if now.Sub(p.lastSent) < p.interval {
+		return
+	}
+	p.lastSent = now
+	p.send(p.update(processed, now))
+}
+
+func (p *compactionProgress) update(processed int64, now time.Time) CompactionProgress {
+	u := p.base
+	u.BytesProcessed = processed
+	if u.TotalBytes <= 0 {
+		return u
+	}
+
+	u.Percent = math.Min(100, float64(processed)/float64(u.TotalBytes)*100)
+	if processed > 0 && processed < u.TotalBytes {
+		elapsed := now.Sub(p.started)
+		u.ETA = time.Duration(float64(elapsed) * float64(u.TotalBytes-processed) / float64(processed))
+	}
+	return u
+}
+
+func (p *compactionProgress) send(u CompactionProgress) {
+	select {
+	case p.ch <- u:
+	default:
+		// the consumer is too slow, the update is dropped
+	}
+}
+
+// done sends the final update if the compaction succeeded and closes the
+// channel in any case. It is nil-safe.
+func (p *compactionProgress) done(success bool, processed int64) {
+	if p == nil {
+		return
+	}
+
+	if success {
+		u := p.base
+		u.BytesProcessed = processed
+		u.Percent = 100
+		u.Done = true
+		p.send(u)
+	}
+	close(p.ch)
+}
+
+// progressWriteSeeker counts the bytes written to w and reports them to
+// progress
+type progressWriteSeeker struct {
+	w        io.WriteSeeker
+	progress *compactionProgress
+	written  int64
+}
+
+func (p *progressWriteSeeker) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.progress.observe(p.written)
+	return n, err
+}
+
+func (p *progressWriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	return p.w.Seek(offset, whence)
+}
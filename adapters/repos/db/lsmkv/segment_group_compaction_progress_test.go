@@ -0,0 +1,144 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_CompactionProgress(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	// newBucket returns a bucket with two large segments, each compaction
+	// reports its progress to a new channel created by newProgress
+	newBucket := func(t *testing.T, newProgress func() chan<- CompactionProgress) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace),
+			WithCompactionProgress(time.Nanosecond, newProgress))
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+
+		value := make([]byte, 1024)
+		for s := 0; s < 2; s++ {
+			for i := 0; i < 5000; i++ {
+				require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%d-%05d", s, i)), value))
+			}
+			require.Nil(t, b.FlushAndSwitch())
+		}
+		return b
+	}
+
+	t.Run("progress is reported until completion", func(t *testing.T) {
+		var ch chan CompactionProgress
+		b := newBucket(t, func() chan<- CompactionProgress {
+			ch = make(chan CompactionProgress, 100_000)
+			return ch
+		})
+		left, right := segmentID(b.disk.segments[0].path), segmentID(b.disk.segments[1].path)
+		total := b.disk.segments[0].size + b.disk.segments[1].size
+
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		require.True(t, compacted)
+
+		var updates []CompactionProgress
+		for u := range ch {
+			updates = append(updates, u)
+		}
+		require.Greater(t, len(updates), 2)
+
+		for i, u := range updates {
+			assert.Equal(t, left, u.LeftSegmentID)
+			assert.Equal(t, right, u.RightSegmentID)
+			assert.Equal(t, total, u.TotalBytes)
+			assert.LessOrEqual(t, u.Percent, float64(100))
+			if i > 0 {
+				assert.GreaterOrEqual(t, u.BytesProcessed, updates[i-1].BytesProcessed)
+			}
+			assert.Equal(t, i == len(updates)-1, u.Done)
+		}
+
+		last := updates[len(updates)-1]
+		assert.Equal(t, float64(100), last.Percent)
+		// the header is written twice, once as a placeholder
+		assert.InDelta(t, b.disk.segments[0].size, last.BytesProcessed, 64)
+	})
+
+	t.Run("slow consumer doesn't block the compaction", func(t *testing.T) {
+		var ch chan CompactionProgress
+		b := newBucket(t, func() chan<- CompactionProgress {
+			ch = make(chan CompactionProgress)
+			return ch
+		})
+
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		require.True(t, compacted)
+
+		_, open := <-ch
+		assert.False(t, open)
+	})
+
+	t.Run("closed without final update if the compaction fails", func(t *testing.T) {
+		var ch chan CompactionProgress
+		b := newBucket(t, func() chan<- CompactionProgress {
+			ch = make(chan CompactionProgress, 100_000)
+			return ch
+		})
+
+		b.disk.abortCompaction.Store(true)
+		defer b.disk.abortCompaction.Store(false)
+		_, err := b.disk.compactOnce()
+		require.Error(t, err)
+
+		for u := range ch {
+			assert.False(t, u.Done)
+		}
+	})
+
+	t.Run("skipped if no channel is returned", func(t *testing.T) {
+		b := newBucket(t, func() chan<- CompactionProgress { return nil })
+
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		assert.True(t, compacted)
+	})
+}
+
+func TestCompactionProgressUpdate(t *testing.T) {
+	started := time.Now()
+	p := &compactionProgress{
+		base:    CompactionProgress{TotalBytes: 1000},
+		started: started,
+	}
+
+	u := p.update(250, started.Add(time.Second))
+	assert.Equal(t, int64(250), u.BytesProcessed)
+	assert.Equal(t, float64(25), u.Percent)
+	assert.Equal(t, 3*time.Second, u.ETA)
+
+	// the new segment can't be larger than its inputs, but the estimate
+	// must never exceed 100% either way
+	u = p.update(1200, started.Add(time.Second))
+	assert.Equal(t, float64(100), u.Percent)
+	assert.Zero(t, u.ETA)
+}
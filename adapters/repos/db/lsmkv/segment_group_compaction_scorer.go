@@ -0,0 +1,74 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import "math"
+
+// CompactionScorer rates how worthwhile it is to compact a pair of consecutive
+// segments. The left segment is always the older one. The pair with the
+// highest score is compacted first, a score of 0 or lower means the pair must
+// not be compacted. If multiple pairs share the highest score, the oldest pair
+// is picked.
+//
+// Scorers only rank pairs, constraints such as matching secondary indexes and
+// the max segment size are enforced by the segment group before a pair is
+// scored, see findCompactionCandidates.
+type CompactionScorer interface {
+	Score(left, right *segment) float64
+}
+
+// DefaultCompactionScorer only considers segments of the same level and prefers
+// lower levels, i.e. the most recently written data. As segments of the same
+// level are consecutive, the oldest pair of the lowest level wins.
+type DefaultCompactionScorer struct{}
+
+func (DefaultCompactionScorer) Score(left, right *segment) float64 {
+	if left.level != right.level {
+		return 0
+	}
+	return 1 / (1 + float64(left.level))
+}
+
+const (
+	defaultLeveledBaseLevelSize   = 64 * 1024 * 1024
+	defaultLeveledLevelMultiplier = 10
+)
+
+// LeveledCompactionScorer implements leveled scoring: every level has a size
+// budget which grows by LevelMultiplier with every level, starting at
+// BaseLevelSize for level 0. Pairs of the same level are scored by their
+// combined size relative to the budget of their level, so levels holding the
+// most data for their size are compacted first. Zero values fall back to a base
+// size of 64MiB and a multiplier of 10.
+type LeveledCompactionScorer struct {
+	BaseLevelSize   int64
+	LevelMultiplier float64
+}
+
+func (s LeveledCompactionScorer) Score(left, right *segment) float64 {
+	if left.level != right.level {
+		return 0
+	}
+
+	base := float64(s.BaseLevelSize)
+	if base <= 0 {
+		base = defaultLeveledBaseLevelSize
+	}
+	multiplier := s.LevelMultiplier
+	if multiplier <= 1 {
+		multiplier = defaultLeveledLevelMultiplier
+	}
+
+	budget := base * math.Pow(multiplier, float64(left.level))
+	// empty segments still need to be compacted eventually
+	return math.Max(float64(left.size+right.size), 1) / budget
+}
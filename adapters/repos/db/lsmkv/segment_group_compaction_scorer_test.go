@@ -0,0 +1,135 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockCompactionScorer struct {
+	scores map[string]float64
+	calls  [][2]string
+}
+
+func (m *mockCompactionScorer) Score(left, right *segment) float64 {
+	m.calls = append(m.calls, [2]string{left.path, right.path})
+	return m.scores[left.path+"+"+right.path]
+}
+
+func TestSegmentGroup_CompactionScorer(t *testing.T) {
+	t.Run("mock scorer decides the pair", func(t *testing.T) {
+		scorer := &mockCompactionScorer{scores: map[string]float64{
+			"seg_01+seg_02": 0.5,
+			"seg_02+seg_03": 2,
+			"seg_03+seg_04": 1,
+		}}
+		sg := &SegmentGroup{
+			compactionScorer: scorer,
+			segments: []*segment{
+				{path: "seg_01", level: 2},
+				{path: "seg_02", level: 1},
+				{path: "seg_03", level: 1},
+				{path: "seg_04", level: 1},
+				{path: "seg_05", level: 0, secondaryIndexCount: 1},
+			},
+		}
+
+		pair, level := sg.findCompactionCandidates()
+		assert.Equal(t, []int{1, 2}, pair)
+		// not promoted, the pair is followed by a segment of the same level
+		assert.Equal(t, uint16(2), level)
+
+		// the last pair is skipped because of mismatching secondary indexes
+		assert.Equal(t, [][2]string{
+			{"seg_01", "seg_02"},
+			{"seg_02", "seg_03"},
+			{"seg_03", "seg_04"},
+		}, scorer.calls)
+	})
+
+	t.Run("ties are won by the oldest pair", func(t *testing.T) {
+		scorer := &mockCompactionScorer{scores: map[string]float64{
+			"seg_01+seg_02": 1,
+			"seg_02+seg_03": 1,
+		}}
+		sg := &SegmentGroup{
+			compactionScorer: scorer,
+			segments: []*segment{
+				{path: "seg_01", level: 0},
+				{path: "seg_02", level: 0},
+				{path: "seg_03", level: 0},
+			},
+		}
+
+		pair, level := sg.findCompactionCandidates()
+		assert.Equal(t, []int{0, 1}, pair)
+		assert.Equal(t, uint16(1), level)
+	})
+
+	t.Run("no pair is picked without a positive score", func(t *testing.T) {
+		scorer := &mockCompactionScorer{}
+		sg := &SegmentGroup{
+			compactionScorer: scorer,
+			segments: []*segment{
+				{path: "seg_01", level: 0},
+				{path: "seg_02", level: 0},
+			},
+		}
+
+		pair, _ := sg.findCompactionCandidates()
+		assert.Nil(t, pair)
+		assert.Len(t, scorer.calls, 1)
+	})
+
+	t.Run("pairs exceeding the max segment size are not scored", func(t *testing.T) {
+		scorer := &mockCompactionScorer{}
+		sg := &SegmentGroup{
+			compactionScorer: scorer,
+			maxSegmentSize:   100,
+			segments: []*segment{
+				{path: "seg_01", level: 0, size: 60},
+				{path: "seg_02", level: 0, size: 60},
+			},
+		}
+
+		pair, _ := sg.findCompactionCandidates()
+		assert.Nil(t, pair)
+		assert.Empty(t, scorer.calls)
+	})
+}
+
+func TestLeveledCompactionScorer(t *testing.T) {
+	scorer := LeveledCompactionScorer{BaseLevelSize: 100, LevelMultiplier: 10}
+
+	assert.Zero(t, scorer.Score(&segment{level: 1}, &segment{level: 0}))
+	assert.Equal(t, 1.0, scorer.Score(&segment{level: 0, size: 50}, &segment{level: 0, size: 50}))
+	assert.Equal(t, 0.5, scorer.Score(&segment{level: 1, size: 250}, &segment{level: 1, size: 250}))
+
+	t.Run("picks the level most over its budget", func(t *testing.T) {
+		sg := &SegmentGroup{
+			compactionScorer: scorer,
+			segments: []*segment{
+				{path: "seg_01", level: 1, size: 900},
+				{path: "seg_02", level: 1, size: 900},
+				{path: "seg_03", level: 0, size: 10},
+				{path: "seg_04", level: 0, size: 10},
+			},
+		}
+
+		pair, level := sg.findCompactionCandidates()
+		require.Equal(t, []int{0, 1}, pair)
+		assert.Equal(t, uint16(2), level)
+	})
+}
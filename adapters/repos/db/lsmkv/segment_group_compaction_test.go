@@ -13,6 +13,7 @@ package lsmkv
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -91,6 +92,46 @@ func TestSegmentGroup_BestCompactionPair(t *testing.T) {
 	}
 }
 
+func TestSegmentGroup_LenAtLevel(t *testing.T) {
+	sg := &SegmentGroup{
+		segments: []*segment{
+			{path: "segment0", level: 2},
+			{path: "segment1", level: 1},
+			{path: "segment2", level: 1},
+			{path: "segment3", level: 0},
+		},
+	}
+
+	assert.Equal(t, 4, sg.Len())
+	assert.Equal(t, 1, sg.LenAtLevel(0))
+	assert.Equal(t, 2, sg.LenAtLevel(1))
+	assert.Equal(t, 1, sg.LenAtLevel(2))
+	assert.Equal(t, 0, sg.LenAtLevel(3))
+	assert.Equal(t, 0, sg.LenAtLevel(-1))
+	assert.Equal(t, 0, sg.LenAtLevel(math.MaxUint16+1))
+}
+
+func TestSegmentGroup_CompactionCandidateIDs(t *testing.T) {
+	sg := &SegmentGroup{
+		segments: []*segment{
+			{size: 9000, path: "/data/segment-1.db", level: 3},
+			{size: 9000, path: "/data/segment-2.db", level: 3},
+			{size: 1000, path: "/data/segment-3.db", level: 2},
+			{size: 1000, path: "/data/segment-4.db", level: 2},
+			{size: 1000, path: "/data/segment-5.db", level: 0},
+		},
+		maxSegmentSize: 10000,
+	}
+	segmentsBefore := append([]*segment{}, sg.segments...)
+
+	// segment-1 and segment-2 have matching levels as well, but are oversized
+	assert.Equal(t, []string{"3", "4"}, sg.CompactionCandidates())
+	assert.Equal(t, segmentsBefore, sg.segments, "segments must not change")
+
+	sg.segments = sg.segments[:2]
+	assert.Equal(t, []string{}, sg.CompactionCandidates())
+}
+
 func TestSegmenGroup_CompactionLargerThanMaxSize(t *testing.T) {
 	maxSegmentSize := int64(10000)
 	// this test only tests the unhappy path which has an early exist condition,
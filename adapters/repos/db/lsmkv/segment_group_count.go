@@ -0,0 +1,118 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/weaviate/weaviate/adapters/repos/db/roaringset"
+	"github.com/weaviate/weaviate/entities/lsmkv"
+)
+
+// Count returns the number of keys on disk, using the counting method suited
+// for the strategy of the segment group:
+//
+//   - replace: the net additions of all segments, see count
+//   - setcollection and mapcollection: the number of distinct keys across all
+//     segments
+//   - roaringset: the number of keys whose merged bitmap is not empty, i.e.
+//     the cardinality of the union of all segments
+//
+// Counting distinct keys requires a scan of all segments. If fast is set, the
+// sum of the key counts of the individual segments is returned instead, which
+// overestimates the count if keys are present in multiple segments. For the
+// replace strategy fast has no effect.
+func (sg *SegmentGroup) Count(fast bool) (int, error) {
+	switch sg.strategy {
+	case StrategyReplace:
+		return sg.count(), nil
+	case StrategySetCollection, StrategyMapCollection, StrategyRoaringSet:
+		if fast {
+			return sg.sumSegmentKeyCounts()
+		}
+		if sg.strategy == StrategyRoaringSet {
+			return sg.countRoaringSetKeys()
+		}
+		return sg.countDistinctCollectionKeys()
+	default:
+		return 0, fmt.Errorf("count not supported for strategy %q", sg.strategy)
+	}
+}
+
+// observeCount updates the object count metric. Outside of the replace
+// strategy the fast approximation is used, as this runs after every flush.
+func (sg *SegmentGroup) observeCount() {
+	count, err := sg.Count(true)
+	if err != nil {
+		sg.logger.WithField("action", "lsm_observe_count").
+			WithField("path", sg.dir).
+			WithError(err).
+			Warn("failed to count keys")
+		return
+	}
+	sg.metrics.ObjectCount(count)
+}
+
+func (sg *SegmentGroup) sumSegmentKeyCounts() (int, error) {
+	sg.rLock("count")
+	defer sg.maintenanceLock.RUnlock()
+
+	count := 0
+	for _, seg := range sg.segments {
+		keys, err := seg.keyCount()
+		if err != nil {
+			return 0, fmt.Errorf("count keys of segment %s: %w", seg.path, err)
+		}
+		count += keys
+	}
+	return count, nil
+}
+
+func (sg *SegmentGroup) countDistinctCollectionKeys() (int, error) {
+	cursors, unlock, err := sg.newCollectionCursors()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	keys := map[string]struct{}{}
+	for _, c := range cursors {
+		for key, _, err := c.first(); ; key, _, err = c.next() {
+			if errors.Is(err, lsmkv.NotFound) {
+				break
+			}
+			if err != nil {
+				return 0, fmt.Errorf("iterate collection segment: %w", err)
+			}
+			keys[string(key)] = struct{}{}
+		}
+	}
+	return len(keys), nil
+}
+
+func (sg *SegmentGroup) countRoaringSetKeys() (int, error) {
+	cursors, unlock, err := sg.newRoaringSetCursors()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	// keys with an empty bitmap, i.e. with all values deleted, are skipped by
+	// the combined cursor
+	c := roaringset.NewCombinedCursor(cursors, true)
+	count := 0
+	for key, _ := c.First(); key != nil; key, _ = c.Next() {
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,95 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_Count(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(t *testing.T, strategy string) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(strategy))
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+		return b
+	}
+
+	t.Run("replace", func(t *testing.T) {
+		b := newBucket(t, StrategyReplace)
+		require.Nil(t, b.Put([]byte("a"), []byte("1")))
+		require.Nil(t, b.Put([]byte("b"), []byte("1")))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.Put([]byte("a"), []byte("2")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		for _, fast := range []bool{true, false} {
+			count, err := b.disk.Count(fast)
+			require.Nil(t, err)
+			assert.Equal(t, 2, count)
+		}
+	})
+
+	t.Run("setcollection", func(t *testing.T) {
+		b := newBucket(t, StrategySetCollection)
+		require.Nil(t, b.SetAdd([]byte("a"), [][]byte{[]byte("1")}))
+		require.Nil(t, b.SetAdd([]byte("b"), [][]byte{[]byte("1")}))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.SetAdd([]byte("a"), [][]byte{[]byte("2")}))
+		require.Nil(t, b.SetAdd([]byte("c"), [][]byte{[]byte("1")}))
+		require.Nil(t, b.FlushAndSwitch())
+
+		count, err := b.disk.Count(false)
+		require.Nil(t, err)
+		assert.Equal(t, 3, count)
+
+		// "a" is counted once per segment
+		count, err = b.disk.Count(true)
+		require.Nil(t, err)
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("roaringset", func(t *testing.T) {
+		b := newBucket(t, StrategyRoaringSet)
+		require.Nil(t, b.RoaringSetAddList([]byte("a"), []uint64{1, 2}))
+		require.Nil(t, b.RoaringSetAddList([]byte("b"), []uint64{1}))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.RoaringSetAddList([]byte("a"), []uint64{3}))
+		require.Nil(t, b.RoaringSetRemoveOne([]byte("b"), 1))
+		require.Nil(t, b.FlushAndSwitch())
+
+		// all values of "b" were removed
+		count, err := b.disk.Count(false)
+		require.Nil(t, err)
+		assert.Equal(t, 1, count)
+
+		count, err = b.disk.Count(true)
+		require.Nil(t, err)
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("unsupported strategy", func(t *testing.T) {
+		b := newBucket(t, StrategyRoaringSetRange)
+		_, err := b.disk.Count(false)
+		assert.ErrorContains(t, err, "not supported")
+	})
+}
@@ -0,0 +1,126 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// segmentIDKey is the logical ID of a segment file, two files with the same
+// key claim to be the same segment. Numeric IDs are compared by value, so a
+// stray copy such as segment-0123.db is detected as a duplicate of
+// segment-123.db.
+func segmentIDKey(name string) string {
+	if id, ok := numericSegmentID(name); ok {
+		return strconv.FormatInt(id, 10)
+	}
+	return segmentID(name)
+}
+
+// quarantineDuplicateSegments detects segment files which claim the same
+// segment ID, e.g. left behind by a botched manual copy. Mounting both would
+// break latest-wins, as the order of the two is undefined. Of each group of
+// duplicates, one file is kept and all others are quarantined. The kept file
+// is the first of:
+//
+//   - the file listed in the manifest
+//   - the file with the canonical name segment-<id>.db
+//   - a file large enough to hold a segment header
+//
+// Remaining ties are broken by name. The returned list no longer contains
+// the quarantined files.
+func (sg *SegmentGroup) quarantineDuplicateSegments(list []os.DirEntry,
+	manifest *segmentManifest,
+) ([]os.DirEntry, error) {
+	byID := map[string][]os.DirEntry{}
+	for _, entry := range list {
+		if filepath.Ext(entry.Name()) != ".db" {
+			continue
+		}
+		key := segmentIDKey(entry.Name())
+		byID[key] = append(byID[key], entry)
+	}
+
+	inManifest := map[string]struct{}{}
+	if manifest != nil {
+		for _, name := range manifest.Segments {
+			inManifest[name] = struct{}{}
+		}
+	}
+
+	score := func(id string, entry os.DirEntry) int {
+		s := 0
+		if _, ok := inManifest[entry.Name()]; ok {
+			s += 4
+		}
+		if entry.Name() == fmt.Sprintf("segment-%s.db", id) {
+			s += 2
+		}
+		if info, err := entry.Info(); err == nil && !isInvalidSegmentSize(info.Size()) {
+			s += 1
+		}
+		return s
+	}
+
+	quarantined := map[string]struct{}{}
+	for id, entries := range byID {
+		if len(entries) < 2 {
+			continue
+		}
+
+		sort.SliceStable(entries, func(a, b int) bool {
+			scoreA, scoreB := score(id, entries[a]), score(id, entries[b])
+			if scoreA != scoreB {
+				return scoreA > scoreB
+			}
+			return entries[a].Name() < entries[b].Name()
+		})
+
+		kept := filepath.Join(sg.dir, entries[0].Name())
+		for _, entry := range entries[1:] {
+			path := filepath.Join(sg.dir, entry.Name())
+			if err := os.Rename(path, path+QuarantineSuffix); err != nil {
+				return nil, fmt.Errorf("quarantine duplicate segment %s: %w", path, err)
+			}
+			quarantined[entry.Name()] = struct{}{}
+
+			sg.logger.WithFields(logrus.Fields{
+				"action":     "lsm_segment_init_duplicate_segment",
+				"segment_id": id,
+				"path":       path,
+				"kept_path":  kept,
+			}).Error("quarantined duplicate segment, another file claims the same segment id")
+		}
+	}
+
+	if len(quarantined) == 0 {
+		return list, nil
+	}
+
+	if err := fsync(sg.dir); err != nil {
+		return nil, fmt.Errorf("fsync segment directory %s: %w", sg.dir, err)
+	}
+
+	remaining := make([]os.DirEntry, 0, len(list)-len(quarantined))
+	for _, entry := range list {
+		if _, ok := quarantined[entry.Name()]; !ok {
+			remaining = append(remaining, entry)
+		}
+	}
+	return remaining, nil
+}
@@ -0,0 +1,116 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_DuplicateSegmentsOnMount(t *testing.T) {
+	ctx := context.Background()
+	logger, hook := test.NewNullLogger()
+
+	newBucket := func(t *testing.T, dir string) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		return b
+	}
+
+	// prepare writes two segments, the newer one updates the key. It returns
+	// the name of the older segment.
+	prepare := func(t *testing.T) (dir, older string) {
+		dir = t.TempDir()
+		b := newBucket(t, dir)
+		require.Nil(t, b.Put([]byte("key"), []byte("old")))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.Put([]byte("key"), []byte("new")))
+		require.Nil(t, b.FlushAndSwitch())
+		older = filepath.Base(b.disk.segmentAtPos(0).path)
+		require.Nil(t, b.Shutdown(ctx))
+		return dir, older
+	}
+
+	copyFile := func(t *testing.T, from, to string) {
+		contents, err := os.ReadFile(from)
+		require.Nil(t, err)
+		require.Nil(t, os.WriteFile(to, contents, 0o666))
+	}
+
+	t.Run("stray copy of a segment is quarantined", func(t *testing.T) {
+		dir, older := prepare(t)
+
+		// the stray copy claims the same numeric id as the older segment
+		stray := "segment-0" + strings.TrimPrefix(older, "segment-")
+		copyFile(t, filepath.Join(dir, older), filepath.Join(dir, stray))
+
+		hook.Reset()
+		b := newBucket(t, dir)
+		defer b.Shutdown(ctx)
+
+		require.Equal(t, 2, b.disk.Len())
+		assert.FileExists(t, filepath.Join(dir, older))
+		assert.NoFileExists(t, filepath.Join(dir, stray))
+		assert.FileExists(t, filepath.Join(dir, stray+QuarantineSuffix))
+
+		// latest wins still holds
+		v, err := b.Get([]byte("key"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("new"), v)
+
+		var logged bool
+		for _, entry := range hook.AllEntries() {
+			if entry.Data["action"] == "lsm_segment_init_duplicate_segment" {
+				logged = true
+				assert.Equal(t, filepath.Join(dir, older), entry.Data["kept_path"])
+			}
+		}
+		assert.True(t, logged)
+	})
+
+	t.Run("segment listed in the manifest is kept", func(t *testing.T) {
+		dir, older := prepare(t)
+
+		// the copy has the canonical name, the manifest lists the other file
+		renamed := "segment-0" + strings.TrimPrefix(older, "segment-")
+		require.Nil(t, os.Rename(filepath.Join(dir, older), filepath.Join(dir, renamed)))
+		manifest, err := loadSegmentManifest(dir)
+		require.Nil(t, err)
+		for i, name := range manifest.Segments {
+			if name == older {
+				manifest.Segments[i] = renamed
+			}
+		}
+		data, err := json.Marshal(manifest)
+		require.Nil(t, err)
+		require.Nil(t, os.WriteFile(filepath.Join(dir, SegmentManifestFile), data, 0o666))
+		copyFile(t, filepath.Join(dir, renamed), filepath.Join(dir, older))
+
+		b := newBucket(t, dir)
+		defer b.Shutdown(ctx)
+
+		require.Equal(t, 2, b.disk.Len())
+		assert.FileExists(t, filepath.Join(dir, renamed))
+		assert.FileExists(t, filepath.Join(dir, older+QuarantineSuffix))
+	})
+}
@@ -0,0 +1,125 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	// exportRowGroupSize is the number of rows after which ExportParquet
+	// flushes a row group, so that the buffered rows don't grow unbounded
+	exportRowGroupSize = 10_000
+
+	// the context is checked every exportCtxCheckInterval exported keys
+	exportCtxCheckInterval = 1_000
+
+	defaultExportKeyColumn   = "key"
+	defaultExportValueColumn = "value"
+)
+
+type ExportOpts struct {
+	// KeyColumn and ValueColumn name the two byte array columns of the
+	// export, they default to "key" and "value"
+	KeyColumn   string
+	ValueColumn string
+	// KeyPrefix limits the export to keys with this prefix, all keys are
+	// exported if empty
+	KeyPrefix []byte
+}
+
+// ExportParquet writes all keys of a "replace" segment group and their latest
+// values to w in the Parquet format, one row per key in key order. Deleted
+// keys are skipped by the Cursor and are not exported. Keys which are only
+// held in the memtable of the bucket are not part of the segment group and are
+// not exported either.
+//
+// The export reads a snapshot of the segments taken when it starts, it does
+// not hold the maintenance lock while writing to w. With a KeyPrefix, the
+// cursor seeks to the prefix and the export stops at the first key without
+// it. It returns the number of rows written.
+func (sg *SegmentGroup) ExportParquet(ctx context.Context, w io.Writer, opts ExportOpts) (int64, error) {
+	if sg.strategy != StrategyReplace {
+		return 0, fmt.Errorf("export only possible for strategy %q", StrategyReplace)
+	}
+
+	keyColumn, valueColumn := opts.KeyColumn, opts.ValueColumn
+	if keyColumn == "" {
+		keyColumn = defaultExportKeyColumn
+	}
+	if valueColumn == "" {
+		valueColumn = defaultExportValueColumn
+	}
+	if keyColumn == valueColumn {
+		return 0, fmt.Errorf("key and value column must have different names, got %q", keyColumn)
+	}
+
+	schema := parquet.NewSchema("lsmkv", parquet.Group{
+		keyColumn:   parquet.Leaf(parquet.ByteArrayType),
+		valueColumn: parquet.Leaf(parquet.ByteArrayType),
+	})
+	// columns are ordered by name in a group, not in the order given above
+	keyLeaf, _ := schema.Lookup(keyColumn)
+	valueLeaf, _ := schema.Lookup(valueColumn)
+
+	writer := parquet.NewWriter(w, schema)
+	row := make(parquet.Row, 2)
+
+	c, err := sg.NewCursor()
+	if err != nil {
+		return 0, fmt.Errorf("open cursor: %w", err)
+	}
+	defer c.Close()
+
+	var key, value []byte
+	var ok bool
+	if len(opts.KeyPrefix) > 0 {
+		key, value, ok = c.Seek(opts.KeyPrefix)
+	} else {
+		key, value, ok = c.Next()
+	}
+
+	var rows int64
+	// keys are ordered, there are no more matches after the first key
+	// without the prefix
+	for ; ok && bytes.HasPrefix(key, opts.KeyPrefix); key, value, ok = c.Next() {
+		if rows%exportCtxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return rows, err
+			}
+		}
+
+		// the writer copies the values into its column buffers, so the
+		// cursor is free to reuse them
+		row[keyLeaf.ColumnIndex] = parquet.ByteArrayValue(key).Level(0, 0, keyLeaf.ColumnIndex)
+		row[valueLeaf.ColumnIndex] = parquet.ByteArrayValue(value).Level(0, 0, valueLeaf.ColumnIndex)
+		if _, err := writer.WriteRows([]parquet.Row{row}); err != nil {
+			return rows, fmt.Errorf("write row: %w", err)
+		}
+		rows++
+
+		if rows%exportRowGroupSize == 0 {
+			if err := writer.Flush(); err != nil {
+				return rows, fmt.Errorf("flush row group: %w", err)
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return rows, fmt.Errorf("close parquet writer: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,153 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_ExportParquet(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	// 12,000 keys "a-00000" to "a-11999" span two row groups, the "b-" keys
+	// follow them in key order
+	const aKeys = 12_000
+	for i := 0; i < aKeys; i++ {
+		require.Nil(t, b.Put([]byte(fmt.Sprintf("a-%05d", i)), []byte(fmt.Sprintf("value-%d", i))))
+	}
+	for i := 0; i < 3; i++ {
+		require.Nil(t, b.Put([]byte(fmt.Sprintf("b-%d", i)), []byte("old")))
+	}
+	require.Nil(t, b.FlushAndSwitch())
+	require.Nil(t, b.Put([]byte("b-0"), []byte("new")))
+	require.Nil(t, b.Delete([]byte("b-1")))
+	require.Nil(t, b.FlushAndSwitch())
+
+	export := func(t *testing.T, opts ExportOpts) (*parquet.File, map[string]string) {
+		var buf bytes.Buffer
+		rows, err := b.disk.ExportParquet(ctx, &buf, opts)
+		require.Nil(t, err)
+
+		file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.Nil(t, err)
+		require.Equal(t, rows, file.NumRows())
+
+		keyColumn, valueColumn := opts.KeyColumn, opts.ValueColumn
+		if keyColumn == "" {
+			keyColumn, valueColumn = "key", "value"
+		}
+		keyLeaf, ok := file.Schema().Lookup(keyColumn)
+		require.True(t, ok)
+		valueLeaf, ok := file.Schema().Lookup(valueColumn)
+		require.True(t, ok)
+
+		reader := parquet.NewReader(file)
+		defer reader.Close()
+
+		exported := map[string]string{}
+		rowBuf := make([]parquet.Row, 100)
+		for {
+			n, err := reader.ReadRows(rowBuf)
+			for _, row := range rowBuf[:n] {
+				exported[string(row[keyLeaf.ColumnIndex].ByteArray())] = string(row[valueLeaf.ColumnIndex].ByteArray())
+			}
+			if err != nil {
+				break
+			}
+		}
+		require.Len(t, exported, int(rows))
+		return file, exported
+	}
+
+	t.Run("all keys", func(t *testing.T) {
+		file, exported := export(t, ExportOpts{})
+
+		assert.Len(t, exported, aKeys+2)
+		assert.Equal(t, "value-42", exported["a-00042"])
+		assert.Equal(t, "new", exported["b-0"])
+		assert.NotContains(t, exported, "b-1")
+		assert.Equal(t, "old", exported["b-2"])
+
+		// a row group is flushed every 10,000 rows
+		require.Len(t, file.RowGroups(), 2)
+		assert.Equal(t, int64(exportRowGroupSize), file.RowGroups()[0].NumRows())
+	})
+
+	t.Run("key prefix and custom columns", func(t *testing.T) {
+		_, exported := export(t, ExportOpts{KeyColumn: "id", ValueColumn: "doc", KeyPrefix: []byte("b-")})
+
+		assert.Equal(t, map[string]string{"b-0": "new", "b-2": "old"}, exported)
+	})
+
+	t.Run("same column names are rejected", func(t *testing.T) {
+		_, err := b.disk.ExportParquet(ctx, &bytes.Buffer{}, ExportOpts{KeyColumn: "x", ValueColumn: "x"})
+		require.NotNil(t, err)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := b.disk.ExportParquet(ctx, &bytes.Buffer{}, ExportOpts{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("key prefix between other keys", func(t *testing.T) {
+		_, exported := export(t, ExportOpts{KeyPrefix: []byte("a-0004")})
+
+		assert.Len(t, exported, 10)
+		assert.Equal(t, "value-40", exported["a-00040"])
+		assert.Equal(t, "value-49", exported["a-00049"])
+	})
+
+	t.Run("segments can be replaced while writing", func(t *testing.T) {
+		var buf bytes.Buffer
+		compacted := false
+		w := exportWriterFunc(func(p []byte) (int, error) {
+			if !compacted {
+				// would wait for the export if it held the maintenance lock
+				var err error
+				compacted, err = b.disk.compactOnce()
+				require.Nil(t, err)
+			}
+			return buf.Write(p)
+		})
+
+		rows, err := b.disk.ExportParquet(ctx, w, ExportOpts{})
+		require.Nil(t, err)
+		assert.True(t, compacted)
+		assert.Equal(t, 1, b.disk.Len())
+		assert.Equal(t, int64(aKeys+2), rows)
+	})
+}
+
+type exportWriterFunc func(p []byte) (int, error)
+
+func (f exportWriterFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,163 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+// gcCompactionMinTombstoneRatio is the share of tombstones a segment needs to
+// be rewritten by the gc compaction
+const gcCompactionMinTombstoneRatio = 0.25
+
+// findGCCompactionCandidate returns the position of the segment with the
+// highest share of tombstones, as long as it reaches
+// gcCompactionMinTombstoneRatio, or emptyIdx if there is none.
+//
+// The tombstone counts of segments which were not created by a compaction are
+// calculated by scanning them the first time, see segment.tombstoneStats. The
+// scans don't hold the maintenance lock. The caller holds the compactionLock,
+// so the segments are not replaced or closed in the meantime and flushes only
+// append new segments, which keeps the positions valid.
+func (sg *SegmentGroup) findGCCompactionCandidate() int {
+	sg.maintenanceLock.RLock()
+	segments := make([]*segment, len(sg.segments))
+	copy(segments, sg.segments)
+	sg.maintenanceLock.RUnlock()
+
+	candidate, candidateRatio := emptyIdx, 0.0
+	for i, seg := range segments {
+		if seg.tombstonesCollected.Load() {
+			continue
+		}
+
+		keys, tombstones, err := seg.tombstoneStats()
+		if err != nil {
+			sg.logger.WithField("action", "lsm_gc_compaction").
+				WithField("path", seg.path).
+				WithError(err).
+				Warn("failed to count tombstones of segment")
+			continue
+		}
+		if keys == 0 {
+			continue
+		}
+
+		ratio := float64(tombstones) / float64(keys)
+		if ratio >= gcCompactionMinTombstoneRatio && ratio > candidateRatio {
+			candidate, candidateRatio = i, ratio
+		}
+	}
+	return candidate
+}
+
+// gcCompactOnce rewrites a single segment without the tombstones which have
+// no entry left to shadow, i.e. keys that are not present in any of the lower
+// segments. Unlike a regular compaction, segments are never merged, so this
+// also reclaims space of segments that are too large to find a compaction
+// partner of similar size.
+//
+// A rewritten segment is not picked again until it is part of a regular
+// compaction, as its remaining tombstones still shadow lower segments.
+// gc compaction is only supported for the replace strategy and skipped if the
+// bucket keeps tombstones.
+func (sg *SegmentGroup) gcCompactOnce(shouldAbort cyclemanager.ShouldAbortCallback) (bool, error) {
+	if sg.strategy != StrategyReplace || sg.keepTombstones || sg.isReadyOnly() {
+		return false, nil
+	}
+
+	candidateIdx := sg.findGCCompactionCandidate()
+	if candidateIdx == emptyIdx {
+		return false, nil
+	}
+
+	if sg.allocChecker != nil {
+		// allocChecker is optional
+		if err := sg.allocChecker.CheckAlloc(100 * 1024 * 1024); err != nil {
+			// same as for the cleanup, don't create garbage when close to the
+			// memory limit
+			sg.logger.WithFields(logrus.Fields{
+				"action": "lsm_gc_compaction",
+				"event":  "gc_compaction_skipped_oom",
+				"path":   sg.dir,
+			}).WithError(err).
+				Warnf("skipping gc compaction due to memory pressure")
+
+			return false, nil
+		}
+	}
+
+	if shouldAbort() {
+		return false, nil
+	}
+
+	oldSegment := sg.segmentAtPos(candidateIdx)
+	segmentId := segmentID(oldSegment.path)
+	tmpSegmentPath := filepath.Join(sg.dir, "segment-"+segmentId+".db.tmp")
+	scratchSpacePath := oldSegment.path + "gc.scratch.d"
+
+	start := time.Now()
+	file, err := openSegmentFileForWrite(tmpSegmentPath)
+	if err != nil {
+		return false, err
+	}
+
+	// segments below the candidate are only changed by compactions and
+	// cleanups, which hold the compaction lock just like the gc compaction
+	var keyExistsBelow keyExistsOnUpperSegmentsFunc = func([]byte) (bool, error) { return false, nil }
+	if candidateIdx > 0 {
+		keyExistsBelow = sg.makeKeyExistsOnUpperSegments(0, candidateIdx-1)
+	}
+
+	cursor, err := oldSegment.newCursor()
+	if err != nil {
+		file.Close()
+		return false, err
+	}
+	c := newSegmentGCCompactorReplace(file, cursor, keyExistsBelow,
+		oldSegment.level, oldSegment.secondaryIndexCount, scratchSpacePath,
+		sg.enableChecksumValidation)
+	if err := c.do(shouldAbort); err != nil {
+		file.Close()
+		return false, err
+	}
+
+	if err := file.Sync(); err != nil {
+		return false, fmt.Errorf("fsync gc compacted segment file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return false, fmt.Errorf("close gc compacted segment file: %w", err)
+	}
+
+	newSegment, err := sg.replaceSegment(candidateIdx, tmpSegmentPath, true)
+	if err != nil {
+		return false, fmt.Errorf("replace gc compacted segment: %w", err)
+	}
+	newSegment.setTombstoneStats(c.keys, c.tombstones)
+
+	sg.logger.WithFields(logrus.Fields{
+		"action":     "lsm_gc_compaction",
+		"path":       sg.dir,
+		"segment_id": segmentId,
+		"size_old":   oldSegment.size,
+		"size_new":   newSegment.size,
+		"tombstones": c.tombstones,
+		"took":       time.Since(start),
+	}).Debug("gc compaction finished")
+
+	return true, nil
+}
@@ -0,0 +1,176 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_GCCompaction(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+	noAbort := func() bool { return false }
+
+	newBucketAt := func(t *testing.T, dir string, opts ...BucketOption) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			append([]BucketOption{WithStrategy(StrategyReplace)}, opts...)...)
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+		return b
+	}
+	newBucket := func(t *testing.T, opts ...BucketOption) *Bucket {
+		return newBucketAt(t, t.TempDir(), opts...)
+	}
+	key := func(i int) []byte { return []byte(fmt.Sprintf("key-%05d", i)) }
+	value := make([]byte, 256)
+
+	t.Run("single segment shrinks", func(t *testing.T) {
+		b := newBucket(t)
+
+		// 1,000 live keys and 3,000 deleted ones in a single segment
+		for i := 0; i < 4000; i++ {
+			require.Nil(t, b.Put(key(i), value))
+		}
+		for i := 1000; i < 4000; i++ {
+			require.Nil(t, b.Delete(key(i)))
+		}
+		require.Nil(t, b.FlushAndSwitch())
+		require.Len(t, b.disk.segments, 1)
+		sizeBefore := b.disk.segments[0].size
+
+		compacted, err := b.disk.gcCompactOnce(noAbort)
+		require.Nil(t, err)
+		require.True(t, compacted)
+
+		require.Len(t, b.disk.segments, 1)
+		assert.Less(t, b.disk.segments[0].size, sizeBefore)
+		keys, tombstones, err := b.disk.segments[0].tombstoneStats()
+		require.Nil(t, err)
+		assert.Equal(t, 1000, keys)
+		assert.Equal(t, 0, tombstones)
+
+		for i := 0; i < 4000; i++ {
+			v, err := b.Get(key(i))
+			require.Nil(t, err)
+			if i < 1000 {
+				assert.Equal(t, value, v)
+			} else {
+				assert.Nil(t, v)
+			}
+		}
+
+		// the rewritten segment is not picked again
+		compacted, err = b.disk.gcCompactOnce(noAbort)
+		require.Nil(t, err)
+		assert.False(t, compacted)
+	})
+
+	t.Run("tombstones shadowing lower segments are kept", func(t *testing.T) {
+		b := newBucket(t)
+
+		for i := 0; i < 100; i++ {
+			require.Nil(t, b.Put(key(i), value))
+		}
+		require.Nil(t, b.FlushAndSwitch())
+
+		// deletes keys of the first segment and keys which never existed
+		for i := 50; i < 150; i++ {
+			require.Nil(t, b.Delete(key(i)))
+		}
+		require.Nil(t, b.FlushAndSwitch())
+		require.Len(t, b.disk.segments, 2)
+
+		compacted, err := b.disk.gcCompactOnce(noAbort)
+		require.Nil(t, err)
+		require.True(t, compacted)
+
+		keys, tombstones, err := b.disk.segments[1].tombstoneStats()
+		require.Nil(t, err)
+		assert.Equal(t, 50, keys)
+		assert.Equal(t, 50, tombstones)
+
+		for i := 0; i < 150; i++ {
+			v, err := b.Get(key(i))
+			require.Nil(t, err)
+			if i < 50 {
+				assert.Equal(t, value, v)
+			} else {
+				assert.Nil(t, v, "key %d", i)
+			}
+		}
+	})
+
+	t.Run("skipped when tombstones are kept", func(t *testing.T) {
+		b := newBucket(t, WithKeepTombstones(true))
+
+		for i := 0; i < 10; i++ {
+			require.Nil(t, b.Put(key(i), value))
+			require.Nil(t, b.Delete(key(i)))
+		}
+		require.Nil(t, b.FlushAndSwitch())
+
+		compacted, err := b.disk.gcCompactOnce(noAbort)
+		require.Nil(t, err)
+		assert.False(t, compacted)
+	})
+
+	t.Run("rewritten segment is not picked again after a restart", func(t *testing.T) {
+		dir := t.TempDir()
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+
+		for i := 0; i < 100; i++ {
+			require.Nil(t, b.Put(key(i), value))
+		}
+		require.Nil(t, b.FlushAndSwitch())
+		// the remaining tombstones shadow the first segment, so the ratio of
+		// the rewritten segment stays above the threshold
+		for i := 50; i < 150; i++ {
+			require.Nil(t, b.Delete(key(i)))
+		}
+		require.Nil(t, b.FlushAndSwitch())
+
+		compacted, err := b.disk.gcCompactOnce(noAbort)
+		require.Nil(t, err)
+		require.True(t, compacted)
+		require.Nil(t, b.Shutdown(ctx))
+
+		b = newBucketAt(t, dir)
+		require.Len(t, b.disk.segments, 2)
+		assert.False(t, b.disk.segments[0].tombstonesCollected.Load())
+		assert.True(t, b.disk.segments[1].tombstonesCollected.Load())
+
+		compacted, err = b.disk.gcCompactOnce(noAbort)
+		require.Nil(t, err)
+		assert.False(t, compacted)
+
+		// a regular compaction drops the marker
+		compacted, err = b.disk.compactOnce()
+		require.Nil(t, err)
+		require.True(t, compacted)
+		require.Nil(t, b.Shutdown(ctx))
+
+		b = newBucketAt(t, dir)
+		require.Len(t, b.disk.segments, 1)
+		assert.False(t, b.disk.segments[0].tombstonesCollected.Load())
+	})
+}
@@ -0,0 +1,67 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+// BenchmarkSegmentGroupGet compares reads with and without debug logging.
+// Without metrics and debug logging, the individual segment reads are not
+// timed, so the "info" case shows the read path without timing overhead.
+func BenchmarkSegmentGroupGet(b *testing.B) {
+	const (
+		segmentCount   = 8
+		keysPerSegment = 1_000
+	)
+
+	for _, level := range []logrus.Level{logrus.InfoLevel, logrus.DebugLevel} {
+		b.Run(level.String(), func(b *testing.B) {
+			ctx := context.Background()
+			logger, _ := test.NewNullLogger()
+			logger.SetLevel(level)
+
+			bucket, err := NewBucketCreator().NewBucket(ctx, b.TempDir(), "", logger, nil,
+				cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+				WithStrategy(StrategyReplace))
+			require.Nil(b, err)
+			defer bucket.Shutdown(ctx)
+
+			value := make([]byte, 16)
+			for s := 0; s < segmentCount; s++ {
+				for i := 0; i < keysPerSegment; i++ {
+					key := make([]byte, 8)
+					binary.BigEndian.PutUint64(key, uint64(s*keysPerSegment+i))
+					require.Nil(b, bucket.Put(key, value))
+				}
+				require.Nil(b, bucket.FlushAndSwitch())
+			}
+
+			// keys of the oldest segment probe all segments
+			key := make([]byte, 8)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				binary.BigEndian.PutUint64(key, uint64(i%keysPerSegment))
+				if _, err := bucket.disk.get(key); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
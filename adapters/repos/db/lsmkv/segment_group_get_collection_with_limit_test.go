@@ -0,0 +1,132 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_GetCollectionWithLimit(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategySetCollection))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	key := []byte("key")
+
+	require.Nil(t, b.SetAdd(key, [][]byte{[]byte("a"), []byte("b"), []byte("c")}))
+	require.Nil(t, b.FlushAndSwitch())
+
+	require.Nil(t, b.SetAdd(key, [][]byte{[]byte("d")}))
+	require.Nil(t, b.SetDeleteSingle(key, []byte("b")))
+	require.Nil(t, b.FlushAndSwitch())
+
+	// "a" is added again, so it is the most recent value
+	require.Nil(t, b.SetAdd(key, [][]byte{[]byte("e"), []byte("a")}))
+	require.Nil(t, b.FlushAndSwitch())
+
+	valuesOf := func(values []value) []string {
+		out := make([]string, len(values))
+		for i, v := range values {
+			assert.False(t, v.tombstone)
+			out[i] = string(v.value)
+		}
+		return out
+	}
+
+	t.Run("limit hit keeps the most recent values", func(t *testing.T) {
+		values, truncated, _, err := b.disk.getCollectionWithLimit(key, 2)
+		require.Nil(t, err)
+		assert.True(t, truncated)
+		assert.Equal(t, []string{"e", "a"}, valuesOf(values))
+
+		// the deleted value "b" does not count towards the limit
+		values, truncated, _, err = b.disk.getCollectionWithLimit(key, 3)
+		require.Nil(t, err)
+		assert.True(t, truncated)
+		assert.Equal(t, []string{"d", "e", "a"}, valuesOf(values))
+	})
+
+	t.Run("limit not hit", func(t *testing.T) {
+		for _, limit := range []int{4, 10} {
+			values, truncated, _, err := b.disk.getCollectionWithLimit(key, limit)
+			require.Nil(t, err)
+			assert.False(t, truncated)
+			assert.Equal(t, []string{"c", "d", "e", "a"}, valuesOf(values))
+		}
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		values, truncated, _, err := b.disk.getCollectionWithLimit([]byte("missing"), 10)
+		require.Nil(t, err)
+		assert.False(t, truncated)
+		assert.Empty(t, values)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		_, _, _, err := b.disk.getCollectionWithLimit(key, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestSegmentGroup_GetCollectionWithLimit_Map(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyMapCollection))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	key := []byte("key")
+	pair := func(k, v string) MapPair {
+		return MapPair{Key: []byte(k), Value: []byte(v)}
+	}
+
+	require.Nil(t, b.MapSetMulti(key, []MapPair{pair("a", "1"), pair("b", "1"), pair("c", "1")}))
+	require.Nil(t, b.FlushAndSwitch())
+
+	// map keys are updated with different values, they must not be counted
+	// twice
+	require.Nil(t, b.MapSetMulti(key, []MapPair{pair("a", "2"), pair("b", "2")}))
+	require.Nil(t, b.MapDeleteKey(key, []byte("c")))
+	require.Nil(t, b.FlushAndSwitch())
+
+	pairsOf := func(values []value) []MapPair {
+		out := make([]MapPair, len(values))
+		for i, v := range values {
+			require.Nil(t, out[i].FromBytes(v.value, false))
+		}
+		return out
+	}
+
+	values, truncated, _, err := b.disk.getCollectionWithLimit(key, 2)
+	require.Nil(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, []MapPair{pair("a", "2"), pair("b", "2")}, pairsOf(values))
+
+	values, truncated, _, err = b.disk.getCollectionWithLimit(key, 1)
+	require.Nil(t, err)
+	assert.True(t, truncated)
+	assert.Equal(t, []MapPair{pair("b", "2")}, pairsOf(values))
+}
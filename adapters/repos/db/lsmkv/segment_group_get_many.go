@@ -0,0 +1,160 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+	"github.com/weaviate/weaviate/entities/lsmkv"
+)
+
+// GetMany is the bulk variant of get. values[i] is the value of keys[i], nil
+// if the key does not exist or was deleted. The maintenance lock is acquired
+// only once for all keys, it is subject to the same timeout as get. Like get,
+// keys in the negative cache are not looked up and keys resolved as deleted
+// or missing are added to it.
+//
+// Instead of probing all segments for one key after the other, the segments
+// are processed from newest to oldest and the bloom filter of each segment is
+// tested for all unresolved keys at once, before any index lookups. This way
+// a filter is loaded into the CPU caches once per batch rather than once per
+// key, see BenchmarkSegmentGroupGetMany. Likewise, all candidates are looked
+// up in the index before any value is read, so that the OS can be advised to
+// read the values of mmapped segments ahead, see prefetchNodes.
+func (sg *SegmentGroup) GetMany(keys [][]byte) ([][]byte, error) {
+	tookLock, err := sg.rLockWithTimeout("get", "")
+	if err != nil {
+		return nil, err
+	}
+	if threshold := sg.getSlowPathThreshold(); tookLock > threshold {
+		sg.logger.WithField("duration", tookLock).
+			WithField("action", "lsm_segment_group_get_many_obtain_maintenance_lock").
+			Debugf("waited over %s to obtain maintenance lock in segment group GetMany()", threshold)
+	}
+	defer sg.maintenanceLock.RUnlock()
+
+	values := make([][]byte, len(keys))
+
+	// positions of the keys which were not found in any of the newer segments
+	pending := make([]int, 0, len(keys))
+	for i := range keys {
+		if !sg.negativeCache.contains(keys[i]) {
+			pending = append(pending, i)
+		}
+	}
+	candidates := make([]int, 0, len(keys))
+
+	// positions of the keys found in the index of the current segment and
+	// their index nodes
+	var found []int
+	var nodes []segmentindex.Node
+
+	for i := len(sg.segments) - 1; i >= 0 && len(pending) > 0; i-- {
+		seg := sg.segments[i]
+		if seg.strategy != segmentindex.StrategyReplace {
+			return nil, fmt.Errorf("get only possible for strategy %q", StrategyReplace)
+		}
+
+		before := time.Now()
+		candidates = seg.bloomFilterCandidates(keys, pending, candidates[:0], before)
+		if len(candidates) == 0 {
+			continue
+		}
+
+		// candidates are a subsequence of pending, so both can be walked in
+		// lockstep. Keys found in the index of this segment are resolved in it,
+		// either to a value or a tombstone, and are dropped from pending in
+		// place.
+		found, nodes = found[:0], nodes[:0]
+		stillPending := pending[:0]
+		c := 0
+		for _, pos := range pending {
+			if c < len(candidates) && candidates[c] == pos {
+				c++
+				node, err := seg.lookupIndexNode(keys[pos], before)
+				if err == nil {
+					found = append(found, pos)
+					nodes = append(nodes, node)
+					continue
+				}
+				if !errors.Is(err, lsmkv.NotFound) {
+					return nil, err
+				}
+			}
+			stillPending = append(stillPending, pos)
+		}
+		pending = stillPending
+
+		seg.prefetchNodes(nodes)
+		for j, pos := range found {
+			v, err := seg.readValue(nodes[j], before)
+			if err != nil && !errors.Is(err, lsmkv.Deleted) {
+				return nil, err
+			}
+			values[pos] = v
+		}
+	}
+
+	// the segments can't change while the lock is held, so the keys without a
+	// value are known to be deleted or missing until the next segment change.
+	// Keys which are cached already are skipped by add.
+	for i, v := range values {
+		if v == nil {
+			sg.negativeCache.add(keys[i])
+		}
+	}
+
+	return values, nil
+}
+
+// bloomFilterCandidates appends the positions in pending of the keys which
+// may be contained in the segment according to its bloom filter to out. All
+// keys are candidates if the segment has no bloom filter.
+func (s *segment) bloomFilterCandidates(keys [][]byte, pending, out []int,
+	before time.Time,
+) []int {
+	if !s.useBloomFilter {
+		return append(out, pending...)
+	}
+
+	negatives := 0
+	for _, pos := range pending {
+		if s.bloomFilter.Test(keys[pos]) {
+			out = append(out, pos)
+		} else {
+			negatives++
+		}
+	}
+
+	// metrics are recorded after the batch, so they don't evict the filter
+	for i := 0; i < negatives; i++ {
+		s.bloomFilterMetrics.trueNegative(before)
+	}
+	return out
+}
+
+// prefetchNodes advises the OS that the nodes are about to be read, so the
+// pages of all values of a batch are read concurrently rather than faulted in
+// one after the other. It only applies to mmapped contents, the advice is a
+// hint and failures are ignored.
+func (s *segment) prefetchNodes(nodes []segmentindex.Node) {
+	if !s.mmapContents || len(nodes) < 2 {
+		return
+	}
+
+	for _, node := range nodes {
+		madviseWillNeed(s.contents, node.Start, node.End)
+	}
+}
@@ -0,0 +1,141 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/edsrzf/mmap-go"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestBucketGetMany(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	for _, tc := range []struct {
+		useBloomFilter bool
+		pread          bool
+	}{
+		{useBloomFilter: true},
+		{useBloomFilter: false},
+		{useBloomFilter: true, pread: true},
+	} {
+		t.Run(fmt.Sprintf("bloom filter=%v pread=%v", tc.useBloomFilter, tc.pread), func(t *testing.T) {
+			b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+				cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+				WithStrategy(StrategyReplace), WithUseBloomFilter(tc.useBloomFilter), WithPread(tc.pread))
+			require.Nil(t, err)
+			defer b.Shutdown(ctx)
+
+			// segment 1
+			for i := 0; i < 10; i++ {
+				require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%d", i)), []byte("v1")))
+			}
+			require.Nil(t, b.FlushAndSwitch())
+
+			// segment 2 updates key-1 and deletes key-2
+			require.Nil(t, b.Put([]byte("key-1"), []byte("v2")))
+			require.Nil(t, b.Delete([]byte("key-2")))
+			require.Nil(t, b.FlushAndSwitch())
+
+			// segment 3 recreates key-2
+			require.Nil(t, b.Put([]byte("key-2"), []byte("v3")))
+			require.Nil(t, b.FlushAndSwitch())
+
+			// the memtable updates key-3 and deletes key-4
+			require.Nil(t, b.Put([]byte("key-3"), []byte("memtable")))
+			require.Nil(t, b.Delete([]byte("key-4")))
+
+			keys := [][]byte{
+				[]byte("key-0"), []byte("key-1"), []byte("key-2"), []byte("key-3"),
+				[]byte("key-4"), []byte("missing"), []byte("key-1"),
+			}
+			values, err := b.GetMany(keys)
+			require.Nil(t, err)
+
+			expected := [][]byte{
+				[]byte("v1"), []byte("v2"), []byte("v3"), []byte("memtable"),
+				nil, nil, []byte("v2"),
+			}
+			assert.Equal(t, expected, values)
+
+			// same results as one Get per key
+			for i, key := range keys {
+				v, err := b.Get(key)
+				require.Nil(t, err)
+				assert.Equal(t, v, values[i])
+			}
+		})
+	}
+
+	t.Run("no keys", func(t *testing.T) {
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		defer b.Shutdown(ctx)
+
+		values, err := b.GetMany(nil)
+		require.Nil(t, err)
+		assert.Empty(t, values)
+	})
+}
+
+func TestSegmentGroupGetMany(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	// values larger than a page, so that each value is prefetched separately
+	value := func(i int) []byte {
+		return bytes.Repeat([]byte{byte(i)}, 5000)
+	}
+	for i := 0; i < 20; i++ {
+		require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%02d", i)), value(i)))
+	}
+	require.Nil(t, b.FlushAndSwitch())
+	require.Nil(t, b.Delete([]byte("key-05")))
+	require.Nil(t, b.FlushAndSwitch())
+
+	keys := [][]byte{[]byte("key-19"), []byte("key-05"), []byte("missing"), []byte("key-00")}
+	values, err := b.disk.GetMany(keys)
+	require.Nil(t, err)
+	require.Len(t, values, len(keys))
+	assert.Equal(t, [][]byte{value(19), nil, nil, value(0)}, values)
+}
+
+func TestMadviseWillNeed(t *testing.T) {
+	contents, err := mmap.MapRegion(nil, 3*os.Getpagesize(), mmap.RDWR, mmap.ANON, 0)
+	require.Nil(t, err)
+	defer contents.Unmap()
+
+	page := uint64(os.Getpagesize())
+	// unaligned, out of bounds and empty ranges must neither fail nor panic
+	madviseWillNeed(contents, 10, 20)
+	madviseWillNeed(contents, page+1, 2*page+1)
+	madviseWillNeed(contents, 2*page, 10*page)
+	madviseWillNeed(contents, 4*page, 5*page)
+	madviseWillNeed(contents, 5, 5)
+}
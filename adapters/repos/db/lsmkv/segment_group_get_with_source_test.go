@@ -0,0 +1,77 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_GetWithSource(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	require.Nil(t, b.Put([]byte("overwritten"), []byte("v1")))
+	require.Nil(t, b.Put([]byte("unchanged"), []byte("v1")))
+	require.Nil(t, b.Put([]byte("deleted"), []byte("v1")))
+	require.Nil(t, b.FlushAndSwitch())
+
+	require.Nil(t, b.Put([]byte("overwritten"), []byte("v2")))
+	require.Nil(t, b.Delete([]byte("deleted")))
+	require.Nil(t, b.FlushAndSwitch())
+
+	require.Nil(t, b.Put([]byte("other"), []byte("v1")))
+	require.Nil(t, b.FlushAndSwitch())
+
+	require.Equal(t, 3, b.disk.Len())
+	firstID := segmentID(b.disk.segmentAtPos(0).path)
+	secondID := segmentID(b.disk.segmentAtPos(1).path)
+
+	t.Run("key in multiple segments resolves to the latest", func(t *testing.T) {
+		v, id, err := b.disk.getWithSource([]byte("overwritten"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("v2"), v)
+		assert.Equal(t, secondID, id)
+	})
+
+	t.Run("key in a single segment", func(t *testing.T) {
+		v, id, err := b.disk.getWithSource([]byte("unchanged"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("v1"), v)
+		assert.Equal(t, firstID, id)
+	})
+
+	t.Run("deleted key reports the tombstone's segment", func(t *testing.T) {
+		v, id, err := b.disk.getWithSource([]byte("deleted"))
+		require.Nil(t, err)
+		assert.Nil(t, v)
+		assert.Equal(t, secondID, id)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		v, id, err := b.disk.getWithSource([]byte("missing"))
+		require.Nil(t, err)
+		assert.Nil(t, v)
+		assert.Empty(t, id)
+	})
+}
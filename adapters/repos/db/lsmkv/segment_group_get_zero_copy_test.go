@@ -0,0 +1,141 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"unsafe"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_GetZeroCopy(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(t testing.TB, opts ...BucketOption) *Bucket {
+		opts = append([]BucketOption{WithStrategy(StrategyReplace)}, opts...)
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			opts...)
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+
+		require.Nil(t, b.Put([]byte("key1"), []byte("value1")))
+		require.Nil(t, b.Put([]byte("key2"), []byte("value2")))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.Put([]byte("key1"), []byte("updated1")))
+		require.Nil(t, b.Delete([]byte("key2")))
+		require.Nil(t, b.FlushAndSwitch())
+		return b
+	}
+
+	// within reports whether value is backed by the contents of the segment
+	within := func(value []byte, seg *segment) bool {
+		start := uintptr(unsafe.Pointer(unsafe.SliceData(seg.contents)))
+		ptr := uintptr(unsafe.Pointer(unsafe.SliceData(value)))
+		return ptr >= start && ptr < start+uintptr(len(seg.contents))
+	}
+
+	t.Run("mmap", func(t *testing.T) {
+		b := newBucket(t)
+		require.True(t, b.disk.mmapContents)
+
+		value, release, err := b.disk.getZeroCopy([]byte("key1"))
+		require.Nil(t, err)
+		assert.Equal(t, "updated1", string(value))
+		assert.True(t, within(value, b.disk.segments[1]))
+
+		// the maintenance lock is held until the value is released
+		assert.False(t, b.disk.maintenanceLock.TryLock())
+		release()
+		require.True(t, b.disk.maintenanceLock.TryLock())
+		b.disk.maintenanceLock.Unlock()
+	})
+
+	t.Run("pread falls back to a copy", func(t *testing.T) {
+		b := newBucket(t, WithPread(true))
+
+		value, release, err := b.disk.getZeroCopy([]byte("key1"))
+		require.Nil(t, err)
+		assert.Equal(t, "updated1", string(value))
+		release()
+	})
+
+	t.Run("deleted and missing keys", func(t *testing.T) {
+		b := newBucket(t)
+
+		for _, key := range []string{"key2", "missing"} {
+			value, release, err := b.disk.getZeroCopy([]byte(key))
+			require.Nil(t, err)
+			assert.Nil(t, value)
+			// the lock is already released, release is a no-op
+			require.True(t, b.disk.maintenanceLock.TryLock())
+			b.disk.maintenanceLock.Unlock()
+			release()
+		}
+	})
+}
+
+func BenchmarkSegmentGroupGetZeroCopy(b *testing.B) {
+	const (
+		keyCount  = 10_000
+		valueSize = 4 * 1024
+	)
+
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	bucket, err := NewBucketCreator().NewBucket(ctx, b.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace))
+	require.Nil(b, err)
+	defer bucket.Shutdown(ctx)
+
+	keys := make([][]byte, keyCount)
+	value := make([]byte, valueSize)
+	for i := range keys {
+		keys[i] = binary.BigEndian.AppendUint64(nil, uint64(i))
+		require.Nil(b, bucket.Put(keys[i], value))
+	}
+	require.Nil(b, bucket.FlushAndSwitch())
+
+	var sink int
+	b.Run("get", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			v, err := bucket.disk.get(keys[i%keyCount])
+			if err != nil {
+				b.Fatal(err)
+			}
+			sink += len(v)
+		}
+	})
+
+	b.Run("getZeroCopy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			v, release, err := bucket.disk.getZeroCopy(keys[i%keyCount])
+			if err != nil {
+				b.Fatal(err)
+			}
+			sink += len(v)
+			release()
+		}
+	})
+	_ = sink
+}
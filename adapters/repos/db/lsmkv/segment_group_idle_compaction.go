@@ -0,0 +1,124 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+	enterrors "github.com/weaviate/weaviate/entities/errors"
+)
+
+// idleCompactionTrigger runs the compaction of a segment group once writes
+// have gone quiet, instead of waiting for the next tick of the compaction
+// cycle, which may be far away after the cycle backed off. This reduces the
+// read amplification right after a bulk load.
+//
+// The trigger is armed when adding a segment pushes the number of segments
+// above threshold. Every further segment restarts the delay, so compaction
+// only starts after no segment was added for delay. It then runs until there
+// is nothing left to compact. Regular cycle runs are serialized with it by the
+// compactionLock.
+type idleCompactionTrigger struct {
+	sync.Mutex
+
+	threshold int
+	delay     time.Duration
+	run       func(shouldAbort cyclemanager.ShouldAbortCallback) bool
+	logger    logrus.FieldLogger
+
+	timer   *time.Timer
+	closed  bool
+	running sync.WaitGroup
+}
+
+func newIdleCompactionTrigger(threshold int, delay time.Duration,
+	run func(shouldAbort cyclemanager.ShouldAbortCallback) bool,
+	logger logrus.FieldLogger,
+) *idleCompactionTrigger {
+	return &idleCompactionTrigger{
+		threshold: threshold,
+		delay:     delay,
+		run:       run,
+		logger:    logger,
+	}
+}
+
+// segmentAdded (re)arms the trigger if segmentCount exceeds the threshold.
+// It is a no-op on a nil trigger, i.e. if idle compaction is disabled.
+func (t *idleCompactionTrigger) segmentAdded(segmentCount int) {
+	if t == nil || segmentCount <= t.threshold {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if t.closed {
+		return
+	}
+	if t.timer == nil {
+		t.timer = time.AfterFunc(t.delay, t.fire)
+		return
+	}
+	t.timer.Reset(t.delay)
+}
+
+func (t *idleCompactionTrigger) fire() {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.closed {
+		return
+	}
+
+	t.running.Add(1)
+	enterrors.GoWrapper(func() {
+		defer t.running.Done()
+
+		t.logger.WithField("action", "lsm_compaction_idle").
+			WithField("delay", t.delay).
+			Debug("no segments added recently, starting compaction")
+
+		for !t.isClosed() {
+			if !t.run(t.isClosed) {
+				return
+			}
+		}
+	}, t.logger)
+}
+
+func (t *idleCompactionTrigger) isClosed() bool {
+	t.Lock()
+	defer t.Unlock()
+
+	return t.closed
+}
+
+// close disarms the trigger and waits for a running compaction to finish. It
+// is a no-op on a nil trigger.
+func (t *idleCompactionTrigger) close() {
+	if t == nil {
+		return
+	}
+
+	t.Lock()
+	t.closed = true
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.Unlock()
+
+	t.running.Wait()
+}
@@ -0,0 +1,112 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_IdleCompaction(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+	delay := 200 * time.Millisecond
+
+	// the compaction cycle never runs, only the idle trigger can compact
+	newBucket := func(t *testing.T, opts ...BucketOption) *Bucket {
+		opts = append([]BucketOption{WithStrategy(StrategyReplace)}, opts...)
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(), opts...)
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+		return b
+	}
+
+	burst := func(t *testing.T, b *Bucket, segments int) {
+		for i := 0; i < segments; i++ {
+			key := []byte(fmt.Sprintf("key-%d", i))
+			require.Nil(t, b.Put(key, []byte("value")))
+			require.Nil(t, b.FlushAndSwitch())
+		}
+	}
+
+	t.Run("compacts after a burst of writes went quiet", func(t *testing.T) {
+		b := newBucket(t, WithIdleCompaction(2, delay))
+
+		burst(t, b, 4)
+		// still within the delay of the last segment
+		assert.Equal(t, 4, b.disk.Len())
+
+		assert.Eventually(t, func() bool {
+			return b.disk.Len() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		for i := 0; i < 4; i++ {
+			value, err := b.Get([]byte(fmt.Sprintf("key-%d", i)))
+			require.Nil(t, err)
+			assert.Equal(t, []byte("value"), value)
+		}
+	})
+
+	t.Run("does not compact below the threshold", func(t *testing.T) {
+		b := newBucket(t, WithIdleCompaction(4, delay))
+
+		burst(t, b, 4)
+		time.Sleep(3 * delay)
+		assert.Equal(t, 4, b.disk.Len())
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		b := newBucket(t)
+
+		burst(t, b, 4)
+		time.Sleep(3 * delay)
+		assert.Equal(t, 4, b.disk.Len())
+	})
+
+	t.Run("shutdown disarms the trigger", func(t *testing.T) {
+		dir := t.TempDir()
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace), WithIdleCompaction(2, delay))
+		require.Nil(t, err)
+
+		burst(t, b, 4)
+		require.Nil(t, b.Shutdown(ctx))
+		assert.True(t, b.disk.idleCompaction.isClosed())
+
+		time.Sleep(3 * delay)
+		segmentFiles, err := filepath.Glob(filepath.Join(dir, "segment-*.db"))
+		require.Nil(t, err)
+		assert.Len(t, segmentFiles, 4)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithIdleCompaction(-1, delay))
+		require.Error(t, err)
+
+		_, err = NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithIdleCompaction(2, 0))
+		require.Error(t, err)
+	})
+}
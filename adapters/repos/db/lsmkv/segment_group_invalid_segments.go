@@ -0,0 +1,74 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+)
+
+// InvalidSegmentPolicy controls what happens to segment files which are
+// obviously invalid on mount, e.g. a 0-byte file left behind by a crash.
+type InvalidSegmentPolicy string
+
+const (
+	// InvalidSegmentPolicyQuarantine renames invalid segments, so that they are
+	// kept for investigation but no longer mounted. This is the default.
+	InvalidSegmentPolicyQuarantine InvalidSegmentPolicy = "quarantine"
+	// InvalidSegmentPolicyDelete deletes invalid segments.
+	InvalidSegmentPolicyDelete InvalidSegmentPolicy = "delete"
+)
+
+// QuarantineSuffix is appended to the name of segment files which were
+// quarantined on mount.
+const QuarantineSuffix = ".quarantined"
+
+// isInvalidSegmentSize reports whether a segment file is too small to even
+// hold a header, such a file cannot be mounted.
+func isInvalidSegmentSize(size int64) bool {
+	return size < segmentindex.HeaderSize
+}
+
+// handleInvalidSegment quarantines or deletes the segment at path according
+// to the configured policy, so that mounting the remaining segments can
+// continue.
+func (sg *SegmentGroup) handleInvalidSegment(path string, size int64) error {
+	logger := sg.logger.WithFields(logrus.Fields{
+		"action": "lsm_segment_init_invalid_segment",
+		"path":   path,
+		"size":   size,
+		"policy": sg.invalidSegmentPolicy,
+	})
+
+	switch sg.invalidSegmentPolicy {
+	case InvalidSegmentPolicyDelete:
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("delete invalid segment %s: %w", path, err)
+		}
+		logger.Error("deleted invalid segment, it is too small to hold a segment header")
+	case InvalidSegmentPolicyQuarantine, "":
+		if err := os.Rename(path, path+QuarantineSuffix); err != nil {
+			return fmt.Errorf("quarantine invalid segment %s: %w", path, err)
+		}
+		logger.Error("quarantined invalid segment, it is too small to hold a segment header")
+	default:
+		return fmt.Errorf("unknown invalid segment policy %q", sg.invalidSegmentPolicy)
+	}
+
+	if err := fsync(sg.dir); err != nil {
+		return fmt.Errorf("fsync segment directory %s: %w", sg.dir, err)
+	}
+	return nil
+}
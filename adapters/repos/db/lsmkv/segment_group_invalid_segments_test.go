@@ -0,0 +1,104 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_InvalidSegmentsOnMount(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	invalidSegments := map[string][]byte{
+		"segment-0000000000000000001.db": {},
+		"segment-0000000000000000002.db": make([]byte, segmentindex.HeaderSize-1),
+	}
+
+	prepare := func(t *testing.T) string {
+		dir := t.TempDir()
+
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		require.Nil(t, b.Put([]byte("key"), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.Shutdown(ctx))
+
+		for name, contents := range invalidSegments {
+			require.Nil(t, os.WriteFile(filepath.Join(dir, name), contents, 0o666))
+		}
+		return dir
+	}
+
+	open := func(t *testing.T, dir string, opts ...BucketOption) *Bucket {
+		opts = append(opts, WithStrategy(StrategyReplace))
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(), opts...)
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+
+		require.Equal(t, 1, b.disk.Len())
+		v, err := b.Get([]byte("key"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value"), v)
+		return b
+	}
+
+	t.Run("quarantine by default", func(t *testing.T) {
+		dir := prepare(t)
+		open(t, dir)
+
+		for name := range invalidSegments {
+			assert.NoFileExists(t, filepath.Join(dir, name))
+			assert.FileExists(t, filepath.Join(dir, name+QuarantineSuffix))
+		}
+	})
+
+	t.Run("quarantined segments are not backed up", func(t *testing.T) {
+		dir := prepare(t)
+		b := open(t, dir)
+
+		files, err := b.ListFiles(ctx, dir)
+		require.Nil(t, err)
+		for _, file := range files {
+			assert.NotEqual(t, QuarantineSuffix, filepath.Ext(file))
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		dir := prepare(t)
+		open(t, dir, WithInvalidSegmentPolicy(InvalidSegmentPolicyDelete))
+
+		for name := range invalidSegments {
+			assert.NoFileExists(t, filepath.Join(dir, name))
+			assert.NoFileExists(t, filepath.Join(dir, name+QuarantineSuffix))
+		}
+	})
+
+	t.Run("unknown policy", func(t *testing.T) {
+		_, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithInvalidSegmentPolicy("ignore"))
+		assert.ErrorContains(t, err, "unknown invalid segment policy")
+	})
+}
@@ -0,0 +1,341 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	enterrors "github.com/weaviate/weaviate/entities/errors"
+	"github.com/weaviate/weaviate/entities/lsmkv"
+)
+
+type IteratorOpts struct {
+	// Reverse iterates from the highest to the lowest key
+	Reverse bool
+	// PrefetchAhead reads up to that many entries ahead of the current position
+	// in the background, which hides the read latency when segments are not
+	// mmapped. 0 disables prefetching.
+	PrefetchAhead int
+}
+
+// SegmentGroupIterator merges the primary indexes of all segments of a
+// "replace" segment group into a single ordered stream of keys. If a key is
+// present in multiple segments, only the value of the latest segment is
+// served. Deleted keys are skipped.
+//
+// The iterator holds a RLock on the maintenance lock of the segment group. It
+// needs to be closed using .Close() or otherwise the lock will never be
+// released.
+type SegmentGroupIterator struct {
+	cursors []segmentIndexCursor
+	heap    *segmentIndexCursorHeap
+	reverse bool
+	started bool
+	key     []byte
+	value   []byte
+	err     error
+	unlock  func()
+
+	// if set, values are read into valueBuf and are only valid until the next
+	// call of Next()
+	reuseValues bool
+	valueBuf    []byte
+
+	// if set, entries are merged in the background and only dequeued by Next()
+	prefetcher *iteratorPrefetcher
+}
+
+func (sg *SegmentGroup) Iterator(opts IteratorOpts) *SegmentGroupIterator {
+	if sg.strategy != StrategyReplace {
+		panic("Iterator() called on strategy other than 'replace'")
+	}
+
+	sg.maintenanceLock.RLock()
+
+	cursors := make([]segmentIndexCursor, len(sg.segments))
+	for i, segment := range sg.segments {
+		cursor, err := segment.newIndexCursor()
+		if err != nil {
+			sg.maintenanceLock.RUnlock()
+			// the iterator holds no entries and reports the error through Err
+			return &SegmentGroupIterator{err: err, unlock: func() {}}
+		}
+		cursors[i] = cursor
+	}
+
+	it := &SegmentGroupIterator{
+		cursors: cursors,
+		heap: &segmentIndexCursorHeap{
+			reverse: opts.Reverse,
+			items:   make([]segmentIndexCursorHeapItem, 0, len(cursors)),
+		},
+		reverse: opts.Reverse,
+		unlock:  sg.maintenanceLock.RUnlock,
+	}
+	if opts.PrefetchAhead <= 0 {
+		return it
+	}
+
+	// the merging iterator is driven by the prefetcher, the returned iterator
+	// only dequeues its entries
+	merger := it
+	merger.unlock = func() {}
+	return &SegmentGroupIterator{
+		reverse:    opts.Reverse,
+		unlock:     sg.maintenanceLock.RUnlock,
+		prefetcher: newIteratorPrefetcher(merger, opts.PrefetchAhead, sg.logger),
+	}
+}
+
+// Next advances the iterator and reports whether a key is available. The
+// first call positions the iterator at the lowest key (or the highest key
+// when iterating in reverse).
+func (it *SegmentGroupIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.prefetcher != nil {
+		return it.nextPrefetched()
+	}
+
+	if !it.started {
+		it.started = true
+		for pos, cursor := range it.cursors {
+			if it.reverse {
+				it.push(pos, cursor.SeekLast())
+			} else {
+				it.push(pos, cursor.First())
+			}
+		}
+		heap.Init(it.heap)
+	}
+
+	for it.heap.Len() > 0 && it.err == nil {
+		// the top of the heap holds the lowest (highest in reverse) key, for
+		// equal keys the latest segment wins
+		top := heap.Pop(it.heap).(segmentIndexCursorHeapItem)
+		key := top.cursor.Key()
+		var value []byte
+		var err error
+		if it.reuseValues {
+			value, it.valueBuf, err = top.cursor.ValueInto(it.valueBuf)
+		} else {
+			value, err = top.cursor.Value()
+		}
+
+		// all older segments containing the same key need to be advanced as
+		// well, otherwise we would encounter the key again
+		for it.heap.Len() > 0 && bytes.Equal(it.heap.items[0].cursor.Key(), key) {
+			dup := heap.Pop(it.heap).(segmentIndexCursorHeapItem)
+			it.advance(dup)
+		}
+
+		// the key is copied before advancing, as it points into the index of the
+		// segment which is only valid for as long as the cursor stays in place
+		it.key = append(it.key[:0], key...)
+		it.advance(top)
+
+		if err != nil {
+			if errors.Is(err, lsmkv.Deleted) {
+				continue
+			}
+			it.err = err
+			return false
+		}
+
+		it.value = value
+		return true
+	}
+
+	it.key = nil
+	it.value = nil
+	return false
+}
+
+func (it *SegmentGroupIterator) push(pos int, ok bool) {
+	if ok {
+		it.heap.items = append(it.heap.items, segmentIndexCursorHeapItem{
+			cursor: it.cursors[pos], segmentPos: pos,
+		})
+		return
+	}
+	if err := it.cursors[pos].Err(); err != nil && it.err == nil {
+		it.err = err
+	}
+}
+
+func (it *SegmentGroupIterator) advance(item segmentIndexCursorHeapItem) {
+	var ok bool
+	if it.reverse {
+		ok = item.cursor.Prev()
+	} else {
+		ok = item.cursor.Next()
+	}
+
+	if ok {
+		heap.Push(it.heap, item)
+		return
+	}
+	if err := item.cursor.Err(); err != nil && it.err == nil {
+		it.err = err
+	}
+}
+
+// Key of the current position. The slice is reused on the next call of Next()
+func (it *SegmentGroupIterator) Key() []byte {
+	return it.key
+}
+
+// Value of the current position
+func (it *SegmentGroupIterator) Value() []byte {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *SegmentGroupIterator) Err() error {
+	return it.err
+}
+
+func (it *SegmentGroupIterator) Close() {
+	if it.prefetcher != nil {
+		// the prefetcher reads from the segments, so it needs to be stopped
+		// before the lock is released
+		it.prefetcher.close()
+	}
+	it.unlock()
+}
+
+func (it *SegmentGroupIterator) nextPrefetched() bool {
+	entry, ok := <-it.prefetcher.entries
+	if !ok {
+		it.key = nil
+		it.value = nil
+		return false
+	}
+	if entry.err != nil {
+		it.err = entry.err
+		it.key = nil
+		it.value = nil
+		return false
+	}
+
+	it.key = entry.key
+	it.value = entry.value
+	return true
+}
+
+// iteratorPrefetcher drives a merging SegmentGroupIterator in the background
+// and buffers up to prefetchAhead entries in a channel, which serves as ring
+// buffer between the prefetching goroutine and the consumer.
+type iteratorPrefetcher struct {
+	entries chan prefetchedEntry
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+type prefetchedEntry struct {
+	key   []byte
+	value []byte
+	err   error
+}
+
+func newIteratorPrefetcher(merger *SegmentGroupIterator, prefetchAhead int,
+	logger logrus.FieldLogger,
+) *iteratorPrefetcher {
+	p := &iteratorPrefetcher{
+		entries: make(chan prefetchedEntry, prefetchAhead),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	enterrors.GoWrapper(func() { p.run(merger) }, logger)
+	return p
+}
+
+func (p *iteratorPrefetcher) run(merger *SegmentGroupIterator) {
+	defer close(p.done)
+	defer close(p.entries)
+
+	for merger.Next() {
+		// the key of the merger is reused on the next call, the value is already
+		// a copy
+		entry := prefetchedEntry{
+			key:   append([]byte{}, merger.Key()...),
+			value: merger.Value(),
+		}
+		if !p.send(entry) {
+			return
+		}
+	}
+	if err := merger.Err(); err != nil {
+		p.send(prefetchedEntry{err: err})
+	}
+}
+
+func (p *iteratorPrefetcher) send(entry prefetchedEntry) bool {
+	select {
+	case p.entries <- entry:
+		return true
+	case <-p.stop:
+		return false
+	}
+}
+
+// close stops the prefetching goroutine and waits for it to exit
+func (p *iteratorPrefetcher) close() {
+	close(p.stop)
+	<-p.done
+}
+
+type segmentIndexCursorHeapItem struct {
+	cursor     segmentIndexCursor
+	segmentPos int
+}
+
+// segmentIndexCursorHeap is a min-heap on the current key of each cursor, or
+// a max-heap if reverse is set. Ties are broken in favor of the latest
+// segment, so that it is always popped first.
+type segmentIndexCursorHeap struct {
+	reverse bool
+	items   []segmentIndexCursorHeapItem
+}
+
+func (h *segmentIndexCursorHeap) Len() int { return len(h.items) }
+
+func (h *segmentIndexCursorHeap) Less(i, j int) bool {
+	cmp := bytes.Compare(h.items[i].cursor.Key(), h.items[j].cursor.Key())
+	if cmp == 0 {
+		return h.items[i].segmentPos > h.items[j].segmentPos
+	}
+	if h.reverse {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+func (h *segmentIndexCursorHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *segmentIndexCursorHeap) Push(x any) {
+	h.items = append(h.items, x.(segmentIndexCursorHeapItem))
+}
+
+func (h *segmentIndexCursorHeap) Pop() any {
+	old := h.items
+	n := len(old)
+	item := old[n-1]
+	h.items = old[:n-1]
+	return item
+}
@@ -0,0 +1,67 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+// BenchmarkSegmentGroupIteratorPrefetch scans a single segment of 1 GiB with
+// mmap disabled, so every value is read from disk.
+func BenchmarkSegmentGroupIteratorPrefetch(b *testing.B) {
+	const (
+		valueSize   = 4 * 1024
+		segmentSize = 1024 * 1024 * 1024
+		keyCount    = segmentSize / valueSize
+	)
+
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	bucket, err := NewBucketCreator().NewBucket(ctx, b.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace), WithPread(true))
+	require.Nil(b, err)
+	defer bucket.Shutdown(ctx)
+
+	value := make([]byte, valueSize)
+	for i := 0; i < keyCount; i++ {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, uint64(i))
+		require.Nil(b, bucket.Put(key, value))
+	}
+	require.Nil(b, bucket.FlushAndSwitch())
+
+	for _, prefetchAhead := range []int{0, 4, 16} {
+		b.Run(fmt.Sprintf("prefetchAhead=%d", prefetchAhead), func(b *testing.B) {
+			b.SetBytes(segmentSize)
+			for i := 0; i < b.N; i++ {
+				it := bucket.disk.Iterator(IteratorOpts{PrefetchAhead: prefetchAhead})
+				count := 0
+				for it.Next() {
+					count++
+				}
+				err := it.Err()
+				it.Close()
+				require.Nil(b, err)
+				require.Equal(b, keyCount, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroupIterator(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	const (
+		keyCount     = 100_000
+		segmentCount = 5
+	)
+
+	keys := make([][]byte, keyCount)
+	for i := range keys {
+		keys[i] = make([]byte, 16)
+		_, err := rand.Read(keys[i])
+		require.Nil(t, err)
+	}
+
+	// spread the keys over multiple segments, then overwrite and delete some
+	// of them in an additional segment, so the iterator needs to merge
+	for s := 0; s < segmentCount; s++ {
+		for i := s; i < keyCount; i += segmentCount {
+			require.Nil(t, b.Put(keys[i], []byte("initial")))
+		}
+		require.Nil(t, b.FlushAndSwitch())
+	}
+
+	expected := map[string]string{}
+	for i, key := range keys {
+		expected[string(key)] = "initial"
+		switch i % 10 {
+		case 0:
+			require.Nil(t, b.Put(key, []byte("updated")))
+			expected[string(key)] = "updated"
+		case 1:
+			require.Nil(t, b.Delete(key))
+			delete(expected, string(key))
+		}
+	}
+	require.Nil(t, b.FlushAndSwitch())
+
+	scan := func(reverse bool, prefetchAhead int) ([][]byte, [][]byte) {
+		it := b.disk.Iterator(IteratorOpts{Reverse: reverse, PrefetchAhead: prefetchAhead})
+		defer it.Close()
+
+		var keys, values [][]byte
+		for it.Next() {
+			keys = append(keys, append([]byte{}, it.Key()...))
+			values = append(values, it.Value())
+		}
+		require.Nil(t, it.Err())
+		return keys, values
+	}
+
+	forwardKeys, forwardValues := scan(false, 0)
+	reverseKeys, reverseValues := scan(true, 0)
+
+	t.Run("forward iteration serves latest values in ascending order", func(t *testing.T) {
+		require.Len(t, forwardKeys, len(expected))
+		for i, key := range forwardKeys {
+			if i > 0 {
+				assert.Negative(t, bytes.Compare(forwardKeys[i-1], key))
+			}
+			assert.Equal(t, expected[string(key)], string(forwardValues[i]))
+		}
+	})
+
+	t.Run("reverse iteration is the exact reverse of forward iteration", func(t *testing.T) {
+		require.Len(t, reverseKeys, len(forwardKeys))
+		for i := range reverseKeys {
+			j := len(forwardKeys) - 1 - i
+			require.Equal(t, forwardKeys[j], reverseKeys[i])
+			require.Equal(t, forwardValues[j], reverseValues[i])
+		}
+	})
+
+	t.Run("prefetching yields the same entries", func(t *testing.T) {
+		for _, prefetchAhead := range []int{1, 16} {
+			keys, values := scan(false, prefetchAhead)
+			assert.Equal(t, forwardKeys, keys)
+			assert.Equal(t, forwardValues, values)
+
+			keys, values = scan(true, prefetchAhead)
+			assert.Equal(t, reverseKeys, keys)
+			assert.Equal(t, reverseValues, values)
+		}
+	})
+
+	t.Run("closing a prefetching iterator early releases the lock", func(t *testing.T) {
+		it := b.disk.Iterator(IteratorOpts{PrefetchAhead: 4})
+		require.True(t, it.Next())
+		it.Close()
+
+		// acquiring the write lock would block if the lock was still held
+		b.disk.maintenanceLock.Lock()
+		b.disk.maintenanceLock.Unlock()
+	})
+}
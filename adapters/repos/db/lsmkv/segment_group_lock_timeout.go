@@ -0,0 +1,61 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrLockTimeout is returned by reads which gave up waiting for the
+// maintenance lock, see WithReadLockTimeout. The segment group is not broken,
+// so callers can treat it as a temporary unavailability, e.g. respond with a
+// 503.
+var ErrLockTimeout = errors.New("timed out waiting for segment group maintenance lock")
+
+const (
+	lockTimeoutInitialBackoff = 100 * time.Microsecond
+	lockTimeoutMaxBackoff     = 20 * time.Millisecond
+)
+
+// rLockWithTimeout is like rLockForKey, but gives up once the configured
+// lockTimeout has passed. The lock is polled with an exponential backoff, as a
+// sync.RWMutex cannot be waited on with a deadline. Without a lockTimeout it
+// waits indefinitely.
+func (sg *SegmentGroup) rLockWithTimeout(op, keyPrefix string) (time.Duration, error) {
+	if sg.lockTimeout <= 0 {
+		return sg.rLockForKey(op, keyPrefix), nil
+	}
+
+	before := time.Now()
+	deadline := before.Add(sg.lockTimeout)
+	backoff := lockTimeoutInitialBackoff
+	for !sg.maintenanceLock.TryRLock() {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			sg.metrics.LockTimeout()
+			return time.Since(before), ErrLockTimeout
+		}
+
+		if backoff > remaining {
+			backoff = remaining
+		}
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > lockTimeoutMaxBackoff {
+			backoff = lockTimeoutMaxBackoff
+		}
+	}
+
+	took := time.Since(before)
+	sg.metrics.RecordLockWait(op, keyPrefix, took)
+	return took, nil
+}
@@ -0,0 +1,98 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_ReadLockTimeout(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(t *testing.T, opts ...BucketOption) *Bucket {
+		opts = append(opts, WithStrategy(StrategyReplace))
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(), opts...)
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+
+		require.Nil(t, b.Put([]byte("key"), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+		return b
+	}
+
+	t.Run("read fails once the timeout expires", func(t *testing.T) {
+		b := newBucket(t, WithReadLockTimeout(20*time.Millisecond))
+
+		b.disk.maintenanceLock.Lock()
+		before := time.Now()
+		_, err := b.disk.get([]byte("key"))
+		took := time.Since(before)
+		b.disk.maintenanceLock.Unlock()
+
+		assert.ErrorIs(t, err, ErrLockTimeout)
+		assert.GreaterOrEqual(t, took, 20*time.Millisecond)
+
+		// the lock is not left acquired
+		require.True(t, b.disk.maintenanceLock.TryLock())
+		b.disk.maintenanceLock.Unlock()
+	})
+
+	t.Run("GetMany fails once the timeout expires", func(t *testing.T) {
+		b := newBucket(t, WithReadLockTimeout(20*time.Millisecond))
+
+		b.disk.maintenanceLock.Lock()
+		_, err := b.disk.GetMany([][]byte{[]byte("key")})
+		b.disk.maintenanceLock.Unlock()
+
+		assert.ErrorIs(t, err, ErrLockTimeout)
+		require.True(t, b.disk.maintenanceLock.TryLock())
+		b.disk.maintenanceLock.Unlock()
+	})
+
+	t.Run("read succeeds once the lock is released in time", func(t *testing.T) {
+		b := newBucket(t, WithReadLockTimeout(time.Second))
+
+		b.disk.maintenanceLock.Lock()
+		time.AfterFunc(20*time.Millisecond, b.disk.maintenanceLock.Unlock)
+
+		v, err := b.disk.get([]byte("key"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value"), v)
+	})
+
+	t.Run("without timeout the read waits", func(t *testing.T) {
+		b := newBucket(t)
+
+		b.disk.maintenanceLock.Lock()
+		time.AfterFunc(50*time.Millisecond, b.disk.maintenanceLock.Unlock)
+
+		v, err := b.disk.get([]byte("key"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value"), v)
+	})
+
+	t.Run("negative timeout is rejected", func(t *testing.T) {
+		_, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace), WithReadLockTimeout(-time.Second))
+		require.NotNil(t, err)
+	})
+}
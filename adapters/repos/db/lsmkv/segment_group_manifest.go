@@ -0,0 +1,217 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// SegmentManifestFile records the order of the segments of a segment group.
+// It is rewritten after every flush and compaction, so that the order does
+// not depend on the file names or the directory listing on startup. Without
+// a manifest, e.g. on the first startup after an upgrade, the segments are
+// ordered by their IDs, see sortSegmentsByID.
+const SegmentManifestFile = "segment_manifest.json"
+
+type segmentManifest struct {
+	// Segments holds the file names of all segments, from oldest to newest
+	Segments []string `json:"segments"`
+
+	// TombstonesCollected holds the file names of the segments rewritten by
+	// the gc compaction, so they are not rewritten again after a restart, see
+	// segment.tombstonesCollected
+	TombstonesCollected []string `json:"tombstonesCollected,omitempty"`
+
+	// version orders snapshots of the same segment group, see
+	// SegmentGroup.manifestVersion
+	version uint64
+}
+
+// loadSegmentManifest reads the manifest in dir. If there is none, e.g. on
+// the first startup after an upgrade, it returns nil and no error.
+func loadSegmentManifest(dir string) (*segmentManifest, error) {
+	// a leftover from an interrupted write, the previous manifest is still
+	// intact
+	if err := os.Remove(filepath.Join(dir, SegmentManifestFile+".tmp")); err != nil &&
+		!errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("delete partially written segment manifest: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, SegmentManifestFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read segment manifest: %w", err)
+	}
+
+	var m segmentManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse segment manifest %s: %w", filepath.Join(dir, SegmentManifestFile), err)
+	}
+	return &m, nil
+}
+
+// sortByManifest orders the directory entries such that segments recorded in
+// the manifest come first, in their recorded order. All other entries, e.g.
+// segments flushed right before a crash and not recorded yet, keep their
+// lexicographic order after them. A nil manifest leaves list untouched.
+func sortByManifest(list []os.DirEntry, m *segmentManifest) {
+	if m == nil {
+		return
+	}
+
+	position := m.positions()
+	sort.SliceStable(list, func(a, b int) bool {
+		return position(list[a].Name()) < position(list[b].Name())
+	})
+}
+
+// sortSegmentsByManifest orders the mounted segments by their position in the
+// manifest. Segments recovered from a compaction carry the name of the right
+// segment, so they take its position. Segments missing in the manifest were
+// flushed after its last update, they come last, ordered by their IDs.
+func sortSegmentsByManifest(segments []*segment, m *segmentManifest) {
+	sortSegmentsByID(segments)
+
+	position := m.positions()
+	sort.SliceStable(segments, func(a, b int) bool {
+		return position(filepath.Base(segments[a].path)) < position(filepath.Base(segments[b].path))
+	})
+}
+
+// positions returns the position of a file name in the manifest. Names which
+// are not part of it are positioned after all others.
+func (m *segmentManifest) positions() func(name string) int {
+	positions := make(map[string]int, len(m.Segments))
+	for i, name := range m.Segments {
+		positions[name] = i
+	}
+
+	return func(name string) int {
+		if pos, ok := positions[name]; ok {
+			return pos
+		}
+		return len(m.Segments)
+	}
+}
+
+// manifestSnapshotLocked captures the current order of segments. Callers need
+// to hold the maintenanceLock exclusively, or have sole access to the segment
+// group during init. Segment groups without a manifestPath, i.e. ones not
+// created through newSegmentGroup, do not persist a manifest, the snapshot is
+// nil then.
+func (sg *SegmentGroup) manifestSnapshotLocked() *segmentManifest {
+	if sg.manifestPath == "" {
+		return nil
+	}
+
+	sg.manifestVersion++
+	m := &segmentManifest{
+		Segments: make([]string, 0, len(sg.segments)),
+		version:  sg.manifestVersion,
+	}
+	for _, seg := range sg.segments {
+		m.Segments = append(m.Segments, filepath.Base(seg.path))
+		if seg.tombstonesCollected.Load() {
+			m.TombstonesCollected = append(m.TombstonesCollected, filepath.Base(seg.path))
+		}
+	}
+	return m
+}
+
+// restoreTombstonesCollected marks the segments recorded as rewritten by the
+// gc compaction. A compaction may have replaced such a segment with one of the
+// same name right before a crash, the replacement is then skipped by the gc
+// compaction until it is compacted again, which is harmless.
+func (m *segmentManifest) restoreTombstonesCollected(segments []*segment) {
+	if m == nil || len(m.TombstonesCollected) == 0 {
+		return
+	}
+
+	collected := make(map[string]struct{}, len(m.TombstonesCollected))
+	for _, name := range m.TombstonesCollected {
+		collected[name] = struct{}{}
+	}
+	for _, seg := range segments {
+		if _, ok := collected[filepath.Base(seg.path)]; ok {
+			seg.tombstonesCollected.Store(true)
+		}
+	}
+}
+
+// updateManifest persists a snapshot taken after a flush or compaction. A
+// failure is not fatal, as a stale manifest only lacks the newest segments,
+// which are ordered last anyway, or lists segments which no longer exist and
+// are skipped on startup.
+func (sg *SegmentGroup) updateManifest(m *segmentManifest) {
+	if err := sg.writeManifest(m); err != nil {
+		sg.logger.WithError(err).
+			WithField("action", "lsm_segment_manifest_update").
+			WithField("path", sg.manifestPath).
+			Error("failed to update segment manifest")
+	}
+}
+
+// writeManifest atomically replaces the manifest with the given snapshot. It
+// must not be called with the maintenanceLock held, so reads are not blocked
+// by the fsync. Snapshots older than the one on disk are dropped, as a flush
+// and a compaction may race to write their snapshots.
+func (sg *SegmentGroup) writeManifest(m *segmentManifest) error {
+	if m == nil {
+		return nil
+	}
+
+	sg.manifestLock.Lock()
+	defer sg.manifestLock.Unlock()
+
+	if m.version <= sg.manifestWritten {
+		return nil
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshal segment manifest: %w", err)
+	}
+
+	tmpPath := sg.manifestPath + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("create segment manifest: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("write segment manifest: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("fsync segment manifest: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close segment manifest: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, sg.manifestPath); err != nil {
+		return fmt.Errorf("rename segment manifest: %w", err)
+	}
+	if err := fsync(sg.dir); err != nil {
+		return fmt.Errorf("fsync segment directory %s: %w", sg.dir, err)
+	}
+	sg.manifestWritten = m.version
+	return nil
+}
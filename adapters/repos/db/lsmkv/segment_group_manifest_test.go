@@ -0,0 +1,132 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_Manifest(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+	dir := t.TempDir()
+
+	openBucket := func(t *testing.T) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		return b
+	}
+
+	readManifest := func(t *testing.T) []string {
+		m, err := loadSegmentManifest(dir)
+		require.Nil(t, err)
+		require.NotNil(t, m)
+		return m.Segments
+	}
+
+	segmentNames := func(b *Bucket) []string {
+		var names []string
+		for _, seg := range b.disk.segments {
+			names = append(names, filepath.Base(seg.path))
+		}
+		return names
+	}
+
+	b := openBucket(t)
+
+	t.Run("manifest is generated on startup", func(t *testing.T) {
+		assert.Empty(t, readManifest(t))
+	})
+
+	t.Run("manifest is updated on flush", func(t *testing.T) {
+		for _, key := range []string{"a", "b", "c"} {
+			require.Nil(t, b.Put([]byte(key), []byte(key)))
+			require.Nil(t, b.FlushAndSwitch())
+		}
+		require.Equal(t, 3, b.disk.Len())
+		assert.Equal(t, segmentNames(b), readManifest(t))
+	})
+
+	t.Run("manifest is updated on compaction", func(t *testing.T) {
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		require.True(t, compacted)
+		require.Equal(t, 2, b.disk.Len())
+		assert.Equal(t, segmentNames(b), readManifest(t))
+	})
+
+	t.Run("outdated snapshots don't replace newer ones", func(t *testing.T) {
+		b.disk.maintenanceLock.Lock()
+		outdated := b.disk.manifestSnapshotLocked()
+		current := b.disk.manifestSnapshotLocked()
+		b.disk.maintenanceLock.Unlock()
+
+		outdated.Segments = []string{"segment-outdated.db"}
+		require.Nil(t, b.disk.writeManifest(current))
+		require.Nil(t, b.disk.writeManifest(outdated))
+		assert.Equal(t, segmentNames(b), readManifest(t))
+	})
+
+	expected := segmentNames(b)
+	require.Nil(t, b.Shutdown(ctx))
+
+	t.Run("segments are loaded in manifest order", func(t *testing.T) {
+		// swap the order in the manifest to prove that it takes precedence over
+		// the directory listing, and add a leftover of an interrupted write
+		reversed := []string{expected[1], expected[0]}
+		data, err := json.Marshal(segmentManifest{Segments: reversed})
+		require.Nil(t, err)
+		require.Nil(t, os.WriteFile(filepath.Join(dir, SegmentManifestFile), data, 0o666))
+		require.Nil(t, os.WriteFile(filepath.Join(dir, SegmentManifestFile+".tmp"), []byte("{"), 0o666))
+
+		b := openBucket(t)
+		defer b.Shutdown(ctx)
+
+		assert.Equal(t, reversed, segmentNames(b))
+		assert.NoFileExists(t, filepath.Join(dir, SegmentManifestFile+".tmp"))
+	})
+
+	t.Run("unknown and missing segments", func(t *testing.T) {
+		// the manifest lists a segment which no longer exists and misses one
+		// which does, e.g. after a crash right after a flush
+		data, err := json.Marshal(segmentManifest{Segments: []string{
+			"segment-0000000000000000001.db", expected[0],
+		}})
+		require.Nil(t, err)
+		require.Nil(t, os.WriteFile(filepath.Join(dir, SegmentManifestFile), data, 0o666))
+
+		b := openBucket(t)
+		defer b.Shutdown(ctx)
+
+		assert.Equal(t, expected, segmentNames(b))
+		assert.Equal(t, expected, readManifest(t))
+	})
+}
+
+func TestSegmentGroup_Manifest_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, SegmentManifestFile), []byte("{"), 0o666))
+
+	_, err := loadSegmentManifest(dir)
+	assert.ErrorContains(t, err, "parse segment manifest")
+}
@@ -0,0 +1,103 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import "sync"
+
+// negativeCache remembers keys which were resolved as deleted or not found by
+// a full scan of the segment group, so that repeated lookups of recently
+// deleted keys don't scan all segments again.
+//
+// Entries are only valid for the set of segments they were resolved against,
+// so the cache is invalidated whenever a segment is added or replaced. The
+// cache holds at most capacity keys, once it is full the oldest key is
+// evicted. A nil cache is disabled, all methods are no-ops.
+type negativeCache struct {
+	sync.Mutex
+	capacity int
+	keys     map[string]struct{}
+	// order holds the cached keys in insertion order as a ring buffer, next is
+	// the position of the oldest key
+	order []string
+	next  int
+}
+
+// newNegativeCache returns nil if capacity is not positive
+func newNegativeCache(capacity int) *negativeCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &negativeCache{
+		capacity: capacity,
+		keys:     make(map[string]struct{}, capacity),
+		order:    make([]string, 0, capacity),
+	}
+}
+
+func (c *negativeCache) contains(key []byte) bool {
+	if c == nil {
+		return false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	_, ok := c.keys[string(key)]
+	return ok
+}
+
+func (c *negativeCache) add(key []byte) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	k := string(key)
+	if _, ok := c.keys[k]; ok {
+		return
+	}
+
+	if len(c.order) < c.capacity {
+		c.order = append(c.order, k)
+	} else {
+		delete(c.keys, c.order[c.next])
+		c.order[c.next] = k
+		c.next = (c.next + 1) % c.capacity
+	}
+	c.keys[k] = struct{}{}
+}
+
+func (c *negativeCache) invalidate() {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	clear(c.keys)
+	c.order = c.order[:0]
+	c.next = 0
+}
+
+func (c *negativeCache) size() int {
+	if c == nil {
+		return 0
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	return len(c.keys)
+}
@@ -0,0 +1,233 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestNegativeCache(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		c := newNegativeCache(0)
+		require.Nil(t, c)
+
+		c.add([]byte("a"))
+		assert.False(t, c.contains([]byte("a")))
+		c.invalidate()
+		assert.Equal(t, 0, c.size())
+	})
+
+	t.Run("evicts oldest key", func(t *testing.T) {
+		c := newNegativeCache(2)
+		c.add([]byte("a"))
+		c.add([]byte("b"))
+		c.add([]byte("a"))
+		c.add([]byte("c"))
+
+		assert.False(t, c.contains([]byte("a")))
+		assert.True(t, c.contains([]byte("b")))
+		assert.True(t, c.contains([]byte("c")))
+		assert.Equal(t, 2, c.size())
+
+		c.add([]byte("d"))
+		assert.False(t, c.contains([]byte("b")))
+		assert.True(t, c.contains([]byte("c")))
+		assert.True(t, c.contains([]byte("d")))
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		c := newNegativeCache(2)
+		c.add([]byte("a"))
+		c.add([]byte("b"))
+		c.invalidate()
+		assert.Equal(t, 0, c.size())
+
+		c.add([]byte("c"))
+		assert.True(t, c.contains([]byte("c")))
+		assert.False(t, c.contains([]byte("a")))
+	})
+}
+
+func TestSegmentGroup_NegativeCache(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(t *testing.T, opts ...BucketOption) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			append([]BucketOption{WithStrategy(StrategyReplace), WithNegativeCacheSize(100)}, opts...)...)
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+		return b
+	}
+
+	t.Run("deleted and missing keys are cached", func(t *testing.T) {
+		b := newBucket(t)
+		require.Nil(t, b.Put([]byte("deleted"), []byte("value")))
+		require.Nil(t, b.Put([]byte("live"), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.Delete([]byte("deleted")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		for i := 0; i < 2; i++ {
+			v, err := b.Get([]byte("deleted"))
+			require.Nil(t, err)
+			assert.Nil(t, v)
+
+			v, err = b.Get([]byte("missing"))
+			require.Nil(t, err)
+			assert.Nil(t, v)
+
+			v, err = b.Get([]byte("live"))
+			require.Nil(t, err)
+			assert.Equal(t, []byte("value"), v)
+		}
+
+		assert.True(t, b.disk.negativeCache.contains([]byte("deleted")))
+		assert.True(t, b.disk.negativeCache.contains([]byte("missing")))
+		assert.Equal(t, 2, b.disk.negativeCache.size())
+	})
+
+	t.Run("GetMany uses the cache", func(t *testing.T) {
+		b := newBucket(t)
+		require.Nil(t, b.Put([]byte("deleted"), []byte("value")))
+		require.Nil(t, b.Put([]byte("live"), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.Delete([]byte("deleted")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		keys := [][]byte{[]byte("deleted"), []byte("live"), []byte("missing")}
+		values, err := b.disk.GetMany(keys)
+		require.Nil(t, err)
+		assert.Equal(t, [][]byte{nil, []byte("value"), nil}, values)
+		assert.True(t, b.disk.negativeCache.contains([]byte("deleted")))
+		assert.True(t, b.disk.negativeCache.contains([]byte("missing")))
+		assert.Equal(t, 2, b.disk.negativeCache.size())
+
+		// cached keys are not looked up, so they stay nil even if they
+		// existed in the segments
+		b.disk.negativeCache.add([]byte("live"))
+		values, err = b.disk.GetMany(keys)
+		require.Nil(t, err)
+		assert.Equal(t, [][]byte{nil, nil, nil}, values)
+	})
+
+	t.Run("invalidated by flush", func(t *testing.T) {
+		b := newBucket(t)
+		require.Nil(t, b.Put([]byte("key"), []byte("value")))
+		require.Nil(t, b.Delete([]byte("key")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		v, err := b.Get([]byte("key"))
+		require.Nil(t, err)
+		assert.Nil(t, v)
+		require.True(t, b.disk.negativeCache.contains([]byte("key")))
+
+		require.Nil(t, b.Put([]byte("key"), []byte("revived")))
+		require.Nil(t, b.FlushAndSwitch())
+		assert.Equal(t, 0, b.disk.negativeCache.size())
+
+		v, err = b.Get([]byte("key"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("revived"), v)
+	})
+
+	t.Run("invalidated by compaction", func(t *testing.T) {
+		b := newBucket(t)
+		require.Nil(t, b.Put([]byte("key"), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.Delete([]byte("key")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		v, err := b.Get([]byte("key"))
+		require.Nil(t, err)
+		assert.Nil(t, v)
+		require.Equal(t, 1, b.disk.negativeCache.size())
+
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		require.True(t, compacted)
+		assert.Equal(t, 0, b.disk.negativeCache.size())
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		defer b.Shutdown(ctx)
+
+		assert.Nil(t, b.disk.negativeCache)
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		_, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace), WithNegativeCacheSize(-1))
+		assert.Error(t, err)
+	})
+
+	t.Run("concurrent reads and flushes", func(t *testing.T) {
+		// readers populate the cache with keys which are deleted on disk while
+		// the keys are revived and flushed. Once a flush returned, no reader may
+		// see a key as deleted anymore.
+		const keys = 50
+		key := func(i int) []byte { return []byte(fmt.Sprintf("key-%03d", i)) }
+
+		b := newBucket(t, WithNegativeCacheSize(keys/2))
+		for i := 0; i < keys; i++ {
+			require.Nil(t, b.Put(key(i), []byte("value")))
+			require.Nil(t, b.Delete(key(i)))
+		}
+		require.Nil(t, b.FlushAndSwitch())
+
+		stop := make(chan struct{})
+		wg := sync.WaitGroup{}
+		for r := 0; r < 4; r++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; ; i = (i + 1) % keys {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					_, err := b.Get(key(i))
+					assert.Nil(t, err)
+				}
+			}()
+		}
+
+		for i := 0; i < keys; i++ {
+			require.Nil(t, b.Put(key(i), []byte("revived")))
+			require.Nil(t, b.FlushAndSwitch())
+
+			for j := 0; j <= i; j++ {
+				v, err := b.Get(key(j))
+				require.Nil(t, err)
+				require.Equal(t, []byte("revived"), v, "key %d after reviving key %d", j, i)
+			}
+		}
+
+		close(stop)
+		wg.Wait()
+	})
+}
@@ -0,0 +1,114 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSortSegmentsByID(t *testing.T) {
+	segments := []*segment{
+		{path: "/dir/segment-1700000000000000300.db"},
+		{path: "/dir/segment-1700000000000000100.db"},
+		{path: "/dir/segment-invalid.db"},
+		{path: "/dir/segment-1700000000000000100_1700000000000000200.db"},
+	}
+
+	sortSegmentsByID(segments)
+
+	var paths []string
+	for _, seg := range segments {
+		paths = append(paths, seg.path)
+	}
+	assert.Equal(t, []string{
+		"/dir/segment-1700000000000000100.db",
+		"/dir/segment-1700000000000000100_1700000000000000200.db",
+		"/dir/segment-1700000000000000300.db",
+		"/dir/segment-invalid.db",
+	}, paths)
+}
+
+func TestSegmentGroup_OrderAfterCompactionRecovery(t *testing.T) {
+	t.Run("with manifest", func(t *testing.T) {
+		testOrderAfterCompactionRecovery(t, false)
+	})
+
+	// e.g. on the first startup after an upgrade
+	t.Run("without manifest", func(t *testing.T) {
+		testOrderAfterCompactionRecovery(t, true)
+	})
+}
+
+func testOrderAfterCompactionRecovery(t *testing.T, removeManifest bool) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+	dir := t.TempDir()
+
+	openBucket := func(t *testing.T) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		return b
+	}
+
+	b := openBucket(t)
+	var ids []string
+	for _, value := range []string{"v1", "v2", "v3"} {
+		require.Nil(t, b.Put([]byte("key"), []byte(value)))
+		require.Nil(t, b.FlushAndSwitch())
+	}
+	for _, seg := range b.disk.segments {
+		ids = append(ids, segmentID(seg.path))
+	}
+	require.Nil(t, b.Shutdown(ctx))
+	require.Len(t, ids, 3)
+
+	// simulate a crash during the compaction of the two newest segments after
+	// both inputs were deleted, but before the .tmp extension of the compacted
+	// segment was stripped. The compacted segment holds the same data as the
+	// newest input.
+	removeSegmentFiles := func(id string) {
+		files, err := filepath.Glob(filepath.Join(dir, "segment-"+id+".*"))
+		require.Nil(t, err)
+		for _, file := range files {
+			require.Nil(t, os.Remove(file))
+		}
+	}
+	compactedPath := filepath.Join(dir, "segment-"+ids[1]+"_"+ids[2]+".db.tmp")
+	require.Nil(t, os.Rename(filepath.Join(dir, "segment-"+ids[2]+".db"), compactedPath))
+	removeSegmentFiles(ids[1])
+	removeSegmentFiles(ids[2])
+	if removeManifest {
+		require.Nil(t, os.Remove(filepath.Join(dir, SegmentManifestFile)))
+	}
+
+	b = openBucket(t)
+	defer b.Shutdown(ctx)
+
+	// the recovered segment is mounted first, but is the newest one
+	require.Equal(t, 2, b.disk.Len())
+	assert.Equal(t, ids[0], segmentID(b.disk.segmentAtPos(0).path))
+	assert.Equal(t, ids[2], segmentID(b.disk.segmentAtPos(1).path))
+
+	v, err := b.Get([]byte("key"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("v3"), v)
+}
@@ -0,0 +1,47 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import "fmt"
+
+// SearchWithFilter walks all keys of a "replace" segment group in ascending
+// order and calls predicate for every key that is neither deleted nor
+// superseded by a newer segment. Only the pairs for which predicate returns
+// true are collected and returned. Values are read into a reusable buffer and
+// only copied for matches, so a selective predicate avoids most allocations
+// of a full scan.
+//
+// The predicate is called while the maintenance lock of the segment group is
+// held. It must therefore be cheap and must never block, otherwise it stalls
+// flushes and compactions. The key and value passed to it are only valid for
+// the duration of the call and must not be retained.
+func (sg *SegmentGroup) SearchWithFilter(predicate func(key, value []byte) bool,
+) (keys, values [][]byte, err error) {
+	it := sg.Iterator(IteratorOpts{})
+	defer it.Close()
+	it.reuseValues = true
+
+	for it.Next() {
+		if !predicate(it.Key(), it.Value()) {
+			continue
+		}
+
+		keys = append(keys, append([]byte{}, it.Key()...))
+		values = append(values, append([]byte{}, it.Value()...))
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, nil, fmt.Errorf("search with filter: %w", err)
+	}
+
+	return keys, values, nil
+}
@@ -0,0 +1,87 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_SearchWithFilter(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	for _, pread := range []bool{false, true} {
+		t.Run(fmt.Sprintf("pread=%t", pread), func(t *testing.T) {
+			b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+				cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+				WithStrategy(StrategyReplace), WithPread(pread))
+			require.Nil(t, err)
+			defer b.Shutdown(ctx)
+
+			key := func(i int) []byte { return []byte(fmt.Sprintf("key-%03d", i)) }
+
+			// segment 1: all keys are "red"
+			for i := 0; i < 100; i++ {
+				require.Nil(t, b.Put(key(i), []byte("red")))
+			}
+			require.Nil(t, b.FlushAndSwitch())
+
+			// segment 2: every third key becomes "blue"
+			for i := 0; i < 100; i += 3 {
+				require.Nil(t, b.Put(key(i), []byte("blue")))
+			}
+			require.Nil(t, b.FlushAndSwitch())
+
+			// segment 3: every sixth key is deleted, some red keys turn blue
+			for i := 0; i < 100; i += 6 {
+				require.Nil(t, b.Delete(key(i)))
+			}
+			for i := 1; i < 10; i += 3 {
+				require.Nil(t, b.Put(key(i), []byte("blue")))
+			}
+			require.Nil(t, b.FlushAndSwitch())
+			require.Equal(t, 3, b.disk.Len())
+
+			var expected [][]byte
+			for i := 0; i < 100; i++ {
+				isBlue := (i%3 == 0 && i%6 != 0) || (i < 10 && i%3 == 1)
+				if isBlue {
+					expected = append(expected, key(i))
+				}
+			}
+
+			calls := 0
+			keys, values, err := b.disk.SearchWithFilter(func(k, v []byte) bool {
+				calls++
+				return bytes.Equal(v, []byte("blue"))
+			})
+			require.Nil(t, err)
+
+			assert.Equal(t, expected, keys)
+			require.Len(t, values, len(expected))
+			for _, v := range values {
+				assert.Equal(t, []byte("blue"), v)
+			}
+
+			// deleted keys are never presented to the predicate
+			assert.Equal(t, 100-17, calls)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+// SegmentAddedObserver is notified about every segment added to a segment
+// group, e.g. by a flush, with the ID and path of the new segment
+type SegmentAddedObserver func(id, path string)
+
+// RegisterSegmentAddedObserver registers an observer which is invoked whenever
+// a segment is added to the group. Observers are invoked in registration order
+// without holding the maintenance lock. They run synchronously on the adding
+// routine, so a blocking observer delays flushes.
+func (sg *SegmentGroup) RegisterSegmentAddedObserver(observer SegmentAddedObserver) {
+	sg.segmentAddedObserversLock.Lock()
+	defer sg.segmentAddedObserversLock.Unlock()
+
+	sg.segmentAddedObservers = append(sg.segmentAddedObservers, observer)
+}
+
+// notifySegmentAdded invokes all registered SegmentAddedObservers. It must be
+// called without holding the maintenance lock.
+func (sg *SegmentGroup) notifySegmentAdded(path string) {
+	sg.segmentAddedObserversLock.Lock()
+	observers := sg.segmentAddedObservers
+	sg.segmentAddedObserversLock.Unlock()
+
+	id := segmentID(path)
+	for _, observer := range observers {
+		observer(id, path)
+	}
+}
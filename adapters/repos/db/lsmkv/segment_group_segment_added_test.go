@@ -0,0 +1,95 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_SegmentAddedObserver(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(t *testing.T, dir string) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		return b
+	}
+
+	b := newBucket(t, t.TempDir())
+	defer b.Shutdown(ctx)
+
+	type event struct {
+		observer int
+		id, path string
+	}
+	var events []event
+	for i := 0; i < 2; i++ {
+		observer := i
+		b.disk.RegisterSegmentAddedObserver(func(id, path string) {
+			// observers must be invoked outside the maintenance lock
+			require.True(t, b.disk.maintenanceLock.TryLock())
+			b.disk.maintenanceLock.Unlock()
+			events = append(events, event{observer: observer, id: id, path: path})
+		})
+	}
+
+	t.Run("flush adds an initialized segment", func(t *testing.T) {
+		events = nil
+		require.Nil(t, b.Put([]byte("key1"), []byte("value1")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		path := b.disk.segmentAtPos(0).path
+		assert.Equal(t, []event{
+			{observer: 0, id: segmentID(path), path: path},
+			{observer: 1, id: segmentID(path), path: path},
+		}, events)
+	})
+
+	t.Run("add initializes the segment at path", func(t *testing.T) {
+		events = nil
+
+		// create a segment in a different bucket and move it over
+		other := newBucket(t, t.TempDir())
+		require.Nil(t, other.Put([]byte("key2"), []byte("value2")))
+		require.Nil(t, other.FlushAndSwitch())
+		source := other.disk.segmentAtPos(0).path
+		contents, err := os.ReadFile(source)
+		require.Nil(t, err)
+		require.Nil(t, other.Shutdown(ctx))
+
+		path := filepath.Join(b.GetDir(), fmt.Sprintf("segment-%d.db", time.Now().UnixNano()))
+		require.Nil(t, os.WriteFile(path, contents, 0o666))
+		require.Nil(t, b.disk.add(path))
+
+		assert.Equal(t, []event{
+			{observer: 0, id: segmentID(path), path: path},
+			{observer: 1, id: segmentID(path), path: path},
+		}, events)
+
+		v, err := b.Get([]byte("key2"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value2"), v)
+	})
+}
@@ -0,0 +1,71 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_SlowPathThreshold(t *testing.T) {
+	ctx := context.Background()
+
+	slowPathLogs := func(t *testing.T, threshold time.Duration, level logrus.Level) int {
+		logger, hook := test.NewNullLogger()
+		logger.SetLevel(level)
+
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace), WithSlowPathThreshold(threshold))
+		require.Nil(t, err)
+		defer b.Shutdown(ctx)
+
+		require.Nil(t, b.Put([]byte("hello"), []byte("world")))
+		require.Nil(t, b.FlushAndSwitch())
+		hook.Reset()
+
+		v, err := b.disk.get([]byte("hello"))
+		require.Nil(t, err)
+		require.Equal(t, []byte("world"), v)
+
+		count := 0
+		for _, entry := range hook.AllEntries() {
+			switch entry.Data["action"] {
+			case "lsm_segment_group_get_obtain_maintenance_lock",
+				"lsm_segment_group_get_individual_segment":
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("reads below the threshold are not logged", func(t *testing.T) {
+		assert.Equal(t, 0, slowPathLogs(t, time.Hour, logrus.DebugLevel))
+	})
+
+	t.Run("reads above the threshold are logged", func(t *testing.T) {
+		// every read takes longer than a nanosecond, so both the lock
+		// acquisition and the segment read are logged
+		assert.Equal(t, 2, slowPathLogs(t, time.Nanosecond, logrus.DebugLevel))
+	})
+
+	t.Run("reads are not logged without debug level", func(t *testing.T) {
+		assert.Equal(t, 0, slowPathLogs(t, time.Nanosecond, logrus.InfoLevel))
+	})
+}
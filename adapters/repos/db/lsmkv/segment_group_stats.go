@@ -0,0 +1,211 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+	"github.com/weaviate/weaviate/entities/lsmkv"
+)
+
+// GroupStats is a point-in-time snapshot of a SegmentGroup
+type GroupStats struct {
+	SegmentCount int
+	// TotalSize is the sum of all segment file sizes in bytes
+	TotalSize int64
+	// Segments are ordered from oldest to newest, like the segments themselves
+	Segments []SegmentStats
+	// NetAdditions is the sum of the per-segment net addition counts, see
+	// SegmentGroup.count. It is only maintained for replace buckets with net
+	// addition counting enabled.
+	NetAdditions int
+	// LastCompaction and LastCleanup are the times of the last successful
+	// compaction and cleanup since the segment group was loaded. They are zero
+	// if there was none.
+	LastCompaction time.Time
+	LastCleanup    time.Time
+	// TombstoneRatio is the share of tombstones among all keys stored in the
+	// segments. It is only calculated for the replace strategy and 0 otherwise.
+	TombstoneRatio float64
+}
+
+type SegmentStats struct {
+	ID    string
+	Level uint16
+	Size  int64
+	// Keys and Tombstones are only calculated for the replace strategy
+	Keys       int
+	Tombstones int
+	// Pinned segments are locked in memory, see SegmentGroup.PinSegment
+	Pinned bool
+}
+
+// Stats returns a snapshot of the segment group. All values are taken under
+// a single read lock, so they are consistent with each other.
+//
+// All values are held in memory. Segments of the replace strategy know their
+// tombstone counts from the flush or compaction which created them. Segments
+// loaded on startup are scanned once before the snapshot is taken, without
+// holding the maintenance lock, see computeMissingTombstoneStats.
+func (sg *SegmentGroup) Stats() GroupStats {
+	// the scans can't be aborted without a deadline
+	stats, _ := sg.StatsContext(context.Background())
+	return stats
+}
+
+// StatsContext is Stats, but stops scanning segments for their tombstone
+// counts once ctx is done. ctx.Err() is returned in that case, segments
+// scanned until then keep their counts, so a later call continues where this
+// one stopped.
+func (sg *SegmentGroup) StatsContext(ctx context.Context) (GroupStats, error) {
+	if err := sg.computeMissingTombstoneStats(ctx); err != nil {
+		return GroupStats{}, err
+	}
+
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	stats := GroupStats{
+		SegmentCount: len(sg.segments),
+		Segments:     make([]SegmentStats, len(sg.segments)),
+	}
+
+	keys, tombstones := 0, 0
+	for i, seg := range sg.segments {
+		segStats := SegmentStats{
+			ID:     segmentID(seg.path),
+			Level:  seg.level,
+			Size:   seg.size,
+			Pinned: seg.pinned.Load(),
+		}
+
+		// segments which could not be scanned are left out
+		if seg.strategy == segmentindex.StrategyReplace && seg.statsKnown.Load() {
+			segStats.Keys = int(seg.statsKeys.Load())
+			segStats.Tombstones = int(seg.statsTombstones.Load())
+			keys += segStats.Keys
+			tombstones += segStats.Tombstones
+		}
+
+		stats.Segments[i] = segStats
+		stats.TotalSize += seg.size
+		stats.NetAdditions += int(seg.countNetAdditions.Load())
+	}
+
+	if keys > 0 {
+		stats.TombstoneRatio = float64(tombstones) / float64(keys)
+	}
+	if ts := sg.lastCompaction.Load(); ts != 0 {
+		stats.LastCompaction = time.Unix(0, ts)
+	}
+	if ts := sg.lastCleanup.Load(); ts != 0 {
+		stats.LastCleanup = time.Unix(0, ts)
+	}
+
+	return stats, nil
+}
+
+// computeMissingTombstoneStats scans the replace segments whose tombstone
+// counts are not known yet. The segments are acquired instead of holding the
+// maintenance lock, so reads, writes, flushes and compactions continue during
+// the scans. ctx is checked between segments.
+func (sg *SegmentGroup) computeMissingTombstoneStats(ctx context.Context) error {
+	if sg.strategy != StrategyReplace || !sg.hasMissingTombstoneStats() {
+		return nil
+	}
+
+	sg.maintenanceLock.RLock()
+	segments := make([]*segment, len(sg.segments))
+	copy(segments, sg.segments)
+	for _, seg := range segments {
+		seg.acquire()
+	}
+	sg.maintenanceLock.RUnlock()
+
+	defer func() {
+		for _, seg := range segments {
+			seg.release()
+		}
+	}()
+
+	for _, seg := range segments {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if seg.strategy != segmentindex.StrategyReplace || seg.statsKnown.Load() {
+			continue
+		}
+		if _, _, err := seg.tombstoneStats(); err != nil {
+			sg.logger.WithField("action", "lsm_segment_group_stats").
+				WithField("path", seg.path).
+				WithError(err).
+				Warn("failed to count tombstones of segment")
+		}
+	}
+	return nil
+}
+
+func (sg *SegmentGroup) hasMissingTombstoneStats() bool {
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	for _, seg := range sg.segments {
+		if seg.strategy == segmentindex.StrategyReplace && !seg.statsKnown.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// tombstoneStats returns the number of keys and how many of them are
+// tombstones. Unless they were already set with setTombstoneStats, e.g. by the
+// flush or compaction which created the segment, the segment is scanned on the
+// first call and the result is cached. The caller needs to make sure the
+// segment is not closed during the scan.
+func (s *segment) tombstoneStats() (keys, tombstones int, err error) {
+	if !s.statsKnown.Load() {
+		keys, tombstones, err := s.countTombstones()
+		if err != nil {
+			return 0, 0, err
+		}
+		s.setTombstoneStats(keys, tombstones)
+	}
+	return int(s.statsKeys.Load()), int(s.statsTombstones.Load()), nil
+}
+
+func (s *segment) setTombstoneStats(keys, tombstones int) {
+	s.statsKeys.Store(int64(keys))
+	s.statsTombstones.Store(int64(tombstones))
+	s.statsKnown.Store(true)
+}
+
+func (s *segment) countTombstones() (keys, tombstones int, err error) {
+	c, err := s.newCursor()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, _, err = c.first(); ; _, _, err = c.next() {
+		switch {
+		case err == nil:
+		case errors.Is(err, lsmkv.Deleted):
+			tombstones++
+		case errors.Is(err, lsmkv.NotFound):
+			return keys, tombstones, nil
+		default:
+			return 0, 0, err
+		}
+		keys++
+	}
+}
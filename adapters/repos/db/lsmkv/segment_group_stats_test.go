@@ -0,0 +1,168 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_Stats(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace), WithCalcCountNetAdditions(true))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	key := func(i int) []byte { return []byte(fmt.Sprintf("key-%d", i)) }
+
+	t.Run("empty segment group", func(t *testing.T) {
+		stats := b.disk.Stats()
+		assert.Equal(t, 0, stats.SegmentCount)
+		assert.Empty(t, stats.Segments)
+		assert.Zero(t, stats.TombstoneRatio)
+		assert.True(t, stats.LastCompaction.IsZero())
+		assert.True(t, stats.LastCleanup.IsZero())
+	})
+
+	// first segment holds 4 keys, the second one 1 key and 2 tombstones
+	for i := 0; i < 4; i++ {
+		require.Nil(t, b.Put(key(i), []byte("value")))
+	}
+	require.Nil(t, b.FlushAndSwitch())
+	require.Nil(t, b.Delete(key(0)))
+	require.Nil(t, b.Delete(key(1)))
+	require.Nil(t, b.Put(key(4), []byte("value")))
+	require.Nil(t, b.FlushAndSwitch())
+
+	t.Run("snapshot matches segments", func(t *testing.T) {
+		stats := b.disk.Stats()
+		require.Equal(t, 2, stats.SegmentCount)
+		require.Len(t, stats.Segments, 2)
+
+		var totalSize int64
+		for i, segStats := range stats.Segments {
+			seg := b.disk.segmentAtPos(i)
+			info, err := os.Stat(seg.path)
+			require.Nil(t, err)
+
+			assert.Equal(t, segmentID(seg.path), segStats.ID)
+			assert.Equal(t, seg.level, segStats.Level)
+			assert.Equal(t, info.Size(), segStats.Size)
+			totalSize += info.Size()
+		}
+		assert.Equal(t, totalSize, stats.TotalSize)
+
+		assert.Equal(t, 4, stats.Segments[0].Keys)
+		assert.Equal(t, 0, stats.Segments[0].Tombstones)
+		assert.Equal(t, 3, stats.Segments[1].Keys)
+		assert.Equal(t, 2, stats.Segments[1].Tombstones)
+		assert.InDelta(t, 2.0/7.0, stats.TombstoneRatio, 1e-9)
+
+		assert.Equal(t, b.disk.count(), stats.NetAdditions)
+		assert.Equal(t, 3, stats.NetAdditions)
+		assert.True(t, stats.LastCompaction.IsZero())
+	})
+
+	t.Run("snapshot reflects compaction", func(t *testing.T) {
+		before := time.Now()
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		require.True(t, compacted)
+
+		stats := b.disk.Stats()
+		require.Equal(t, 1, stats.SegmentCount)
+		assert.Equal(t, uint16(1), stats.Segments[0].Level)
+		assert.Equal(t, b.disk.segmentAtPos(0).size, stats.TotalSize)
+		assert.Equal(t, 3, stats.NetAdditions)
+		assert.False(t, stats.LastCompaction.Before(before))
+
+		// tombstones of the oldest segment are dropped on compaction
+		assert.Equal(t, 3, stats.Segments[0].Keys)
+		assert.Zero(t, stats.TombstoneRatio)
+	})
+	t.Run("compaction sets tombstone stats upfront", func(t *testing.T) {
+		require.Nil(t, b.Delete(key(2)))
+		require.Nil(t, b.Put(key(5), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.Put(key(6), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		// the first segment is not part of the compaction, so the tombstone
+		// is kept
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		require.True(t, compacted)
+		require.Equal(t, 2, b.disk.Len())
+
+		seg := b.disk.segmentAtPos(1)
+		require.True(t, seg.statsKnown.Load())
+		keys, tombstones, err := seg.countTombstones()
+		require.Nil(t, err)
+		assert.Equal(t, int64(keys), seg.statsKeys.Load())
+		assert.Equal(t, int64(tombstones), seg.statsTombstones.Load())
+		assert.Equal(t, 3, keys)
+		assert.Equal(t, 1, tombstones)
+	})
+}
+
+func TestSegmentGroup_Stats_TombstoneCounts(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+	dir := t.TempDir()
+
+	openBucket := func() *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		return b
+	}
+
+	b := openBucket()
+	require.Nil(t, b.Put([]byte("a"), []byte("value")))
+	require.Nil(t, b.Put([]byte("b"), []byte("value")))
+	require.Nil(t, b.Delete([]byte("b")))
+	require.Nil(t, b.FlushAndSwitch())
+
+	t.Run("flush sets tombstone stats upfront", func(t *testing.T) {
+		seg := b.disk.segmentAtPos(0)
+		require.True(t, seg.statsKnown.Load())
+		assert.Equal(t, int64(2), seg.statsKeys.Load())
+		assert.Equal(t, int64(1), seg.statsTombstones.Load())
+	})
+
+	require.Nil(t, b.Shutdown(ctx))
+	b = openBucket()
+	defer b.Shutdown(ctx)
+
+	t.Run("segments loaded on startup are scanned once", func(t *testing.T) {
+		require.False(t, b.disk.segmentAtPos(0).statsKnown.Load())
+
+		stats := b.disk.Stats()
+		require.Len(t, stats.Segments, 1)
+		assert.Equal(t, 2, stats.Segments[0].Keys)
+		assert.Equal(t, 1, stats.Segments[0].Tombstones)
+		assert.True(t, b.disk.segmentAtPos(0).statsKnown.Load())
+	})
+}
@@ -0,0 +1,92 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TierSizeAmplification returns the size ratio of each pair of consecutive
+// segment levels, i.e. [size(level1)/size(level0), size(level2)/size(level1),
+// ...], where the size of a level is the sum of the sizes of its segments. It
+// is computed from the in-memory segment sizes and doesn't touch the disk.
+//
+// In a healthy bucket each level is expected to be roughly 1.5 to 10 times
+// larger than the one below it. A ratio above 20 means data piles up in the
+// upper level while the lower levels stay small, which makes every further
+// compaction into that level expensive. Typical remedies are lowering the
+// max segment size, so that large segments stop growing, or raising the
+// compaction throughput if compactions can't keep up with flushes. It can
+// also point at a compaction which keeps failing, see the compaction logs.
+//
+// A pair is reported as 0 if the lower level holds no segments, e.g. because
+// they were just compacted into the upper level.
+func (sg *SegmentGroup) TierSizeAmplification() []float64 {
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	return tierSizeAmplification(sg.segments)
+}
+
+func tierSizeAmplification(segments []*segment) []float64 {
+	var sizes []int64
+	for _, seg := range segments {
+		for int(seg.level) >= len(sizes) {
+			sizes = append(sizes, 0)
+		}
+		sizes[seg.level] += seg.size
+	}
+
+	if len(sizes) < 2 {
+		return nil
+	}
+
+	ratios := make([]float64, len(sizes)-1)
+	for level := 1; level < len(sizes); level++ {
+		if sizes[level-1] == 0 {
+			continue
+		}
+		ratios[level-1] = float64(sizes[level]) / float64(sizes[level-1])
+	}
+	return ratios
+}
+
+// tierPairLabel is the value of the tier_pair label of the ratio between
+// level and the level below it, e.g. "1/0"
+func tierPairLabel(level int) string {
+	return fmt.Sprintf("%d/%d", level, level-1)
+}
+
+// reportTierSizeAmplification sets the gauge of every current tier pair and
+// deletes the gauges of pairs which disappeared since the last report, e.g.
+// because the top level was compacted away.
+func (sg *SegmentGroup) reportTierSizeAmplification() {
+	ratios := sg.TierSizeAmplification()
+	for i, ratio := range ratios {
+		sg.metrics.TierAmplification.With(prometheus.Labels{
+			"strategy":  sg.strategy,
+			"path":      sg.dir,
+			"tier_pair": tierPairLabel(i + 1),
+		}).Set(ratio)
+	}
+
+	for i := len(ratios); i < sg.reportedTierPairs; i++ {
+		sg.metrics.TierAmplification.Delete(prometheus.Labels{
+			"strategy":  sg.strategy,
+			"path":      sg.dir,
+			"tier_pair": tierPairLabel(i + 1),
+		})
+	}
+	sg.reportedTierPairs = len(ratios)
+}
@@ -0,0 +1,112 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+func TestTierSizeAmplification(t *testing.T) {
+	tcs := []struct {
+		name     string
+		segments []*segment
+		expected []float64
+	}{
+		{
+			name:     "no segments",
+			expected: nil,
+		},
+		{
+			name:     "single level",
+			segments: []*segment{{level: 0, size: 100}, {level: 0, size: 100}},
+			expected: nil,
+		},
+		{
+			name: "sizes are summed per level",
+			segments: []*segment{
+				{level: 2, size: 2000},
+				{level: 1, size: 300},
+				{level: 1, size: 100},
+				{level: 0, size: 100},
+			},
+			expected: []float64{4, 5},
+		},
+		{
+			name: "empty lower level",
+			segments: []*segment{
+				{level: 2, size: 2000},
+				{level: 0, size: 100},
+			},
+			expected: []float64{0, 0},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sg := &SegmentGroup{segments: tc.segments}
+			assert.Equal(t, tc.expected, sg.TierSizeAmplification())
+		})
+	}
+}
+
+func TestTierPairLabel(t *testing.T) {
+	assert.Equal(t, "1/0", tierPairLabel(1))
+	assert.Equal(t, "5/4", tierPairLabel(5))
+}
+
+func TestReportTierSizeAmplification(t *testing.T) {
+	promMetrics := monitoring.GetMetrics()
+	sg := &SegmentGroup{
+		metrics:  NewMetrics(promMetrics, "TierAmplificationReport", "shard"),
+		strategy: StrategyReplace,
+		dir:      "tier-amplification-report",
+		segments: []*segment{
+			{level: 2, size: 2000},
+			{level: 1, size: 400},
+			{level: 0, size: 100},
+		},
+	}
+	series := testutil.CollectAndCount(promMetrics.LSMTierAmplification)
+
+	sg.reportTierSizeAmplification()
+	assert.Equal(t, series+2, testutil.CollectAndCount(promMetrics.LSMTierAmplification))
+
+	gauge := func(tierPair string) float64 {
+		return testutil.ToFloat64(sg.metrics.TierAmplification.With(prometheus.Labels{
+			"strategy":  sg.strategy,
+			"path":      sg.dir,
+			"tier_pair": tierPair,
+		}))
+	}
+	assert.Equal(t, float64(4), gauge("1/0"))
+	assert.Equal(t, float64(5), gauge("2/1"))
+
+	t.Run("pairs which disappeared are deleted", func(t *testing.T) {
+		sg.segments = []*segment{{level: 1, size: 300}, {level: 0, size: 100}}
+
+		sg.reportTierSizeAmplification()
+		assert.Equal(t, series+1, testutil.CollectAndCount(promMetrics.LSMTierAmplification))
+		assert.Equal(t, float64(3), gauge("1/0"))
+	})
+
+	t.Run("all pairs are deleted for a single level", func(t *testing.T) {
+		sg.segments = []*segment{{level: 0, size: 100}}
+
+		sg.reportTierSizeAmplification()
+		assert.Equal(t, series, testutil.CollectAndCount(promMetrics.LSMTierAmplification))
+	})
+}
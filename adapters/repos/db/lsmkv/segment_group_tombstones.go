@@ -0,0 +1,98 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+	"github.com/weaviate/weaviate/entities/lsmkv"
+)
+
+// KeyTombstoneCount is the number of segments in which Key is a tombstone
+type KeyTombstoneCount struct {
+	Key   []byte
+	Count int
+}
+
+// TombstonesByKey returns the limit keys which are tombstones in the most
+// segments, ordered by count descending. A limit of 0 or less returns all of
+// them. Keys which were deleted over and over again point to objects which
+// are frequently updated or deleted.
+//
+// This is a diagnostic tool only, it must never be called from the hot path:
+// all segments are scanned while holding the maintenance RLock, and all
+// tombstoned keys are held in memory. Unless built with the debug tag, every
+// call logs a warning. Only the replace strategy is supported, the result is
+// nil for all others.
+func (sg *SegmentGroup) TombstonesByKey(limit int) []KeyTombstoneCount {
+	if !debugBuild {
+		sg.logger.WithField("action", "lsm_segment_group_tombstones_by_key").
+			WithField("path", sg.dir).
+			Warn("scanning all segments for tombstones, this is expensive and " +
+				"should only be done for diagnostics")
+	}
+
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	counts := map[string]int{}
+	for _, seg := range sg.segments {
+		if seg.strategy != segmentindex.StrategyReplace {
+			return nil
+		}
+		if err := seg.countTombstonesByKey(counts); err != nil {
+			sg.logger.WithField("action", "lsm_segment_group_tombstones_by_key").
+				WithField("path", seg.path).
+				WithError(err).
+				Warn("failed to count tombstones of segment")
+		}
+	}
+
+	out := make([]KeyTombstoneCount, 0, len(counts))
+	for key, count := range counts {
+		out = append(out, KeyTombstoneCount{Key: []byte(key), Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return bytes.Compare(out[i].Key, out[j].Key) < 0
+	})
+
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// countTombstonesByKey increments the count of every key which is a
+// tombstone in the segment
+func (s *segment) countTombstonesByKey(counts map[string]int) error {
+	c, err := s.newCursor()
+	if err != nil {
+		return err
+	}
+	for key, _, err := c.first(); ; key, _, err = c.next() {
+		switch {
+		case err == nil:
+		case errors.Is(err, lsmkv.Deleted):
+			counts[string(key)]++
+		case errors.Is(err, lsmkv.NotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,88 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroupTombstonesByKey(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(t *testing.T, strategy string) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(strategy))
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+		return b
+	}
+
+	t.Run("counts tombstones across segments", func(t *testing.T) {
+		b := newBucket(t, StrategyReplace)
+
+		// "a" is deleted in three segments, "b" in two and "c" in one. "d" is
+		// never deleted, "e" is recreated after its deletion.
+		deletes := [][]string{
+			{"a", "b", "c", "e"},
+			{"a", "b"},
+			{"a"},
+		}
+		for _, keys := range deletes {
+			for _, key := range []string{"a", "b", "c", "d"} {
+				require.Nil(t, b.Put([]byte(key), []byte("value")))
+			}
+			require.Nil(t, b.FlushAndSwitch())
+			for _, key := range keys {
+				require.Nil(t, b.Delete([]byte(key)))
+			}
+			require.Nil(t, b.FlushAndSwitch())
+		}
+		require.Nil(t, b.Put([]byte("e"), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		assert.Equal(t, []KeyTombstoneCount{
+			{Key: []byte("a"), Count: 3},
+			{Key: []byte("b"), Count: 2},
+			{Key: []byte("c"), Count: 1},
+			{Key: []byte("e"), Count: 1},
+		}, b.disk.TombstonesByKey(0))
+
+		assert.Equal(t, []KeyTombstoneCount{
+			{Key: []byte("a"), Count: 3},
+			{Key: []byte("b"), Count: 2},
+		}, b.disk.TombstonesByKey(2))
+	})
+
+	t.Run("no tombstones", func(t *testing.T) {
+		b := newBucket(t, StrategyReplace)
+		require.Nil(t, b.Put([]byte("a"), []byte("value")))
+		require.Nil(t, b.FlushAndSwitch())
+
+		assert.Empty(t, b.disk.TombstonesByKey(10))
+	})
+
+	t.Run("unsupported strategy", func(t *testing.T) {
+		b := newBucket(t, StrategySetCollection)
+		require.Nil(t, b.SetAdd([]byte("a"), [][]byte{[]byte("value")}))
+		require.Nil(t, b.FlushAndSwitch())
+
+		assert.Nil(t, b.disk.TombstonesByKey(10))
+	})
+}
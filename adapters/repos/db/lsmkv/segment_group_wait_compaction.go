@@ -0,0 +1,36 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"time"
+)
+
+const markReadOnlyPollInterval = 10 * time.Millisecond
+
+// waitForInFlightCompaction returns once no routine replacing segments holds
+// the compactionLock. New ones don't start while the group is read-only.
+func (sg *SegmentGroup) waitForInFlightCompaction(ctx context.Context) error {
+	ticker := time.NewTicker(markReadOnlyPollInterval)
+	defer ticker.Stop()
+
+	for !sg.compactionLock.TryLock() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	sg.compactionLock.Unlock()
+	return nil
+}
@@ -0,0 +1,79 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const waitForCompactionInterval = 100 * time.Millisecond
+
+// WaitForCompaction compacts and cleans up the segment group until there is
+// nothing left to compact or clean up, so that the segments on disk are in a
+// stable state, e.g. before asserting on them in tests. It returns once no
+// further progress is possible or with the context's error once ctx expires.
+func (sg *SegmentGroup) WaitForCompaction(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		progressed, err := sg.compactOrCleanupOnce(ctx)
+		if err != nil {
+			return err
+		}
+		if !progressed {
+			// a cleanup skipped due to the expired context is no progress
+			return ctx.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(waitForCompactionInterval):
+		}
+	}
+}
+
+// compactOrCleanupOnce runs a single compaction, or a single cleanup if there
+// is nothing to compact, or a single gc compaction if there is nothing to
+// clean up either. Unlike compactOrCleanup, errors are returned instead
+// of logged.
+func (sg *SegmentGroup) compactOrCleanupOnce(ctx context.Context) (bool, error) {
+	sg.compactionLock.Lock()
+	defer sg.compactionLock.Unlock()
+
+	compacted, err := sg.compactOnce()
+	if err != nil {
+		return false, fmt.Errorf("compact: %w", err)
+	}
+	if compacted {
+		return true, nil
+	}
+
+	shouldAbort := func() bool { return ctx.Err() != nil }
+	cleaned, err := sg.segmentCleaner.cleanupOnce(shouldAbort)
+	if err != nil {
+		return false, fmt.Errorf("cleanup: %w", err)
+	}
+	if cleaned {
+		return true, nil
+	}
+
+	gcCompacted, err := sg.gcCompactOnce(shouldAbort)
+	if err != nil {
+		return false, fmt.Errorf("gc compaction: %w", err)
+	}
+	return gcCompacted, nil
+}
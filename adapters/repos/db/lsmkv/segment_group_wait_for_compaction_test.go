@@ -0,0 +1,64 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_WaitForCompaction(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	for i := 0; i < 4; i++ {
+		require.Nil(t, b.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i))))
+		require.Nil(t, b.FlushAndSwitch())
+	}
+	require.Equal(t, 4, b.disk.Len())
+
+	t.Run("expired context", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.ErrorIs(t, b.disk.WaitForCompaction(cancelled), context.Canceled)
+		assert.Equal(t, 4, b.disk.Len())
+	})
+
+	t.Run("compacts until stable", func(t *testing.T) {
+		require.Nil(t, b.disk.WaitForCompaction(ctx))
+		assert.Equal(t, 1, b.disk.Len())
+		assert.Empty(t, b.disk.CompactionCandidates())
+
+		for i := 0; i < 4; i++ {
+			value, err := b.Get([]byte(fmt.Sprintf("key%d", i)))
+			require.Nil(t, err)
+			assert.Equal(t, fmt.Sprintf("value%d", i), string(value))
+		}
+	})
+
+	t.Run("nothing to compact", func(t *testing.T) {
+		require.Nil(t, b.disk.WaitForCompaction(ctx))
+		assert.Equal(t, 1, b.disk.Len())
+	})
+}
@@ -77,6 +77,22 @@ func (s *segment) initCountNetAdditions(exists existsOnLowerSegmentsFn, overwrit
 		}
 	}
 
+	countNet, err := s.computeCountNetAdditions(exists)
+	if err != nil {
+		return err
+	}
+	s.countNetAdditions.Store(int64(countNet))
+
+	if err := s.storeCountNetOnDisk(); err != nil {
+		return fmt.Errorf("store count net additions on disk: %w", err)
+	}
+
+	return nil
+}
+
+// computeCountNetAdditions scans all keys of the segment and returns the
+// number of keys which are new (or deleted) compared to the lower segments.
+func (s *segment) computeCountNetAdditions(exists existsOnLowerSegmentsFn) (int, error) {
 	var lastErr error
 	countNet := 0
 	cb := func(key []byte, tombstone bool) {
@@ -99,21 +115,11 @@ func (s *segment) initCountNetAdditions(exists existsOnLowerSegmentsFn, overwrit
 
 	extr.do()
 
-	s.countNetAdditions = countNet
-
-	if lastErr != nil {
-		return lastErr
-	}
-
-	if err := s.storeCountNetOnDisk(); err != nil {
-		return fmt.Errorf("store count net additions on disk: %w", err)
-	}
-
-	return nil
+	return countNet, lastErr
 }
 
 func (s *segment) storeCountNetOnDisk() error {
-	return storeCountNetOnDisk(s.countNetPath(), s.countNetAdditions)
+	return storeCountNetOnDisk(s.countNetPath(), int(s.countNetAdditions.Load()))
 }
 
 func storeCountNetOnDisk(path string, value int) error {
@@ -132,7 +138,7 @@ func (s *segment) loadCountNetFromDisk() error {
 		return err
 	}
 
-	s.countNetAdditions = int(binary.LittleEndian.Uint64(data[0:8]))
+	s.countNetAdditions.Store(int64(binary.LittleEndian.Uint64(data[0:8])))
 
 	return nil
 }
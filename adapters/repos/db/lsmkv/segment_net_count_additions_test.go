@@ -320,3 +320,56 @@ func corruptCNAFile(fname string, corruptValue uint64) error {
 
 	return f.Close()
 }
+
+func TestRecomputeNetAdditions(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+	dirName := t.TempDir()
+
+	openBucket := func(t *testing.T) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, dirName, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace), WithCalcCountNetAdditions(true))
+		require.NoError(t, err)
+		return b
+	}
+
+	b := openBucket(t)
+
+	// segment 1: a, b, c; segment 2: update a, delete b, add d
+	require.NoError(t, b.Put([]byte("a"), []byte("1")))
+	require.NoError(t, b.Put([]byte("b"), []byte("1")))
+	require.NoError(t, b.Put([]byte("c"), []byte("1")))
+	require.NoError(t, b.FlushAndSwitch())
+	require.NoError(t, b.Put([]byte("a"), []byte("2")))
+	require.NoError(t, b.Delete([]byte("b")))
+	require.NoError(t, b.Put([]byte("d"), []byte("1")))
+	require.NoError(t, b.FlushAndSwitch())
+	require.Equal(t, 3, b.Count())
+
+	t.Run("cancelled context", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		require.ErrorIs(t, b.disk.RecomputeNetAdditions(cancelled), context.Canceled)
+	})
+
+	t.Run("fixes drifted counts", func(t *testing.T) {
+		b.disk.segments[0].countNetAdditions.Store(100)
+		b.disk.segments[1].countNetAdditions.Store(-7)
+		require.NoError(t, b.disk.segments[1].storeCountNetOnDisk())
+		require.Equal(t, 93, b.Count())
+
+		require.NoError(t, b.disk.RecomputeNetAdditions(ctx))
+		assert.Equal(t, 3, b.Count())
+		assert.Equal(t, int64(3), b.disk.segments[0].countNetAdditions.Load())
+		assert.Equal(t, int64(0), b.disk.segments[1].countNetAdditions.Load())
+	})
+
+	t.Run("recomputed counts are persisted", func(t *testing.T) {
+		require.NoError(t, b.Shutdown(ctx))
+
+		b = openBucket(t)
+		defer b.Shutdown(ctx)
+		assert.Equal(t, 3, b.Count())
+	})
+}
@@ -0,0 +1,221 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/edsrzf/mmap-go"
+	"github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+)
+
+// OpenFileDescriptors returns the number of segments that currently hold
+// their file open for pread. Memory-mapped segments close their file after
+// mapping it, so they are not counted.
+func (sg *SegmentGroup) OpenFileDescriptors() int {
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	return sg.openFileDescriptorsUnlocked()
+}
+
+// closeLeastRecentlyReadSegments makes sure no more than maxOpenSegmentFiles
+// pread segments hold their files open. If the limit is exceeded, the
+// contents of the least recently read segments are released. They are
+// reopened lazily on their next access.
+//
+// Segments can be reopened by readers at any time, so the limit is a soft
+// one: it is enforced periodically as part of the compaction cycle, but can
+// temporarily be exceeded in between.
+func (sg *SegmentGroup) closeLeastRecentlyReadSegments() error {
+	if sg.maxOpenSegmentFiles <= 0 {
+		return nil
+	}
+
+	if sg.OpenFileDescriptors() <= sg.maxOpenSegmentFiles {
+		return nil
+	}
+
+	// compactions and cleanups read from segments without holding the
+	// maintenance lock, but they run on the same cycle as this method, so they
+	// can't be in progress at this point. Flushes hold a long-lived RLock,
+	// see replaceCompactedSegmentsBlocking for details on this lock.
+	sg.flushVsCompactLock.Lock()
+	defer sg.flushVsCompactLock.Unlock()
+
+	sg.maintenanceLock.Lock()
+	defer sg.maintenanceLock.Unlock()
+
+	open := make([]*segment, 0, len(sg.segments))
+	for _, seg := range sg.segments {
+		if seg.holdsFileDescriptor() && seg.canReleaseContents() {
+			open = append(open, seg)
+		}
+	}
+
+	excess := sg.openFileDescriptorsUnlocked() - sg.maxOpenSegmentFiles
+	if excess <= 0 {
+		return nil
+	}
+
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].lastRead.Load() < open[j].lastRead.Load()
+	})
+
+	for i := 0; i < excess && i < len(open); i++ {
+		if err := open[i].releaseContents(); err != nil {
+			return fmt.Errorf("release contents of segment %q: %w", open[i].path, err)
+		}
+	}
+
+	sg.logger.WithFields(logrus.Fields{
+		"action":   "lsm_segment_group_close_segment_files",
+		"path":     sg.dir,
+		"released": min(excess, len(open)),
+		"max_open": sg.maxOpenSegmentFiles,
+	}).Debug("released least recently read segment files")
+
+	return nil
+}
+
+func (sg *SegmentGroup) openFileDescriptorsUnlocked() int {
+	count := 0
+	for _, seg := range sg.segments {
+		if seg.holdsFileDescriptor() {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *segment) isContentsOpen() bool {
+	return !s.contentsReleased.Load()
+}
+
+// holdsFileDescriptor is true for pread segments with open contents, see
+// newSegment
+func (s *segment) holdsFileDescriptor() bool {
+	return !s.mmapContents && s.isContentsOpen()
+}
+
+// canReleaseContents is false for inverted segments, as they keep data
+// derived from their contents in memory which can't be rebuilt on reopen, for
+// pinned segments, as unmapping their contents would unlock them, and for
+// segments acquired by readers
+func (s *segment) canReleaseContents() bool {
+	return s.strategy != segmentindex.StrategyInverted && !s.pinned.Load() &&
+		!s.isAcquired()
+}
+
+// releaseContents unmaps the contents of the segment and closes its file.
+// Must only be called while no reader can access the segment, i.e. while
+// holding the maintenanceLock of the segment group exclusively.
+func (s *segment) releaseContents() error {
+	s.contentsLock.Lock()
+	defer s.contentsLock.Unlock()
+
+	if s.contentsReleased.Load() {
+		return nil
+	}
+
+	if err := s.closeContents(); err != nil {
+		return err
+	}
+
+	s.contents = nil
+	s.contentFile = nil
+	s.contentsReleased.Store(true)
+	return nil
+}
+
+// ensureContentsOpen reopens the contents of the segment if they were
+// released and records the access for the least-recently-read eviction. It
+// is safe to be called concurrently by readers.
+func (s *segment) ensureContentsOpen() error {
+	s.lastRead.Store(time.Now().UnixNano())
+
+	if !s.contentsReleased.Load() {
+		return nil
+	}
+
+	s.contentsLock.Lock()
+	defer s.contentsLock.Unlock()
+
+	if !s.contentsReleased.Load() {
+		// reopened by a concurrent reader
+		return nil
+	}
+
+	if err := s.reopenContents(); err != nil {
+		return fmt.Errorf("reopen segment %q: %w", s.path, err)
+	}
+
+	s.contentsReleased.Store(false)
+	return nil
+}
+
+// cursorOpenFailed logs that a cursor can't read the segments of the bucket,
+// e.g. because released contents can't be reopened after running out of file
+// descriptors. Such a cursor holds no entries.
+func (b *Bucket) cursorOpenFailed(err error) error {
+	err = fmt.Errorf("open segments of bucket %q: %w", b.dir, err)
+	b.logger.WithError(err).WithField("action", "lsm_bucket_cursor_open_segments").
+		Error("cursor can't read segments")
+	return err
+}
+
+func (s *segment) reopenContents() error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+
+	contents, err := mmap.MapRegion(file, int(s.size), mmap.RDONLY, 0, 0)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("mmap file: %w", err)
+	}
+
+	header, err := readSegmentHeader(file, contents, s.mmapContents)
+	if err != nil {
+		contents.Unmap()
+		file.Close()
+		return fmt.Errorf("parse header: %w", err)
+	}
+
+	primaryIndex, secondaryIndices, err := loadSegmentIndexes(header, file,
+		contents, s.size, s.mmapContents)
+	if err != nil {
+		contents.Unmap()
+		file.Close()
+		return err
+	}
+
+	s.contents = contents
+	s.index = primaryIndex
+	if len(secondaryIndices) > 0 {
+		s.secondaryIndices = secondaryIndices
+	}
+
+	// see newSegment, only pread segments keep their file open
+	if s.mmapContents {
+		file.Close()
+	} else {
+		s.contentFile = file
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_MaxOpenSegmentFiles(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	segmentCount := 5
+	key := func(i int) []byte { return []byte(fmt.Sprintf("key-%d", i)) }
+	val := func(i int) []byte { return []byte(fmt.Sprintf("value-%d", i)) }
+
+	newBucket := func(t *testing.T, pread bool) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace), WithPread(pread), WithMaxOpenSegmentFiles(2))
+		require.Nil(t, err)
+
+		for i := 0; i < segmentCount; i++ {
+			require.Nil(t, b.Put(key(i), val(i)))
+			require.Nil(t, b.FlushAndSwitch())
+		}
+		return b
+	}
+
+	t.Run("mmapped segments hold no file descriptors", func(t *testing.T) {
+		b := newBucket(t, false)
+		defer b.Shutdown(ctx)
+
+		require.Equal(t, segmentCount, b.disk.Len())
+		assert.Equal(t, 0, b.disk.OpenFileDescriptors())
+
+		require.Nil(t, b.disk.closeLeastRecentlyReadSegments())
+		for _, seg := range b.disk.segments {
+			assert.True(t, seg.isContentsOpen())
+		}
+	})
+
+	t.Run("pread", func(t *testing.T) {
+		func() {
+			b := newBucket(t, true)
+			defer b.Shutdown(ctx)
+
+			sg := b.disk
+			require.Equal(t, segmentCount, sg.Len())
+			assert.Equal(t, segmentCount, sg.OpenFileDescriptors())
+
+			t.Run("least recently read segments are closed", func(t *testing.T) {
+				// read from the oldest segments last, so the middle ones become the
+				// least recently read
+				for _, i := range []int{2, 3, 4, 0, 1} {
+					v, err := b.Get(key(i))
+					require.Nil(t, err)
+					require.Equal(t, val(i), v)
+				}
+
+				require.Nil(t, sg.closeLeastRecentlyReadSegments())
+				assert.Equal(t, 2, sg.OpenFileDescriptors())
+
+				for i, seg := range sg.segments {
+					assert.Equal(t, i < 2, seg.isContentsOpen(), "segment %d", i)
+				}
+			})
+
+			t.Run("closed segments are reopened lazily on get", func(t *testing.T) {
+				v, err := b.Get(key(3))
+				require.Nil(t, err)
+				assert.Equal(t, val(3), v)
+				assert.Equal(t, 3, sg.OpenFileDescriptors())
+				assert.True(t, sg.segments[3].isContentsOpen())
+			})
+
+			t.Run("closed segments are reopened lazily by cursors", func(t *testing.T) {
+				require.Nil(t, sg.closeLeastRecentlyReadSegments())
+				assert.Equal(t, 2, sg.OpenFileDescriptors())
+
+				c := b.Cursor()
+				count := 0
+				for k, v := c.First(); k != nil; k, v = c.Next() {
+					assert.Equal(t, val(count), v)
+					count++
+				}
+				c.Close()
+
+				assert.Equal(t, segmentCount, count)
+				assert.Equal(t, segmentCount, sg.OpenFileDescriptors())
+			})
+
+			t.Run("no files are closed without exceeding the limit", func(t *testing.T) {
+				sg.maxOpenSegmentFiles = 0
+				require.Nil(t, sg.closeLeastRecentlyReadSegments())
+				assert.Equal(t, segmentCount, sg.OpenFileDescriptors())
+			})
+
+			t.Run("shutdown with closed segments", func(t *testing.T) {
+				sg.maxOpenSegmentFiles = 1
+				require.Nil(t, sg.closeLeastRecentlyReadSegments())
+				assert.Equal(t, 1, sg.OpenFileDescriptors())
+			})
+		}()
+	})
+
+	t.Run("segments which can't be reopened report errors", func(t *testing.T) {
+		b := newBucket(t, true)
+		defer b.Shutdown(ctx)
+
+		sg := b.disk
+		require.Nil(t, sg.closeLeastRecentlyReadSegments())
+		pos := -1
+		for i, seg := range sg.segments {
+			if !seg.isContentsOpen() {
+				pos = i
+			}
+		}
+		require.GreaterOrEqual(t, pos, 0)
+		released := sg.segments[pos]
+
+		// simulates any error on reopen, e.g. running out of file descriptors
+		hidden := released.path + ".hidden"
+		require.Nil(t, os.Rename(released.path, hidden))
+		defer os.Rename(hidden, released.path)
+
+		_, err := b.Get(key(pos))
+		assert.Error(t, err)
+
+		c := b.Cursor()
+		k, _ := c.First()
+		assert.Nil(t, k)
+		assert.Error(t, c.Err())
+		c.Close()
+
+		_, err = sg.NewCursor()
+		assert.Error(t, err)
+
+		it := sg.Iterator(IteratorOpts{})
+		assert.False(t, it.Next())
+		assert.Error(t, it.Err())
+		it.Close()
+
+		// the locks were released, so flushes are still possible
+		require.Nil(t, b.Put(key(segmentCount), val(segmentCount)))
+		require.Nil(t, b.FlushAndSwitch())
+	})
+}
@@ -0,0 +1,72 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentPartialFlush(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+	key, value := []byte("key"), []byte("value")
+
+	newBucket := func(dir string) (*Bucket, error) {
+		return NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+	}
+
+	// flushSegment flushes a single segment and returns its path together with
+	// the contents of the WAL it was flushed from
+	flushSegment := func(t *testing.T, dir string) (string, []byte) {
+		b, err := newBucket(dir)
+		require.Nil(t, err)
+		defer b.Shutdown(ctx)
+
+		require.Nil(t, b.Put(key, value))
+		require.Nil(t, b.active.commitlog.flushBuffers())
+		wal, err := os.ReadFile(b.active.path + ".wal")
+		require.Nil(t, err)
+
+		path := b.active.path
+		require.Nil(t, b.FlushAndSwitch())
+		return path, wal
+	}
+
+	t.Run("crash between segment write and WAL delete", func(t *testing.T) {
+		dir := t.TempDir()
+		path, wal := flushSegment(t, dir)
+
+		// the segment was only partially written, the WAL is still present
+		info, err := os.Stat(path + ".db")
+		require.Nil(t, err)
+		require.Nil(t, os.Truncate(path+".db", info.Size()/2))
+		require.Nil(t, os.WriteFile(path+".wal", wal, 0o666))
+
+		b, err := newBucket(dir)
+		require.Nil(t, err)
+		defer b.Shutdown(ctx)
+
+		res, err := b.Get(key)
+		require.Nil(t, err)
+		assert.Equal(t, value, res)
+	})
+
+}
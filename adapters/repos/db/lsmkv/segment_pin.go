@@ -0,0 +1,151 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrPinUnsupported is returned by PinSegment on platforms which can't lock
+// memory
+var ErrPinUnsupported = errors.New("pinning segments is not supported on this platform")
+
+// PinSegment locks the contents of the segment with the given ID in memory,
+// so that its pages are not evicted and reads of it never wait for the disk.
+// This is a tuning knob for latency-critical buckets, e.g. to keep the most
+// recent segments resident.
+//
+// The size of the segment together with all segments already pinned in the
+// segment group is checked against the alloc checker first, the pin is
+// rejected if there is not enough memory. Pinned segments keep their
+// contents open even if there are more open segment files than configured.
+// When a pinned segment is compacted or cleaned up, the resulting segment is
+// pinned again as long as the memory allows it.
+//
+// Locking memory may be restricted by RLIMIT_MEMLOCK, in which case an error
+// is returned as well. Pinning an already pinned segment is a no-op.
+func (sg *SegmentGroup) PinSegment(id string) error {
+	sg.pinLock.Lock()
+	defer sg.pinLock.Unlock()
+
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	seg := sg.segmentByIDLocked(id)
+	if seg == nil {
+		return fmt.Errorf("segment %q not found", id)
+	}
+	return sg.pinSegmentLocked(seg)
+}
+
+// UnpinSegment releases the pin of the segment with the given ID, its pages
+// can be evicted again. Unknown and unpinned segments are ignored.
+func (sg *SegmentGroup) UnpinSegment(id string) {
+	sg.pinLock.Lock()
+	defer sg.pinLock.Unlock()
+
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	seg := sg.segmentByIDLocked(id)
+	if seg == nil || !seg.pinned.Load() {
+		return
+	}
+
+	if err := munlock(seg.contents); err != nil {
+		// the pages stay locked until the segment is closed, there is nothing
+		// else to do about it
+		sg.logger.WithFields(logrus.Fields{
+			"action": "lsm_segment_unpin",
+			"path":   seg.path,
+		}).WithError(err).Warn("failed to unlock segment contents")
+	}
+	seg.pinned.Store(false)
+	sg.pinnedBytes -= seg.size
+}
+
+// pinSegmentLocked must be called while holding the pinLock and the
+// maintenanceLock
+func (sg *SegmentGroup) pinSegmentLocked(seg *segment) error {
+	if seg.pinned.Load() {
+		return nil
+	}
+
+	// locked pages are not part of the heap the alloc checker observes, so
+	// the segments pinned before are accounted for as well
+	if sg.allocChecker != nil {
+		if err := sg.allocChecker.CheckAlloc(sg.pinnedBytes + seg.size); err != nil {
+			return fmt.Errorf("pin segment %q: %w", segmentID(seg.path), err)
+		}
+	}
+
+	// contents which were released can't be released again while the
+	// maintenanceLock is held, see closeLeastRecentlyReadSegments
+	if err := seg.ensureContentsOpen(); err != nil {
+		return err
+	}
+	if err := mlock(seg.contents); err != nil {
+		return fmt.Errorf("pin segment %q: %w", segmentID(seg.path), err)
+	}
+
+	seg.pinned.Store(true)
+	sg.pinnedBytes += seg.size
+	return nil
+}
+
+func (sg *SegmentGroup) segmentByIDLocked(id string) *segment {
+	for _, seg := range sg.segments {
+		if segmentID(seg.path) == id {
+			return seg
+		}
+	}
+	return nil
+}
+
+// repinReplacedSegment pins the segment at pos if any of the segments it
+// replaced, e.g. by a compaction, was pinned. It is called after the
+// replacement, so that reads are not blocked while the new contents are
+// locked. A failed pin is logged, the new segment then stays unpinned.
+func (sg *SegmentGroup) repinReplacedSegment(pos int, replaced ...*segment) {
+	wasPinned := false
+	for _, seg := range replaced {
+		wasPinned = wasPinned || seg.pinned.Load()
+	}
+	if !wasPinned {
+		return
+	}
+
+	sg.pinLock.Lock()
+	defer sg.pinLock.Unlock()
+
+	// the replaced segments were closed, which released their pins
+	for _, seg := range replaced {
+		if seg.pinned.Load() {
+			seg.pinned.Store(false)
+			sg.pinnedBytes -= seg.size
+		}
+	}
+
+	sg.maintenanceLock.RLock()
+	defer sg.maintenanceLock.RUnlock()
+
+	seg := sg.segments[pos]
+	if err := sg.pinSegmentLocked(seg); err != nil {
+		sg.logger.WithFields(logrus.Fields{
+			"action": "lsm_segment_pin",
+			"path":   seg.path,
+		}).WithError(err).Warn("failed to pin segment replacing a pinned segment")
+	}
+}
@@ -0,0 +1,24 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+//go:build linux
+
+package lsmkv
+
+import "golang.org/x/sys/unix"
+
+func mlock(b []byte) error {
+	return unix.Mlock(b)
+}
+
+func munlock(b []byte) error {
+	return unix.Munlock(b)
+}
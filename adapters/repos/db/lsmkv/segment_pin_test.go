@@ -0,0 +1,150 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegmentGroup_PinSegment(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pinning segments is only supported on linux")
+	}
+
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	newBucket := func(t *testing.T, segments int, opts ...BucketOption) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			append([]BucketOption{WithStrategy(StrategyReplace)}, opts...)...)
+		require.Nil(t, err)
+		t.Cleanup(func() { b.Shutdown(ctx) })
+
+		for i := 0; i < segments; i++ {
+			require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+			require.Nil(t, b.FlushAndSwitch())
+		}
+		return b
+	}
+	firstSegmentID := func(b *Bucket) string {
+		return segmentID(b.disk.segments[0].path)
+	}
+
+	t.Run("pin and unpin", func(t *testing.T) {
+		b := newBucket(t, 1)
+		id := firstSegmentID(b)
+
+		require.Nil(t, b.disk.PinSegment(id))
+		assert.True(t, b.disk.Stats().Segments[0].Pinned)
+		assert.False(t, b.disk.segments[0].canReleaseContents())
+		// pinning twice is a no-op
+		require.Nil(t, b.disk.PinSegment(id))
+
+		v, err := b.Get([]byte("key-0"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value"), v)
+
+		b.disk.UnpinSegment(id)
+		assert.False(t, b.disk.Stats().Segments[0].Pinned)
+		assert.True(t, b.disk.segments[0].canReleaseContents())
+		// unpinning twice or unknown segments is ignored
+		b.disk.UnpinSegment(id)
+		b.disk.UnpinSegment("unknown")
+	})
+
+	t.Run("unknown segment", func(t *testing.T) {
+		b := newBucket(t, 1)
+
+		err := b.disk.PinSegment("unknown")
+		assert.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("rejected by the memory budget", func(t *testing.T) {
+		b := newBucket(t, 1, WithAllocChecker(&fakeAllocChecker{err: errors.New("not enough memory")}))
+
+		err := b.disk.PinSegment(firstSegmentID(b))
+		assert.ErrorContains(t, err, "not enough memory")
+		assert.False(t, b.disk.Stats().Segments[0].Pinned)
+	})
+
+	t.Run("pinned segments count towards the memory budget", func(t *testing.T) {
+		checker := &budgetAllocChecker{}
+		b := newBucket(t, 2, WithAllocChecker(checker))
+		first, second := b.disk.segments[0], b.disk.segments[1]
+		// enough for either segment, but not for both
+		checker.budget = first.size
+		if second.size > checker.budget {
+			checker.budget = second.size
+		}
+
+		require.Nil(t, b.disk.PinSegment(segmentID(first.path)))
+		err := b.disk.PinSegment(segmentID(second.path))
+		assert.ErrorContains(t, err, "exceeds budget")
+		assert.Equal(t, first.size, b.disk.pinnedBytes)
+
+		b.disk.UnpinSegment(segmentID(first.path))
+		assert.Equal(t, int64(0), b.disk.pinnedBytes)
+		require.Nil(t, b.disk.PinSegment(segmentID(second.path)))
+		assert.Equal(t, second.size, b.disk.pinnedBytes)
+	})
+
+	t.Run("compacted segment is pinned again", func(t *testing.T) {
+		b := newBucket(t, 2)
+		require.Nil(t, b.disk.PinSegment(firstSegmentID(b)))
+
+		compacted, err := b.disk.compactOnce()
+		require.Nil(t, err)
+		require.True(t, compacted)
+
+		require.Len(t, b.disk.segments, 1)
+		assert.True(t, b.disk.Stats().Segments[0].Pinned)
+		// the replaced segment is no longer part of the total
+		assert.Equal(t, b.disk.segments[0].size, b.disk.pinnedBytes)
+	})
+
+	t.Run("gc compacted segment is pinned again", func(t *testing.T) {
+		b := newBucket(t, 0)
+		require.Nil(t, b.Put([]byte("key"), []byte("value")))
+		require.Nil(t, b.Delete([]byte("deleted")))
+		require.Nil(t, b.FlushAndSwitch())
+		require.Nil(t, b.disk.PinSegment(firstSegmentID(b)))
+
+		compacted, err := b.disk.gcCompactOnce(func() bool { return false })
+		require.Nil(t, err)
+		require.True(t, compacted)
+
+		assert.True(t, b.disk.Stats().Segments[0].Pinned)
+	})
+}
+
+// budgetAllocChecker rejects allocations larger than budget
+type budgetAllocChecker struct {
+	fakeAllocChecker
+	budget int64
+}
+
+func (c *budgetAllocChecker) CheckAlloc(sizeInBytes int64) error {
+	if sizeInBytes > c.budget {
+		return fmt.Errorf("%d bytes exceeds budget of %d", sizeInBytes, c.budget)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+)
+
+// Segments are always memory-mapped, but with mmapContents disabled point
+// lookups never touch the mapping: the header and the indexes are read into
+// memory with pread when the segment is opened, and nodes are read from the
+// file on every access, see newNodeReader and copyNode.
+//
+// This matters on network filesystems, where accessing a mapping of a file
+// which was truncated or became unavailable raises SIGBUS instead of
+// returning an error. Cursors of some strategies and the recalculation of
+// net additions still read from the mapping.
+
+// readSegmentHeader parses the header from the mapped contents or, with pread,
+// directly from the file.
+func readSegmentHeader(file *os.File, contents []byte, mmapContents bool) (*segmentindex.Header, error) {
+	if mmapContents {
+		return segmentindex.ParseHeader(bytes.NewReader(contents[:segmentindex.HeaderSize]))
+	}
+	return segmentindex.ParseHeader(io.NewSectionReader(file, 0, segmentindex.HeaderSize))
+}
+
+// loadSegmentIndexes returns the primary and secondary indexes of a segment.
+// With mmapContents they point into the mapped contents, otherwise the index
+// region is read into memory.
+func loadSegmentIndexes(header *segmentindex.Header, file *os.File,
+	contents []byte, size int64, mmapContents bool,
+) (diskIndex, []diskIndex, error) {
+	secondaries := make([]diskIndex, header.SecondaryIndices)
+
+	if mmapContents {
+		primary, err := header.PrimaryIndex(contents)
+		if err != nil {
+			return nil, nil, fmt.Errorf("extract primary index position: %w", err)
+		}
+		for i := range secondaries {
+			secondary, err := header.SecondaryIndex(contents, uint16(i))
+			if err != nil {
+				return nil, nil, fmt.Errorf("get position for secondary index at %d: %w", i, err)
+			}
+			secondaries[i] = segmentindex.NewDiskTree(secondary)
+		}
+		return segmentindex.NewDiskTree(primary), secondaries, nil
+	}
+
+	if uint64(size) < header.IndexStart {
+		return nil, nil, fmt.Errorf("index start %d exceeds segment size %d",
+			header.IndexStart, size)
+	}
+
+	// the index region runs from IndexStart to the end of the file
+	region := make([]byte, uint64(size)-header.IndexStart)
+	if _, err := file.ReadAt(region, int64(header.IndexStart)); err != nil {
+		return nil, nil, fmt.Errorf("read index region: %w", err)
+	}
+
+	if header.SecondaryIndices == 0 {
+		return segmentindex.NewDiskTree(region), secondaries, nil
+	}
+
+	// the region starts with the absolute offsets of the secondary indexes,
+	// the primary index sits between the offsets and the first secondary index
+	offsetsEnd := uint64(header.SecondaryIndices) * 8
+	if uint64(len(region)) < offsetsEnd {
+		return nil, nil, fmt.Errorf("index region of %d bytes too small for %d secondary indexes",
+			len(region), header.SecondaryIndices)
+	}
+	offsets := make([]uint64, header.SecondaryIndices)
+	for i := range offsets {
+		offset := binary.LittleEndian.Uint64(region[i*8:])
+		if offset < header.IndexStart+offsetsEnd || offset > uint64(size) {
+			return nil, nil, fmt.Errorf("secondary index %d at invalid offset %d", i, offset)
+		}
+		offsets[i] = offset - header.IndexStart
+	}
+
+	for i := range secondaries {
+		end := uint64(len(region))
+		if i < len(offsets)-1 {
+			end = offsets[i+1]
+		}
+		secondaries[i] = segmentindex.NewDiskTree(region[offsets[i]:end])
+	}
+
+	return segmentindex.NewDiskTree(region[offsetsEnd:offsets[0]]), secondaries, nil
+}
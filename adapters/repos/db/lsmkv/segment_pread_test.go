@@ -0,0 +1,111 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestSegment_PreadReadPath(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyReplace), WithPread(true), WithSecondaryIndices(2))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+	require.False(t, b.mmapContents)
+
+	for seg := 0; seg < 2; seg++ {
+		for i := seg * 10; i < (seg+1)*10; i++ {
+			require.Nil(t, b.Put([]byte(fmt.Sprintf("key-%02d", i)), []byte(fmt.Sprintf("value-%02d", i)),
+				WithSecondaryKey(0, []byte(fmt.Sprintf("first-%02d", i))),
+				WithSecondaryKey(1, []byte(fmt.Sprintf("second-%02d", i)))))
+		}
+		require.Nil(t, b.FlushAndSwitch())
+	}
+	require.Equal(t, 2, b.disk.Len())
+
+	t.Run("get by primary and secondary keys", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			expected := []byte(fmt.Sprintf("value-%02d", i))
+
+			v, err := b.Get([]byte(fmt.Sprintf("key-%02d", i)))
+			require.Nil(t, err)
+			assert.Equal(t, expected, v)
+
+			v, err = b.GetBySecondary(0, []byte(fmt.Sprintf("first-%02d", i)))
+			require.Nil(t, err)
+			assert.Equal(t, expected, v)
+
+			v, err = b.GetBySecondary(1, []byte(fmt.Sprintf("second-%02d", i)))
+			require.Nil(t, err)
+			assert.Equal(t, expected, v)
+		}
+
+		v, err := b.Get([]byte("missing"))
+		require.Nil(t, err)
+		assert.Nil(t, v)
+	})
+
+	t.Run("indexes read with pread match the mapped ones", func(t *testing.T) {
+		seg := b.disk.segmentAtPos(0)
+		file, err := os.Open(seg.path)
+		require.Nil(t, err)
+		defer file.Close()
+
+		header, err := readSegmentHeader(file, nil, false)
+		require.Nil(t, err)
+		mappedHeader, err := readSegmentHeader(nil, seg.contents, true)
+		require.Nil(t, err)
+		require.Equal(t, mappedHeader, header)
+
+		primary, secondaries, err := loadSegmentIndexes(header, file, nil, seg.size, false)
+		require.Nil(t, err)
+		mappedPrimary, mappedSecondaries, err := loadSegmentIndexes(header, nil, seg.contents, seg.size, true)
+		require.Nil(t, err)
+
+		assertSameKeys := func(t *testing.T, expected, actual diskIndex) {
+			expectedKeys, err := expected.AllKeys()
+			require.Nil(t, err)
+			actualKeys, err := actual.AllKeys()
+			require.Nil(t, err)
+			assert.Len(t, actualKeys, 10)
+			assert.Equal(t, expectedKeys, actualKeys)
+		}
+
+		assertSameKeys(t, mappedPrimary, primary)
+		require.Len(t, secondaries, 2)
+		for i := range secondaries {
+			assertSameKeys(t, mappedSecondaries[i], secondaries[i])
+		}
+	})
+
+	t.Run("segments are reopened with pread", func(t *testing.T) {
+		seg := b.disk.segmentAtPos(1)
+		require.Nil(t, seg.releaseContents())
+		require.Nil(t, seg.ensureContentsOpen())
+
+		v, err := b.Get([]byte("key-15"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value-15"), v)
+	})
+}
@@ -26,7 +26,7 @@ func (sg *SegmentGroup) initAndPrecomputeNewSegment(path string) (*segment, erro
 	//
 	// The only known caller of Lock() is the compaction routine, so we can
 	// synchronize with it by holding the flushVsCompactLock.
-	sg.flushVsCompactLock.Lock()
+	sg.flushVsCompactLock.lockForFlush()
 	defer sg.flushVsCompactLock.Unlock()
 
 	// It is now safe to hold the RLock on the maintenanceLock because we know
@@ -45,6 +45,8 @@ func (sg *SegmentGroup) initAndPrecomputeNewSegment(path string) (*segment, erro
 			calcCountNetAdditions:    sg.calcCountNetAdditions,
 			overwriteDerived:         true,
 			enableChecksumValidation: sg.enableChecksumValidation,
+			maxReadRetries:           sg.maxReadRetries,
+			readRetryDelay:           sg.readRetryDelay,
 		})
 	if err != nil {
 		return nil, fmt.Errorf("init and pre-compute new segment %s: %w", path, err)
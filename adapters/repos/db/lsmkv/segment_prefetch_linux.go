@@ -0,0 +1,36 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+//go:build linux
+
+package lsmkv
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// madviseWillNeed advises the kernel to read contents[start:end] ahead.
+// madvise requires a page aligned address, contents itself is page aligned
+// as it is mmapped.
+func madviseWillNeed(contents []byte, start, end uint64) {
+	pageMask := uint64(os.Getpagesize() - 1)
+	start &^= pageMask
+	if end > uint64(len(contents)) {
+		end = uint64(len(contents))
+	}
+	if start >= end {
+		return
+	}
+
+	_ = unix.Madvise(contents[start:end], unix.MADV_WILLNEED)
+}
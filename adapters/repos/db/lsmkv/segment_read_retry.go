@@ -0,0 +1,87 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"errors"
+	"io"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxReadRetries = 3
+	defaultReadRetryDelay = 10 * time.Millisecond
+)
+
+// readerAt returns the reader used for pread access to the segment contents.
+// Failed reads are retried according to the segment's retry configuration.
+func (s *segment) readerAt() io.ReaderAt {
+	if s.maxReadRetries <= 0 {
+		return s.contentFile
+	}
+
+	return &retryingReaderAt{
+		r:          s.contentFile,
+		path:       s.path,
+		maxRetries: s.maxReadRetries,
+		delay:      s.readRetryDelay,
+		logger:     s.logger,
+		metrics:    s.metrics,
+	}
+}
+
+// retryingReaderAt retries failed reads with an exponential backoff, to ride
+// out transient storage errors, e.g. on network-attached disks. io.EOF is a
+// regular result of reading past the end of the file and is never retried.
+type retryingReaderAt struct {
+	r          io.ReaderAt
+	path       string
+	maxRetries int
+	delay      time.Duration
+	logger     logrus.FieldLogger
+	metrics    *Metrics
+}
+
+func (r *retryingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.r.ReadAt(p, off)
+	if err == nil || errors.Is(err, io.EOF) {
+		return n, err
+	}
+
+	originalErr := err
+	delay := r.delay
+	for attempt := 1; attempt <= r.maxRetries; attempt++ {
+		var errno syscall.Errno
+		errors.As(err, &errno)
+		r.logger.WithFields(logrus.Fields{
+			"action":  "lsm_segment_read_retry",
+			"path":    r.path,
+			"offset":  off,
+			"attempt": attempt,
+			"errno":   int(errno),
+		}).WithError(err).Warnf("segment read failed, retrying in %s", delay)
+		r.metrics.SegmentReadRetry()
+
+		time.Sleep(delay)
+		delay *= 2
+
+		n, err = r.r.ReadAt(p, off)
+		if err == nil || errors.Is(err, io.EOF) {
+			return n, err
+		}
+	}
+
+	return n, originalErr
+}
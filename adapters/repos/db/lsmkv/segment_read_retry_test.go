@@ -0,0 +1,96 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryingReaderAt(t *testing.T) {
+	newReader := func(r io.ReaderAt) (*retryingReaderAt, *test.Hook) {
+		logger, hook := test.NewNullLogger()
+		return &retryingReaderAt{
+			r:          r,
+			path:       "segment-123.db",
+			maxRetries: 3,
+			delay:      time.Millisecond,
+			logger:     logger,
+		}, hook
+	}
+
+	t.Run("transient error is retried", func(t *testing.T) {
+		flaky := &flakyReaderAt{r: strings.NewReader("hello world"), failures: 2}
+		r, hook := newReader(flaky)
+
+		buf := make([]byte, 5)
+		n, err := r.ReadAt(buf, 6)
+		require.Nil(t, err)
+		assert.Equal(t, 5, n)
+		assert.Equal(t, "world", string(buf))
+		assert.Equal(t, 3, flaky.calls)
+
+		require.Len(t, hook.AllEntries(), 2)
+		for _, entry := range hook.AllEntries() {
+			assert.Equal(t, logrus.WarnLevel, entry.Level)
+			assert.Equal(t, int(syscall.EIO), entry.Data["errno"])
+		}
+	})
+
+	t.Run("original error is returned once retries are exhausted", func(t *testing.T) {
+		flaky := &flakyReaderAt{r: strings.NewReader("hello world"), failures: 100}
+		r, hook := newReader(flaky)
+
+		_, err := r.ReadAt(make([]byte, 5), 0)
+		require.NotNil(t, err)
+		assert.ErrorIs(t, err, syscall.EIO)
+		assert.Contains(t, err.Error(), "attempt 1")
+		assert.Equal(t, 4, flaky.calls)
+		assert.Len(t, hook.AllEntries(), 3)
+	})
+
+	t.Run("EOF is not retried", func(t *testing.T) {
+		flaky := &flakyReaderAt{r: strings.NewReader("hello world")}
+		r, hook := newReader(flaky)
+
+		n, err := r.ReadAt(make([]byte, 10), 6)
+		assert.ErrorIs(t, err, io.EOF)
+		assert.Equal(t, 5, n)
+		assert.Equal(t, 1, flaky.calls)
+		assert.Len(t, hook.AllEntries(), 0)
+	})
+}
+
+// flakyReaderAt fails the first n calls with EIO before delegating to r
+type flakyReaderAt struct {
+	r        io.ReaderAt
+	failures int
+	calls    int
+}
+
+func (f *flakyReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return 0, fmt.Errorf("attempt %d: %w", f.calls, &os.PathError{Op: "read", Path: "segment-123.db", Err: syscall.EIO})
+	}
+	return f.r.ReadAt(p, off)
+}
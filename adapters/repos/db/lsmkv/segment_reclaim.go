@@ -0,0 +1,118 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+	"github.com/weaviate/weaviate/entities/lsmkv"
+)
+
+// errSparseWritesUnsupported is returned by punchHole if the platform or file
+// system cannot deallocate a range of a file
+var errSparseWritesUnsupported = errors.New("sparse writes not supported")
+
+// reclaimValue zeroes the value of key in all segments, deallocating the
+// underlying disk blocks. It returns the number of value bytes zeroed.
+//
+// The caller needs to make sure the key is shadowed by a tombstone, as the
+// zeroed values would otherwise become visible. The exclusive maintenanceLock
+// makes sure that no read copies a value while it is being zeroed.
+func (sg *SegmentGroup) reclaimValue(key []byte) (int64, error) {
+	// see replaceCompactedSegmentsBlocking for why the flushVsCompactLock is
+	// needed before obtaining the maintenanceLock exclusively
+	sg.flushVsCompactLock.Lock()
+	defer sg.flushVsCompactLock.Unlock()
+
+	sg.maintenanceLock.Lock()
+	defer sg.maintenanceLock.Unlock()
+
+	var total int64
+	for _, seg := range sg.segments {
+		n, err := seg.reclaimValue(key)
+		if err != nil {
+			if errors.Is(err, errSparseWritesUnsupported) {
+				sg.logger.WithField("action", "lsm_segment_reclaim_value").
+					WithField("path", seg.path).
+					Debug("skipped reclaiming value, sparse writes not supported")
+				return total, nil
+			}
+			return total, fmt.Errorf("segment %s: %w", seg.path, err)
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+// reclaimValue deallocates the value of key in place. Segments written with
+// checksums are left untouched, as modifying them would fail validation.
+func (s *segment) reclaimValue(key []byte) (int64, error) {
+	if s.strategy != segmentindex.StrategyReplace {
+		return 0, fmt.Errorf("reclaim value only possible for strategy %q", StrategyReplace)
+	}
+
+	if s.version >= segmentindex.SegmentV1 {
+		return 0, nil
+	}
+
+	if s.useBloomFilter && !s.bloomFilter.Test(key) {
+		return 0, nil
+	}
+
+	if err := s.ensureContentsOpen(); err != nil {
+		return 0, err
+	}
+
+	node, err := s.index.Get(key)
+	if err != nil {
+		if errors.Is(err, lsmkv.NotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	// byte         meaning
+	// 0         is tombstone
+	// 1-8       data length as Little Endian uint64
+	// 9-length  data
+	header := make([]byte, 9)
+	if err := s.copyNode(header, nodeOffset{node.Start, node.Start + 9}); err != nil {
+		return 0, fmt.Errorf("read node header: %w", err)
+	}
+	if header[0] == 0x01 {
+		return 0, nil
+	}
+	valueLength := binary.LittleEndian.Uint64(header[1:9])
+	if valueLength == 0 {
+		return 0, nil
+	}
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY, 0)
+	if err != nil {
+		return 0, fmt.Errorf("open segment for writing: %w", err)
+	}
+	defer f.Close()
+
+	if err := punchHole(f, int64(node.Start+9), int64(valueLength)); err != nil {
+		return 0, err
+	}
+	if err := f.Sync(); err != nil {
+		return 0, fmt.Errorf("fsync segment: %w", err)
+	}
+
+	return int64(valueLength), nil
+}
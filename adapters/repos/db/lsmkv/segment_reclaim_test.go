@@ -0,0 +1,100 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/cyclemanager"
+)
+
+func TestBucket_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+	dir := t.TempDir()
+
+	openBucket := func(t *testing.T) *Bucket {
+		b, err := NewBucketCreator().NewBucket(ctx, dir, "", logger, nil,
+			cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+			WithStrategy(StrategyReplace))
+		require.Nil(t, err)
+		return b
+	}
+
+	b := openBucket(t)
+
+	large := bytes.Repeat([]byte{0xab}, 1024*1024)
+	require.Nil(t, b.Put([]byte("large"), large))
+	require.Nil(t, b.Put([]byte("kept"), []byte("value")))
+	require.Nil(t, b.FlushAndSwitch())
+	segmentPath := b.disk.segmentAtPos(0).path
+
+	t.Run("value is reclaimed", func(t *testing.T) {
+		require.Nil(t, b.active.setTombstone([]byte("large")))
+
+		reclaimed, err := b.disk.reclaimValue([]byte("large"))
+		require.Nil(t, err)
+		if reclaimed == 0 {
+			t.Skip("file system does not support sparse writes")
+		}
+		assert.Equal(t, int64(len(large)), reclaimed)
+
+		contents, err := os.ReadFile(segmentPath)
+		require.Nil(t, err)
+		assert.False(t, bytes.Contains(contents, large[:4096]))
+	})
+
+	t.Run("soft deleted key is gone", func(t *testing.T) {
+		require.Nil(t, b.SoftDelete([]byte("large")))
+
+		v, err := b.Get([]byte("large"))
+		require.Nil(t, err)
+		assert.Nil(t, v)
+
+		v, err = b.Get([]byte("kept"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value"), v)
+	})
+
+	t.Run("tombstone survives a restart", func(t *testing.T) {
+		require.Nil(t, b.Shutdown(ctx))
+		b = openBucket(t)
+		defer b.Shutdown(ctx)
+
+		v, err := b.Get([]byte("large"))
+		require.Nil(t, err)
+		assert.Nil(t, v)
+
+		v, err = b.Get([]byte("kept"))
+		require.Nil(t, err)
+		assert.Equal(t, []byte("value"), v)
+	})
+}
+
+func TestBucket_SoftDeleteRequiresReplaceStrategy(t *testing.T) {
+	ctx := context.Background()
+	logger, _ := test.NewNullLogger()
+
+	b, err := NewBucketCreator().NewBucket(ctx, t.TempDir(), "", logger, nil,
+		cyclemanager.NewCallbackGroupNoop(), cyclemanager.NewCallbackGroupNoop(),
+		WithStrategy(StrategyMapCollection))
+	require.Nil(t, err)
+	defer b.Shutdown(ctx)
+
+	assert.ErrorContains(t, b.SoftDelete([]byte("key")), "soft delete only possible")
+}
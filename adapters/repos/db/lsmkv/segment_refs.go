@@ -0,0 +1,72 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// acquire keeps the contents of the segment open until release is called.
+// This allows long-lived readers, such as cursors, to read the segment
+// without holding the maintenanceLock. If the segment is replaced by a
+// compaction or cleanup in the meantime, closing it is deferred until the
+// last reference is released. Its files may be deleted before that, but the
+// open contents stay readable.
+//
+// acquire must be called while holding the maintenanceLock, so that the
+// segment isn't closed concurrently.
+func (s *segment) acquire() {
+	s.refsLock.Lock()
+	defer s.refsLock.Unlock()
+
+	s.refs++
+}
+
+// release drops a reference taken by acquire and closes the segment if it was
+// closed while being acquired
+func (s *segment) release() {
+	s.refsLock.Lock()
+	defer s.refsLock.Unlock()
+
+	s.refs--
+	if s.refs > 0 || !s.closePending {
+		return
+	}
+
+	s.closePending = false
+	if err := s.closeContents(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"action": "lsm_segment_release",
+			"path":   s.path,
+		}).WithError(err).Error("failed to close released segment")
+	}
+}
+
+func (s *segment) isAcquired() bool {
+	s.refsLock.Lock()
+	defer s.refsLock.Unlock()
+
+	return s.refs > 0
+}
+
+// deferCloseWhileAcquired reports whether the segment is acquired by a
+// reader. The segment is then closed by the last release instead.
+func (s *segment) deferCloseWhileAcquired() bool {
+	s.refsLock.Lock()
+	defer s.refsLock.Unlock()
+
+	if s.refs == 0 {
+		return false
+	}
+	s.closePending = true
+	return true
+}
@@ -22,29 +22,17 @@ import (
 )
 
 func (s *segment) get(key []byte) ([]byte, error) {
-	if s.strategy != segmentindex.StrategyReplace {
-		return nil, fmt.Errorf("get only possible for strategy %q", StrategyReplace)
-	}
-
-	before := time.Now()
-
-	if s.useBloomFilter && !s.bloomFilter.Test(key) {
-		s.bloomFilterMetrics.trueNegative(before)
-		return nil, lsmkv.NotFound
-	}
-
-	node, err := s.index.Get(key)
+	node, before, err := s.lookupNode(key)
 	if err != nil {
-		if errors.Is(err, lsmkv.NotFound) {
-			if s.useBloomFilter {
-				s.bloomFilterMetrics.falsePositive(before)
-			}
-			return nil, lsmkv.NotFound
-		} else {
-			return nil, err
-		}
+		return nil, err
 	}
 
+	return s.readValue(node, before)
+}
+
+// readValue reads the value of node and records the bloom filter true
+// positive, before is the start of the lookup
+func (s *segment) readValue(node segmentindex.Node, before time.Time) ([]byte, error) {
 	defer func() {
 		if s.useBloomFilter {
 			s.bloomFilterMetrics.truePositive(before)
@@ -63,7 +51,7 @@ func (s *segment) get(key []byte) ([]byte, error) {
 	// Similar approach was used to fix SEGFAULT in collection strategy
 	// https://github.com/weaviate/weaviate/issues/1837
 	contentsCopy := make([]byte, node.End-node.Start)
-	if err = s.copyNode(contentsCopy, nodeOffset{node.Start, node.End}); err != nil {
+	if err := s.copyNode(contentsCopy, nodeOffset{node.Start, node.End}); err != nil {
 		return nil, err
 	}
 
@@ -75,6 +63,71 @@ func (s *segment) get(key []byte) ([]byte, error) {
 	return v, nil
 }
 
+// getZeroCopy is like get, but for mmapped segments the value is not copied
+// and points directly into the mmapped contents. It is only valid while the
+// caller holds the maintenance lock of the segment group, see
+// SegmentGroup.getZeroCopy. Segments which are not mmapped fall back to get.
+func (s *segment) getZeroCopy(key []byte) ([]byte, error) {
+	if !s.mmapContents {
+		return s.get(key)
+	}
+
+	node, before, err := s.lookupNode(key)
+	if err != nil {
+		return nil, err
+	}
+	if s.useBloomFilter {
+		s.bloomFilterMetrics.truePositive(before)
+	}
+
+	_, v, err := s.replaceStratParseData(s.contents[node.Start:node.End])
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// lookupNode finds the index node of key. The bloom filter metrics are
+// recorded for negative lookups only, the caller records the true positive
+// once it has read the value. before is the start of the lookup.
+func (s *segment) lookupNode(key []byte) (node segmentindex.Node, before time.Time, err error) {
+	if s.strategy != segmentindex.StrategyReplace {
+		return node, before, fmt.Errorf("get only possible for strategy %q", StrategyReplace)
+	}
+
+	before = time.Now()
+
+	if s.useBloomFilter && !s.bloomFilter.Test(key) {
+		s.bloomFilterMetrics.trueNegative(before)
+		return node, before, lsmkv.NotFound
+	}
+
+	node, err = s.lookupIndexNode(key, before)
+	return node, before, err
+}
+
+// lookupIndexNode is lookupNode without the bloom filter check, for callers
+// which already checked the bloom filter
+func (s *segment) lookupIndexNode(key []byte, before time.Time) (segmentindex.Node, error) {
+	if err := s.ensureContentsOpen(); err != nil {
+		return segmentindex.Node{}, err
+	}
+
+	node, err := s.index.Get(key)
+	if err != nil {
+		if errors.Is(err, lsmkv.NotFound) {
+			if s.useBloomFilter {
+				s.bloomFilterMetrics.falsePositive(before)
+			}
+			return node, lsmkv.NotFound
+		}
+		return node, err
+	}
+
+	return node, nil
+}
+
 func (s *segment) getBySecondaryIntoMemory(pos int, key []byte, buffer []byte) ([]byte, []byte, []byte, error) {
 	if s.strategy != segmentindex.StrategyReplace {
 		return nil, nil, nil, fmt.Errorf("get only possible for strategy %q", StrategyReplace)
@@ -88,6 +141,10 @@ func (s *segment) getBySecondaryIntoMemory(pos int, key []byte, buffer []byte) (
 		return nil, nil, nil, lsmkv.NotFound
 	}
 
+	if err := s.ensureContentsOpen(); err != nil {
+		return nil, nil, nil, err
+	}
+
 	node, err := s.secondaryIndices[pos].Get(key)
 	if err != nil {
 		return nil, nil, nil, err
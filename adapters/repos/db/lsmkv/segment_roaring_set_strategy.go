@@ -31,6 +31,10 @@ func (s *segment) roaringSetGet(key []byte) (roaringset.BitmapLayer, error) {
 		return out, lsmkv.NotFound
 	}
 
+	if err := s.ensureContentsOpen(); err != nil {
+		return out, err
+	}
+
 	node, err := s.index.Get(key)
 	if err != nil {
 		return out, err
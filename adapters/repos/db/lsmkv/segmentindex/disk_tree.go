@@ -13,6 +13,7 @@ package segmentindex
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -172,6 +173,78 @@ func (t *DiskTree) seekAt(offset int64, key []byte, includingKey bool) (Node, er
 	}
 }
 
+// Prev returns the node with the highest key that is strictly lower than the
+// provided key, or lsmkv.NotFound if no such node exists
+func (t *DiskTree) Prev(key []byte) (Node, error) {
+	if len(t.data) == 0 {
+		return Node{}, lsmkv.NotFound
+	}
+
+	return t.prevAt(0, key)
+}
+
+func (t *DiskTree) prevAt(offset int64, key []byte) (Node, error) {
+	node, err := t.readNodeAt(offset)
+	if err != nil {
+		return Node{}, err
+	}
+
+	if bytes.Compare(node.key, key) >= 0 {
+		if node.leftChild < 0 {
+			return Node{}, lsmkv.NotFound
+		}
+
+		return t.prevAt(node.leftChild, key)
+	}
+
+	self := Node{
+		Key:   node.key,
+		Start: node.startPos,
+		End:   node.endPos,
+	}
+
+	if node.rightChild < 0 {
+		return self, nil
+	}
+
+	right, err := t.prevAt(node.rightChild, key)
+	if err == nil {
+		return right, nil
+	}
+
+	if errors.Is(err, lsmkv.NotFound) {
+		return self, nil
+	}
+
+	return Node{}, err
+}
+
+// Last returns the node with the highest key in the tree, or lsmkv.NotFound
+// if the tree is empty
+func (t *DiskTree) Last() (Node, error) {
+	if len(t.data) == 0 {
+		return Node{}, lsmkv.NotFound
+	}
+
+	offset := int64(0)
+	for {
+		node, err := t.readNodeAt(offset)
+		if err != nil {
+			return Node{}, err
+		}
+
+		if node.rightChild < 0 {
+			return Node{
+				Key:   node.key,
+				Start: node.startPos,
+				End:   node.endPos,
+			}, nil
+		}
+
+		offset = node.rightChild
+	}
+}
+
 // AllKeys is a relatively expensive operation as it basically does a full disk
 // read of the index. It is meant for one of operations, such as initializing a
 // segment where we need access to all keys, e.g. to build a bloom filter. This
@@ -200,6 +273,36 @@ func (t *DiskTree) AllKeys() ([][]byte, error) {
 	return out, nil
 }
 
+// KeyCount returns the number of nodes in the tree. Like AllKeys it reads the
+// entire index, but does not copy any of the keys.
+func (t *DiskTree) KeyCount() (int, error) {
+	count := 0
+	bufferPos := 0
+	for {
+		remaining := len(t.data) - bufferPos
+		if remaining == 0 {
+			break
+		}
+
+		// every node has at least 36 bytes: 4 bytes for the key length and 32
+		// bytes for position and children
+		if remaining < 36 {
+			return count, fmt.Errorf("%d trailing bytes at the end of the index", remaining)
+		}
+
+		keyLen := int(binary.LittleEndian.Uint32(t.data[bufferPos:]))
+		nodeLen := 36 + keyLen
+		if remaining < nodeLen {
+			return count, fmt.Errorf("node at %d exceeds index of size %d", bufferPos, len(t.data))
+		}
+
+		bufferPos += nodeLen
+		count++
+	}
+
+	return count, nil
+}
+
 func (t *DiskTree) Size() int {
 	return len(t.data)
 }
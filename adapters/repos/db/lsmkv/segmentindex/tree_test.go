@@ -194,6 +194,40 @@ func TestTree(t *testing.T) {
 			assert.Equal(t, lsmkv.NotFound, err)
 		})
 
+		t.Run("prev", func(t *testing.T) {
+			n, err := dTree.Prev([]byte("foobar"))
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("abc"), n.Key)
+			assert.Equal(t, uint64(4), n.Start)
+			assert.Equal(t, uint64(5), n.End)
+
+			n, err = dTree.Prev([]byte("zzzz"))
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("zzz"), n.Key)
+
+			n, err = dTree.Prev([]byte("zzzzz"))
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("zzzz"), n.Key)
+
+			n, err = dTree.Prev([]byte("g"))
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("foobar"), n.Key)
+
+			_, err = dTree.Prev([]byte("aaa"))
+			assert.Equal(t, lsmkv.NotFound, err)
+		})
+
+		t.Run("last", func(t *testing.T) {
+			n, err := dTree.Last()
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("zzzz"), n.Key)
+			assert.Equal(t, uint64(100), n.Start)
+			assert.Equal(t, uint64(102), n.End)
+
+			_, err = NewDiskTree(nil).Last()
+			assert.Equal(t, lsmkv.NotFound, err)
+		})
+
 		t.Run("get all keys (for building bloom filters at segment init time)", func(t *testing.T) {
 			expected := [][]byte{
 				[]byte("aaa"),
@@ -208,5 +242,18 @@ func TestTree(t *testing.T) {
 			require.Nil(t, err)
 			assert.ElementsMatch(t, expected, keys)
 		})
+
+		t.Run("count keys", func(t *testing.T) {
+			count, err := dTree.KeyCount()
+			require.Nil(t, err)
+			assert.Equal(t, 5, count)
+
+			count, err = NewDiskTree(nil).KeyCount()
+			require.Nil(t, err)
+			assert.Equal(t, 0, count)
+
+			_, err = NewDiskTree(dTree.data[:len(dTree.data)-1]).KeyCount()
+			assert.NotNil(t, err)
+		})
 	})
 }
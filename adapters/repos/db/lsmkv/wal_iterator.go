@@ -0,0 +1,181 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/weaviate/weaviate/usecases/integrity"
+)
+
+// WALIterator streams the entries of the write-ahead-log of a bucket with the
+// "replace" strategy, so only a single entry is held in memory at a time.
+//
+// Iteration stops at the end of the log or at the first entry which can't be
+// read, e.g. because its checksum is invalid or it was only partially written
+// before a crash. Err reports why iteration stopped early; all entries before
+// the broken one are valid.
+type WALIterator struct {
+	reader           io.Reader
+	checksumReader   integrity.ChecksumReader
+	closer           io.Closer
+	secondaryIndices uint16
+
+	bufRecord *bytes.Buffer
+	node      segmentReplaceNode
+	seqNo     uint64
+	done      bool
+	err       error
+}
+
+// newWALIterator opens the write-ahead-log at path. The number of secondary
+// indices of the bucket is required to parse the entries.
+func newWALIterator(path string, secondaryIndices uint16) (*WALIterator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open write-ahead-log")
+	}
+
+	it := newWALIteratorFromReader(bufio.NewReader(f), secondaryIndices)
+	it.closer = f
+	return it, nil
+}
+
+func newWALIteratorFromReader(r io.Reader, secondaryIndices uint16) *WALIterator {
+	return &WALIterator{
+		reader:           r,
+		checksumReader:   integrity.NewCRC32Reader(r),
+		secondaryIndices: secondaryIndices,
+		bufRecord:        bytes.NewBuffer(nil),
+	}
+}
+
+// Next advances to the next entry and reports whether there is one.
+func (it *WALIterator) Next() bool {
+	if it.done || it.err != nil {
+		return false
+	}
+
+	var commitType CommitType
+	err := binary.Read(it.checksumReader, binary.LittleEndian, &commitType)
+	if errors.Is(err, io.EOF) {
+		it.done = true
+		return false
+	}
+	if err != nil {
+		it.err = errors.Wrap(err, "read commit type")
+		return false
+	}
+	if !CommitTypeReplace.Is(commitType) {
+		it.err = errors.Errorf("found a %s commit on a replace bucket", commitType.String())
+		return false
+	}
+
+	var version uint8
+	if err := binary.Read(it.checksumReader, binary.LittleEndian, &version); err != nil {
+		it.err = errors.Wrap(err, "read commit version")
+		return false
+	}
+
+	var node segmentReplaceNode
+	switch version {
+	case 0:
+		// entries of version 0 are not covered by a checksum
+		node, err = ParseReplaceNode(it.reader, it.secondaryIndices)
+	case 1:
+		var record io.Reader
+		if record, err = it.readRecord(); err == nil {
+			node, err = ParseReplaceNode(record, it.secondaryIndices)
+		}
+	default:
+		err = errors.Errorf("unsupported commit version %d", version)
+	}
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.node = node
+	it.seqNo++
+	return true
+}
+
+// readRecord reads a length prefixed record and validates it against the
+// checksum following it. Like the commit logger, which never resets its hash,
+// the checksum covers everything read so far, not just the record.
+func (it *WALIterator) readRecord() (io.Reader, error) {
+	var recordLen uint32
+	if err := binary.Read(it.checksumReader, binary.LittleEndian, &recordLen); err != nil {
+		return nil, errors.Wrap(err, "read commit node length")
+	}
+
+	it.bufRecord.Reset()
+	if _, err := io.CopyN(it.bufRecord, it.checksumReader, int64(recordLen)); err != nil {
+		return nil, errors.Wrap(err, "read commit node")
+	}
+
+	var checksum [4]byte
+	if _, err := io.ReadFull(it.reader, checksum[:]); err != nil {
+		return nil, errors.Wrap(err, "read commit checksum")
+	}
+	if !bytes.Equal(checksum[:], it.checksumReader.Hash()) {
+		return nil, errors.Wrap(ErrInvalidChecksum, "read commit entry")
+	}
+
+	return it.bufRecord, nil
+}
+
+// Key returns the primary key of the current entry.
+func (it *WALIterator) Key() []byte {
+	return it.node.primaryKey
+}
+
+// Value returns the value of the current entry. It is empty for tombstones.
+func (it *WALIterator) Value() []byte {
+	return it.node.value
+}
+
+// Tombstone reports whether the current entry deletes its key.
+func (it *WALIterator) Tombstone() bool {
+	return it.node.tombstone
+}
+
+// SecondaryKeys returns the secondary keys of the current entry, if any.
+func (it *WALIterator) SecondaryKeys() [][]byte {
+	return it.node.secondaryKeys
+}
+
+// SeqNo returns the position of the current entry in the log, starting at 1.
+// The log has no sequence numbers of its own, entries are ordered by the time
+// they were written.
+func (it *WALIterator) SeqNo() uint64 {
+	return it.seqNo
+}
+
+// Err returns the error which stopped the iteration early, nil if the end of
+// the log was reached.
+func (it *WALIterator) Err() error {
+	return it.err
+}
+
+// Close closes the underlying file if the iterator was opened by path.
+func (it *WALIterator) Close() error {
+	if it.closer == nil {
+		return nil
+	}
+	return it.closer.Close()
+}
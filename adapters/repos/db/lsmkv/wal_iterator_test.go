@@ -0,0 +1,105 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package lsmkv
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWALIterator(t *testing.T) {
+	// all entries are of equal size, so the offset of each entry is known
+	const entries = 3
+	logger, _ := test.NewNullLogger()
+
+	writeWAL := func(t *testing.T) (string, int64) {
+		cl, err := newCommitLogger(path.Join(t.TempDir(), "memtable"), logger)
+		require.NoError(t, err)
+
+		for i := 0; i < entries; i++ {
+			require.NoError(t, cl.put(segmentReplaceNode{
+				primaryKey:          []byte(fmt.Sprintf("key-%d", i)),
+				value:               []byte(fmt.Sprintf("value-%d", i)),
+				tombstone:           i == entries-1,
+				secondaryIndexCount: 1,
+				secondaryKeys:       [][]byte{[]byte(fmt.Sprintf("secondary-%d", i))},
+			}))
+		}
+		require.NoError(t, cl.close())
+
+		info, err := os.Stat(cl.path)
+		require.NoError(t, err)
+		return cl.path, info.Size() / entries
+	}
+
+	readAll := func(t *testing.T, walPath string) ([]string, error) {
+		it, err := newWALIterator(walPath, 1)
+		require.NoError(t, err)
+		defer it.Close()
+
+		var keys []string
+		for it.Next() {
+			assert.Equal(t, uint64(len(keys)+1), it.SeqNo())
+			keys = append(keys, string(it.Key()))
+		}
+		return keys, it.Err()
+	}
+
+	t.Run("complete log", func(t *testing.T) {
+		walPath, _ := writeWAL(t)
+
+		it, err := newWALIterator(walPath, 1)
+		require.NoError(t, err)
+		defer it.Close()
+
+		for i := 0; i < entries; i++ {
+			require.True(t, it.Next())
+			assert.Equal(t, uint64(i+1), it.SeqNo())
+			assert.Equal(t, []byte(fmt.Sprintf("key-%d", i)), it.Key())
+			assert.Equal(t, []byte(fmt.Sprintf("value-%d", i)), it.Value())
+			assert.Equal(t, i == entries-1, it.Tombstone())
+			assert.Equal(t, [][]byte{[]byte(fmt.Sprintf("secondary-%d", i))}, it.SecondaryKeys())
+		}
+		assert.False(t, it.Next())
+		assert.NoError(t, it.Err())
+	})
+
+	t.Run("torn final entry", func(t *testing.T) {
+		walPath, entrySize := writeWAL(t)
+		require.NoError(t, os.Truncate(walPath, entries*entrySize-3))
+
+		keys, err := readAll(t, walPath)
+		assert.Equal(t, []string{"key-0", "key-1"}, keys)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid checksum", func(t *testing.T) {
+		walPath, entrySize := writeWAL(t)
+
+		f, err := os.OpenFile(walPath, os.O_RDWR, 0o666)
+		require.NoError(t, err)
+		// flip a byte in the node of the second entry
+		_, err = f.WriteAt([]byte{0xff}, entrySize+10)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		keys, err := readAll(t, walPath)
+		assert.Equal(t, []string{"key-0"}, keys)
+		assert.ErrorIs(t, err, ErrInvalidChecksum)
+	})
+}
@@ -158,6 +158,24 @@ func extractDistanceFromParams(params dto.GetParams) float32 {
 	return float32(dist)
 }
 
+// CollectionObjectCountAsync returns an estimate of the number of objects in
+// the given class, based on the eventually consistent counts of the shards
+// loaded on this node. It is not meant for exact counting, use an aggregation
+// for that.
+func (db *DB) CollectionObjectCountAsync(className string) int64 {
+	idx := db.GetIndex(schema.ClassName(className))
+	if idx == nil {
+		return 0
+	}
+
+	var count int64
+	idx.ForEachLoadedShard(func(name string, shard ShardLike) error {
+		count += int64(shard.ObjectCountAsync())
+		return nil
+	})
+	return count
+}
+
 func (db *DB) CrossClassVectorSearch(ctx context.Context, vector models.Vector, targetVector string, offset, limit int,
 	filters *filters.LocalFilter,
 ) ([]search.Result, error) {
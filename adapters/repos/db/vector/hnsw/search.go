@@ -30,6 +30,7 @@ import (
 	"github.com/weaviate/weaviate/entities/dto"
 	enterrors "github.com/weaviate/weaviate/entities/errors"
 	"github.com/weaviate/weaviate/entities/storobj"
+	ent "github.com/weaviate/weaviate/entities/vectorindex/hnsw"
 	"github.com/weaviate/weaviate/usecases/floatcomp"
 )
 
@@ -151,8 +152,14 @@ func (h *hnsw) cacheSize() int64 {
 	return size
 }
 
-func (h *hnsw) acornParams(allowList helpers.AllowList) (bool, int) {
+// acornParams returns whether the filtered search uses ACORN and the M of
+// the search. A filter strategy set on ctx, see ent.ContextWithFilterStrategy,
+// takes precedence over the one configured on the index.
+func (h *hnsw) acornParams(ctx context.Context, allowList helpers.AllowList) (bool, int) {
 	useAcorn := h.acornSearch.Load()
+	if strategy, ok := ent.FilterStrategyFromContext(ctx); ok {
+		useAcorn = strategy == ent.FilterStrategyAcorn
+	}
 	var M int
 
 	if allowList != nil && useAcorn {
@@ -182,7 +189,7 @@ func (h *hnsw) searchLayerByVectorWithDistancer(ctx context.Context,
 	visitedExp := h.pools.visitedLists.Borrow()
 	h.pools.visitedListsLock.RUnlock()
 
-	useAcorn, M := h.acornParams(allowList)
+	useAcorn, M := h.acornParams(ctx, allowList)
 
 	candidates := h.pools.pqCandidates.GetMin(ef)
 	results := h.pools.pqResults.GetMax(ef)
@@ -682,7 +689,7 @@ func (h *hnsw) knnSearchByVector(ctx context.Context, searchVec []float32, k int
 		return nil, nil, nil
 	}
 
-	useAcorn, _ := h.acornParams(allowList)
+	useAcorn, _ := h.acornParams(ctx, allowList)
 
 	if allowList != nil && useAcorn {
 		allowList = NewFastSet(allowList)
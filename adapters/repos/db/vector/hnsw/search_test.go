@@ -210,24 +210,45 @@ func TestAcornPercentage(t *testing.T) {
 	t.Run("check acorn params on different filter percentags", func(t *testing.T) {
 		vectorIndex.acornSearch.Store(false)
 		allowList := helpers.NewAllowList(1, 2, 3)
-		useAcorn, M := vectorIndex.acornParams(allowList)
+		useAcorn, M := vectorIndex.acornParams(context.Background(), allowList)
 		assert.False(t, useAcorn)
 		assert.Equal(t, 0, M)
 
 		vectorIndex.acornSearch.Store(true)
 
-		useAcorn, M = vectorIndex.acornParams(allowList)
+		useAcorn, M = vectorIndex.acornParams(context.Background(), allowList)
 		assert.True(t, useAcorn)
 		assert.Equal(t, 3, M)
 
 		vectorIndex.acornSearch.Store(true)
 
 		largerAllowList := helpers.NewAllowList(1, 2, 3, 4, 5)
-		useAcorn, M = vectorIndex.acornParams(largerAllowList)
+		useAcorn, M = vectorIndex.acornParams(context.Background(), largerAllowList)
 		// should be false as allow list percentage is 50%
 		assert.False(t, useAcorn)
 		assert.Equal(t, 2, M)
 	})
+
+	t.Run("filter strategy of the context overrides the index", func(t *testing.T) {
+		allowList := helpers.NewAllowList(1, 2, 3)
+
+		vectorIndex.acornSearch.Store(false)
+		ctx := ent.ContextWithFilterStrategy(context.Background(), ent.FilterStrategyAcorn)
+		useAcorn, M := vectorIndex.acornParams(ctx, allowList)
+		assert.True(t, useAcorn)
+		assert.Equal(t, 3, M)
+
+		vectorIndex.acornSearch.Store(true)
+		ctx = ent.ContextWithFilterStrategy(context.Background(), ent.FilterStrategySweeping)
+		useAcorn, M = vectorIndex.acornParams(ctx, allowList)
+		assert.False(t, useAcorn)
+		assert.Equal(t, 0, M)
+
+		// an empty strategy keeps the one of the index
+		ctx = ent.ContextWithFilterStrategy(context.Background(), "")
+		useAcorn, _ = vectorIndex.acornParams(ctx, allowList)
+		assert.True(t, useAcorn)
+	})
 }
 
 func TestRescore(t *testing.T) {
@@ -24,6 +24,13 @@ type MetaProvider interface {
 	MetaInfo() (map[string]interface{}, error)
 }
 
+// HealthChecker is implemented by modules which depend on an external service
+// and can cheaply verify that it is reachable. The result is a diagnostic, it
+// doesn't affect the readiness of the node.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
 type Client interface {
 	Vectorizers() map[string]VectorizerClient
 }
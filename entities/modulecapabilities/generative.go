@@ -28,6 +28,14 @@ type ExtractRequestParamsFn = func(field *ast.ObjectField) interface{}
 // GenerateDebugInformation exposes debug information
 type GenerateDebugInformation struct {
 	Prompt string
+	// Reasoning holds the reasoning of the model which was removed from the
+	// result, if any
+	Reasoning string
+	// Model and Endpoint hold the model and endpoint which served the
+	// generation after header overrides and fallbacks, if the module
+	// reports them
+	Model    string
+	Endpoint string
 }
 
 // GenerateResponse defines generative response. Params files hold module specific
@@ -36,6 +44,15 @@ type GenerateResponse struct {
 	Result *string
 	Params map[string]interface{}
 	Debug  *GenerateDebugInformation
+	// ToolCalls holds the function calls requested by the model, if any
+	ToolCalls []ToolCall
+}
+
+// ToolCall is a function call requested by a generative model. Arguments
+// holds the JSON encoded arguments of the call
+type ToolCall struct {
+	Name      string
+	Arguments string
 }
 
 // GenerativeClient defines generative client
@@ -36,6 +36,32 @@ type NearParam interface {
 	SimilarityMetricProvided() bool
 }
 
+// NearParamWithAutocut is implemented by near params which can limit the
+// results to natural score clusters, a value of 0 disables autocut
+type NearParamWithAutocut interface {
+	GetAutocut() int
+}
+
+// NearParamWithAdditionalCollections is implemented by near params which
+// search other collections alongside the queried one
+type NearParamWithAdditionalCollections interface {
+	GetAdditionalCollections() []string
+}
+
+// NearParamWithMaxCertainty is implemented by near params which exclude
+// results that are too similar, e.g. near-duplicates of the query. A nil
+// value disables the upper bound.
+type NearParamWithMaxCertainty interface {
+	GetMaxCertainty() *float64
+}
+
+// NearParamWithFilterStrategy is implemented by near params which override
+// the filter strategy of the vector index. An empty value keeps the one
+// configured on the index.
+type NearParamWithFilterStrategy interface {
+	GetFilterStrategy() string
+}
+
 // ValidateFn validates a given module param
 type ValidateFn = func(param interface{}) error
 
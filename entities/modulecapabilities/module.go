@@ -62,6 +62,13 @@ type Dependency[T dto.Embedding] interface {
 	VectorSearch() VectorForParams[T]
 }
 
+// ModuleWithClose is implemented by modules which need to release resources,
+// such as background goroutines, when the server shuts down
+type ModuleWithClose interface {
+	Module
+	Close() error
+}
+
 type ModuleHasAltNames interface {
 	AltNames() []string
 }
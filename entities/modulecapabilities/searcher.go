@@ -37,6 +37,14 @@ type VectorForParams[T dto.Embedding] interface {
 		className string, findVectorFn FindVectorFn[T], cfg moduletools.ClassConfig) (T, error)
 }
 
+// VectorSpaceValidator can optionally be implemented by a VectorForParams.
+// If the vector space of a target vector is configured with a module which
+// doesn't provide the search, the modules which do are asked to validate it,
+// so that the query fails with a descriptive error.
+type VectorSpaceValidator interface {
+	ValidateVectorSpace(className, targetVector, vectorizer string) error
+}
+
 // Searcher defines all methods for all searchers
 // for getting a vector from a given raw searcher content
 type Searcher[T dto.Embedding] interface {
@@ -0,0 +1,33 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package hnsw
+
+import "context"
+
+type filterStrategyKey struct{}
+
+// ContextWithFilterStrategy returns a context which overrides the filter
+// strategy of the index for searches using it. An empty strategy keeps the
+// one configured on the index and returns ctx unchanged.
+func ContextWithFilterStrategy(ctx context.Context, strategy string) context.Context {
+	if strategy == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, filterStrategyKey{}, strategy)
+}
+
+// FilterStrategyFromContext returns the filter strategy set by
+// ContextWithFilterStrategy, false if there is none
+func FilterStrategyFromContext(ctx context.Context) (string, bool) {
+	strategy, ok := ctx.Value(filterStrategyKey{}).(string)
+	return strategy, ok
+}
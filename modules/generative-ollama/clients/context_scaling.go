@@ -0,0 +1,168 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/entities/moduletools"
+	"github.com/weaviate/weaviate/modules/generative-ollama/config"
+	ollamaparams "github.com/weaviate/weaviate/modules/generative-ollama/parameters"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+// defaultNumCtx is the context window Ollama uses if the request doesn't set
+// the num_ctx option
+const defaultNumCtx = 2048
+
+// contextFillRatio is the share of the context window a prompt may take up
+// before it is scaled down, the rest is left for the response
+const contextFillRatio = 0.9
+
+// estimateTokens is a rough heuristic of about 4 characters per token, it
+// doesn't depend on the tokenizer of the model
+func estimateTokens(prompt string) int {
+	return len(prompt) / 4
+}
+
+// numCtx returns the num_ctx option of the request, defaultNumCtx if not set
+func numCtx(params ollamaparams.Params) int {
+	var n int64
+	switch value := params.RawOptions["num_ctx"].(type) {
+	case int:
+		n = int64(value)
+	case int64:
+		n = value
+	case float64:
+		n = int64(value)
+	case json.Number:
+		n, _ = value.Int64()
+	}
+	if n <= 0 {
+		return defaultNumCtx
+	}
+	return int(n)
+}
+
+// singleResultPrompt substitutes the properties into prompt, see
+// fitPromptToContext
+func (v *ollama) singleResultPrompt(ctx context.Context, cfg moduletools.ClassConfig, options interface{},
+	textProperties map[string]string, prompt string,
+) (string, error) {
+	return v.fitPromptToContext(ctx, cfg, options, []map[string]string{textProperties},
+		func(props []map[string]string) (string, error) {
+			return v.generateForPrompt(props[0], prompt)
+		})
+}
+
+// allResultsPrompt builds the prompt of a grouped task, see
+// fitPromptToContext
+func (v *ollama) allResultsPrompt(ctx context.Context, cfg moduletools.ClassConfig, options interface{},
+	textProperties []map[string]string, task string,
+) (string, error) {
+	return v.fitPromptToContext(ctx, cfg, options, textProperties,
+		func(props []map[string]string) (string, error) {
+			return v.generatePromptForTask(props, task)
+		})
+}
+
+// fitPromptToContext builds the prompt from textProperties. If the class
+// enables autoScaleContext and the estimated prompt exceeds 90% of the
+// context window, the longest property value is halved until the prompt fits
+// or no value can be shortened any further. Otherwise Ollama would silently
+// truncate the prompt, dropping its beginning. Truncations are counted and
+// logged only if a value was actually shortened, a prompt which still doesn't
+// fit is logged separately.
+func (v *ollama) fitPromptToContext(ctx context.Context, cfg moduletools.ClassConfig, options interface{},
+	textProperties []map[string]string, build func([]map[string]string) (string, error),
+) (string, error) {
+	prompt, err := build(textProperties)
+	if err != nil || !config.NewClassSettings(cfg).AutoScaleContext() {
+		return prompt, err
+	}
+
+	numCtx := numCtx(v.getParameters(ctx, cfg, options))
+	limit := int(float64(numCtx) * contextFillRatio)
+	if estimateTokens(prompt) <= limit {
+		return prompt, nil
+	}
+
+	// the properties belong to the caller
+	truncated := make([]map[string]string, len(textProperties))
+	for i, props := range textProperties {
+		truncated[i] = make(map[string]string, len(props))
+		for name, value := range props {
+			truncated[i][name] = value
+		}
+	}
+
+	originalLength := len(prompt)
+	shortened := false
+	for estimateTokens(prompt) > limit && halveLongestValue(truncated) {
+		shortened = true
+		if prompt, err = build(truncated); err != nil {
+			return "", err
+		}
+	}
+
+	if shortened {
+		monitoring.GetMetrics().GenerativePromptTruncated.WithLabelValues(cacheMetricsModule).Inc()
+		v.logger.WithFields(logrus.Fields{
+			"action":           "ollama_prompt_truncated",
+			"num_ctx":          numCtx,
+			"original_length":  originalLength,
+			"truncated_length": len(prompt),
+			"estimated_tokens": estimateTokens(prompt),
+		}).Warn("truncated property values to fit the prompt into the context window of the model")
+	}
+	if estimateTokens(prompt) > limit {
+		v.logger.WithFields(logrus.Fields{
+			"action":           "ollama_prompt_exceeds_context",
+			"num_ctx":          numCtx,
+			"prompt_length":    len(prompt),
+			"estimated_tokens": estimateTokens(prompt),
+		}).Warn("prompt doesn't fit into the context window of the model, " +
+			"the property values can't be shortened any further")
+	}
+	return prompt, nil
+}
+
+// halveLongestValue truncates the longest value to half of its characters.
+// Ties are broken by position and name, so the result is deterministic. It
+// returns false if no value has more than one character left.
+func halveLongestValue(textProperties []map[string]string) bool {
+	longestPos, longestName, longestLen := -1, "", 1
+	for i, props := range textProperties {
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if n := utf8.RuneCountInString(props[name]); n > longestLen {
+				longestPos, longestName, longestLen = i, name, n
+			}
+		}
+	}
+	if longestPos < 0 {
+		return false
+	}
+
+	value := textProperties[longestPos][longestName]
+	textProperties[longestPos][longestName] = string([]rune(value)[:longestLen/2])
+	return true
+}
@@ -0,0 +1,171 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ollamaparams "github.com/weaviate/weaviate/modules/generative-ollama/parameters"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+func TestNumCtx(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    interface{}
+		expected int
+	}{
+		{name: "not set", value: nil, expected: defaultNumCtx},
+		{name: "int", value: 4096, expected: 4096},
+		{name: "int64", value: int64(8192), expected: 8192},
+		{name: "float64", value: float64(1024), expected: 1024},
+		{name: "json number", value: json.Number("512"), expected: 512},
+		{name: "invalid", value: "large", expected: defaultNumCtx},
+		{name: "negative", value: -1, expected: defaultNumCtx},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := ollamaparams.Params{}
+			if tc.value != nil {
+				params.RawOptions = map[string]interface{}{"num_ctx": tc.value}
+			}
+			assert.Equal(t, tc.expected, numCtx(params))
+		})
+	}
+}
+
+func TestHalveLongestValue(t *testing.T) {
+	props := []map[string]string{
+		{"title": "abcd", "body": "äöüßäöüß"},
+		{"body": "12345678"},
+	}
+
+	// ties are broken by position first
+	require.True(t, halveLongestValue(props))
+	assert.Equal(t, "äöüß", props[0]["body"])
+	assert.Equal(t, "12345678", props[1]["body"])
+
+	require.True(t, halveLongestValue(props))
+	assert.Equal(t, "1234", props[1]["body"])
+
+	// and by name second
+	require.True(t, halveLongestValue(props))
+	assert.Equal(t, "äö", props[0]["body"])
+	assert.Equal(t, "abcd", props[0]["title"])
+
+	assert.False(t, halveLongestValue([]map[string]string{{"title": "a"}}))
+}
+
+func TestAutoScaleContext(t *testing.T) {
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input generateInput
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		prompts = append(prompts, input.Prompt)
+		require.Nil(t, json.NewEncoder(w).Encode(generateResponse{Response: "answer"}))
+	}))
+	defer server.Close()
+
+	truncated := func() float64 {
+		return testutil.ToFloat64(monitoring.GetMetrics().GenerativePromptTruncated.WithLabelValues(cacheMetricsModule))
+	}
+
+	c := New(0, nullLogger())
+	longText := strings.Repeat("a", 2000)
+	options := ollamaparams.Params{RawOptions: map[string]interface{}{"num_ctx": 100}}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		prompts = nil
+		before := truncated()
+		settings := &fakeClassConfig{apiEndpoint: server.URL}
+
+		_, err := c.GenerateSingleResult(context.Background(), map[string]string{"text": longText},
+			"summarize {text}", options, false, settings)
+		require.Nil(t, err)
+		require.Len(t, prompts, 1)
+		assert.Equal(t, "summarize "+longText, prompts[0])
+		assert.Equal(t, before, truncated())
+	})
+
+	settings := &fakeClassConfig{
+		apiEndpoint: server.URL,
+		settings:    map[string]interface{}{"autoScaleContext": true},
+	}
+
+	t.Run("single result is truncated", func(t *testing.T) {
+		prompts = nil
+		before := truncated()
+		props := map[string]string{"text": longText}
+
+		_, err := c.GenerateSingleResult(context.Background(), props,
+			"summarize {text}", options, false, settings)
+		require.Nil(t, err)
+		require.Len(t, prompts, 1)
+		assert.True(t, strings.HasPrefix(prompts[0], "summarize aaa"))
+		assert.LessOrEqual(t, estimateTokens(prompts[0]), 90)
+		assert.Equal(t, before+1, truncated())
+		// the properties of the caller are left untouched
+		assert.Equal(t, longText, props["text"])
+	})
+
+	t.Run("all results are truncated", func(t *testing.T) {
+		prompts = nil
+		props := []map[string]string{{"text": longText}, {"text": "short"}}
+
+		_, err := c.GenerateAllResults(context.Background(), props, "summarize", options, false, settings)
+		require.Nil(t, err)
+		require.Len(t, prompts, 1)
+		assert.LessOrEqual(t, estimateTokens(prompts[0]), 90)
+		assert.Contains(t, prompts[0], "short")
+	})
+
+	t.Run("prompt fitting the context is unchanged", func(t *testing.T) {
+		prompts = nil
+		before := truncated()
+
+		_, err := c.GenerateSingleResult(context.Background(), map[string]string{"text": longText},
+			"summarize {text}", nil, false, settings)
+		require.Nil(t, err)
+		require.Len(t, prompts, 1)
+		assert.Equal(t, "summarize "+longText, prompts[0])
+		assert.Equal(t, before, truncated())
+	})
+
+	t.Run("prompt which can't be shortened is not counted as truncated", func(t *testing.T) {
+		prompts = nil
+		before := truncated()
+		logger, hook := test.NewNullLogger()
+		c := New(0, logger)
+		task := "summarize " + longText + " {text}"
+
+		_, err := c.GenerateSingleResult(context.Background(), map[string]string{"text": "a"},
+			task, options, false, settings)
+		require.Nil(t, err)
+		require.Len(t, prompts, 1)
+		assert.Equal(t, "summarize "+longText+" a", prompts[0])
+		assert.Equal(t, before, truncated())
+
+		var actions []interface{}
+		for _, entry := range hook.AllEntries() {
+			actions = append(actions, entry.Data["action"])
+		}
+		assert.Equal(t, []interface{}{"ollama_prompt_exceeds_context"}, actions)
+	})
+}
@@ -0,0 +1,132 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/entities/modulecapabilities"
+	"github.com/weaviate/weaviate/entities/moduletools"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+// apiError is returned when the Ollama API rejects a request
+type apiError struct {
+	statusCode int
+	msg        string
+}
+
+func newAPIError(statusCode int, msg string) *apiError {
+	if msg != "" {
+		return &apiError{statusCode: statusCode, msg: fmt.Sprintf("connection to Ollama API failed with error: %s", msg)}
+	}
+	return &apiError{statusCode: statusCode, msg: fmt.Sprintf("connection to Ollama API failed with status: %d", statusCode)}
+}
+
+func (e *apiError) Error() string {
+	return e.msg
+}
+
+// WithFallbackProviders sets the providers tried in order when a request to
+// Ollama fails with a server error or times out. Fallbacks are passed the
+// same prompt and request params, so they should serve compatible models,
+// otherwise the same prompt may produce different results.
+func (v *ollama) WithFallbackProviders(providers ...modulecapabilities.GenerativeClient) *ollama {
+	v.fallbackProviders = providers
+	return v
+}
+
+// shouldFallBack reports whether err indicates an overloaded or unavailable
+// server, rather than a request that is going to fail on any provider
+func shouldFallBack(err error) bool {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.statusCode >= 500
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (v *ollama) generateWithFallback(ctx context.Context, cfg moduletools.ClassConfig, prompt string,
+	options interface{}, debug bool, primaryErr error,
+) (*modulecapabilities.GenerateResponse, error) {
+	monitoring.GetMetrics().GenerativePrimaryFailed.WithLabelValues(cacheMetricsModule).Inc()
+
+	err := primaryErr
+	for i, provider := range v.fallbackProviders {
+		// the caller gave up, there is no point in trying the remaining
+		// providers with an expired context
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("fallback aborted: %w, last error: %v", ctx.Err(), err)
+		}
+
+		v.logger.WithFields(logrus.Fields{
+			"action":   "ollama_fallback",
+			"fallback": i,
+		}).WithError(err).Warn("Ollama request failed, trying fallback provider")
+
+		res, fallbackErr := provider.Generate(ctx, cfg, prompt, options, debug)
+		if fallbackErr == nil {
+			monitoring.GetMetrics().GenerativeFallbackUsed.WithLabelValues(cacheMetricsModule).Inc()
+			return res, nil
+		}
+		err = fallbackErr
+	}
+	return nil, fmt.Errorf("all %d fallback providers failed, last error: %w, primary error: %v",
+		len(v.fallbackProviders), err, primaryErr)
+}
+
+// endpointClient sends all requests to a fixed Ollama server, regardless of
+// the apiEndpoint configured for the class and the X-Ollama-BaseURL header.
+// It is used as a fallback provider.
+type endpointClient struct {
+	*ollama
+	baseURL string
+}
+
+// NewEndpointClient returns a client for the Ollama server at baseURL, which
+// can be used as a fallback provider
+func NewEndpointClient(baseURL string, timeout time.Duration, logger logrus.FieldLogger) modulecapabilities.GenerativeClient {
+	client := New(timeout, logger)
+	client.ignoreBaseURLHeader = true
+	return &endpointClient{ollama: client, baseURL: baseURL}
+}
+
+func (c *endpointClient) GenerateSingleResult(ctx context.Context, textProperties map[string]string, prompt string, options interface{}, debug bool, cfg moduletools.ClassConfig) (*modulecapabilities.GenerateResponse, error) {
+	forPrompt, err := c.singleResultPrompt(ctx, cfg, options, textProperties, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return c.Generate(ctx, cfg, forPrompt, options, debug)
+}
+
+func (c *endpointClient) GenerateAllResults(ctx context.Context, textProperties []map[string]string, task string, options interface{}, debug bool, cfg moduletools.ClassConfig) (*modulecapabilities.GenerateResponse, error) {
+	forTask, err := c.allResultsPrompt(ctx, cfg, options, textProperties, task)
+	if err != nil {
+		return nil, err
+	}
+	return c.Generate(ctx, cfg, forTask, options, debug)
+}
+
+func (c *endpointClient) Generate(ctx context.Context, cfg moduletools.ClassConfig, prompt string, options interface{}, debug bool) (*modulecapabilities.GenerateResponse, error) {
+	params := c.getParameters(ctx, cfg, options)
+	params.ApiEndpoint = c.baseURL
+	return c.ollama.Generate(ctx, cfg, prompt, params, debug)
+}
@@ -0,0 +1,182 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+func TestFallbackProviders(t *testing.T) {
+	newServer := func(status int, delay time.Duration, answer generateResponse) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			w.WriteHeader(status)
+			require.Nil(t, json.NewEncoder(w).Encode(answer))
+		}))
+	}
+
+	fallback := newServer(http.StatusOK, 0, generateResponse{Response: "from fallback"})
+	defer fallback.Close()
+	failingFallback := newServer(http.StatusBadGateway, 0, generateResponse{Error: "fallback overloaded"})
+	defer failingFallback.Close()
+
+	metrics := monitoring.GetMetrics()
+	primaryFailed := func() float64 {
+		return testutil.ToFloat64(metrics.GenerativePrimaryFailed.WithLabelValues(cacheMetricsModule))
+	}
+	fallbackUsed := func() float64 {
+		return testutil.ToFloat64(metrics.GenerativeFallbackUsed.WithLabelValues(cacheMetricsModule))
+	}
+
+	tests := []struct {
+		name           string
+		status         int
+		delay          time.Duration
+		answer         generateResponse
+		expectedResult string
+		expectedErr    string
+		expectFallback bool
+	}{
+		{
+			name:           "server error",
+			status:         http.StatusServiceUnavailable,
+			answer:         generateResponse{Error: "server overloaded"},
+			expectedResult: "from fallback",
+			expectFallback: true,
+		},
+		{
+			name:           "timeout",
+			status:         http.StatusOK,
+			delay:          200 * time.Millisecond,
+			answer:         generateResponse{Response: "too late"},
+			expectedResult: "from fallback",
+			expectFallback: true,
+		},
+		{
+			name:        "client error",
+			status:      http.StatusNotFound,
+			answer:      generateResponse{Error: "model not found"},
+			expectedErr: "model not found",
+		},
+		{
+			name:           "success",
+			status:         http.StatusOK,
+			answer:         generateResponse{Response: "from primary"},
+			expectedResult: "from primary",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			primary := newServer(test.status, test.delay, test.answer)
+			defer primary.Close()
+
+			c := New(100*time.Millisecond, nullLogger()).WithFallbackProviders(
+				NewEndpointClient(failingFallback.URL, time.Second, nullLogger()),
+				NewEndpointClient(fallback.URL, time.Second, nullLogger()),
+			)
+			failedBefore, usedBefore := primaryFailed(), fallbackUsed()
+
+			res, err := c.Generate(context.Background(), &fakeClassConfig{apiEndpoint: primary.URL},
+				"prompt", nil, false)
+			if test.expectedErr != "" {
+				require.NotNil(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+			} else {
+				require.Nil(t, err)
+				assert.Equal(t, test.expectedResult, *res.Result)
+			}
+
+			expectedCount := 0.0
+			if test.expectFallback {
+				expectedCount = 1
+			}
+			assert.Equal(t, expectedCount, primaryFailed()-failedBefore)
+			assert.Equal(t, expectedCount, fallbackUsed()-usedBefore)
+		})
+	}
+
+	t.Run("all fallbacks fail", func(t *testing.T) {
+		primary := newServer(http.StatusInternalServerError, 0, generateResponse{Error: "primary down"})
+		defer primary.Close()
+
+		c := New(time.Second, nullLogger()).WithFallbackProviders(
+			NewEndpointClient(failingFallback.URL, time.Second, nullLogger()))
+
+		_, err := c.Generate(context.Background(), &fakeClassConfig{apiEndpoint: primary.URL},
+			"prompt", nil, false)
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "fallback overloaded")
+		assert.Contains(t, err.Error(), "primary down")
+	})
+
+	t.Run("non-JSON server error", func(t *testing.T) {
+		primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("<html>bad gateway</html>"))
+		}))
+		defer primary.Close()
+
+		c := New(time.Second, nullLogger()).WithFallbackProviders(
+			NewEndpointClient(fallback.URL, time.Second, nullLogger()))
+
+		res, err := c.Generate(context.Background(), &fakeClassConfig{apiEndpoint: primary.URL},
+			"prompt", nil, false)
+		require.Nil(t, err)
+		assert.Equal(t, "from fallback", *res.Result)
+	})
+
+	t.Run("fallbacks ignore the base URL header", func(t *testing.T) {
+		primary := newServer(http.StatusServiceUnavailable, 0, generateResponse{Error: "primary down"})
+		defer primary.Close()
+
+		c := New(time.Second, nullLogger()).WithFallbackProviders(
+			NewEndpointClient(fallback.URL, time.Second, nullLogger()))
+
+		ctx := context.WithValue(context.Background(), "X-Ollama-BaseURL", []string{primary.URL})
+		res, err := c.Generate(ctx, &fakeClassConfig{apiEndpoint: "http://unreachable"},
+			"prompt", nil, false)
+		require.Nil(t, err)
+		assert.Equal(t, "from fallback", *res.Result)
+	})
+
+	t.Run("expired caller context", func(t *testing.T) {
+		primary := newServer(http.StatusOK, 200*time.Millisecond, generateResponse{Response: "too late"})
+		defer primary.Close()
+		fallbackCalled := false
+		unusedFallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fallbackCalled = true
+		}))
+		defer unusedFallback.Close()
+
+		c := New(time.Second, nullLogger()).WithFallbackProviders(
+			NewEndpointClient(unusedFallback.URL, time.Second, nullLogger()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		failedBefore := primaryFailed()
+		_, err := c.Generate(ctx, &fakeClassConfig{apiEndpoint: primary.URL}, "prompt", nil, false)
+		require.NotNil(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.False(t, fallbackCalled)
+		assert.Equal(t, 0.0, primaryFailed()-failedBefore)
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"regexp"
 	"strings"
@@ -25,11 +26,14 @@ import (
 	"github.com/weaviate/weaviate/modules/generative-ollama/config"
 	ollamaparams "github.com/weaviate/weaviate/modules/generative-ollama/parameters"
 	"github.com/weaviate/weaviate/usecases/modulecomponents"
+	"github.com/weaviate/weaviate/usecases/monitoring"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/weaviate/weaviate/entities/modulecapabilities"
 	"github.com/weaviate/weaviate/entities/moduletools"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
 var compile, _ = regexp.Compile(`{([\w\s]*?)}`)
@@ -37,6 +41,27 @@ var compile, _ = regexp.Compile(`{([\w\s]*?)}`)
 type ollama struct {
 	httpClient *http.Client
 	logger     logrus.FieldLogger
+
+	cache    ResponseCache
+	cacheTTL time.Duration
+
+	// RateLimiter limits the requests sent to Ollama, nil means unlimited
+	RateLimiter        *rate.Limiter
+	tenantRateLimiters *tenantRateLimiters
+
+	fallbackProviders []modulecapabilities.GenerativeClient
+
+	// tracer creates a span for every Generate call, see WithTracer
+	tracer trace.Tracer
+
+	// promptSanitizer cleans property values before they are substituted into
+	// prompts, nil uses them as they are
+	promptSanitizer PromptSanitizer
+
+	// ignoreBaseURLHeader makes the client send requests to the configured
+	// apiEndpoint even if the X-Ollama-BaseURL header is passed, see
+	// NewEndpointClient
+	ignoreBaseURLHeader bool
 }
 
 func New(timeout time.Duration, logger logrus.FieldLogger) *ollama {
@@ -48,8 +73,15 @@ func New(timeout time.Duration, logger logrus.FieldLogger) *ollama {
 	}
 }
 
+// WithResponseCache enables caching of deterministic responses for ttl
+func (v *ollama) WithResponseCache(cache ResponseCache, ttl time.Duration) *ollama {
+	v.cache = cache
+	v.cacheTTL = ttl
+	return v
+}
+
 func (v *ollama) GenerateSingleResult(ctx context.Context, textProperties map[string]string, prompt string, options interface{}, debug bool, cfg moduletools.ClassConfig) (*modulecapabilities.GenerateResponse, error) {
-	forPrompt, err := v.generateForPrompt(textProperties, prompt)
+	forPrompt, err := v.singleResultPrompt(ctx, cfg, options, textProperties, prompt)
 	if err != nil {
 		return nil, err
 	}
@@ -57,38 +89,98 @@ func (v *ollama) GenerateSingleResult(ctx context.Context, textProperties map[st
 }
 
 func (v *ollama) GenerateAllResults(ctx context.Context, textProperties []map[string]string, task string, options interface{}, debug bool, cfg moduletools.ClassConfig) (*modulecapabilities.GenerateResponse, error) {
-	forTask, err := v.generatePromptForTask(textProperties, task)
+	forTask, err := v.allResultsPrompt(ctx, cfg, options, textProperties, task)
 	if err != nil {
 		return nil, err
 	}
 	return v.Generate(ctx, cfg, forTask, options, debug)
 }
 
-func (v *ollama) Generate(ctx context.Context, cfg moduletools.ClassConfig, prompt string, options interface{}, debug bool) (*modulecapabilities.GenerateResponse, error) {
-	params := v.getParameters(cfg, options)
-	debugInformation := v.getDebugInformation(debug, prompt)
+func (v *ollama) Generate(ctx context.Context, cfg moduletools.ClassConfig, prompt string, options interface{}, debug bool) (res *modulecapabilities.GenerateResponse, err error) {
+	params := v.getParameters(ctx, cfg, options)
+	ollamaUrl := v.getOllamaUrl(ctx, params.ApiEndpoint, params.GeneratePath)
+	ctx, span := v.startGenerateSpan(ctx, params.Model, ollamaUrl, prompt)
+	defer func() { endGenerateSpan(span, err) }()
 
-	ollamaUrl := v.getOllamaUrl(ctx, params.ApiEndpoint)
-	input := generateInput{
-		Model:  params.Model,
-		Prompt: prompt,
-		Stream: false,
+	if err := config.ValidateOptions(params.Temperature, params.TopP, params.TopK); err != nil {
+		return nil, errors.Wrap(err, "invalid request parameters")
+	}
+	debugInformation := v.getDebugInformation(debug, prompt, params.Model, ollamaUrl)
+
+	res, err = v.generateCached(ctx, params, cfg.Tenant(), prompt, debugInformation)
+	if err != nil {
+		if len(v.fallbackProviders) > 0 && ctx.Err() == nil && shouldFallBack(err) {
+			return v.generateWithFallback(ctx, cfg, prompt, options, debug, err)
+		}
+		return nil, err
 	}
-	if params.Temperature != nil {
-		input.Options = &generateOptions{Temperature: params.Temperature}
+
+	// reasoning is stripped after caching, as the setting is not part of the
+	// cache key
+	if settings := config.NewClassSettings(cfg); settings.StripThinking() {
+		return stripThinking(res, settings.ThinkingTag()), nil
 	}
+	return res, nil
+}
 
-	body, err := json.Marshal(input)
+// generateCached serves the response from the response cache if possible and
+// generates it otherwise
+func (v *ollama) generateCached(ctx context.Context, params ollamaparams.Params, tenant, prompt string,
+	debugInformation *modulecapabilities.GenerateDebugInformation,
+) (*modulecapabilities.GenerateResponse, error) {
+	if !v.isCacheable(params) {
+		return v.generate(ctx, params, tenant, prompt, debugInformation)
+	}
+
+	body, err := json.Marshal(v.generateInput(params, prompt, false))
 	if err != nil {
 		return nil, errors.Wrap(err, "marshal body")
 	}
+	key := responseCacheKey(v.getOllamaUrl(ctx, params.ApiEndpoint, params.GeneratePath), body)
+	if cached, ok := v.cache.Get(key); ok {
+		monitoring.GetMetrics().GenerativeResponseCache.WithLabelValues(cacheMetricsModule, "hit").Inc()
+		res := *cached
+		res.Debug = debugInformation
+		return &res, nil
+	}
+	monitoring.GetMetrics().GenerativeResponseCache.WithLabelValues(cacheMetricsModule, "miss").Inc()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", ollamaUrl,
-		bytes.NewReader(body))
+	res, err := v.generate(ctx, params, tenant, prompt, debugInformation)
 	if err != nil {
-		return nil, errors.Wrap(err, "create POST request")
+		return nil, err
+	}
+	v.cache.Set(key, res, v.cacheTTL)
+	return res, nil
+}
+
+// isCacheable reports whether the response for params is deterministic and
+// can be served from the response cache. Requests with a temperature other
+// than 0 or with raw options, which may set one, are not deterministic.
+// Responses filtered by minResponseEntropy are not cached, so a low quality
+// response is not served again. Requests continuing a conversation, passing a
+// suffix or think are not cached either.
+func (v *ollama) isCacheable(params ollamaparams.Params) bool {
+	if v.cache == nil {
+		return false
+	}
+	if params.Temperature != nil && *params.Temperature != 0 {
+		return false
+	}
+	return len(params.Context) == 0 && params.Suffix == "" && len(params.RawOptions) == 0 &&
+		params.Think == nil && params.MinResponseEntropy == nil
+}
+
+func (v *ollama) generate(ctx context.Context, params ollamaparams.Params, tenant, prompt string,
+	debugInformation *modulecapabilities.GenerateDebugInformation,
+) (*modulecapabilities.GenerateResponse, error) {
+	req, err := v.newGenerateRequest(ctx, params, prompt, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.waitForRateLimit(ctx, tenant); err != nil {
+		return nil, err
 	}
-	req.Header.Add("Content-Type", "application/json")
 
 	res, err := v.httpClient.Do(req)
 	if err != nil {
@@ -103,26 +195,214 @@ func (v *ollama) Generate(ctx context.Context, cfg moduletools.ClassConfig, prom
 
 	var resBody generateResponse
 	if err := json.Unmarshal(bodyBytes, &resBody); err != nil {
+		if res.StatusCode >= 500 {
+			// e.g. a proxy in front of an overloaded server
+			return nil, newAPIError(res.StatusCode, "")
+		}
 		return nil, errors.Wrap(err, fmt.Sprintf("unmarshal response body. Got: %v", string(bodyBytes)))
 	}
 
-	if resBody.Error != "" {
-		return nil, errors.Errorf("connection to Ollama API failed with error: %s", resBody.Error)
+	if resBody.Error != "" || res.StatusCode != 200 {
+		return nil, newAPIError(res.StatusCode, resBody.Error)
 	}
 
-	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("connection to Ollama API failed with status: %d", res.StatusCode)
+	if len(resBody.ToolCalls) > 0 {
+		// the serialized tool calls are returned as the result for clients
+		// unaware of tool calls
+		toolCallsJSON, err := json.Marshal(resBody.ToolCalls)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal tool calls")
+		}
+		result := string(toolCallsJSON)
+		return &modulecapabilities.GenerateResponse{
+			Result:    &result,
+			Debug:     debugInformation,
+			Params:    v.getResponseParams(false, resBody.Context, resBody.DoneReason),
+			ToolCalls: v.getToolCalls(resBody.ToolCalls),
+		}, nil
 	}
 
 	textResponse := resBody.Response
 
+	if isLowQualityResponse(textResponse, params.MinResponseEntropy) {
+		return &modulecapabilities.GenerateResponse{
+			Result: nil,
+			Debug:  debugInformation,
+			Params: v.getResponseParams(true, resBody.Context, resBody.DoneReason),
+		}, nil
+	}
+
 	return &modulecapabilities.GenerateResponse{
 		Result: &textResponse,
 		Debug:  debugInformation,
+		Params: v.getResponseParams(false, resBody.Context, resBody.DoneReason),
 	}, nil
 }
 
-func (v *ollama) getParameters(cfg moduletools.ClassConfig, options interface{}) ollamaparams.Params {
+// newGenerateRequest creates the request to the generate endpoint. If stream
+// is set, Ollama responds with one JSON object per generated token.
+func (v *ollama) newGenerateRequest(ctx context.Context, params ollamaparams.Params, prompt string,
+	stream bool,
+) (*http.Request, error) {
+	ollamaUrl := v.getOllamaUrl(ctx, params.ApiEndpoint, params.GeneratePath)
+	body, err := json.Marshal(v.generateInput(params, prompt, stream))
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal body")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", ollamaUrl,
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "create POST request")
+	}
+	req.Header.Add("Content-Type", "application/json")
+	injectTraceContext(ctx, req)
+
+	return req, nil
+}
+
+// generateInput is the body of a request to the generate endpoint
+func (v *ollama) generateInput(params ollamaparams.Params, prompt string, stream bool) generateInput {
+	input := generateInput{
+		Model:   params.Model,
+		Prompt:  prompt,
+		Stream:  stream,
+		Context: params.Context,
+		Suffix:  params.Suffix,
+		Think:   params.Think,
+	}
+	if params.Temperature != nil || params.TopP != nil || params.TopK != nil || params.RepeatPenalty != nil ||
+		len(params.RawOptions) > 0 {
+		input.Options = &generateOptions{
+			Temperature:   params.Temperature,
+			TopP:          params.TopP,
+			TopK:          params.TopK,
+			RepeatPenalty: params.RepeatPenalty,
+			Raw:           params.RawOptions,
+		}
+	}
+	return input
+}
+
+// getResponseParams returns the ollama specific response params. The context
+// is returned so that callers can pass it back in a follow up request to
+// continue the conversation. The done reason tells whether the generation
+// completed naturally ("stop") or was cut off ("length"), it is only sent by
+// newer Ollama versions.
+func (v *ollama) getResponseParams(lowQuality bool, context []int, doneReason string) map[string]interface{} {
+	params := map[string]interface{}{}
+	if lowQuality {
+		params["generativeLowQuality"] = true
+	}
+	if len(context) > 0 {
+		params["context"] = context
+	}
+	if doneReason != "" {
+		params["doneReason"] = doneReason
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return map[string]interface{}{ollamaparams.Name: params}
+}
+
+func (v *ollama) getToolCalls(toolCalls []OllamaToolCall) []modulecapabilities.ToolCall {
+	out := make([]modulecapabilities.ToolCall, len(toolCalls))
+	for i, toolCall := range toolCalls {
+		out[i] = modulecapabilities.ToolCall{
+			Name:      toolCall.Function.Name,
+			Arguments: toolCall.Function.Arguments,
+		}
+	}
+	return out
+}
+
+// stripThinking removes all blocks enclosed in the given tag, e.g.
+// <think>...</think>, from the result. An unclosed block is removed up to the
+// end of the result, as the output was cut off while the model was still
+// reasoning. If debug information was requested, the removed reasoning is
+// preserved in it. The response is copied, as it may be shared with the cache.
+func stripThinking(res *modulecapabilities.GenerateResponse, tag string) *modulecapabilities.GenerateResponse {
+	if res.Result == nil {
+		return res
+	}
+
+	openTag, closeTag := "<"+tag+">", "</"+tag+">"
+	remaining := *res.Result
+	var result strings.Builder
+	var reasoning []string
+	for {
+		start := strings.Index(remaining, openTag)
+		if start < 0 {
+			result.WriteString(remaining)
+			break
+		}
+		result.WriteString(remaining[:start])
+		remaining = remaining[start+len(openTag):]
+
+		end := strings.Index(remaining, closeTag)
+		if end < 0 {
+			reasoning = append(reasoning, strings.TrimSpace(remaining))
+			break
+		}
+		reasoning = append(reasoning, strings.TrimSpace(remaining[:end]))
+		remaining = remaining[end+len(closeTag):]
+	}
+	if reasoning == nil {
+		return res
+	}
+
+	out := *res
+	stripped := strings.TrimSpace(result.String())
+	out.Result = &stripped
+	if res.Debug != nil {
+		debug := *res.Debug
+		debug.Reasoning = strings.Join(reasoning, "\n")
+		out.Debug = &debug
+	}
+	return &out
+}
+
+// isLowQualityResponse reports whether the response falls below the given
+// entropy threshold. A nil threshold disables the check.
+//
+// The heuristic is based on the character-level Shannon entropy of the
+// response. Natural language text usually scores around 4 bits per
+// character, while degenerate output, such as a model repeating the same
+// token over and over, scores much lower (e.g. "aaaa" scores 0 and "abab"
+// scores 1). A threshold of about 2.5 bits per character catches most
+// repetitive responses without discarding short, legitimate answers.
+func isLowQualityResponse(response string, minEntropy *float64) bool {
+	if minEntropy == nil {
+		return false
+	}
+	return shannonEntropy(response) < *minEntropy
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character,
+// computed over its runes. An empty string has an entropy of 0.
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// getParameters resolves the request parameters. The model passed with the
+// X-Ollama-Model header takes precedence over the options and the class config.
+func (v *ollama) getParameters(ctx context.Context, cfg moduletools.ClassConfig, options interface{}) ollamaparams.Params {
 	settings := config.NewClassSettings(cfg)
 
 	var params ollamaparams.Params
@@ -132,30 +412,85 @@ func (v *ollama) getParameters(cfg moduletools.ClassConfig, options interface{})
 	if params.ApiEndpoint == "" {
 		params.ApiEndpoint = settings.ApiEndpoint()
 	}
+	params.GeneratePath = settings.GeneratePath()
+	if headerModel := v.getValueFromContext(ctx, "X-Ollama-Model"); headerModel != "" {
+		params.Model = headerModel
+	}
 	if params.Model == "" {
 		params.Model = settings.Model()
 	}
+	if params.Suffix == "" {
+		params.Suffix = settings.Suffix()
+	}
+	if params.Temperature == nil {
+		params.Temperature = settings.Temperature()
+	}
+	if params.TopP == nil {
+		params.TopP = settings.TopP()
+	}
+	if params.TopK == nil {
+		params.TopK = settings.TopK()
+	}
+	if params.RepeatPenalty == nil {
+		params.RepeatPenalty = settings.RepeatPenalty()
+	}
+	if params.Think == nil {
+		params.Think = settings.Think()
+	}
 	return params
 }
 
-func (v *ollama) getDebugInformation(debug bool, prompt string) *modulecapabilities.GenerateDebugInformation {
+// getDebugInformation returns the debug information if debug is set. model
+// and endpoint are the resolved ones, so that generations can be attributed to
+// the server which actually served them.
+func (v *ollama) getDebugInformation(debug bool, prompt, model, endpoint string) *modulecapabilities.GenerateDebugInformation {
 	if debug {
 		return &modulecapabilities.GenerateDebugInformation{
-			Prompt: prompt,
+			Prompt:   prompt,
+			Model:    model,
+			Endpoint: endpoint,
 		}
 	}
 	return nil
 }
 
-func (v *ollama) getOllamaUrl(ctx context.Context, baseURL string) string {
+// getOllamaUrl returns the URL of the generate endpoint. The base URL and
+// path passed with the X-Ollama-BaseURL and X-Ollama-Path headers take
+// precedence over the ones passed in, unless the client ignores the base URL
+// header.
+func (v *ollama) getOllamaUrl(ctx context.Context, baseURL, path string) string {
 	passedBaseURL := baseURL
-	if headerBaseURL := v.getValueFromContext(ctx, "X-Ollama-BaseURL"); headerBaseURL != "" {
+	if headerBaseURL := v.getValueFromContext(ctx, "X-Ollama-BaseURL"); headerBaseURL != "" && !v.ignoreBaseURLHeader {
 		passedBaseURL = headerBaseURL
 	}
-	return fmt.Sprintf("%s/api/generate", passedBaseURL)
+	passedPath := path
+	if headerPath := v.getValueFromContext(ctx, "X-Ollama-Path"); headerPath != "" {
+		passedPath = headerPath
+	}
+	if passedPath == "" {
+		passedPath = config.DefaultGeneratePath
+	}
+	return joinURLPath(passedBaseURL, passedPath)
+}
+
+// joinURLPath joins baseURL and path with exactly one slash, regardless of
+// trailing slashes of baseURL and leading slashes of path
+func joinURLPath(baseURL, path string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(path, "/")
 }
 
 func (v *ollama) generatePromptForTask(textProperties []map[string]string, task string) (string, error) {
+	if v.promptSanitizer != nil {
+		sanitized := make([]map[string]string, len(textProperties))
+		for i, props := range textProperties {
+			sanitized[i] = make(map[string]string, len(props))
+			for name, value := range props {
+				sanitized[i][name] = v.sanitizeProperty(value)
+			}
+		}
+		textProperties = sanitized
+	}
+
 	marshal, err := json.Marshal(textProperties)
 	if err != nil {
 		return "", err
@@ -174,7 +509,7 @@ func (v *ollama) generateForPrompt(textProperties map[string]string, prompt stri
 		if value == "" {
 			return "", errors.Errorf("Following property has empty value: '%v'. Make sure you spell the property name correctly, verify that the property exists and has a value", replacedProperty)
 		}
-		prompt = strings.ReplaceAll(prompt, originalProperty, value)
+		prompt = strings.ReplaceAll(prompt, originalProperty, v.sanitizeProperty(value))
 	}
 	return prompt, nil
 }
@@ -186,25 +521,97 @@ func (v *ollama) getValueFromContext(ctx context.Context, key string) string {
 type generateInput struct {
 	Model   string           `json:"model"`
 	Prompt  string           `json:"prompt"`
+	Suffix  string           `json:"suffix,omitempty"`
 	Stream  bool             `json:"stream"`
+	Context []int            `json:"context,omitempty"`
 	Options *generateOptions `json:"options,omitempty"`
+	// KeepAlive controls how long the model stays loaded after the request,
+	// "-1" keeps it loaded indefinitely
+	KeepAlive string `json:"keep_alive,omitempty"`
+	// Think is only sent if set, older Ollama versions reject it
+	Think *bool `json:"think,omitempty"`
 }
 
 type generateOptions struct {
-	Temperature *float64 `json:"temperature,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	TopK          *int     `json:"top_k,omitempty"`
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty"`
+	// Raw holds options without a typed field, they are merged into the
+	// options object. Typed fields take precedence over raw keys of the same
+	// name.
+	Raw map[string]interface{} `json:"-"`
+}
+
+func (o generateOptions) MarshalJSON() ([]byte, error) {
+	// the alias drops the MarshalJSON method to avoid infinite recursion
+	type typedOptions generateOptions
+	typed, err := json.Marshal(typedOptions(o))
+	if err != nil || len(o.Raw) == 0 {
+		return typed, err
+	}
+
+	var typedFields map[string]json.RawMessage
+	if err := json.Unmarshal(typed, &typedFields); err != nil {
+		return nil, err
+	}
+	merged := make(map[string]interface{}, len(o.Raw)+len(typedFields))
+	for key, value := range o.Raw {
+		merged[key] = value
+	}
+	for key, value := range typedFields {
+		merged[key] = value
+	}
+	return json.Marshal(merged)
 }
 
 // The entire response for an error ends up looking different, may want to add omitempty everywhere.
 type generateResponse struct {
-	Model              string `json:"model,omitempty"`
-	CreatedAt          string `json:"created_at,omitempty"`
-	Response           string `json:"response,omitempty"`
-	Done               bool   `json:"done,omitempty"`
-	Context            []int  `json:"context,omitempty"`
-	TotalDuration      int    `json:"total_duration,omitempty"`
-	LoadDuration       int    `json:"load_duration,omitempty"`
-	PromptEvalDuration int    `json:"prompt_eval_duration,omitempty"`
-	EvalCount          int    `json:"eval_count,omitempty"`
-	EvalDuration       int    `json:"eval_duration,omitempty"`
-	Error              string `json:"error,omitempty"`
+	Model              string           `json:"model,omitempty"`
+	CreatedAt          string           `json:"created_at,omitempty"`
+	Response           string           `json:"response,omitempty"`
+	Done               bool             `json:"done,omitempty"`
+	DoneReason         string           `json:"done_reason,omitempty"`
+	Context            []int            `json:"context,omitempty"`
+	ToolCalls          []OllamaToolCall `json:"tool_calls,omitempty"`
+	TotalDuration      int              `json:"total_duration,omitempty"`
+	LoadDuration       int              `json:"load_duration,omitempty"`
+	PromptEvalDuration int              `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int              `json:"eval_count,omitempty"`
+	EvalDuration       int              `json:"eval_duration,omitempty"`
+	Error              string           `json:"error,omitempty"`
+}
+
+// OllamaToolCall is a function call requested by the model
+type OllamaToolCall struct {
+	Function OllamaFunctionCall `json:"function"`
+}
+
+type OllamaFunctionCall struct {
+	Name string `json:"name"`
+	// Arguments holds the JSON encoded arguments of the call
+	Arguments string `json:"arguments"`
+}
+
+// UnmarshalJSON accepts the arguments both as a JSON object, as sent by
+// Ollama, and as an already encoded string.
+func (f *OllamaFunctionCall) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	f.Name = raw.Name
+	f.Arguments = ""
+	if len(raw.Arguments) == 0 || string(raw.Arguments) == "null" {
+		return nil
+	}
+	if raw.Arguments[0] == '"' {
+		return json.Unmarshal(raw.Arguments, &f.Arguments)
+	}
+	f.Arguments = string(raw.Arguments)
+	return nil
 }
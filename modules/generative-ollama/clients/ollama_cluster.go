@@ -0,0 +1,300 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	enterrors "github.com/weaviate/weaviate/entities/errors"
+	"github.com/weaviate/weaviate/entities/modulecapabilities"
+	"github.com/weaviate/weaviate/entities/moduletools"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// routingPromptPrefixLength is the number of prompt bytes taken into account
+// when routing a request to a server of the cluster
+const routingPromptPrefixLength = 64
+
+// OllamaCluster routes requests across multiple Ollama servers. A server is
+// selected using rendezvous hashing on the model and the beginning of the
+// prompt, so that identical requests always end up on the same server, which
+// allows it to reuse its KV cache.
+//
+// Servers are health checked in the background. Unreachable servers are
+// removed from the selection until they recover. Note that an explicitly
+// passed X-Ollama-BaseURL header still takes precedence over the selection.
+type OllamaCluster struct {
+	servers    []*clusterServer
+	httpClient *http.Client
+	logger     logrus.FieldLogger
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type clusterServer struct {
+	baseURL string
+	client  *ollama
+
+	sync.RWMutex
+	healthy bool
+}
+
+func NewCluster(baseURLs []string, timeout, healthCheckInterval time.Duration,
+	logger logrus.FieldLogger,
+) (*OllamaCluster, error) {
+	if len(baseURLs) == 0 {
+		return nil, errors.New("at least one Ollama server is required")
+	}
+
+	servers := make([]*clusterServer, len(baseURLs))
+	for i, baseURL := range baseURLs {
+		servers[i] = &clusterServer{
+			baseURL: baseURL,
+			client:  New(timeout, logger),
+			healthy: true,
+		}
+	}
+
+	c := &OllamaCluster{
+		servers:    servers,
+		httpClient: &http.Client{Timeout: timeout},
+		logger:     logger,
+		stop:       make(chan struct{}),
+	}
+
+	if healthCheckInterval > 0 {
+		enterrors.GoWrapper(func() { c.healthCheckLoop(healthCheckInterval) }, logger)
+	}
+
+	return c, nil
+}
+
+func (c *OllamaCluster) GenerateSingleResult(ctx context.Context, textProperties map[string]string, prompt string, options interface{}, debug bool, cfg moduletools.ClassConfig) (*modulecapabilities.GenerateResponse, error) {
+	forPrompt, err := c.servers[0].client.singleResultPrompt(ctx, cfg, options, textProperties, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return c.Generate(ctx, cfg, forPrompt, options, debug)
+}
+
+func (c *OllamaCluster) GenerateAllResults(ctx context.Context, textProperties []map[string]string, task string, options interface{}, debug bool, cfg moduletools.ClassConfig) (*modulecapabilities.GenerateResponse, error) {
+	forTask, err := c.servers[0].client.allResultsPrompt(ctx, cfg, options, textProperties, task)
+	if err != nil {
+		return nil, err
+	}
+	return c.Generate(ctx, cfg, forTask, options, debug)
+}
+
+func (c *OllamaCluster) Generate(ctx context.Context, cfg moduletools.ClassConfig, prompt string, options interface{}, debug bool) (*modulecapabilities.GenerateResponse, error) {
+	params := c.servers[0].client.getParameters(ctx, cfg, options)
+
+	server, err := c.selectServer(params.Model, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	params.ApiEndpoint = server.baseURL
+	return server.client.Generate(ctx, cfg, prompt, params, debug)
+}
+
+func (c *OllamaCluster) GenerateAllResultsStream(ctx context.Context, textProperties []map[string]string, task string, options interface{}, debug bool, cfg moduletools.ClassConfig) (<-chan StreamChunk, error) {
+	forTask, err := c.servers[0].client.allResultsPrompt(ctx, cfg, options, textProperties, task)
+	if err != nil {
+		return nil, err
+	}
+	return c.GenerateStream(ctx, cfg, forTask, options, debug)
+}
+
+func (c *OllamaCluster) GenerateStream(ctx context.Context, cfg moduletools.ClassConfig, prompt string, options interface{}, debug bool) (<-chan StreamChunk, error) {
+	params := c.servers[0].client.getParameters(ctx, cfg, options)
+
+	server, err := c.selectServer(params.Model, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	params.ApiEndpoint = server.baseURL
+	return server.client.GenerateStream(ctx, cfg, prompt, params, debug)
+}
+
+func (c *OllamaCluster) MetaInfo() (map[string]interface{}, error) {
+	return c.servers[0].client.MetaInfo()
+}
+
+// WithResponseCache enables caching of deterministic responses for ttl. The
+// cache is shared by all servers of the cluster.
+func (c *OllamaCluster) WithResponseCache(cache ResponseCache, ttl time.Duration) *OllamaCluster {
+	for _, server := range c.servers {
+		server.client.WithResponseCache(cache, ttl)
+	}
+	return c
+}
+
+// WithRateLimit limits the requests sent to Ollama across all tenants. The
+// limit applies to the cluster as a whole, not to every server.
+func (c *OllamaCluster) WithRateLimit(limit RateLimit) *OllamaCluster {
+	limiter := limit.newLimiter()
+	for _, server := range c.servers {
+		server.client.withRateLimiter(limiter)
+	}
+	return c
+}
+
+// WithTenantRateLimit limits the requests sent to the cluster for each tenant
+// separately
+func (c *OllamaCluster) WithTenantRateLimit(limit RateLimit) *OllamaCluster {
+	limiters := newTenantRateLimiters(limit)
+	for _, server := range c.servers {
+		server.client.withTenantRateLimiters(limiters)
+	}
+	return c
+}
+
+// WithFallbackProviders sets the providers tried in order when the selected
+// server fails with a server error or times out
+func (c *OllamaCluster) WithFallbackProviders(providers ...modulecapabilities.GenerativeClient) *OllamaCluster {
+	for _, server := range c.servers {
+		server.client.WithFallbackProviders(providers...)
+	}
+	return c
+}
+
+// WithTracer sets the tracer creating a span for every Generate call on any
+// of the servers
+func (c *OllamaCluster) WithTracer(tracer trace.Tracer) *OllamaCluster {
+	for _, server := range c.servers {
+		server.client.WithTracer(tracer)
+	}
+	return c
+}
+
+// WithPromptSanitizer sanitizes every property value before it is substituted
+// into a prompt, see PromptSanitizer
+func (c *OllamaCluster) WithPromptSanitizer(sanitizer PromptSanitizer) *OllamaCluster {
+	for _, server := range c.servers {
+		server.client.WithPromptSanitizer(sanitizer)
+	}
+	return c
+}
+
+// Close stops the background health checks
+func (c *OllamaCluster) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+// selectServer picks the healthy server with the highest rendezvous weight
+// for the given model and prompt
+func (c *OllamaCluster) selectServer(model, prompt string) (*clusterServer, error) {
+	key := routingKey(model, prompt)
+
+	var selected *clusterServer
+	var highest uint64
+	for _, server := range c.servers {
+		if !server.isHealthy() {
+			continue
+		}
+		if weight := rendezvousWeight(key, server.baseURL); selected == nil || weight > highest {
+			selected = server
+			highest = weight
+		}
+	}
+
+	if selected == nil {
+		return nil, errors.Errorf("none of the %d Ollama servers is reachable", len(c.servers))
+	}
+	return selected, nil
+}
+
+func routingKey(model, prompt string) [sha256.Size]byte {
+	if len(prompt) > routingPromptPrefixLength {
+		prompt = prompt[:routingPromptPrefixLength]
+	}
+	return sha256.Sum256([]byte(model + prompt))
+}
+
+func rendezvousWeight(key [sha256.Size]byte, baseURL string) uint64 {
+	h := sha256.New()
+	h.Write(key[:])
+	h.Write([]byte(baseURL))
+	return binary.BigEndian.Uint64(h.Sum(nil))
+}
+
+func (c *OllamaCluster) healthCheckLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.checkHealth(interval)
+		}
+	}
+}
+
+func (c *OllamaCluster) checkHealth(timeout time.Duration) {
+	for _, server := range c.servers {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := c.ping(ctx, server.baseURL)
+		cancel()
+
+		wasHealthy := server.isHealthy()
+		server.setHealthy(err == nil)
+
+		if err != nil && wasHealthy {
+			c.logger.WithField("action", "ollama_cluster_health_check").
+				WithField("base_url", server.baseURL).
+				WithError(err).
+				Warn("Ollama server unreachable, removing it from the cluster")
+		} else if err == nil && !wasHealthy {
+			c.logger.WithField("action", "ollama_cluster_health_check").
+				WithField("base_url", server.baseURL).
+				Info("Ollama server recovered, adding it back to the cluster")
+		}
+	}
+}
+
+func (c *OllamaCluster) ping(ctx context.Context, baseURL string) error {
+	return ping(ctx, c.httpClient, baseURL)
+}
+
+// WarmUp loads the given models into memory on every server of the cluster,
+// as requests for the same model can be routed to any of them. See
+// ollama.WarmUp for details.
+func (c *OllamaCluster) WarmUp(ctx context.Context, models []string) {
+	for _, server := range c.servers {
+		server.client.WarmUp(ctx, server.baseURL, models)
+	}
+}
+
+func (s *clusterServer) isHealthy() bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.healthy
+}
+
+func (s *clusterServer) setHealthy(healthy bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.healthy = healthy
+}
+
+var _ = modulecapabilities.GenerativeClient(&OllamaCluster{})
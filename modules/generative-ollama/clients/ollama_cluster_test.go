@@ -0,0 +1,151 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaCluster(t *testing.T) {
+	servers := make([]*testClusterServer, 3)
+	baseURLs := make([]string, len(servers))
+	for i := range servers {
+		servers[i] = newTestClusterServer(t, fmt.Sprintf("server-%d", i))
+		defer servers[i].Close()
+		baseURLs[i] = servers[i].URL
+	}
+
+	c, err := NewCluster(baseURLs, 5*time.Second, 0, nullLogger())
+	require.Nil(t, err)
+	defer c.Close()
+
+	settings := &fakeClassConfig{apiEndpoint: "http://not-used"}
+
+	t.Run("identical requests are routed to the same server", func(t *testing.T) {
+		prompt := "What is the meaning of life?"
+		first, err := c.Generate(context.Background(), settings, prompt, nil, false)
+		require.Nil(t, err)
+
+		for i := 0; i < 10; i++ {
+			res, err := c.Generate(context.Background(), settings, prompt, nil, false)
+			require.Nil(t, err)
+			assert.Equal(t, *first.Result, *res.Result)
+		}
+	})
+
+	t.Run("only the prompt prefix is used for routing", func(t *testing.T) {
+		prefix := strings.Repeat("a", routingPromptPrefixLength)
+		first, err := c.Generate(context.Background(), settings, prefix+"first", nil, false)
+		require.Nil(t, err)
+		second, err := c.Generate(context.Background(), settings, prefix+"second", nil, false)
+		require.Nil(t, err)
+		assert.Equal(t, *first.Result, *second.Result)
+	})
+
+	t.Run("requests are spread across servers", func(t *testing.T) {
+		for _, s := range servers {
+			s.requests.Store(0)
+		}
+		for i := 0; i < 100; i++ {
+			_, err := c.Generate(context.Background(), settings, fmt.Sprintf("prompt %d", i), nil, false)
+			require.Nil(t, err)
+		}
+		for _, s := range servers {
+			assert.Greater(t, s.requests.Load(), int64(0), s.name)
+		}
+	})
+
+	t.Run("unreachable servers are removed and re-added after recovery", func(t *testing.T) {
+		prompt := "route me"
+		res, err := c.Generate(context.Background(), settings, prompt, nil, false)
+		require.Nil(t, err)
+		selected := *res.Result
+
+		var down *testClusterServer
+		for _, s := range servers {
+			if s.name == selected {
+				down = s
+			}
+		}
+		require.NotNil(t, down)
+
+		down.healthy.Store(false)
+		c.checkHealth(time.Second)
+
+		res, err = c.Generate(context.Background(), settings, prompt, nil, false)
+		require.Nil(t, err)
+		assert.NotEqual(t, selected, *res.Result)
+
+		down.healthy.Store(true)
+		c.checkHealth(time.Second)
+
+		res, err = c.Generate(context.Background(), settings, prompt, nil, false)
+		require.Nil(t, err)
+		assert.Equal(t, selected, *res.Result)
+	})
+
+	t.Run("error when no server is reachable", func(t *testing.T) {
+		for _, s := range servers {
+			s.healthy.Store(false)
+		}
+		c.checkHealth(time.Second)
+		defer func() {
+			for _, s := range servers {
+				s.healthy.Store(true)
+			}
+			c.checkHealth(time.Second)
+		}()
+
+		_, err := c.Generate(context.Background(), settings, "prompt", nil, false)
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "none of the 3 Ollama servers is reachable")
+	})
+}
+
+type testClusterServer struct {
+	*httptest.Server
+	name     string
+	healthy  atomic.Bool
+	requests atomic.Int64
+}
+
+// newTestClusterServer answers every generate request with its own name
+func newTestClusterServer(t *testing.T, name string) *testClusterServer {
+	s := &testClusterServer{name: name}
+	s.healthy.Store(true)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/version":
+			if !s.healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"version":"0.1.0"}`))
+		case "/api/generate":
+			s.requests.Add(1)
+			w.Write([]byte(fmt.Sprintf(`{"response":%q}`, name)))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	return s
+}
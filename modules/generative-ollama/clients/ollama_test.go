@@ -24,6 +24,8 @@ import (
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/modulecapabilities"
+	ollamaparams "github.com/weaviate/weaviate/modules/generative-ollama/parameters"
 )
 
 func nullLogger() logrus.FieldLogger {
@@ -83,6 +85,647 @@ func TestGetAnswer(t *testing.T) {
 	}
 }
 
+func TestGetAnswerWithMinResponseEntropy(t *testing.T) {
+	textProperties := []map[string]string{{"prop": "My name is john"}}
+	minEntropy := 2.5
+
+	tests := []struct {
+		name               string
+		response           string
+		expectedLowQuality bool
+	}{
+		{
+			name:     "natural language response",
+			response: "Your name is John, as mentioned in the provided text.",
+		},
+		{
+			name:               "repetitive response",
+			response:           "no no no no no no no no no no no no",
+			expectedLowQuality: true,
+		},
+		{
+			name:               "empty response",
+			response:           "",
+			expectedLowQuality: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := &testAnswerHandler{
+				t:      t,
+				answer: generateResponse{Response: test.response},
+			}
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			c := New(0, nullLogger())
+
+			settings := &fakeClassConfig{apiEndpoint: server.URL}
+			options := ollamaparams.Params{MinResponseEntropy: &minEntropy}
+			res, err := c.GenerateAllResults(context.Background(), textProperties, "What is my name?", options, false, settings)
+			require.Nil(t, err)
+
+			if test.expectedLowQuality {
+				assert.Nil(t, res.Result)
+				require.NotNil(t, res.Params)
+				assert.Equal(t, map[string]interface{}{"generativeLowQuality": true}, res.Params[ollamaparams.Name])
+			} else {
+				require.NotNil(t, res.Result)
+				assert.Equal(t, test.response, *res.Result)
+				assert.Nil(t, res.Params)
+			}
+		})
+	}
+}
+
+func TestGetAnswerWithContext(t *testing.T) {
+	var received [][]int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input generateInput
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		received = append(received, input.Context)
+
+		// every turn extends the context of the previous one
+		answer := generateResponse{
+			Response: "Your name is John.",
+			Context:  append(append([]int{}, input.Context...), len(input.Context)+1),
+		}
+		require.Nil(t, json.NewEncoder(w).Encode(answer))
+	}))
+	defer server.Close()
+
+	c := New(0, nullLogger())
+	settings := &fakeClassConfig{apiEndpoint: server.URL}
+
+	res, err := c.Generate(context.Background(), settings, "What is my name?", nil, false)
+	require.Nil(t, err)
+	require.NotNil(t, res.Params)
+	firstContext := res.Params[ollamaparams.Name].(map[string]interface{})["context"]
+	assert.Equal(t, []int{1}, firstContext)
+
+	options := ollamaparams.Params{Context: firstContext.([]int)}
+	res, err = c.Generate(context.Background(), settings, "Are you sure?", options, false)
+	require.Nil(t, err)
+	require.NotNil(t, res.Params)
+	assert.Equal(t, []int{1, 2}, res.Params[ollamaparams.Name].(map[string]interface{})["context"])
+
+	require.Len(t, received, 2)
+	assert.Nil(t, received[0])
+	assert.Equal(t, []int{1}, received[1])
+}
+
+func TestGetAnswerWithToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model":"llama3.1","done":true,"tool_calls":[` +
+			`{"function":{"name":"get_weather","arguments":{"city":"Vilnius"}}},` +
+			`{"function":{"name":"get_time","arguments":"{\"zone\":\"UTC\"}"}}]}`))
+	}))
+	defer server.Close()
+
+	c := New(0, nullLogger())
+	settings := &fakeClassConfig{apiEndpoint: server.URL}
+
+	res, err := c.Generate(context.Background(), settings, "What is the weather in Vilnius?", nil, false)
+	require.Nil(t, err)
+
+	assert.Equal(t, []modulecapabilities.ToolCall{
+		{Name: "get_weather", Arguments: `{"city":"Vilnius"}`},
+		{Name: "get_time", Arguments: `{"zone":"UTC"}`},
+	}, res.ToolCalls)
+
+	require.NotNil(t, res.Result)
+	var toolCalls []OllamaToolCall
+	require.Nil(t, json.Unmarshal([]byte(*res.Result), &toolCalls))
+	require.Len(t, toolCalls, 2)
+	assert.Equal(t, "get_weather", toolCalls[0].Function.Name)
+	assert.Equal(t, `{"city":"Vilnius"}`, toolCalls[0].Function.Arguments)
+}
+
+func TestGetAnswerWithDoneReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "cut off by num_predict",
+			response: `{"model":"llama3.1","response":"Once upon","done":true,"done_reason":"length"}`,
+			expected: map[string]interface{}{ollamaparams.Name: map[string]interface{}{"doneReason": "length"}},
+		},
+		{
+			name:     "completed naturally",
+			response: `{"model":"llama3.1","response":"The end.","done":true,"done_reason":"stop"}`,
+			expected: map[string]interface{}{ollamaparams.Name: map[string]interface{}{"doneReason": "stop"}},
+		},
+		{
+			name:     "older Ollama versions omit the done reason",
+			response: `{"model":"llama3.1","response":"The end.","done":true}`,
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(test.response))
+			}))
+			defer server.Close()
+
+			c := New(0, nullLogger())
+			settings := &fakeClassConfig{apiEndpoint: server.URL}
+
+			res, err := c.Generate(context.Background(), settings, "Tell me a story", nil, false)
+			require.Nil(t, err)
+			require.NotNil(t, res.Result)
+			assert.Equal(t, test.expected, res.Params)
+		})
+	}
+}
+
+func TestGetAnswerStripThinking(t *testing.T) {
+	response := "<think>\nThe user asks for my name.\n</think>\n\nI am Llama."
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Nil(t, json.NewEncoder(w).Encode(generateResponse{Response: response}))
+	}))
+	defer server.Close()
+
+	c := New(0, nullLogger())
+
+	t.Run("disabled by default", func(t *testing.T) {
+		settings := &fakeClassConfig{apiEndpoint: server.URL}
+		res, err := c.Generate(context.Background(), settings, "Who are you?", nil, true)
+		require.Nil(t, err)
+		assert.Equal(t, response, *res.Result)
+		assert.Empty(t, res.Debug.Reasoning)
+	})
+
+	t.Run("stripped", func(t *testing.T) {
+		settings := &fakeClassConfig{
+			apiEndpoint: server.URL,
+			settings:    map[string]interface{}{"stripThinking": true},
+		}
+		res, err := c.Generate(context.Background(), settings, "Who are you?", nil, false)
+		require.Nil(t, err)
+		assert.Equal(t, "I am Llama.", *res.Result)
+		assert.Nil(t, res.Debug)
+	})
+
+	t.Run("stripped and preserved in debug information", func(t *testing.T) {
+		settings := &fakeClassConfig{
+			apiEndpoint: server.URL,
+			settings:    map[string]interface{}{"stripThinking": true},
+		}
+		res, err := c.Generate(context.Background(), settings, "Who are you?", nil, true)
+		require.Nil(t, err)
+		assert.Equal(t, "I am Llama.", *res.Result)
+		require.NotNil(t, res.Debug)
+		assert.Equal(t, "Who are you?", res.Debug.Prompt)
+		assert.Equal(t, "The user asks for my name.", res.Debug.Reasoning)
+	})
+
+	t.Run("custom tag", func(t *testing.T) {
+		settings := &fakeClassConfig{
+			apiEndpoint: server.URL,
+			settings:    map[string]interface{}{"stripThinking": true, "thinkingTag": "reasoning"},
+		}
+		res, err := c.Generate(context.Background(), settings, "Who are you?", nil, false)
+		require.Nil(t, err)
+		assert.Equal(t, response, *res.Result)
+	})
+}
+
+func TestStripThinking(t *testing.T) {
+	tests := []struct {
+		name          string
+		result        string
+		wantResult    string
+		wantReasoning string
+	}{
+		{
+			name:       "no reasoning",
+			result:     "Hello!",
+			wantResult: "Hello!",
+		},
+		{
+			name:          "multiple blocks",
+			result:        "<think>first</think>Hello <think>second</think>world",
+			wantResult:    "Hello world",
+			wantReasoning: "first\nsecond",
+		},
+		{
+			name:          "unclosed block",
+			result:        "Hello <think>cut off while",
+			wantResult:    "Hello",
+			wantReasoning: "cut off while",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.result
+			res := &modulecapabilities.GenerateResponse{
+				Result: &result,
+				Debug:  &modulecapabilities.GenerateDebugInformation{Prompt: "prompt"},
+			}
+
+			stripped := stripThinking(res, "think")
+			assert.Equal(t, test.wantResult, *stripped.Result)
+			assert.Equal(t, test.wantReasoning, stripped.Debug.Reasoning)
+			// the original response is left untouched
+			assert.Equal(t, test.result, *res.Result)
+			assert.Empty(t, res.Debug.Reasoning)
+		})
+	}
+}
+
+func TestGenerateInputSuffix(t *testing.T) {
+	t.Run("suffix is omitted when empty", func(t *testing.T) {
+		body, err := json.Marshal(generateInput{Model: "codellama:code", Prompt: "def add("})
+		require.Nil(t, err)
+		assert.NotContains(t, string(body), "suffix")
+	})
+
+	t.Run("suffix is sent when set", func(t *testing.T) {
+		var input generateInput
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.Nil(t, err)
+			assert.Contains(t, string(body), `"suffix":"    return result"`)
+			require.Nil(t, json.Unmarshal(body, &input))
+			require.Nil(t, json.NewEncoder(w).Encode(generateResponse{Response: "a, b):"}))
+		}))
+		defer server.Close()
+
+		c := New(0, nullLogger())
+		settings := &fakeClassConfig{apiEndpoint: server.URL}
+		options := ollamaparams.Params{Suffix: "    return result"}
+
+		res, err := c.Generate(context.Background(), settings, "def add(", options, false)
+		require.Nil(t, err)
+		assert.Equal(t, "a, b):", *res.Result)
+		assert.Equal(t, "def add(", input.Prompt)
+	})
+}
+
+func TestGenerateInputThink(t *testing.T) {
+	t.Run("think is only marshaled when set", func(t *testing.T) {
+		think := false
+		for _, tc := range []struct {
+			think    *bool
+			expected string
+		}{
+			{think: nil, expected: ""},
+			{think: &think, expected: `"think":false`},
+		} {
+			body, err := json.Marshal(generateInput{Model: "deepseek-r1", Prompt: "prompt", Think: tc.think})
+			require.Nil(t, err)
+			if tc.expected == "" {
+				assert.NotContains(t, string(body), "think")
+			} else {
+				assert.Contains(t, string(body), tc.expected)
+			}
+		}
+	})
+
+	var input generateInput
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		input = generateInput{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		require.Nil(t, json.NewEncoder(w).Encode(generateResponse{Response: "answer"}))
+	}))
+	defer server.Close()
+
+	c := New(0, nullLogger())
+
+	t.Run("think is taken from the class settings", func(t *testing.T) {
+		settings := &fakeClassConfig{apiEndpoint: server.URL, settings: map[string]interface{}{"think": false}}
+		_, err := c.Generate(context.Background(), settings, "prompt", nil, false)
+		require.Nil(t, err)
+		require.NotNil(t, input.Think)
+		assert.False(t, *input.Think)
+	})
+
+	t.Run("request param takes precedence", func(t *testing.T) {
+		think := true
+		settings := &fakeClassConfig{apiEndpoint: server.URL, settings: map[string]interface{}{"think": false}}
+		_, err := c.Generate(context.Background(), settings, "prompt", ollamaparams.Params{Think: &think}, false)
+		require.Nil(t, err)
+		require.NotNil(t, input.Think)
+		assert.True(t, *input.Think)
+	})
+
+	t.Run("think is not sent by default", func(t *testing.T) {
+		settings := &fakeClassConfig{apiEndpoint: server.URL}
+		_, err := c.Generate(context.Background(), settings, "prompt", nil, false)
+		require.Nil(t, err)
+		assert.Nil(t, input.Think)
+	})
+}
+
+func TestGetParametersModelPrecedence(t *testing.T) {
+	c := New(0, nullLogger())
+	cfg := &fakeClassConfig{model: "class-model"}
+	options := ollamaparams.Params{Model: "options-model"}
+	withHeader := context.WithValue(context.Background(), "X-Ollama-Model", []string{"header-model"})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		options  interface{}
+		expected string
+	}{
+		{
+			name:     "header takes precedence over options and class config",
+			ctx:      withHeader,
+			options:  options,
+			expected: "header-model",
+		},
+		{
+			name:     "header takes precedence over class config",
+			ctx:      withHeader,
+			expected: "header-model",
+		},
+		{
+			name:     "options take precedence over class config",
+			ctx:      context.Background(),
+			options:  options,
+			expected: "options-model",
+		},
+		{
+			name:     "class config is used without header and options",
+			ctx:      context.Background(),
+			expected: "class-model",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := c.getParameters(test.ctx, cfg, test.options)
+			assert.Equal(t, test.expected, params.Model)
+		})
+	}
+}
+
+func TestGetOllamaUrl(t *testing.T) {
+	c := New(0, nullLogger())
+	withHeaders := func(headers map[string]string) context.Context {
+		ctx := context.Background()
+		for key, value := range headers {
+			ctx = context.WithValue(ctx, key, []string{value})
+		}
+		return ctx
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		baseURL  string
+		path     string
+		expected string
+	}{
+		{
+			name:     "default path",
+			ctx:      context.Background(),
+			baseURL:  "http://localhost:11434",
+			expected: "http://localhost:11434/api/generate",
+		},
+		{
+			name:     "custom path",
+			ctx:      context.Background(),
+			baseURL:  "http://gateway",
+			path:     "/ollama/api/generate",
+			expected: "http://gateway/ollama/api/generate",
+		},
+		{
+			name:     "trailing slash of base URL",
+			ctx:      context.Background(),
+			baseURL:  "http://gateway/",
+			path:     "/ollama/api/generate",
+			expected: "http://gateway/ollama/api/generate",
+		},
+		{
+			name:     "path without leading slash",
+			ctx:      context.Background(),
+			baseURL:  "http://gateway",
+			path:     "ollama/api/generate",
+			expected: "http://gateway/ollama/api/generate",
+		},
+		{
+			name:     "duplicate slashes",
+			ctx:      context.Background(),
+			baseURL:  "http://gateway/prefix//",
+			path:     "//api/generate",
+			expected: "http://gateway/prefix/api/generate",
+		},
+		{
+			name:     "headers take precedence",
+			ctx:      withHeaders(map[string]string{"X-Ollama-BaseURL": "http://header/", "X-Ollama-Path": "proxy/api/generate"}),
+			baseURL:  "http://gateway",
+			path:     "/ollama/api/generate",
+			expected: "http://header/proxy/api/generate",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, c.getOllamaUrl(test.ctx, test.baseURL, test.path))
+		})
+	}
+}
+
+func TestGenerateCustomPath(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		require.Nil(t, json.NewEncoder(w).Encode(generateResponse{Response: "answer"}))
+	}))
+	defer server.Close()
+
+	c := New(0, nullLogger())
+	settings := &fakeClassConfig{apiEndpoint: server.URL + "/", settings: map[string]interface{}{
+		"generatePath": "/ollama/api/generate",
+	}}
+
+	_, err := c.Generate(context.Background(), settings, "prompt", nil, false)
+	require.Nil(t, err)
+	assert.Equal(t, "/ollama/api/generate", path)
+}
+
+func TestGenerateDebugInformationResolvedModelAndEndpoint(t *testing.T) {
+	var model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input generateInput
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		model = input.Model
+		require.Nil(t, json.NewEncoder(w).Encode(generateResponse{Response: "answer"}))
+	}))
+	defer server.Close()
+
+	c := New(0, nullLogger())
+
+	t.Run("from class settings", func(t *testing.T) {
+		settings := &fakeClassConfig{apiEndpoint: server.URL, model: "settings-model"}
+		res, err := c.Generate(context.Background(), settings, "prompt", nil, true)
+		require.Nil(t, err)
+		require.NotNil(t, res.Debug)
+		assert.Equal(t, "settings-model", res.Debug.Model)
+		assert.Equal(t, server.URL+"/api/generate", res.Debug.Endpoint)
+	})
+
+	t.Run("header overrides", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "X-Ollama-Model", []string{"header-model"})
+		ctx = context.WithValue(ctx, "X-Ollama-BaseURL", []string{server.URL})
+		ctx = context.WithValue(ctx, "X-Ollama-Path", []string{"/proxy/api/generate"})
+		settings := &fakeClassConfig{apiEndpoint: "http://unreachable", model: "settings-model"}
+		res, err := c.Generate(ctx, settings, "prompt", nil, true)
+		require.Nil(t, err)
+		require.NotNil(t, res.Debug)
+		assert.Equal(t, "header-model", model)
+		assert.Equal(t, "header-model", res.Debug.Model)
+		assert.Equal(t, server.URL+"/proxy/api/generate", res.Debug.Endpoint)
+	})
+
+	t.Run("debug disabled", func(t *testing.T) {
+		settings := &fakeClassConfig{apiEndpoint: server.URL}
+		res, err := c.Generate(context.Background(), settings, "prompt", nil, false)
+		require.Nil(t, err)
+		assert.Nil(t, res.Debug)
+	})
+}
+
+func TestGenerateOptionsFromClassSettings(t *testing.T) {
+	var input generateInput
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		require.Nil(t, json.NewEncoder(w).Encode(generateResponse{Response: "answer"}))
+	}))
+	defer server.Close()
+
+	c := New(0, nullLogger())
+	settings := &fakeClassConfig{apiEndpoint: server.URL, settings: map[string]interface{}{
+		"temperature":   0.2,
+		"topP":          0.9,
+		"topK":          40,
+		"repeatPenalty": 1.1,
+	}}
+
+	t.Run("class settings are used as defaults", func(t *testing.T) {
+		_, err := c.Generate(context.Background(), settings, "prompt", nil, false)
+		require.Nil(t, err)
+		require.NotNil(t, input.Options)
+		assert.Equal(t, 0.2, *input.Options.Temperature)
+		assert.Equal(t, 0.9, *input.Options.TopP)
+		assert.Equal(t, 40, *input.Options.TopK)
+		assert.Equal(t, 1.1, *input.Options.RepeatPenalty)
+	})
+
+	t.Run("request params take precedence", func(t *testing.T) {
+		temperature, topK := 1.5, 10
+		options := ollamaparams.Params{Temperature: &temperature, TopK: &topK}
+
+		_, err := c.Generate(context.Background(), settings, "prompt", options, false)
+		require.Nil(t, err)
+		require.NotNil(t, input.Options)
+		assert.Equal(t, 1.5, *input.Options.Temperature)
+		assert.Equal(t, 0.9, *input.Options.TopP)
+		assert.Equal(t, 10, *input.Options.TopK)
+		assert.Equal(t, 1.1, *input.Options.RepeatPenalty)
+	})
+}
+
+func TestGenerateRawOptions(t *testing.T) {
+	var options map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Options map[string]interface{} `json:"options"`
+		}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		options = input.Options
+		require.Nil(t, json.NewEncoder(w).Encode(generateResponse{Response: "answer"}))
+	}))
+	defer server.Close()
+
+	c := New(0, nullLogger())
+	settings := &fakeClassConfig{apiEndpoint: server.URL}
+	temperature := 0.5
+	params := ollamaparams.Params{
+		Temperature: &temperature,
+		RawOptions: map[string]interface{}{
+			"num_ctx":     4096,
+			"stop":        []string{"\n"},
+			"temperature": 1.0,
+		},
+	}
+
+	_, err := c.Generate(context.Background(), settings, "prompt", params, false)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"num_ctx": 4096.0,
+		"stop":    []interface{}{"\n"},
+		// the typed field takes precedence over the raw option
+		"temperature": 0.5,
+	}, options)
+	assert.False(t, c.isCacheable(params))
+}
+
+func TestGenerateValidatesOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid requests must not be sent")
+	}))
+	defer server.Close()
+
+	floatPtr := func(f float64) *float64 { return &f }
+	intPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		name        string
+		params      ollamaparams.Params
+		expectedErr string
+	}{
+		{
+			name:        "temperature too high",
+			params:      ollamaparams.Params{Temperature: floatPtr(50)},
+			expectedErr: "temperature has to be a float value between 0 and 2, got 50",
+		},
+		{
+			name:        "negative temperature",
+			params:      ollamaparams.Params{Temperature: floatPtr(-0.5)},
+			expectedErr: "temperature has to be a float value between 0 and 2, got -0.5",
+		},
+		{
+			name:        "topP too high",
+			params:      ollamaparams.Params{TopP: floatPtr(2)},
+			expectedErr: "topP has to be a float value greater than 0 and less or equal 1, got 2",
+		},
+		{
+			name:        "negative topK",
+			params:      ollamaparams.Params{TopK: intPtr(-1)},
+			expectedErr: "topK has to be an integer value above or equal 1, got -1",
+		},
+		{
+			name:   "all invalid parameters are listed",
+			params: ollamaparams.Params{Temperature: floatPtr(3), TopP: floatPtr(0), TopK: intPtr(0)},
+			expectedErr: "temperature has to be a float value between 0 and 2, got 3, " +
+				"topP has to be a float value greater than 0 and less or equal 1, got 0, " +
+				"topK has to be an integer value above or equal 1, got 0",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := New(0, nullLogger())
+			settings := &fakeClassConfig{apiEndpoint: server.URL}
+
+			_, err := c.Generate(context.Background(), settings, "prompt", test.params, false)
+			require.NotNil(t, err)
+			assert.Equal(t, "invalid request parameters: "+test.expectedErr, err.Error())
+		})
+	}
+}
+
+func TestShannonEntropy(t *testing.T) {
+	assert.Equal(t, 0.0, shannonEntropy(""))
+	assert.Equal(t, 0.0, shannonEntropy("aaaa"))
+	assert.Equal(t, 1.0, shannonEntropy("abab"))
+	assert.Equal(t, 2.0, shannonEntropy("abcd"))
+	assert.Equal(t, 1.0, shannonEntropy("żżóó"))
+}
+
 type testAnswerHandler struct {
 	t *testing.T
 	// the test handler will report as not ready before the time has passed
@@ -120,10 +763,13 @@ func (f *testAnswerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 type fakeClassConfig struct {
 	apiEndpoint string
+	model       string
+	settings    map[string]interface{}
+	tenant      string
 }
 
 func (cfg *fakeClassConfig) Tenant() string {
-	return ""
+	return cfg.tenant
 }
 
 func (cfg *fakeClassConfig) Class() map[string]interface{} {
@@ -134,6 +780,12 @@ func (cfg *fakeClassConfig) ClassByModuleName(moduleName string) map[string]inte
 	settings := map[string]interface{}{
 		"apiEndpoint": cfg.apiEndpoint,
 	}
+	if cfg.model != "" {
+		settings["model"] = cfg.model
+	}
+	for name, value := range cfg.settings {
+		settings[name] = value
+	}
 	return settings
 }
 
@@ -0,0 +1,110 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultMaxPropertyLength is the number of characters property values are
+// truncated to by the DefaultPromptSanitizer, unless configured otherwise
+const DefaultMaxPropertyLength = 10_000
+
+// PromptSanitizer cleans property values before they are substituted into a
+// prompt.
+//
+// Sanitizing is a best-effort mitigation against prompt injection, e.g. a
+// property containing "Ignore previous instructions". It is not a security
+// guarantee: there are countless ways to phrase an injection and a model may
+// follow instructions no filter recognizes. Don't rely on it to protect
+// sensitive data or actions.
+type PromptSanitizer interface {
+	Sanitize(propertyValue string) string
+}
+
+// DefaultPromptSanitizer strips null bytes, removes the forbidden phrases
+// regardless of their case and truncates values to the max property length
+type DefaultPromptSanitizer struct {
+	maxPropertyLength int
+	// forbidden matches any of the forbidden phrases, nil if there are none
+	forbidden *regexp.Regexp
+}
+
+// NewDefaultPromptSanitizer creates a sanitizer which truncates values to
+// maxPropertyLength characters, DefaultMaxPropertyLength if it is not
+// positive, and removes all occurrences of forbiddenPhrases
+func NewDefaultPromptSanitizer(maxPropertyLength int, forbiddenPhrases ...string) *DefaultPromptSanitizer {
+	if maxPropertyLength <= 0 {
+		maxPropertyLength = DefaultMaxPropertyLength
+	}
+
+	var quoted []string
+	for _, phrase := range forbiddenPhrases {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			quoted = append(quoted, regexp.QuoteMeta(phrase))
+		}
+	}
+
+	s := &DefaultPromptSanitizer{maxPropertyLength: maxPropertyLength}
+	if len(quoted) > 0 {
+		s.forbidden = regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)`)
+	}
+	return s
+}
+
+func (s *DefaultPromptSanitizer) Sanitize(propertyValue string) string {
+	value := strings.ReplaceAll(propertyValue, "\x00", "")
+
+	if s.forbidden != nil {
+		// removing a phrase can join its surroundings into a new occurrence,
+		// e.g. "ignore ignore previous instructionsprevious instructions".
+		// Every round shortens the value, so this terminates.
+		for s.forbidden.MatchString(value) {
+			value = s.forbidden.ReplaceAllString(value, "")
+		}
+	}
+
+	return truncateRunes(value, s.maxPropertyLength)
+}
+
+// truncateRunes cuts value after max characters, without splitting a
+// multi-byte character
+func truncateRunes(value string, max int) string {
+	if len(value) <= max {
+		// fewer bytes than max also means fewer characters
+		return value
+	}
+
+	count := 0
+	for i := range value {
+		if count == max {
+			return value[:i]
+		}
+		count++
+	}
+	return value
+}
+
+// WithPromptSanitizer sanitizes every property value before it is substituted
+// into a prompt, see PromptSanitizer. By default values are used as they are.
+func (v *ollama) WithPromptSanitizer(sanitizer PromptSanitizer) *ollama {
+	v.promptSanitizer = sanitizer
+	return v
+}
+
+func (v *ollama) sanitizeProperty(value string) string {
+	if v.promptSanitizer == nil {
+		return value
+	}
+	return v.promptSanitizer.Sanitize(value)
+}
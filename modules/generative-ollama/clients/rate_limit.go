@@ -0,0 +1,138 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit configures a token bucket, every request to Ollama takes one
+// token. A burst size of 0 defaults to the number of tokens per second.
+type RateLimit struct {
+	TokensPerSecond float64
+	BurstSize       int
+}
+
+func (r RateLimit) newLimiter() *rate.Limiter {
+	burst := r.BurstSize
+	if burst <= 0 {
+		burst = max(1, int(math.Ceil(r.TokensPerSecond)))
+	}
+	return rate.NewLimiter(rate.Limit(r.TokensPerSecond), burst)
+}
+
+// minTenantLimiterSweepInterval bounds how often idle tenant limiters are
+// swept, so that high rates with small bursts don't sweep on every request
+const minTenantLimiterSweepInterval = time.Second
+
+// tenantRateLimiters lazily creates a limiter per tenant. A limiter which is
+// full again behaves exactly like a new one, so full limiters are dropped by a
+// sweep which runs at most once per refill window, i.e. the time an empty
+// limiter takes to fill up. This bounds the limiters to the tenants which
+// were active recently, also on clusters with millions of tenants.
+type tenantRateLimiters struct {
+	limit         RateLimit
+	sweepInterval time.Duration
+
+	sync.Mutex
+	limiters  map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+func newTenantRateLimiters(limit RateLimit) *tenantRateLimiters {
+	limiter := limit.newLimiter()
+	sweepInterval := minTenantLimiterSweepInterval
+	if limit.TokensPerSecond > 0 {
+		refill := time.Duration(float64(limiter.Burst()) / limit.TokensPerSecond * float64(time.Second))
+		sweepInterval = max(sweepInterval, refill)
+	}
+	return &tenantRateLimiters{
+		limit:         limit,
+		sweepInterval: sweepInterval,
+		limiters:      map[string]*rate.Limiter{},
+		lastSweep:     time.Now(),
+	}
+}
+
+func (t *tenantRateLimiters) get(tenant string) *rate.Limiter {
+	t.Lock()
+	defer t.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.lastSweep) >= t.sweepInterval {
+		t.sweep(now)
+	}
+
+	limiter, ok := t.limiters[tenant]
+	if !ok {
+		limiter = t.limit.newLimiter()
+		t.limiters[tenant] = limiter
+	}
+	return limiter
+}
+
+// sweep drops the limiters which are full at now. Must be called with the
+// lock held.
+func (t *tenantRateLimiters) sweep(now time.Time) {
+	for tenant, limiter := range t.limiters {
+		if limiter.TokensAt(now) >= float64(limiter.Burst()) {
+			delete(t.limiters, tenant)
+		}
+	}
+	t.lastSweep = now
+}
+
+// WithRateLimit limits the requests sent to Ollama across all tenants
+func (v *ollama) WithRateLimit(limit RateLimit) *ollama {
+	return v.withRateLimiter(limit.newLimiter())
+}
+
+func (v *ollama) withRateLimiter(limiter *rate.Limiter) *ollama {
+	v.RateLimiter = limiter
+	return v
+}
+
+// WithTenantRateLimit limits the requests sent to Ollama for each tenant
+// separately, so that a single tenant can't use up the shared rate limit.
+// Requests against classes without multi-tenancy are only subject to the
+// shared rate limit.
+func (v *ollama) WithTenantRateLimit(limit RateLimit) *ollama {
+	return v.withTenantRateLimiters(newTenantRateLimiters(limit))
+}
+
+func (v *ollama) withTenantRateLimiters(limiters *tenantRateLimiters) *ollama {
+	v.tenantRateLimiters = limiters
+	return v
+}
+
+// waitForRateLimit blocks until the request is allowed by the tenant and the
+// shared rate limit. The tenant limit is waited for first, so that a
+// throttled tenant doesn't hold on to shared tokens.
+func (v *ollama) waitForRateLimit(ctx context.Context, tenant string) error {
+	if v.tenantRateLimiters != nil && tenant != "" {
+		if err := v.tenantRateLimiters.get(tenant).Wait(ctx); err != nil {
+			return errors.Wrapf(err, "wait for rate limit of tenant %q", tenant)
+		}
+	}
+	if v.RateLimiter != nil {
+		if err := v.RateLimiter.Wait(ctx); err != nil {
+			return errors.Wrap(err, "wait for rate limit")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	handler := &testAnswerHandler{t: t, answer: generateResponse{Response: "answer"}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	// a request that would have to wait for a token fails right away, as the
+	// wait would exceed the deadline
+	generate := func(c *ollama, tenant string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		settings := &fakeClassConfig{apiEndpoint: server.URL, tenant: tenant}
+		_, err := c.Generate(ctx, settings, "prompt", nil, false)
+		return err
+	}
+
+	t.Run("shared limit", func(t *testing.T) {
+		c := New(time.Minute, nullLogger()).WithRateLimit(RateLimit{TokensPerSecond: 0.1, BurstSize: 2})
+
+		require.Nil(t, generate(c, "tenant1"))
+		require.Nil(t, generate(c, "tenant2"))
+		err := generate(c, "tenant3")
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "wait for rate limit")
+	})
+
+	t.Run("tenant limit", func(t *testing.T) {
+		c := New(time.Minute, nullLogger()).
+			WithRateLimit(RateLimit{TokensPerSecond: 0.1, BurstSize: 3}).
+			WithTenantRateLimit(RateLimit{TokensPerSecond: 0.1, BurstSize: 1})
+
+		require.Nil(t, generate(c, "tenant1"))
+		err := generate(c, "tenant1")
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), `wait for rate limit of tenant "tenant1"`)
+
+		// other tenants and requests without a tenant are not affected
+		require.Nil(t, generate(c, "tenant2"))
+		require.Nil(t, generate(c, ""))
+
+		// the throttled tenant didn't use up a shared token
+		err = generate(c, "tenant3")
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "wait for rate limit")
+		assert.NotContains(t, err.Error(), "tenant")
+	})
+
+	t.Run("full tenant limiters are evicted", func(t *testing.T) {
+		limiters := newTenantRateLimiters(RateLimit{TokensPerSecond: 1, BurstSize: 2})
+		assert.Equal(t, 2*time.Second, limiters.sweepInterval)
+
+		idle := limiters.get("idle")
+		active := limiters.get("active")
+		require.True(t, active.AllowN(time.Now(), 2))
+		require.Equal(t, 2, len(limiters.limiters))
+
+		limiters.Lock()
+		limiters.sweep(time.Now())
+		limiters.Unlock()
+		assert.Equal(t, 1, len(limiters.limiters))
+		assert.Same(t, active, limiters.get("active"))
+		assert.NotSame(t, idle, limiters.get("idle"))
+
+		// once refilled, the active limiter is evicted as well
+		limiters.Lock()
+		limiters.sweep(time.Now().Add(limiters.sweepInterval))
+		limiters.Unlock()
+		assert.Equal(t, 0, len(limiters.limiters))
+	})
+
+	t.Run("tenant limiters are swept at most once per interval", func(t *testing.T) {
+		limiters := newTenantRateLimiters(RateLimit{TokensPerSecond: 1000, BurstSize: 1})
+		assert.Equal(t, minTenantLimiterSweepInterval, limiters.sweepInterval)
+
+		limiters.get("tenant1")
+		limiters.get("tenant2")
+		assert.Equal(t, 2, len(limiters.limiters))
+
+		limiters.lastSweep = time.Now().Add(-limiters.sweepInterval)
+		limiters.get("tenant3")
+		assert.Equal(t, 1, len(limiters.limiters))
+	})
+
+	t.Run("default burst size", func(t *testing.T) {
+		limiter := RateLimit{TokensPerSecond: 2.5}.newLimiter()
+		assert.Equal(t, 3, limiter.Burst())
+		limiter = RateLimit{TokensPerSecond: 0.5}.newLimiter()
+		assert.Equal(t, 1, limiter.Burst())
+	})
+}
+
+func TestClusterRateLimitIsShared(t *testing.T) {
+	c, err := NewCluster([]string{"http://a", "http://b"}, time.Minute, 0, nullLogger())
+	require.Nil(t, err)
+	defer c.Close()
+
+	c.WithRateLimit(RateLimit{TokensPerSecond: 1}).WithTenantRateLimit(RateLimit{TokensPerSecond: 1})
+	assert.Same(t, c.servers[0].client.RateLimiter, c.servers[1].client.RateLimiter)
+	assert.Same(t, c.servers[0].client.tenantRateLimiters, c.servers[1].client.tenantRateLimiters)
+}
@@ -0,0 +1,198 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	enterrors "github.com/weaviate/weaviate/entities/errors"
+	"github.com/weaviate/weaviate/entities/modulecapabilities"
+)
+
+// cacheMetricsModule identifies the module in the response cache metrics
+const cacheMetricsModule = "generative-ollama"
+
+// ResponseCache stores generated responses, so that identical deterministic
+// requests don't need to be sent to Ollama again
+type ResponseCache interface {
+	Get(key string) (*modulecapabilities.GenerateResponse, bool)
+	Set(key string, resp *modulecapabilities.GenerateResponse, ttl time.Duration)
+}
+
+// responseCacheKey returns the cache key for a request with the given body
+// sent to url. The body holds the model, the prompt and all options that
+// affect the response. Both fields are length-prefixed, so that different
+// pairs never hash the same input.
+func responseCacheKey(url string, body []byte) string {
+	h := sha256.New()
+	for _, field := range [][]byte{[]byte(url), body} {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+		h.Write(length[:])
+		h.Write(field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DefaultResponseCacheMaxEntries bounds the entries of a MemoryResponseCache
+// if no other limit is configured
+const DefaultResponseCacheMaxEntries = 10000
+
+// MemoryResponseCache is an in-memory ResponseCache. It holds at most
+// maxEntries entries, once it is full the least recently used entry is
+// evicted. Expired entries are never returned and are removed by a background
+// sweeper, which is stopped by Close.
+type MemoryResponseCache struct {
+	sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element // values are *responseCacheEntry
+	// lru holds the entries from most to least recently used
+	lru *list.List
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type responseCacheEntry struct {
+	key       string
+	resp      *modulecapabilities.GenerateResponse
+	expiresAt time.Time
+}
+
+// NewMemoryResponseCache creates a cache which holds at most maxEntries
+// entries and removes expired entries every sweepInterval. A sweepInterval of
+// 0 disables the sweeper, a maxEntries of 0 or less defaults to
+// DefaultResponseCacheMaxEntries.
+func NewMemoryResponseCache(sweepInterval time.Duration, maxEntries int,
+	logger logrus.FieldLogger,
+) *MemoryResponseCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultResponseCacheMaxEntries
+	}
+	c := &MemoryResponseCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+		stop:       make(chan struct{}),
+	}
+	if sweepInterval > 0 {
+		enterrors.GoWrapper(func() { c.sweepLoop(sweepInterval) }, logger)
+	}
+	return c
+}
+
+func (c *MemoryResponseCache) Get(key string) (*modulecapabilities.GenerateResponse, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*responseCacheEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.resp, true
+}
+
+func (c *MemoryResponseCache) Set(key string, resp *modulecapabilities.GenerateResponse, ttl time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	entry := &responseCacheEntry{key: key, resp: resp, expiresAt: time.Now().Add(ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// Len returns the number of entries, including expired ones which were not
+// removed yet
+func (c *MemoryResponseCache) Len() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.lru.Len()
+}
+
+// Invalidate removes the entry for key, if any
+func (c *MemoryResponseCache) Invalidate(key string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Clear removes all entries
+func (c *MemoryResponseCache) Clear() {
+	c.Lock()
+	defer c.Unlock()
+
+	clear(c.entries)
+	c.lru.Init()
+}
+
+// Close stops the background sweeper
+func (c *MemoryResponseCache) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+// removeElement must be called with the lock held
+func (c *MemoryResponseCache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*responseCacheEntry).key)
+}
+
+func (c *MemoryResponseCache) sweepLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.sweep()
+		}
+	}
+}
+
+func (c *MemoryResponseCache) sweep() {
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if !now.Before(elem.Value.(*responseCacheEntry).expiresAt) {
+			c.removeElement(elem)
+		}
+		elem = next
+	}
+}
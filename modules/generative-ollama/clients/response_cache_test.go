@@ -0,0 +1,193 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/modulecapabilities"
+	ollamaparams "github.com/weaviate/weaviate/modules/generative-ollama/parameters"
+)
+
+func TestMemoryResponseCache(t *testing.T) {
+	result := "cached"
+	resp := &modulecapabilities.GenerateResponse{Result: &result}
+
+	t.Run("returns entries until they expire", func(t *testing.T) {
+		c := NewMemoryResponseCache(0, 0, nullLogger())
+		defer c.Close()
+
+		c.Set("key", resp, 50*time.Millisecond)
+		got, ok := c.Get("key")
+		require.True(t, ok)
+		assert.Equal(t, resp, got)
+
+		time.Sleep(60 * time.Millisecond)
+		_, ok = c.Get("key")
+		assert.False(t, ok)
+	})
+
+	t.Run("invalidates entries", func(t *testing.T) {
+		c := NewMemoryResponseCache(0, 0, nullLogger())
+		defer c.Close()
+
+		c.Set("a", resp, time.Minute)
+		c.Set("b", resp, time.Minute)
+		c.Invalidate("a")
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+		_, ok = c.Get("b")
+		assert.True(t, ok)
+
+		c.Clear()
+		_, ok = c.Get("b")
+		assert.False(t, ok)
+	})
+
+	t.Run("sweeper removes expired entries", func(t *testing.T) {
+		c := NewMemoryResponseCache(10*time.Millisecond, 0, nullLogger())
+		defer c.Close()
+
+		c.Set("expired", resp, time.Millisecond)
+		c.Set("valid", resp, time.Minute)
+
+		assert.Eventually(t, func() bool {
+			return c.Len() == 1
+		}, time.Second, 10*time.Millisecond)
+		_, ok := c.Get("valid")
+		assert.True(t, ok)
+	})
+
+	t.Run("least recently used entries are evicted", func(t *testing.T) {
+		c := NewMemoryResponseCache(0, 2, nullLogger())
+		defer c.Close()
+
+		c.Set("a", resp, time.Minute)
+		c.Set("b", resp, time.Minute)
+		_, ok := c.Get("a")
+		require.True(t, ok)
+
+		c.Set("c", resp, time.Minute)
+		assert.Equal(t, 2, c.Len())
+		_, ok = c.Get("b")
+		assert.False(t, ok)
+		_, ok = c.Get("a")
+		assert.True(t, ok)
+		_, ok = c.Get("c")
+		assert.True(t, ok)
+
+		// updating an entry doesn't evict another one
+		c.Set("a", resp, time.Minute)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("max entries default", func(t *testing.T) {
+		c := NewMemoryResponseCache(0, 0, nullLogger())
+		defer c.Close()
+		assert.Equal(t, DefaultResponseCacheMaxEntries, c.maxEntries)
+	})
+}
+
+func TestGenerateWithResponseCache(t *testing.T) {
+	var requests atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		require.Nil(t, json.NewEncoder(w).Encode(generateResponse{Response: "John"}))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	otherServer := httptest.NewServer(handler)
+	defer otherServer.Close()
+
+	cache := NewMemoryResponseCache(0, 0, nullLogger())
+	defer cache.Close()
+	c := New(0, nullLogger()).WithResponseCache(cache, time.Minute)
+	settings := &fakeClassConfig{apiEndpoint: server.URL}
+
+	generateCtx := func(ctx context.Context, prompt string, options interface{}, debug bool) *modulecapabilities.GenerateResponse {
+		res, err := c.Generate(ctx, settings, prompt, options, debug)
+		require.Nil(t, err)
+		require.NotNil(t, res.Result)
+		assert.Equal(t, "John", *res.Result)
+		return res
+	}
+	generate := func(prompt string, options interface{}, debug bool) *modulecapabilities.GenerateResponse {
+		return generateCtx(context.Background(), prompt, options, debug)
+	}
+
+	zero, warm, topP, topK, penalty, entropy := 0.0, 0.7, 0.9, 40, 1.1, 0.5
+
+	generate("What is my name?", nil, false)
+	assert.Equal(t, int32(1), requests.Load())
+
+	t.Run("identical deterministic requests are served from the cache", func(t *testing.T) {
+		generate("What is my name?", nil, false)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("debug information belongs to the request", func(t *testing.T) {
+		res := generate("What is my name?", nil, true)
+		require.NotNil(t, res.Debug)
+		assert.Equal(t, "What is my name?", res.Debug.Prompt)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("different prompts and models are not served from the cache", func(t *testing.T) {
+		generate("What is your name?", nil, false)
+		generate("What is my name?", ollamaparams.Params{Model: "llama3.1"}, false)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("different options are not served from the cache", func(t *testing.T) {
+		generate("What is my name?", ollamaparams.Params{Temperature: &zero}, false)
+		generate("What is my name?", ollamaparams.Params{Temperature: &zero, TopP: &topP}, false)
+		generate("What is my name?", ollamaparams.Params{Temperature: &zero, TopK: &topK}, false)
+		generate("What is my name?", ollamaparams.Params{Temperature: &zero, RepeatPenalty: &penalty}, false)
+		assert.Equal(t, int32(7), requests.Load())
+
+		generate("What is my name?", ollamaparams.Params{Temperature: &zero, TopP: &topP}, false)
+		assert.Equal(t, int32(7), requests.Load())
+	})
+
+	t.Run("different servers are not served from the cache", func(t *testing.T) {
+		generate("What is my name?", ollamaparams.Params{ApiEndpoint: otherServer.URL}, false)
+		ctx := context.WithValue(context.Background(), "X-Ollama-Path", []string{"/other/api/generate"})
+		generateCtx(ctx, "What is my name?", nil, false)
+		assert.Equal(t, int32(9), requests.Load())
+	})
+
+	t.Run("non-deterministic requests are never cached", func(t *testing.T) {
+		generate("What is my name?", ollamaparams.Params{Temperature: &warm}, false)
+		generate("What is my name?", ollamaparams.Params{Temperature: &warm}, false)
+		assert.Equal(t, int32(11), requests.Load())
+	})
+
+	t.Run("entropy filtered requests are never cached", func(t *testing.T) {
+		generate("What is my name?", ollamaparams.Params{MinResponseEntropy: &entropy}, false)
+		generate("What is my name?", ollamaparams.Params{MinResponseEntropy: &entropy}, false)
+		assert.Equal(t, int32(13), requests.Load())
+	})
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	assert.Equal(t, responseCacheKey("url", []byte("body")), responseCacheKey("url", []byte("body")))
+	assert.NotEqual(t, responseCacheKey("ab", []byte("c")), responseCacheKey("a", []byte("bc")))
+	assert.NotEqual(t, responseCacheKey("url", []byte("a")), responseCacheKey("url", []byte("b")))
+}
@@ -0,0 +1,138 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+	enterrors "github.com/weaviate/weaviate/entities/errors"
+	"github.com/weaviate/weaviate/entities/modulecapabilities"
+	"github.com/weaviate/weaviate/entities/moduletools"
+	"github.com/weaviate/weaviate/modules/generative-ollama/config"
+)
+
+// StreamChunk is a part of a streamed generation. Tokens arrive in the order
+// they are generated. The last chunk of a stream either has Done set and
+// carries the debug information and the response params, or it carries the
+// error that ended the stream.
+type StreamChunk struct {
+	Token  string
+	Done   bool
+	Debug  *modulecapabilities.GenerateDebugInformation
+	Params map[string]interface{}
+	Err    error
+}
+
+// GenerateAllResultsStream is like GenerateAllResults, but returns the result
+// token by token as Ollama generates it.
+//
+// Streaming the result to the user requires the GraphQL handler to support
+// streaming responses, e.g. via HTTP chunked encoding. Until it does, the
+// chunks have to be collected before responding.
+func (v *ollama) GenerateAllResultsStream(ctx context.Context, textProperties []map[string]string, task string, options interface{}, debug bool, cfg moduletools.ClassConfig) (<-chan StreamChunk, error) {
+	forTask, err := v.allResultsPrompt(ctx, cfg, options, textProperties, task)
+	if err != nil {
+		return nil, err
+	}
+	return v.GenerateStream(ctx, cfg, forTask, options, debug)
+}
+
+// GenerateStream is like Generate, but returns the result token by token as
+// Ollama generates it. Errors before the first token, e.g. an unreachable
+// server, are returned directly. Streamed responses are neither cached, nor
+// checked for low quality, nor stripped of reasoning, and there is no fallback
+// to other providers. The client timeout applies to the entire stream.
+//
+// The channel is closed after the last chunk. The caller must either drain it
+// or cancel ctx.
+func (v *ollama) GenerateStream(ctx context.Context, cfg moduletools.ClassConfig, prompt string, options interface{}, debug bool) (<-chan StreamChunk, error) {
+	params := v.getParameters(ctx, cfg, options)
+	if err := config.ValidateOptions(params.Temperature, params.TopP, params.TopK); err != nil {
+		return nil, errors.Wrap(err, "invalid request parameters")
+	}
+	debugInformation := v.getDebugInformation(debug, prompt, params.Model,
+		v.getOllamaUrl(ctx, params.ApiEndpoint, params.GeneratePath))
+
+	req, err := v.newGenerateRequest(ctx, params, prompt, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.waitForRateLimit(ctx, cfg.Tenant()); err != nil {
+		return nil, err
+	}
+
+	res, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "send POST request")
+	}
+
+	if res.StatusCode != 200 {
+		defer res.Body.Close()
+		var resBody generateResponse
+		bodyBytes, _ := io.ReadAll(res.Body)
+		// the body is only used for the error message, if any
+		_ = json.Unmarshal(bodyBytes, &resBody)
+		return nil, newAPIError(res.StatusCode, resBody.Error)
+	}
+
+	chunks := make(chan StreamChunk)
+	enterrors.GoWrapper(func() {
+		defer close(chunks)
+		defer res.Body.Close()
+
+		send := func(chunk StreamChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		decoder := json.NewDecoder(res.Body)
+		for {
+			var resBody generateResponse
+			if err := decoder.Decode(&resBody); err != nil {
+				if errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF
+				}
+				send(StreamChunk{Err: errors.Wrap(err, "read response stream")})
+				return
+			}
+
+			if resBody.Error != "" {
+				send(StreamChunk{Err: newAPIError(res.StatusCode, resBody.Error)})
+				return
+			}
+
+			if resBody.Done {
+				send(StreamChunk{
+					Token:  resBody.Response,
+					Done:   true,
+					Debug:  debugInformation,
+					Params: v.getResponseParams(false, resBody.Context, resBody.DoneReason),
+				})
+				return
+			}
+
+			if !send(StreamChunk{Token: resBody.Response}) {
+				return
+			}
+		}
+	}, v.logger)
+
+	return chunks, nil
+}
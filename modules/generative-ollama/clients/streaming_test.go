@@ -0,0 +1,144 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func streamingServer(t *testing.T, status int, lines ...generateResponse) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input generateInput
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		assert.True(t, input.Stream)
+		assert.Contains(t, input.Prompt, "My name is john")
+
+		w.WriteHeader(status)
+		for _, line := range lines {
+			out, err := json.Marshal(line)
+			require.Nil(t, err)
+			w.Write(append(out, '\n'))
+			w.(http.Flusher).Flush()
+		}
+	}))
+}
+
+func collectChunks(chunks <-chan StreamChunk) (tokens []string, last StreamChunk) {
+	for chunk := range chunks {
+		tokens = append(tokens, chunk.Token)
+		last = chunk
+	}
+	return tokens, last
+}
+
+func TestGenerateAllResultsStream(t *testing.T) {
+	textProperties := []map[string]string{{"prop": "My name is john"}}
+
+	t.Run("tokens arrive in order", func(t *testing.T) {
+		server := streamingServer(t, http.StatusOK,
+			generateResponse{Response: "Your"},
+			generateResponse{Response: " name"},
+			generateResponse{Response: " is john"},
+			generateResponse{Done: true, DoneReason: "stop", Context: []int{1, 2}},
+		)
+		defer server.Close()
+
+		c := New(0, nullLogger())
+		chunks, err := c.GenerateAllResultsStream(context.Background(), textProperties,
+			"What is my name?", nil, true, &fakeClassConfig{apiEndpoint: server.URL})
+		require.Nil(t, err)
+
+		tokens, last := collectChunks(chunks)
+		assert.Equal(t, "Your name is john", strings.Join(tokens, ""))
+		require.Nil(t, last.Err)
+		assert.True(t, last.Done)
+		require.NotNil(t, last.Debug)
+		assert.Contains(t, last.Debug.Prompt, "What is my name?")
+		assert.Equal(t, map[string]interface{}{
+			"ollama": map[string]interface{}{"context": []int{1, 2}, "doneReason": "stop"},
+		}, last.Params)
+	})
+
+	t.Run("error status is returned directly", func(t *testing.T) {
+		server := streamingServer(t, http.StatusInternalServerError,
+			generateResponse{Error: "model not loaded"})
+		defer server.Close()
+
+		c := New(0, nullLogger())
+		_, err := c.GenerateAllResultsStream(context.Background(), textProperties,
+			"What is my name?", nil, false, &fakeClassConfig{apiEndpoint: server.URL})
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "model not loaded")
+	})
+
+	t.Run("error within the stream ends it", func(t *testing.T) {
+		server := streamingServer(t, http.StatusOK,
+			generateResponse{Response: "Your"},
+			generateResponse{Error: "out of memory"},
+		)
+		defer server.Close()
+
+		c := New(0, nullLogger())
+		chunks, err := c.GenerateAllResultsStream(context.Background(), textProperties,
+			"What is my name?", nil, false, &fakeClassConfig{apiEndpoint: server.URL})
+		require.Nil(t, err)
+
+		tokens, last := collectChunks(chunks)
+		assert.Equal(t, []string{"Your", ""}, tokens)
+		require.NotNil(t, last.Err)
+		assert.Contains(t, last.Err.Error(), "out of memory")
+		assert.False(t, last.Done)
+	})
+
+	t.Run("stream cut off before done", func(t *testing.T) {
+		server := streamingServer(t, http.StatusOK, generateResponse{Response: "Your"})
+		defer server.Close()
+
+		c := New(0, nullLogger())
+		chunks, err := c.GenerateAllResultsStream(context.Background(), textProperties,
+			"What is my name?", nil, false, &fakeClassConfig{apiEndpoint: server.URL})
+		require.Nil(t, err)
+
+		_, last := collectChunks(chunks)
+		assert.ErrorIs(t, last.Err, io.ErrUnexpectedEOF)
+	})
+
+	t.Run("cancelling the context closes the stream", func(t *testing.T) {
+		server := streamingServer(t, http.StatusOK,
+			generateResponse{Response: "Your"},
+			generateResponse{Response: " name"},
+			generateResponse{Done: true},
+		)
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		c := New(0, nullLogger())
+		chunks, err := c.GenerateAllResultsStream(ctx, textProperties,
+			"What is my name?", nil, false, &fakeClassConfig{apiEndpoint: server.URL})
+		require.Nil(t, err)
+
+		<-chunks
+		cancel()
+		for range chunks {
+			// drain until closed
+		}
+	})
+}
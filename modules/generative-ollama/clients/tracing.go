@@ -0,0 +1,71 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/weaviate/weaviate/modules/generative-ollama"
+
+// WithTracer sets the tracer creating a span for every Generate call. By
+// default the tracer of the global tracer provider is used.
+func (v *ollama) WithTracer(tracer trace.Tracer) *ollama {
+	v.tracer = tracer
+	return v
+}
+
+func (v *ollama) getTracer() trace.Tracer {
+	if v.tracer != nil {
+		return v.tracer
+	}
+	return otel.Tracer(tracerName)
+}
+
+// startGenerateSpan starts the span of a Generate call as a child of the span
+// in ctx, if any
+func (v *ollama) startGenerateSpan(ctx context.Context, model, endpoint, prompt string) (context.Context, trace.Span) {
+	return v.getTracer().Start(ctx, "ollama.generate",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("ollama.model", model),
+			attribute.Int("ollama.prompt_length", len(prompt)),
+			attribute.String("ollama.endpoint", endpoint),
+		))
+}
+
+// endGenerateSpan marks span as failed if err is set and ends it
+func endGenerateSpan(span trace.Span, err error) {
+	if err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			span.SetAttributes(attribute.Int("http.response.status_code", apiErr.statusCode))
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// injectTraceContext adds the trace context of ctx to the headers of req, so
+// traces of the Ollama server can be correlated with the Weaviate query
+func injectTraceContext(ctx context.Context, req *http.Request) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
@@ -0,0 +1,136 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordingTracer keeps all started spans in memory
+type recordingTracer struct {
+	noop.Tracer
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	span := &recordingSpan{
+		name:  name,
+		attrs: map[attribute.Key]attribute.Value{},
+		sc: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{0x01},
+			SpanID:     trace.SpanID{byte(len(t.spans) + 1)},
+			TraceFlags: trace.FlagsSampled,
+		}),
+	}
+	span.SetAttributes(cfg.Attributes()...)
+	t.spans = append(t.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordingSpan struct {
+	noop.Span
+	name       string
+	sc         trace.SpanContext
+	attrs      map[attribute.Key]attribute.Value
+	statusCode codes.Code
+	errors     []error
+	ended      bool
+}
+
+func (s *recordingSpan) SpanContext() trace.SpanContext { return s.sc }
+
+func (s *recordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, attr := range kv {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, description string) { s.statusCode = code }
+
+func (s *recordingSpan) RecordError(err error, options ...trace.EventOption) {
+	s.errors = append(s.errors, err)
+}
+
+func (s *recordingSpan) End(options ...trace.SpanEndOption) { s.ended = true }
+
+func TestGenerateTracing(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(prev)
+
+	var traceparent string
+	newServer := func(status int, answer generateResponse) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceparent = r.Header.Get("traceparent")
+			w.WriteHeader(status)
+			require.Nil(t, json.NewEncoder(w).Encode(answer))
+		}))
+	}
+
+	t.Run("success", func(t *testing.T) {
+		server := newServer(http.StatusOK, generateResponse{Response: "answer"})
+		defer server.Close()
+
+		tracer := &recordingTracer{}
+		c := New(time.Second, nullLogger()).WithTracer(tracer)
+
+		_, err := c.Generate(context.Background(),
+			&fakeClassConfig{apiEndpoint: server.URL, model: "llama3"}, "some prompt", nil, false)
+		require.Nil(t, err)
+
+		require.Len(t, tracer.spans, 1)
+		span := tracer.spans[0]
+		assert.True(t, span.ended)
+		assert.Equal(t, codes.Unset, span.statusCode)
+		assert.Equal(t, "llama3", span.attrs["ollama.model"].AsString())
+		assert.Equal(t, int64(len("some prompt")), span.attrs["ollama.prompt_length"].AsInt64())
+		assert.Equal(t, server.URL+"/api/generate", span.attrs["ollama.endpoint"].AsString())
+
+		// the span of the request is propagated to the server
+		assert.Equal(t, "00-"+span.sc.TraceID().String()+"-"+span.sc.SpanID().String()+"-01", traceparent)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		server := newServer(http.StatusNotFound, generateResponse{Error: "model not found"})
+		defer server.Close()
+
+		tracer := &recordingTracer{}
+		c := New(time.Second, nullLogger()).WithTracer(tracer)
+
+		_, err := c.Generate(context.Background(),
+			&fakeClassConfig{apiEndpoint: server.URL}, "prompt", nil, false)
+		require.NotNil(t, err)
+
+		require.Len(t, tracer.spans, 1)
+		span := tracer.spans[0]
+		assert.True(t, span.ended)
+		assert.Equal(t, codes.Error, span.statusCode)
+		assert.Equal(t, int64(http.StatusNotFound), span.attrs["http.response.status_code"].AsInt64())
+		require.Len(t, span.errors, 1)
+		assert.Contains(t, span.errors[0].Error(), "model not found")
+	})
+}
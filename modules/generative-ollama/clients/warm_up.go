@@ -0,0 +1,134 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/modules/generative-ollama/config"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+const (
+	// warmUpPrompt is sent to load a model, the response is discarded
+	warmUpPrompt = "ping"
+	// warmUpKeepAlive keeps warmed up models loaded indefinitely
+	warmUpKeepAlive = "-1"
+)
+
+// WarmUp loads the given models on the Ollama server at baseURL, so that the
+// first user request doesn't incur the load delay of a cold model. Once the
+// server responds to a ping, a short placeholder prompt is sent for each model
+// which keeps the model loaded indefinitely. Failures, e.g. unknown models,
+// are logged but never returned, warm-up is best effort.
+func (v *ollama) WarmUp(ctx context.Context, baseURL string, models []string) {
+	logger := v.logger.WithFields(logrus.Fields{
+		"action":   "ollama_model_warm_up",
+		"base_url": baseURL,
+	})
+
+	if err := ping(ctx, v.httpClient, baseURL); err != nil {
+		logger.WithError(err).Warn("Ollama server unreachable, skipping model warm-up")
+		return
+	}
+
+	for _, model := range models {
+		start := time.Now()
+		err := v.warmUpModel(ctx, baseURL, model)
+		took := time.Since(start)
+
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		monitoring.GetMetrics().GenerativeModelWarmUpLatency.
+			WithLabelValues(cacheMetricsModule, model, result).Observe(took.Seconds())
+
+		if err != nil {
+			logger.WithField("model", model).WithError(err).
+				Warn("failed to warm up Ollama model")
+			continue
+		}
+		logger.WithField("model", model).WithField("took", took).
+			Info("warmed up Ollama model")
+	}
+}
+
+func (v *ollama) warmUpModel(ctx context.Context, baseURL, model string) error {
+	body, err := json.Marshal(generateInput{
+		Model:     model,
+		Prompt:    warmUpPrompt,
+		Stream:    false,
+		KeepAlive: warmUpKeepAlive,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal body")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		joinURLPath(baseURL, config.DefaultGeneratePath), bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "create POST request")
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	res, err := v.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "send POST request")
+	}
+	defer res.Body.Close()
+
+	bodyBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrap(err, "read response body")
+	}
+
+	var resBody generateResponse
+	if err := json.Unmarshal(bodyBytes, &resBody); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unmarshal response body. Got: %v", string(bodyBytes)))
+	}
+	if resBody.Error != "" {
+		return errors.Errorf("warm-up request failed with error: %s", resBody.Error)
+	}
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("warm-up request failed with status: %d", res.StatusCode)
+	}
+	return nil
+}
+
+// ping checks that the Ollama server at baseURL is reachable
+func ping(ctx context.Context, httpClient *http.Client, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/api/version", baseURL), nil)
+	if err != nil {
+		return errors.Wrap(err, "create health check request")
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "send health check request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("health check failed with status: %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmUp(t *testing.T) {
+	var mu sync.Mutex
+	var requests []generateInput
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/version" {
+			w.Write([]byte(`{"version":"0.5.0"}`))
+			return
+		}
+
+		var input generateInput
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		mu.Lock()
+		requests = append(requests, input)
+		mu.Unlock()
+
+		if input.Model == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+			return
+		}
+		w.Write([]byte(`{"response":"pong","done":true}`))
+	}))
+	defer server.Close()
+
+	t.Run("loads all models and keeps them loaded", func(t *testing.T) {
+		requests = nil
+		logger, hook := test.NewNullLogger()
+		c := New(5*time.Second, logger)
+
+		c.WarmUp(context.Background(), server.URL, []string{"llama3", "missing", "mistral"})
+
+		require.Len(t, requests, 3)
+		for i, model := range []string{"llama3", "missing", "mistral"} {
+			assert.Equal(t, model, requests[i].Model)
+			assert.Equal(t, warmUpPrompt, requests[i].Prompt)
+			assert.Equal(t, "-1", requests[i].KeepAlive)
+		}
+
+		// the unknown model is reported, but doesn't stop the warm-up
+		var warnings []*logrus.Entry
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == logrus.WarnLevel {
+				warnings = append(warnings, entry)
+			}
+		}
+		require.Len(t, warnings, 1)
+		assert.Equal(t, "missing", warnings[0].Data["model"])
+		assert.ErrorContains(t, warnings[0].Data[logrus.ErrorKey].(error), "not found")
+	})
+
+	t.Run("unreachable server", func(t *testing.T) {
+		requests = nil
+		logger, hook := test.NewNullLogger()
+		c := New(5*time.Second, logger)
+
+		c.WarmUp(context.Background(), "http://127.0.0.1:0", []string{"llama3"})
+
+		assert.Empty(t, requests)
+		require.NotNil(t, hook.LastEntry())
+		assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+		assert.Contains(t, hook.LastEntry().Message, "skipping model warm-up")
+	})
+}
@@ -18,8 +18,14 @@ import (
 	"github.com/weaviate/weaviate/entities/modulecapabilities"
 	"github.com/weaviate/weaviate/entities/moduletools"
 	"github.com/weaviate/weaviate/entities/schema"
+	"github.com/weaviate/weaviate/modules/generative-ollama/config"
 )
 
+// ClassConfigDefaults is empty on purpose. Defaults returned here would be
+// persisted in the schema of every new class, so later changes to them would
+// not apply to existing classes. Instead, the class settings resolve unset
+// values to the current defaults whenever they are read, see
+// config.NewClassSettings.
 func (m *GenerativeOllamaModule) ClassConfigDefaults() map[string]interface{} {
 	return map[string]interface{}{}
 }
@@ -33,7 +39,8 @@ func (m *GenerativeOllamaModule) PropertyConfigDefaults(
 func (m *GenerativeOllamaModule) ValidateClass(ctx context.Context,
 	class *models.Class, cfg moduletools.ClassConfig,
 ) error {
-	return nil
+	settings := config.NewClassSettings(cfg)
+	return settings.Validate(class)
 }
 
 var _ = modulecapabilities.ClassConfigurator(New())
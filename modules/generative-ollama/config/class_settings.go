@@ -12,6 +12,10 @@
 package config
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/weaviate/weaviate/entities/models"
 	"github.com/weaviate/weaviate/entities/moduletools"
@@ -19,15 +23,29 @@ import (
 )
 
 const (
-	apiEndpointProperty = "apiEndpoint"
-	modelProperty       = "model"
+	apiEndpointProperty      = "apiEndpoint"
+	modelProperty            = "model"
+	suffixProperty           = "suffix"
+	temperatureProperty      = "temperature"
+	topPProperty             = "topP"
+	topKProperty             = "topK"
+	repeatPenaltyProperty    = "repeatPenalty"
+	stripThinkingProperty    = "stripThinking"
+	thinkingTagProperty      = "thinkingTag"
+	generatePathProperty     = "generatePath"
+	thinkProperty            = "think"
+	autoScaleContextProperty = "autoScaleContext"
 )
 
 const (
-	DefaultApiEndpoint = "http://localhost:11434"
-	DefaultModel       = "llama3"
+	DefaultApiEndpoint  = "http://localhost:11434"
+	DefaultModel        = "llama3"
+	DefaultThinkingTag  = "think"
+	DefaultGeneratePath = "/api/generate"
 )
 
+var thinkingTagPattern = regexp.MustCompile(`^[A-Za-z][\w-]*$`)
+
 type classSettings struct {
 	cfg                  moduletools.ClassConfig
 	propertyValuesHelper basesettings.PropertyValuesHelper
@@ -49,6 +67,61 @@ func (ic *classSettings) Validate(class *models.Class) error {
 	if model == "" {
 		return errors.New("model cannot be empty")
 	}
+
+	var errorMessages []string
+	if temperature := ic.Temperature(); temperature != nil && !validTemperature(*temperature) {
+		errorMessages = append(errorMessages, fmt.Sprintf("%s %s", temperatureProperty, temperatureRange))
+	}
+	if topP := ic.TopP(); topP != nil && !validTopP(*topP) {
+		errorMessages = append(errorMessages, fmt.Sprintf("%s %s", topPProperty, topPRange))
+	}
+	if topK := ic.TopK(); topK != nil && !validTopK(*topK) {
+		errorMessages = append(errorMessages, fmt.Sprintf("%s %s", topKProperty, topKRange))
+	}
+	if !thinkingTagPattern.MatchString(ic.ThinkingTag()) {
+		errorMessages = append(errorMessages, fmt.Sprintf("%s has to be a tag name without angle brackets, e.g. %q", thinkingTagProperty, DefaultThinkingTag))
+	}
+	if len(errorMessages) > 0 {
+		return fmt.Errorf("%s", strings.Join(errorMessages, ", "))
+	}
+	return nil
+}
+
+const (
+	temperatureRange = "has to be a float value between 0 and 2"
+	topPRange        = "has to be a float value greater than 0 and less or equal 1"
+	topKRange        = "has to be an integer value above or equal 1"
+)
+
+func validTemperature(temperature float64) bool {
+	return temperature >= 0 && temperature <= 2
+}
+
+func validTopP(topP float64) bool {
+	return topP > 0 && topP <= 1
+}
+
+func validTopK(topK int) bool {
+	return topK >= 1
+}
+
+// ValidateOptions checks the options of a single request against the same
+// ranges as the class settings. The error lists every invalid option with its
+// value.
+func ValidateOptions(temperature, topP *float64, topK *int) error {
+	var errorMessages []string
+	if temperature != nil && !validTemperature(*temperature) {
+		errorMessages = append(errorMessages, fmt.Sprintf("%s %s, got %v", temperatureProperty, temperatureRange, *temperature))
+	}
+	if topP != nil && !validTopP(*topP) {
+		errorMessages = append(errorMessages, fmt.Sprintf("%s %s, got %v", topPProperty, topPRange, *topP))
+	}
+	if topK != nil && !validTopK(*topK) {
+		errorMessages = append(errorMessages, fmt.Sprintf("%s %s, got %v", topKProperty, topKRange, *topK))
+	}
+	if len(errorMessages) > 0 {
+		return fmt.Errorf("%s", strings.Join(errorMessages, ", "))
+	}
 	return nil
 }
 
@@ -56,6 +129,25 @@ func (ic *classSettings) getStringProperty(name, defaultValue string) string {
 	return ic.propertyValuesHelper.GetPropertyAsString(ic.cfg, name, defaultValue)
 }
 
+func (ic *classSettings) getFloatProperty(name string) *float64 {
+	return ic.propertyValuesHelper.GetPropertyAsFloat64(ic.cfg, name, nil)
+}
+
+func (ic *classSettings) getIntProperty(name string) *int {
+	return ic.propertyValuesHelper.GetPropertyAsInt(ic.cfg, name, nil)
+}
+
+// getOptionalBoolProperty returns nil if the property is not set. A missing
+// property is the only case in which the helper returns the notExistsValue
+// instead of the default, so it is detected by varying the former.
+func (ic *classSettings) getOptionalBoolProperty(name string) *bool {
+	value := ic.propertyValuesHelper.GetPropertyAsBoolWithNotExists(ic.cfg, name, false, false)
+	if value != ic.propertyValuesHelper.GetPropertyAsBoolWithNotExists(ic.cfg, name, false, true) {
+		return nil
+	}
+	return &value
+}
+
 func (ic *classSettings) ApiEndpoint() string {
 	return ic.getStringProperty(apiEndpointProperty, DefaultApiEndpoint)
 }
@@ -63,3 +155,60 @@ func (ic *classSettings) ApiEndpoint() string {
 func (ic *classSettings) Model() string {
 	return ic.getStringProperty(modelProperty, DefaultModel)
 }
+
+func (ic *classSettings) Suffix() string {
+	return ic.getStringProperty(suffixProperty, "")
+}
+
+// Temperature, TopP, TopK and RepeatPenalty are the class level defaults for
+// the request parameters of the same name. They are nil if not configured, in
+// which case Ollama uses the defaults of the model.
+func (ic *classSettings) Temperature() *float64 {
+	return ic.getFloatProperty(temperatureProperty)
+}
+
+func (ic *classSettings) TopP() *float64 {
+	return ic.getFloatProperty(topPProperty)
+}
+
+func (ic *classSettings) TopK() *int {
+	return ic.getIntProperty(topKProperty)
+}
+
+func (ic *classSettings) RepeatPenalty() *float64 {
+	return ic.getFloatProperty(repeatPenaltyProperty)
+}
+
+// StripThinking reports whether reasoning blocks, enclosed in ThinkingTag,
+// are removed from the responses of reasoning models such as deepseek-r1
+func (ic *classSettings) StripThinking() bool {
+	return ic.propertyValuesHelper.GetPropertyAsBool(ic.cfg, stripThinkingProperty, false)
+}
+
+// ThinkingTag is the name of the tag enclosing reasoning blocks, without angle
+// brackets
+func (ic *classSettings) ThinkingTag() string {
+	return ic.getStringProperty(thinkingTagProperty, DefaultThinkingTag)
+}
+
+// Think enables or disables the reasoning output of reasoning models, it is
+// nil if not configured, in which case the model decides. Unlike
+// StripThinking, Ollama does not generate the reasoning at all if disabled.
+// It requires a recent Ollama version.
+func (ic *classSettings) Think() *bool {
+	return ic.getOptionalBoolProperty(thinkProperty)
+}
+
+// GeneratePath is the path of the generate endpoint relative to ApiEndpoint.
+// It only needs to be changed if Ollama runs behind a reverse proxy which
+// rewrites paths, e.g. /ollama/api/generate.
+func (ic *classSettings) GeneratePath() string {
+	return ic.getStringProperty(generatePathProperty, DefaultGeneratePath)
+}
+
+// AutoScaleContext reports whether property values are truncated if the
+// prompt likely exceeds the context window of the model, which Ollama would
+// otherwise truncate silently
+func (ic *classSettings) AutoScaleContext() bool {
+	return ic.propertyValuesHelper.GetPropertyAsBool(ic.cfg, autoScaleContextProperty, false)
+}
@@ -26,6 +26,14 @@ func Test_classSettings_Validate(t *testing.T) {
 		cfg             moduletools.ClassConfig
 		wantApiEndpoint string
 		wantModel       string
+		wantSuffix      string
+		wantTemperature *float64
+		wantTopP        *float64
+		wantTopK        *int
+		wantPenalty     *float64
+		wantStrip       bool
+		wantTag         string
+		wantPath        string
 		wantErr         error
 	}{
 		{
@@ -35,19 +43,88 @@ func Test_classSettings_Validate(t *testing.T) {
 			},
 			wantApiEndpoint: "http://localhost:11434",
 			wantModel:       "llama3",
+			wantTag:         "think",
+			wantPath:        "/api/generate",
 			wantErr:         nil,
 		},
 		{
 			name: "everything non default configured",
 			cfg: fakeClassConfig{
 				classConfig: map[string]interface{}{
-					"model": "mistral",
+					"model":        "mistral",
+					"suffix":       "}",
+					"generatePath": "/ollama/api/generate",
 				},
 			},
 			wantApiEndpoint: "http://localhost:11434",
 			wantModel:       "mistral",
+			wantSuffix:      "}",
+			wantTag:         "think",
+			wantPath:        "/ollama/api/generate",
 			wantErr:         nil,
 		},
+		{
+			name: "sampling settings configured",
+			cfg: fakeClassConfig{
+				classConfig: map[string]interface{}{
+					"temperature":   0.2,
+					"topP":          0.9,
+					"topK":          40,
+					"repeatPenalty": 1.1,
+				},
+			},
+			wantApiEndpoint: "http://localhost:11434",
+			wantModel:       "llama3",
+			wantTemperature: ptFloat64(0.2),
+			wantTopP:        ptFloat64(0.9),
+			wantTopK:        ptInt(40),
+			wantPenalty:     ptFloat64(1.1),
+			wantTag:         "think",
+			wantPath:        "/api/generate",
+		},
+		{
+			name: "thinking stripped with custom tag",
+			cfg: fakeClassConfig{
+				classConfig: map[string]interface{}{
+					"stripThinking": true,
+					"thinkingTag":   "reasoning",
+				},
+			},
+			wantApiEndpoint: "http://localhost:11434",
+			wantModel:       "llama3",
+			wantStrip:       true,
+			wantTag:         "reasoning",
+			wantPath:        "/api/generate",
+		},
+		{
+			name: "thinking tag with angle brackets",
+			cfg: fakeClassConfig{
+				classConfig: map[string]interface{}{
+					"thinkingTag": "<think>",
+				},
+			},
+			wantErr: errors.New(`thinkingTag has to be a tag name without angle brackets, e.g. "think"`),
+		},
+		{
+			name: "temperature out of range",
+			cfg: fakeClassConfig{
+				classConfig: map[string]interface{}{
+					"temperature": 2.5,
+				},
+			},
+			wantErr: errors.New("temperature has to be a float value between 0 and 2"),
+		},
+		{
+			name: "topP and topK out of range",
+			cfg: fakeClassConfig{
+				classConfig: map[string]interface{}{
+					"topP": 0,
+					"topK": 0,
+				},
+			},
+			wantErr: errors.New("topP has to be a float value greater than 0 and less or equal 1, " +
+				"topK has to be an integer value above or equal 1"),
+		},
 		{
 			name: "empty model",
 			cfg: fakeClassConfig{
@@ -68,6 +145,14 @@ func Test_classSettings_Validate(t *testing.T) {
 			} else {
 				assert.NoError(t, ic.Validate(nil))
 				assert.Equal(t, tt.wantModel, ic.Model())
+				assert.Equal(t, tt.wantSuffix, ic.Suffix())
+				assert.Equal(t, tt.wantTemperature, ic.Temperature())
+				assert.Equal(t, tt.wantTopP, ic.TopP())
+				assert.Equal(t, tt.wantTopK, ic.TopK())
+				assert.Equal(t, tt.wantPenalty, ic.RepeatPenalty())
+				assert.Equal(t, tt.wantStrip, ic.StripThinking())
+				assert.Equal(t, tt.wantTag, ic.ThinkingTag())
+				assert.Equal(t, tt.wantPath, ic.GeneratePath())
 			}
 		})
 	}
@@ -96,3 +181,11 @@ func (f fakeClassConfig) Property(propName string) map[string]interface{} {
 func (f fakeClassConfig) TargetVector() string {
 	return ""
 }
+
+func ptFloat64(in float64) *float64 {
+	return &in
+}
+
+func ptInt(in int) *int {
+	return &in
+}
@@ -14,18 +14,25 @@ package modgenerativeollama
 import (
 	"context"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	enterrors "github.com/weaviate/weaviate/entities/errors"
 	"github.com/weaviate/weaviate/entities/modulecapabilities"
 	"github.com/weaviate/weaviate/entities/moduletools"
 	ollama "github.com/weaviate/weaviate/modules/generative-ollama/clients"
+	"github.com/weaviate/weaviate/modules/generative-ollama/config"
 	"github.com/weaviate/weaviate/modules/generative-ollama/parameters"
 )
 
 const Name = "generative-ollama"
 
+const clusterHealthCheckInterval = 10 * time.Second
+
 func New() *GenerativeOllamaModule {
 	return &GenerativeOllamaModule{}
 }
@@ -33,6 +40,7 @@ func New() *GenerativeOllamaModule {
 type GenerativeOllamaModule struct {
 	generative                   generativeClient
 	additionalPropertiesProvider map[string]modulecapabilities.GenerativeProperty
+	responseCache                *ollama.MemoryResponseCache
 }
 
 type generativeClient interface {
@@ -48,6 +56,18 @@ func (m *GenerativeOllamaModule) Type() modulecapabilities.ModuleType {
 	return modulecapabilities.Text2TextGenerative
 }
 
+// Close stops the health checks of the Ollama cluster and the sweeper of the
+// response cache, if they are used
+func (m *GenerativeOllamaModule) Close() error {
+	if cluster, ok := m.generative.(*ollama.OllamaCluster); ok {
+		cluster.Close()
+	}
+	if m.responseCache != nil {
+		m.responseCache.Close()
+	}
+	return nil
+}
+
 func (m *GenerativeOllamaModule) Init(ctx context.Context,
 	params moduletools.ModuleInitParams,
 ) error {
@@ -61,12 +81,195 @@ func (m *GenerativeOllamaModule) Init(ctx context.Context,
 func (m *GenerativeOllamaModule) initAdditional(ctx context.Context, timeout time.Duration,
 	logger logrus.FieldLogger,
 ) error {
-	client := ollama.New(timeout, logger)
-	m.generative = client
+	var cacheTTL time.Duration
+	if ttl := os.Getenv("OLLAMA_RESPONSE_CACHE_TTL"); ttl != "" {
+		// cache responses of deterministic requests for the given duration
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil || parsed <= 0 {
+			return errors.Errorf("invalid OLLAMA_RESPONSE_CACHE_TTL %q, must be a positive duration", ttl)
+		}
+		cacheTTL = parsed
+	}
+	if cacheTTL > 0 {
+		maxEntries := ollama.DefaultResponseCacheMaxEntries
+		if value := os.Getenv("OLLAMA_RESPONSE_CACHE_MAX_ENTRIES"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				return errors.Errorf("invalid OLLAMA_RESPONSE_CACHE_MAX_ENTRIES %q, must be a positive integer", value)
+			}
+			maxEntries = parsed
+		}
+		m.responseCache = ollama.NewMemoryResponseCache(cacheTTL, maxEntries, logger)
+	}
+
+	// models to load at startup, so that the first request for them doesn't
+	// have to wait for the model to be loaded
+	var warmUpModels []string
+	if models := os.Getenv("OLLAMA_WARM_UP_MODELS"); models != "" {
+		for _, model := range strings.Split(models, ",") {
+			if model = strings.TrimSpace(model); model != "" {
+				warmUpModels = append(warmUpModels, model)
+			}
+		}
+	}
+	var warmUp func(ctx context.Context)
+
+	// limit the requests sent to Ollama, both in total and per tenant
+	rateLimit, err := rateLimitFromEnv("OLLAMA_RATE_LIMIT")
+	if err != nil {
+		return err
+	}
+	tenantRateLimit, err := rateLimitFromEnv("OLLAMA_TENANT_RATE_LIMIT")
+	if err != nil {
+		return err
+	}
+
+	// sanitize property values substituted into prompts, opt-in
+	promptSanitizer, err := promptSanitizerFromEnv()
+	if err != nil {
+		return err
+	}
+
+	// Ollama servers to fail over to, in order, when a request fails with a
+	// server error or times out. They should serve the same models.
+	var fallbackProviders []modulecapabilities.GenerativeClient
+	if baseURLs := os.Getenv("OLLAMA_FALLBACK_BASE_URLS"); baseURLs != "" {
+		for _, baseURL := range strings.Split(baseURLs, ",") {
+			if baseURL = strings.TrimSpace(baseURL); baseURL != "" {
+				fallbackProviders = append(fallbackProviders,
+					ollama.NewEndpointClient(baseURL, timeout, logger))
+			}
+		}
+	}
+
+	if baseURLs := os.Getenv("OLLAMA_CLUSTER_BASE_URLS"); baseURLs != "" {
+		// route requests across a cluster of Ollama servers instead of using
+		// the apiEndpoint configured for the class
+		var clusterURLs []string
+		for _, baseURL := range strings.Split(baseURLs, ",") {
+			if baseURL = strings.TrimSpace(baseURL); baseURL != "" {
+				clusterURLs = append(clusterURLs, baseURL)
+			}
+		}
+		client, err := ollama.NewCluster(clusterURLs, timeout,
+			clusterHealthCheckInterval, logger)
+		if err != nil {
+			return errors.Wrap(err, "init Ollama cluster")
+		}
+		if m.responseCache != nil {
+			client.WithResponseCache(m.responseCache, cacheTTL)
+		}
+		if rateLimit != nil {
+			client.WithRateLimit(*rateLimit)
+		}
+		if tenantRateLimit != nil {
+			client.WithTenantRateLimit(*tenantRateLimit)
+		}
+		if len(fallbackProviders) > 0 {
+			client.WithFallbackProviders(fallbackProviders...)
+		}
+		if promptSanitizer != nil {
+			client.WithPromptSanitizer(promptSanitizer)
+		}
+		warmUp = func(ctx context.Context) { client.WarmUp(ctx, warmUpModels) }
+		m.generative = client
+	} else {
+		client := ollama.New(timeout, logger)
+		if m.responseCache != nil {
+			client.WithResponseCache(m.responseCache, cacheTTL)
+		}
+		if rateLimit != nil {
+			client.WithRateLimit(*rateLimit)
+		}
+		if tenantRateLimit != nil {
+			client.WithTenantRateLimit(*tenantRateLimit)
+		}
+		if len(fallbackProviders) > 0 {
+			client.WithFallbackProviders(fallbackProviders...)
+		}
+		if promptSanitizer != nil {
+			client.WithPromptSanitizer(promptSanitizer)
+		}
+		// the apiEndpoint is configured per class, so warm-up targets the
+		// default endpoint unless another one is given
+		warmUpEndpoint := config.DefaultApiEndpoint
+		if endpoint := os.Getenv("OLLAMA_WARM_UP_API_ENDPOINT"); endpoint != "" {
+			warmUpEndpoint = endpoint
+		}
+		warmUp = func(ctx context.Context) { client.WarmUp(ctx, warmUpEndpoint, warmUpModels) }
+		m.generative = client
+	}
+
+	if len(warmUpModels) > 0 {
+		// loading a model can take a while, don't delay the startup
+		enterrors.GoWrapper(func() { warmUp(context.Background()) }, logger)
+	}
 	m.additionalPropertiesProvider = parameters.AdditionalGenerativeParameters(m.generative)
 	return nil
 }
 
+// rateLimitFromEnv reads a rate limit from the <prefix>_TOKENS_PER_SECOND and
+// <prefix>_BURST_SIZE env vars, nil means no limit is configured
+func rateLimitFromEnv(prefix string) (*ollama.RateLimit, error) {
+	tokensPerSecond := os.Getenv(prefix + "_TOKENS_PER_SECOND")
+	if tokensPerSecond == "" {
+		return nil, nil
+	}
+
+	var limit ollama.RateLimit
+	parsed, err := strconv.ParseFloat(tokensPerSecond, 64)
+	if err != nil || parsed <= 0 {
+		return nil, errors.Errorf("invalid %s_TOKENS_PER_SECOND %q, must be a positive number",
+			prefix, tokensPerSecond)
+	}
+	limit.TokensPerSecond = parsed
+
+	if burstSize := os.Getenv(prefix + "_BURST_SIZE"); burstSize != "" {
+		parsed, err := strconv.Atoi(burstSize)
+		if err != nil || parsed <= 0 {
+			return nil, errors.Errorf("invalid %s_BURST_SIZE %q, must be a positive integer",
+				prefix, burstSize)
+		}
+		limit.BurstSize = parsed
+	}
+	return &limit, nil
+}
+
+// promptSanitizerFromEnv returns the DefaultPromptSanitizer if
+// OLLAMA_PROMPT_SANITIZER_ENABLED is set, configured by
+// OLLAMA_PROMPT_MAX_PROPERTY_LENGTH and the comma separated
+// OLLAMA_PROMPT_FORBIDDEN_PHRASES. nil means property values are used as
+// they are.
+func promptSanitizerFromEnv() (ollama.PromptSanitizer, error) {
+	enabled := os.Getenv("OLLAMA_PROMPT_SANITIZER_ENABLED")
+	if enabled == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(enabled)
+	if err != nil {
+		return nil, errors.Errorf("invalid OLLAMA_PROMPT_SANITIZER_ENABLED %q, must be a boolean", enabled)
+	}
+	if !parsed {
+		return nil, nil
+	}
+
+	maxPropertyLength := ollama.DefaultMaxPropertyLength
+	if length := os.Getenv("OLLAMA_PROMPT_MAX_PROPERTY_LENGTH"); length != "" {
+		parsed, err := strconv.Atoi(length)
+		if err != nil || parsed <= 0 {
+			return nil, errors.Errorf("invalid OLLAMA_PROMPT_MAX_PROPERTY_LENGTH %q, must be a positive integer", length)
+		}
+		maxPropertyLength = parsed
+	}
+
+	var forbiddenPhrases []string
+	if phrases := os.Getenv("OLLAMA_PROMPT_FORBIDDEN_PHRASES"); phrases != "" {
+		forbiddenPhrases = strings.Split(phrases, ",")
+	}
+
+	return ollama.NewDefaultPromptSanitizer(maxPropertyLength, forbiddenPhrases...), nil
+}
+
 func (m *GenerativeOllamaModule) RootHandler() http.Handler {
 	// TODO: remove once this is a capability interface
 	return nil
@@ -85,4 +288,5 @@ var (
 	_ = modulecapabilities.Module(New())
 	_ = modulecapabilities.MetaProvider(New())
 	_ = modulecapabilities.AdditionalGenerativeProperties(New())
+	_ = modulecapabilities.ModuleWithClose(New())
 )
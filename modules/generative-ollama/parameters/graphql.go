@@ -13,10 +13,71 @@ package parameters
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/tailor-inc/graphql"
+	"github.com/tailor-inc/graphql/language/ast"
 )
 
+// optionsScalar accepts an object of arbitrary Ollama options, e.g.
+// {num_ctx: 4096, stop: ["\n"]}
+var optionsScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "OllamaOptions",
+	Description: "A custom scalar type for an object of Ollama options",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		if options, ok := optionsFromAST(valueAST).(map[string]interface{}); ok {
+			return options
+		}
+		return nil
+	},
+})
+
+// optionsFromAST converts a literal to its Go value. It returns nil for
+// values that can't be passed to Ollama, e.g. enums or variables.
+func optionsFromAST(valueAST ast.Value) interface{} {
+	switch v := valueAST.(type) {
+	case *ast.ObjectValue:
+		out := make(map[string]interface{}, len(v.Fields))
+		for _, field := range v.Fields {
+			value := optionsFromAST(field.Value)
+			if value == nil {
+				return nil
+			}
+			out[field.Name.Value] = value
+		}
+		return out
+	case *ast.ListValue:
+		out := make([]interface{}, len(v.Values))
+		for i, item := range v.Values {
+			value := optionsFromAST(item)
+			if value == nil {
+				return nil
+			}
+			out[i] = value
+		}
+		return out
+	case *ast.IntValue:
+		if i, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+			return i
+		}
+	case *ast.FloatValue:
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			return f
+		}
+	case *ast.StringValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	}
+	return nil
+}
+
 func input(prefix string) *graphql.InputObjectFieldConfig {
 	return &graphql.InputObjectFieldConfig{
 		Description: fmt.Sprintf("%s settings", Name),
@@ -35,8 +96,51 @@ func input(prefix string) *graphql.InputObjectFieldConfig {
 					Description: "temperature",
 					Type:        graphql.Float,
 				},
+				"topP": &graphql.InputObjectFieldConfig{
+					Description: "topP",
+					Type:        graphql.Float,
+				},
+				"topK": &graphql.InputObjectFieldConfig{
+					Description: "topK",
+					Type:        graphql.Int,
+				},
+				"repeatPenalty": &graphql.InputObjectFieldConfig{
+					Description: "repeatPenalty",
+					Type:        graphql.Float,
+				},
+				"minResponseEntropy": &graphql.InputObjectFieldConfig{
+					Description: "minResponseEntropy",
+					Type:        graphql.Float,
+				},
+				"context": &graphql.InputObjectFieldConfig{
+					Description: "context",
+					Type:        graphql.NewList(graphql.Int),
+				},
+				"suffix": &graphql.InputObjectFieldConfig{
+					Description: "suffix",
+					Type:        graphql.String,
+				},
+				"think": &graphql.InputObjectFieldConfig{
+					Description: "think",
+					Type:        graphql.Boolean,
+				},
+				"options": &graphql.InputObjectFieldConfig{
+					Description: "options passed to Ollama as is",
+					Type:        optionsScalar,
+				},
 			},
 		}),
 		DefaultValue: nil,
 	}
 }
+
+func output(prefix string) *graphql.Field {
+	return &graphql.Field{Type: graphql.NewObject(graphql.ObjectConfig{
+		Name: fmt.Sprintf("%s%sFields", prefix, Name),
+		Fields: graphql.Fields{
+			"generativeLowQuality": &graphql.Field{Type: graphql.Boolean},
+			"context":              &graphql.Field{Type: graphql.NewList(graphql.Int)},
+			"doneReason":           &graphql.Field{Type: graphql.String},
+		},
+	})}
+}
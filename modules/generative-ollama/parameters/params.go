@@ -17,9 +17,32 @@ import (
 )
 
 type Params struct {
-	ApiEndpoint string
-	Model       string
-	Temperature *float64
+	ApiEndpoint   string
+	Model         string
+	Temperature   *float64
+	TopP          *float64
+	TopK          *int
+	RepeatPenalty *float64
+	// MinResponseEntropy is the minimal character-level Shannon entropy
+	// (in bits per character) a response needs to have to be returned.
+	// Responses below the threshold are treated as low quality.
+	MinResponseEntropy *float64
+	// Context is the context returned by a previous Ollama response. Passing
+	// it back continues that conversation without resending its history.
+	Context []int
+	// Suffix is the text after the completion. Code models use it for
+	// fill-in-the-middle generation. Only the generate endpoint supports it.
+	Suffix string
+	// RawOptions are passed to Ollama as part of the options object, so that
+	// options without a typed field can be set, e.g. num_ctx or seed. Typed
+	// fields take precedence over raw options of the same name.
+	RawOptions map[string]interface{}
+	// Think enables or disables the reasoning output of reasoning models. If
+	// nil, it is left to the model.
+	Think *bool
+	// GeneratePath is the path of the generate endpoint. It is taken from the
+	// class settings and can't be set per request.
+	GeneratePath string
 }
 
 func extract(field *ast.ObjectField) interface{} {
@@ -34,6 +57,24 @@ func extract(field *ast.ObjectField) interface{} {
 				out.Model = gqlparser.GetValueAsStringOrEmpty(f)
 			case "temperature":
 				out.Temperature = gqlparser.GetValueAsFloat64(f)
+			case "topP":
+				out.TopP = gqlparser.GetValueAsFloat64(f)
+			case "topK":
+				out.TopK = gqlparser.GetValueAsInt(f)
+			case "repeatPenalty":
+				out.RepeatPenalty = gqlparser.GetValueAsFloat64(f)
+			case "minResponseEntropy":
+				out.MinResponseEntropy = gqlparser.GetValueAsFloat64(f)
+			case "context":
+				out.Context = gqlparser.GetValueAsIntArray(f)
+			case "suffix":
+				out.Suffix = gqlparser.GetValueAsStringOrEmpty(f)
+			case "think":
+				out.Think = gqlparser.GetValueAsBool(f)
+			case "options":
+				if options, ok := optionsFromAST(f.Value).(map[string]interface{}); ok {
+					out.RawOptions = options
+				}
 			default:
 				// do nothing
 			}
@@ -0,0 +1,43 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tailor-inc/graphql/language/ast"
+	"github.com/tailor-inc/graphql/language/parser"
+)
+
+func TestExtractOptions(t *testing.T) {
+	parse := func(t *testing.T, options string) Params {
+		doc, err := parser.Parse(parser.ParseParams{Source: "{ q(ollama: {options: " + options + "}) }"})
+		require.Nil(t, err)
+		field := doc.Definitions[0].(*ast.OperationDefinition).SelectionSet.Selections[0].(*ast.Field)
+		return extract(&ast.ObjectField{Value: field.Arguments[0].Value}).(Params)
+	}
+
+	params := parse(t, `{num_ctx: 4096, mirostat_tau: 5.5, stop: ["a", "b"], numa: true}`)
+	assert.Equal(t, map[string]interface{}{
+		"num_ctx":      int64(4096),
+		"mirostat_tau": 5.5,
+		"stop":         []interface{}{"a", "b"},
+		"numa":         true,
+	}, params.RawOptions)
+
+	// enums can't be passed to Ollama
+	params = parse(t, `{num_ctx: FOO}`)
+	assert.Nil(t, params.RawOptions)
+	assert.Nil(t, optionsScalar.ParseLiteral(&ast.EnumValue{Value: "FOO"}))
+}
@@ -17,6 +17,6 @@ const Name = "ollama"
 
 func AdditionalGenerativeParameters(client modulecapabilities.GenerativeClient) map[string]modulecapabilities.GenerativeProperty {
 	return map[string]modulecapabilities.GenerativeProperty{
-		Name: {Client: client, RequestParamsFunction: input, ResponseParamsFunction: nil, ExtractRequestParamsFunction: extract},
+		Name: {Client: client, RequestParamsFunction: input, ResponseParamsFunction: output, ExtractRequestParamsFunction: extract},
 	}
 }
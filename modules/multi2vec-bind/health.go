@@ -0,0 +1,76 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package modbind
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+const (
+	// healthProbeTimeout is the maximum time the vectorizer may take to answer
+	// a health probe before it is considered unhealthy
+	healthProbeTimeout = 2 * time.Second
+
+	// healthCacheTTL is how long the result of a health probe is reused, so
+	// that frequent health checks don't add inference load
+	healthCacheTTL = 30 * time.Second
+
+	// healthProbeThermal is a base64 encoded, grayscale 1x1 pixel PNG. It is
+	// the cheapest possible thermal input to send to the vectorizer.
+	healthProbeThermal = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAAAAAA6fptVAAAACklEQVR4nGNgAAAAAgABSK+kcQAAAABJRU5ErkJggg=="
+)
+
+// healthCache holds the result of the last health probe
+type healthCache struct {
+	sync.Mutex
+	probedAt time.Time
+	err      error
+}
+
+// Health sends a tiny thermal image to the vectorizer and returns an error if
+// the vectorizer fails to vectorize it within healthProbeTimeout. The result
+// of the probe is exposed through the vectorizer_last_error metric and reused
+// for healthCacheTTL. Concurrent calls wait for the same probe.
+func (m *BindModule) Health(ctx context.Context) error {
+	m.health.Lock()
+	defer m.health.Unlock()
+
+	if !m.health.probedAt.IsZero() && time.Since(m.health.probedAt) < healthCacheTTL {
+		return m.health.err
+	}
+	m.health.err = m.probeHealth(ctx)
+	m.health.probedAt = time.Now()
+	return m.health.err
+}
+
+func (m *BindModule) probeHealth(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	_, err := m.bindVectorizer.VectorizeThermal(ctx, healthProbeThermal, nil)
+	if err != nil {
+		err = errors.Wrap(err, "vectorize thermal health probe")
+	}
+
+	lastError := 0.0
+	if err != nil {
+		lastError = 1
+	}
+	monitoring.GetMetrics().VectorizerLastError.WithLabelValues(Name).Set(lastError)
+
+	return err
+}
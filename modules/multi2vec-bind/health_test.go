@@ -0,0 +1,97 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package modbind
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/moduletools"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+func TestHealth(t *testing.T) {
+	lastError := func() float64 {
+		return testutil.ToFloat64(monitoring.GetMetrics().VectorizerLastError.WithLabelValues(Name))
+	}
+
+	t.Run("healthy vectorizer", func(t *testing.T) {
+		vectorizer := &fakeThermalVectorizer{}
+		m := &BindModule{bindVectorizer: vectorizer}
+
+		require.Nil(t, m.Health(context.Background()))
+		assert.Equal(t, []string{healthProbeThermal}, vectorizer.thermal)
+		assert.Equal(t, 0.0, lastError())
+	})
+
+	t.Run("result is cached", func(t *testing.T) {
+		vectorizer := &fakeThermalVectorizer{err: errors.New("connection refused")}
+		m := &BindModule{bindVectorizer: vectorizer}
+
+		require.NotNil(t, m.Health(context.Background()))
+		require.NotNil(t, m.Health(context.Background()))
+		assert.Len(t, vectorizer.thermal, 1)
+
+		// the probe is repeated once the result expired
+		m.health.probedAt = time.Now().Add(-healthCacheTTL)
+		vectorizer.err = nil
+		require.Nil(t, m.Health(context.Background()))
+		assert.Len(t, vectorizer.thermal, 2)
+	})
+
+	t.Run("failing vectorizer", func(t *testing.T) {
+		m := &BindModule{bindVectorizer: &fakeThermalVectorizer{err: errors.New("connection refused")}}
+
+		err := m.Health(context.Background())
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "connection refused")
+		assert.Equal(t, 1.0, lastError())
+	})
+
+	t.Run("slow vectorizer", func(t *testing.T) {
+		m := &BindModule{bindVectorizer: &fakeThermalVectorizer{delay: time.Minute}}
+
+		before := time.Now()
+		err := m.Health(context.Background())
+		require.NotNil(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(before), healthProbeTimeout+time.Second)
+		assert.Equal(t, 1.0, lastError())
+	})
+}
+
+type fakeThermalVectorizer struct {
+	bindVectorizer
+	thermal []string
+	delay   time.Duration
+	err     error
+}
+
+func (f *fakeThermalVectorizer) VectorizeThermal(ctx context.Context, thermal string,
+	cfg moduletools.ClassConfig,
+) ([]float32, error) {
+	f.thermal = append(f.thermal, thermal)
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []float32{1, 2, 3}, nil
+}
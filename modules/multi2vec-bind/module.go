@@ -15,6 +15,7 @@ import (
 	"context"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/weaviate/weaviate/usecases/modulecomponents/batch"
@@ -54,6 +55,7 @@ type BindModule struct {
 	nearTextTransformer        modulecapabilities.TextTransform
 	metaClient                 metaClient
 	logger                     logrus.FieldLogger
+	health                     healthCache
 }
 
 type metaClient interface {
@@ -151,7 +153,14 @@ func (m *BindModule) initVectorizer(ctx context.Context, timeout time.Duration,
 		return errors.Wrap(err, "init remote vectorizer")
 	}
 
-	m.bindVectorizer = vectorizer.New(client)
+	// thermalURL makes the server fetch user supplied URLs, so it is off
+	// unless the hosts to fetch from are allowed explicitly
+	var thermalURLHosts []string
+	if hosts := os.Getenv("BIND_THERMAL_URL_ALLOWED_HOSTS"); hosts != "" {
+		thermalURLHosts = strings.Split(hosts, ",")
+	}
+
+	m.bindVectorizer = vectorizer.New(client).WithThermalURLHosts(thermalURLHosts)
 	m.textVectorizer = vectorizer.New(client)
 	m.metaClient = client
 
@@ -194,4 +203,5 @@ var (
 	_ = modulecapabilities.Module(New())
 	_ = modulecapabilities.Vectorizer[[]float32](New())
 	_ = modulecapabilities.InputVectorizer[[]float32](New())
+	_ = modulecapabilities.HealthChecker(New())
 )
@@ -12,6 +12,12 @@
 package modbind
 
 import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
 	"github.com/weaviate/weaviate/entities/modulecapabilities"
 	"github.com/weaviate/weaviate/usecases/modulecomponents/arguments/nearAudio"
 	"github.com/weaviate/weaviate/usecases/modulecomponents/arguments/nearDepth"
@@ -53,11 +59,48 @@ func (m *BindModule) initNearIMU() error {
 }
 
 func (m *BindModule) initNearThermal() error {
-	m.nearThermalSearcher = nearThermal.NewSearcher(m.bindVectorizer)
+	searcher := nearThermal.NewSearcher(m.bindVectorizer).
+		WithVectorSpaceValidator(nearThermal.NewVectorSpaceValidator([]string{Name}))
+	preprocess, err := thermalPreprocessor()
+	if err != nil {
+		return err
+	}
+	if preprocess != nil {
+		searcher = searcher.WithPreprocessor(preprocess)
+	}
+	m.nearThermalSearcher = searcher
 	m.nearThermalGraphqlProvider = nearThermal.New()
 	return nil
 }
 
+// thermalPreprocessor returns the preprocessing of nearThermal queries
+// configured with BIND_THERMAL_TEMPERATURE_RANGE, e.g. "273.15,373.15" to
+// normalize radiometric images to that range in Kelvin. It returns nil if
+// nothing is configured.
+func thermalPreprocessor() (nearThermal.PreprocessFn, error) {
+	value := strings.TrimSpace(os.Getenv("BIND_THERMAL_TEMPERATURE_RANGE"))
+	if value == "" {
+		return nil, nil
+	}
+
+	bounds := strings.Split(value, ",")
+	if len(bounds) != 2 {
+		return nil, errors.Errorf("BIND_THERMAL_TEMPERATURE_RANGE must be \"minK,maxK\", got %q", value)
+	}
+	minK, err := strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse BIND_THERMAL_TEMPERATURE_RANGE")
+	}
+	maxK, err := strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse BIND_THERMAL_TEMPERATURE_RANGE")
+	}
+	if minK < 0 || maxK <= minK {
+		return nil, errors.Errorf("invalid BIND_THERMAL_TEMPERATURE_RANGE [%v, %v]", minK, maxK)
+	}
+	return nearThermal.NormalizeTemperatureRange(minK, maxK), nil
+}
+
 func (m *BindModule) initNearDepth() error {
 	m.nearDepthSearcher = nearDepth.NewSearcher(m.bindVectorizer)
 	m.nearDepthGraphqlProvider = nearDepth.New()
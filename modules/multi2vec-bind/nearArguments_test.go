@@ -0,0 +1,53 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package modbind
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThermalPreprocessor(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		t.Setenv("BIND_THERMAL_TEMPERATURE_RANGE", "")
+		preprocess, err := thermalPreprocessor()
+		require.NoError(t, err)
+		assert.Nil(t, preprocess)
+	})
+
+	t.Run("temperature range", func(t *testing.T) {
+		t.Setenv("BIND_THERMAL_TEMPERATURE_RANGE", "273.15, 373.15")
+		preprocess, err := thermalPreprocessor()
+		require.NoError(t, err)
+		require.NotNil(t, preprocess)
+
+		processed, err := preprocess(healthProbeThermal)
+		require.NoError(t, err)
+		assert.NotEmpty(t, processed)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, value := range []string{"273.15", "a,b", "373.15,273.15", "-1,10"} {
+			t.Setenv("BIND_THERMAL_TEMPERATURE_RANGE", value)
+			_, err := thermalPreprocessor()
+			assert.Error(t, err, value)
+		}
+	})
+
+	t.Run("module init fails on invalid configuration", func(t *testing.T) {
+		t.Setenv("BIND_THERMAL_TEMPERATURE_RANGE", "invalid")
+		m := &BindModule{}
+		assert.Error(t, m.initNearThermal())
+	})
+}
@@ -77,6 +77,17 @@ func (ic *classSettings) ThermalFieldsWeights() ([]float32, error) {
 	return ic.getFieldsWeights("thermal")
 }
 
+// ThermalExtractor returns the name of the extractor for thermal images
+func (ic *classSettings) ThermalExtractor() string {
+	if ic.cfg == nil {
+		return DefaultThermalExtractor
+	}
+	if name, ok := ic.cfg.Class()["thermalExtractor"].(string); ok && name != "" {
+		return name
+	}
+	return DefaultThermalExtractor
+}
+
 func (ic *classSettings) DepthField(property string) bool {
 	return ic.field("depthFields", property)
 }
@@ -202,6 +213,18 @@ func (ic *classSettings) Validate() error {
 		}
 	}
 
+	if extractor, ok := ic.cfg.Class()["thermalExtractor"]; ok {
+		name, ok := extractor.(string)
+		if !ok {
+			return errors.New("thermalExtractor must be a string")
+		}
+		if name != "" {
+			if _, err := thermalExtractorFactory(name); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -73,6 +73,30 @@ func Test_classSettings_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "should pass with registered thermalExtractor",
+			fields: fields{
+				cfg: newConfigBuilder().addSetting("thermalFields", []interface{}{"field"}).
+					addSetting("thermalExtractor", "rest").build(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "should not pass with unknown thermalExtractor",
+			fields: fields{
+				cfg: newConfigBuilder().addSetting("thermalFields", []interface{}{"field"}).
+					addSetting("thermalExtractor", "unknown").build(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "should not pass with non-string thermalExtractor",
+			fields: fields{
+				cfg: newConfigBuilder().addSetting("thermalFields", []interface{}{"field"}).
+					addSetting("thermalExtractor", 1.0).build(),
+			},
+			wantErr: true,
+		},
 		{
 			name: "should pass with proper value in imageFields",
 			fields: fields{
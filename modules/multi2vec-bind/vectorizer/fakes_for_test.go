@@ -102,3 +102,20 @@ func (c *fakeClient) Vectorize(ctx context.Context,
 	}
 	return result, nil
 }
+
+// MockExtractor returns Vector, or Err if set, for every image. It records
+// the images it was called with in Extracted and is not safe for concurrent
+// use.
+type MockExtractor struct {
+	Vector    []float32
+	Err       error
+	Extracted [][]byte
+}
+
+func (e *MockExtractor) Extract(rawImageBytes []byte) ([]float32, error) {
+	e.Extracted = append(e.Extracted, rawImageBytes)
+	if e.Err != nil {
+		return nil, e.Err
+	}
+	return e.Vector, nil
+}
@@ -0,0 +1,164 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package vectorizer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ThermalFeatureExtractor turns the raw bytes of a thermal image into a
+// vector. Thermal cameras of different vendors produce different raw formats,
+// so the extractor is selected per class with the thermalExtractor setting.
+type ThermalFeatureExtractor interface {
+	Extract(rawImageBytes []byte) ([]float32, error)
+}
+
+// thermalContextExtractor is implemented by extractors which can be
+// cancelled, e.g. because they call a remote service. It is preferred over
+// Extract if implemented.
+type thermalContextExtractor interface {
+	ExtractContext(ctx context.Context, rawImageBytes []byte) ([]float32, error)
+}
+
+// thermalEncodedExtractor is implemented by extractors which take the thermal
+// image as it was passed by the user, i.e. base64 encoded. The image isn't
+// decoded for them, so that every encoding the inference container accepts
+// keeps working.
+type thermalEncodedExtractor interface {
+	ExtractEncoded(ctx context.Context, thermal string) ([]float32, error)
+}
+
+// ThermalExtractorFactory creates an extractor. client is the client of the
+// inference container the module is configured with.
+type ThermalExtractorFactory func(client Client) ThermalFeatureExtractor
+
+// DefaultThermalExtractor is used if the class doesn't set thermalExtractor.
+// It sends thermal images to the inference container, like all other media.
+const DefaultThermalExtractor = "rest"
+
+var thermalExtractors = struct {
+	sync.RWMutex
+	factories map[string]ThermalExtractorFactory
+}{factories: map[string]ThermalExtractorFactory{}}
+
+func init() {
+	RegisterThermalExtractor(DefaultThermalExtractor, func(client Client) ThermalFeatureExtractor {
+		return NewRestThermalExtractor(client)
+	})
+}
+
+// RegisterThermalExtractor makes an extractor available under name. It is
+// meant to be called from init functions, registering the same name twice
+// panics.
+func RegisterThermalExtractor(name string, factory ThermalExtractorFactory) {
+	thermalExtractors.Lock()
+	defer thermalExtractors.Unlock()
+
+	if factory == nil {
+		panic("multi2vec-bind: thermal extractor factory is nil")
+	}
+	if _, ok := thermalExtractors.factories[name]; ok {
+		panic(fmt.Sprintf("multi2vec-bind: thermal extractor %q registered twice", name))
+	}
+	thermalExtractors.factories[name] = factory
+}
+
+func thermalExtractorFactory(name string) (ThermalExtractorFactory, error) {
+	thermalExtractors.RLock()
+	defer thermalExtractors.RUnlock()
+
+	factory, ok := thermalExtractors.factories[name]
+	if !ok {
+		names := make([]string, 0, len(thermalExtractors.factories))
+		for name := range thermalExtractors.factories {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, errors.Errorf("unknown thermal extractor %q, available: %v", name, names)
+	}
+	return factory, nil
+}
+
+// extractThermal decodes the base64 encoded thermal image and passes the raw
+// bytes to extractor. Extractors which take the encoded image get it
+// unchanged.
+func extractThermal(ctx context.Context, extractor ThermalFeatureExtractor, thermal string) ([]float32, error) {
+	if ee, ok := extractor.(thermalEncodedExtractor); ok {
+		vector, err := ee.ExtractEncoded(ctx, thermal)
+		if err != nil {
+			return nil, errors.Wrap(err, "extract thermal features")
+		}
+		return vector, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(thermal)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode thermal image")
+	}
+	return extractThermalRaw(ctx, extractor, raw)
+}
+
+// extractThermalRaw passes the raw bytes of a thermal image to extractor
+func extractThermalRaw(ctx context.Context, extractor ThermalFeatureExtractor, raw []byte) ([]float32, error) {
+	var (
+		vector []float32
+		err    error
+	)
+	if ce, ok := extractor.(thermalContextExtractor); ok {
+		vector, err = ce.ExtractContext(ctx, raw)
+	} else {
+		vector, err = extractor.Extract(raw)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "extract thermal features")
+	}
+	return vector, nil
+}
+
+// RestThermalExtractor sends the thermal image base64 encoded to the
+// inference container.
+type RestThermalExtractor struct {
+	client Client
+}
+
+func NewRestThermalExtractor(client Client) *RestThermalExtractor {
+	return &RestThermalExtractor{client: client}
+}
+
+// Extract is bound by the timeout of the module's HTTP client only, prefer
+// ExtractContext.
+func (e *RestThermalExtractor) Extract(rawImageBytes []byte) ([]float32, error) {
+	return e.ExtractContext(context.Background(), rawImageBytes)
+}
+
+func (e *RestThermalExtractor) ExtractContext(ctx context.Context, rawImageBytes []byte) ([]float32, error) {
+	return e.ExtractEncoded(ctx, base64.StdEncoding.EncodeToString(rawImageBytes))
+}
+
+// ExtractEncoded passes thermal to the inference container as is, it is
+// decoded there.
+func (e *RestThermalExtractor) ExtractEncoded(ctx context.Context, thermal string) ([]float32, error) {
+	res, err := e.client.Vectorize(ctx, nil, nil, nil, nil, nil, []string{thermal}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.ThermalVectors) != 1 {
+		return nil, errors.New("empty vector")
+	}
+	return res.ThermalVectors[0], nil
+}
@@ -0,0 +1,174 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package vectorizer
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/models"
+	"github.com/weaviate/weaviate/modules/multi2vec-bind/ent"
+)
+
+// testMockExtractor is shared by all tests, as extractors can't be
+// unregistered
+var testMockExtractor = &MockExtractor{Vector: []float32{7, 8, 9}}
+
+func init() {
+	RegisterThermalExtractor("test-mock", func(Client) ThermalFeatureExtractor {
+		return testMockExtractor
+	})
+}
+
+type fakeThermalClient struct {
+	thermal []string
+}
+
+func (c *fakeThermalClient) Vectorize(ctx context.Context,
+	texts, images, audio, video, imu, thermal, depth []string,
+) (*ent.VectorizationResult, error) {
+	c.thermal = append(c.thermal, thermal...)
+	res := &ent.VectorizationResult{}
+	for range texts {
+		res.TextVectors = append(res.TextVectors, []float32{1, 2, 3})
+	}
+	for range thermal {
+		res.ThermalVectors = append(res.ThermalVectors, []float32{4, 5, 6})
+	}
+	return res, nil
+}
+
+func TestThermalExtractorRegistry(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		factory, err := thermalExtractorFactory(DefaultThermalExtractor)
+		require.NoError(t, err)
+		assert.IsType(t, &RestThermalExtractor{}, factory(&fakeThermalClient{}))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := thermalExtractorFactory("unknown")
+		assert.ErrorContains(t, err, `unknown thermal extractor "unknown"`)
+	})
+
+	t.Run("registered twice", func(t *testing.T) {
+		assert.Panics(t, func() {
+			RegisterThermalExtractor(DefaultThermalExtractor, func(Client) ThermalFeatureExtractor {
+				return &MockExtractor{}
+			})
+		})
+	})
+}
+
+func TestRestThermalExtractor(t *testing.T) {
+	client := &fakeThermalClient{}
+	vector, err := NewRestThermalExtractor(client).Extract([]byte("raw"))
+	require.NoError(t, err)
+	assert.Equal(t, []float32{4, 5, 6}, vector)
+	assert.Equal(t, []string{base64.StdEncoding.EncodeToString([]byte("raw"))}, client.thermal)
+}
+
+func TestVectorizeThermalWithExtractor(t *testing.T) {
+	thermal := base64.StdEncoding.EncodeToString([]byte("raw"))
+
+	t.Run("default extractor", func(t *testing.T) {
+		client := &fakeThermalClient{}
+		v := New(client)
+		config := newConfigBuilder().build()
+
+		vector, err := v.VectorizeThermal(context.Background(), thermal, config)
+		require.NoError(t, err)
+		assert.Equal(t, []float32{4, 5, 6}, vector)
+		assert.Equal(t, []string{thermal}, client.thermal)
+	})
+
+	t.Run("extractor selected by class config", func(t *testing.T) {
+		client := &fakeThermalClient{}
+		v := New(client)
+		config := newConfigBuilder().addSetting("thermalExtractor", "test-mock").build()
+
+		vector, err := v.VectorizeThermal(context.Background(), thermal, config)
+		require.NoError(t, err)
+		assert.Equal(t, []float32{7, 8, 9}, vector)
+		assert.Empty(t, client.thermal)
+		assert.Equal(t, []byte("raw"), testMockExtractor.Extracted[len(testMockExtractor.Extracted)-1])
+	})
+
+	t.Run("default extractor passes the input unchanged", func(t *testing.T) {
+		for _, thermal := range []string{
+			base64.RawStdEncoding.EncodeToString([]byte("raw?>")),
+			base64.URLEncoding.EncodeToString([]byte("raw?>")),
+			"data:image/png;base64," + thermal,
+		} {
+			client := &fakeThermalClient{}
+			v := New(client)
+			config := newConfigBuilder().build()
+
+			vector, err := v.VectorizeThermal(context.Background(), thermal, config)
+			require.NoError(t, err)
+			assert.Equal(t, []float32{4, 5, 6}, vector)
+			assert.Equal(t, []string{thermal}, client.thermal)
+		}
+	})
+
+	t.Run("invalid base64", func(t *testing.T) {
+		v := New(&fakeThermalClient{})
+		config := newConfigBuilder().addSetting("thermalExtractor", "test-mock").build()
+
+		_, err := v.VectorizeThermal(context.Background(), "not base64!", config)
+		assert.ErrorContains(t, err, "decode thermal image")
+	})
+
+	t.Run("object with text and thermal fields", func(t *testing.T) {
+		client := &fakeThermalClient{}
+		v := New(client)
+		config := newConfigBuilder().
+			addSetting("textFields", []interface{}{"text"}).
+			addSetting("thermalFields", []interface{}{"thermal"}).
+			addSetting("thermalExtractor", "test-mock").
+			build()
+		object := &models.Object{Properties: map[string]interface{}{
+			"text":    "a text",
+			"thermal": thermal,
+		}}
+
+		vector, _, err := v.Object(context.Background(), object, config)
+		require.NoError(t, err)
+		// mean of the text vector {1, 2, 3} and the extracted {7, 8, 9}
+		assert.Equal(t, []float32{4, 5, 6}, vector)
+		assert.Empty(t, client.thermal)
+	})
+
+	t.Run("object with thermal fields only", func(t *testing.T) {
+		client := &fakeThermalClient{}
+		v := New(client)
+		config := newConfigBuilder().
+			addSetting("thermalFields", []interface{}{"thermal"}).
+			addSetting("thermalExtractor", "test-mock").
+			build()
+		object := &models.Object{Properties: map[string]interface{}{"thermal": thermal}}
+
+		vector, _, err := v.Object(context.Background(), object, config)
+		require.NoError(t, err)
+		assert.Equal(t, []float32{7, 8, 9}, vector)
+	})
+}
+
+func TestMockExtractor(t *testing.T) {
+	e := &MockExtractor{Err: errors.New("failed")}
+	_, err := e.Extract([]byte("raw"))
+	assert.ErrorContains(t, err, "failed")
+	assert.Equal(t, [][]byte{[]byte("raw")}, e.Extracted)
+}
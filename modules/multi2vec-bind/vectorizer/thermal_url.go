@@ -0,0 +1,181 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package vectorizer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/weaviate/weaviate/entities/moduletools"
+)
+
+const (
+	// maxThermalURLBytes bounds the size of a thermal image fetched from a
+	// thermalURL, so that a large response can't exhaust memory
+	maxThermalURLBytes = 32 * 1024 * 1024
+	// thermalURLTimeout bounds fetching a thermal image if the context has no
+	// earlier deadline
+	thermalURLTimeout = 30 * time.Second
+	// maxThermalURLRedirects bounds the redirects followed when fetching a
+	// thermal image, every redirect target needs to be allowed as well
+	maxThermalURLRedirects = 3
+)
+
+// thermalURLFetcher fetches thermal images server-side. Fetching is opt-in:
+// only hosts on the allow-list are fetched, an empty allow-list disables
+// thermalURL altogether. Hosts resolving to private, loopback, link-local or
+// otherwise non-public addresses are rejected when connecting, so that an
+// allowed name can't be pointed at internal services.
+type thermalURLFetcher struct {
+	allowedHosts map[string]struct{}
+	httpClient   *http.Client
+	// allowNonPublic skips the address check, it is only set by tests which
+	// fetch from a local server
+	allowNonPublic bool
+}
+
+func newThermalURLFetcher(allowedHosts []string) *thermalURLFetcher {
+	f := &thermalURLFetcher{allowedHosts: map[string]struct{}{}}
+	for _, host := range allowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			f.allowedHosts[host] = struct{}{}
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: thermalURLTimeout, Control: f.checkAddress}
+	f.httpClient = &http.Client{
+		Timeout: thermalURLTimeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxThermalURLRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxThermalURLRedirects)
+			}
+			return f.checkURL(req.URL)
+		},
+	}
+	return f
+}
+
+func (f *thermalURLFetcher) enabled() bool {
+	return len(f.allowedHosts) > 0
+}
+
+func (f *thermalURLFetcher) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme %q is not allowed", u.Scheme)
+	}
+	if _, ok := f.allowedHosts[strings.ToLower(u.Hostname())]; !ok {
+		return fmt.Errorf("host %q is not allowed", u.Hostname())
+	}
+	return nil
+}
+
+// checkAddress is called for every connection after the host was resolved,
+// so that it also covers redirects and DNS records changing between checks
+func (f *thermalURLFetcher) checkAddress(network, address string, _ syscall.RawConn) error {
+	if f.allowNonPublic {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("address %s is not allowed", host)
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+func (f *thermalURLFetcher) fetch(ctx context.Context, thermalURL string) ([]byte, error) {
+	if !f.enabled() {
+		return nil, errors.New("thermalURL is disabled, " +
+			"allow hosts with BIND_THERMAL_URL_ALLOWED_HOSTS to enable it")
+	}
+	u, err := url.Parse(thermalURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.checkURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	// read one byte more than allowed to detect images which are too large
+	raw, err := io.ReadAll(io.LimitReader(res.Body, maxThermalURLBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxThermalURLBytes {
+		return nil, fmt.Errorf("thermal image exceeds %d bytes", maxThermalURLBytes)
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("empty thermal image")
+	}
+	return raw, nil
+}
+
+// VectorizeThermalURL fetches the thermal image referenced by thermalURL and
+// vectorizes it with the thermal extractor of the class. The host of the URL
+// needs to be allowed, see WithThermalURLHosts. It implements
+// nearThermal.ThermalURLVectorizer.
+func (v *Vectorizer) VectorizeThermalURL(ctx context.Context, thermalURL string, cfg moduletools.ClassConfig) ([]float32, error) {
+	extractor, err := v.thermalExtractor(NewClassSettings(cfg))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := v.thermalURLs.fetch(ctx, thermalURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch thermal image")
+	}
+	return extractThermalRaw(ctx, extractor, raw)
+}
+
+// WithThermalURLHosts allows fetching thermal images from hosts, matched
+// case-insensitively against the host name of the thermalURL. thermalURL is
+// disabled as long as no host is allowed.
+func (v *Vectorizer) WithThermalURLHosts(hosts []string) *Vectorizer {
+	v.thermalURLs = newThermalURLFetcher(hosts)
+	return v
+}
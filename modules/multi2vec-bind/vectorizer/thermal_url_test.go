@@ -0,0 +1,128 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package vectorizer
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVectorizeThermalURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/thermal.png":
+			w.Write([]byte("raw"))
+		case "/large.png":
+			w.Write(make([]byte, maxThermalURLBytes+1))
+		case "/redirect":
+			http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	cfg := newConfigBuilder().build()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	// the test server listens on a loopback address, which is rejected
+	// outside of tests
+	newVectorizer := func(client Client, hosts ...string) *Vectorizer {
+		v := New(client).WithThermalURLHosts(hosts)
+		v.thermalURLs.allowNonPublic = true
+		return v
+	}
+
+	t.Run("fetched image is vectorized", func(t *testing.T) {
+		client := &fakeThermalClient{}
+		vector, err := newVectorizer(client, serverURL.Hostname()).VectorizeThermalURL(ctx, server.URL+"/thermal.png", cfg)
+		require.NoError(t, err)
+		assert.Equal(t, []float32{4, 5, 6}, vector)
+		assert.Equal(t, []string{base64.StdEncoding.EncodeToString([]byte("raw"))}, client.thermal)
+	})
+
+	t.Run("fetched image is passed to the class extractor", func(t *testing.T) {
+		cfg := newConfigBuilder().addSetting("thermalExtractor", "test-mock").build()
+		before := len(testMockExtractor.Extracted)
+		vector, err := newVectorizer(&fakeThermalClient{}, serverURL.Hostname()).VectorizeThermalURL(ctx, server.URL+"/thermal.png", cfg)
+		require.NoError(t, err)
+		assert.Equal(t, []float32{7, 8, 9}, vector)
+		assert.Equal(t, []byte("raw"), testMockExtractor.Extracted[before])
+	})
+
+	t.Run("unexpected status", func(t *testing.T) {
+		client := &fakeThermalClient{}
+		_, err := newVectorizer(client, serverURL.Hostname()).VectorizeThermalURL(ctx, server.URL+"/missing.png", cfg)
+		assert.ErrorContains(t, err, "unexpected status 404")
+		assert.Empty(t, client.thermal)
+	})
+
+	t.Run("image too large", func(t *testing.T) {
+		client := &fakeThermalClient{}
+		_, err := newVectorizer(client, serverURL.Hostname()).VectorizeThermalURL(ctx, server.URL+"/large.png", cfg)
+		assert.ErrorContains(t, err, "exceeds")
+		assert.Empty(t, client.thermal)
+	})
+
+	t.Run("disabled without allowed hosts", func(t *testing.T) {
+		client := &fakeThermalClient{}
+		_, err := New(client).VectorizeThermalURL(ctx, server.URL+"/thermal.png", cfg)
+		assert.ErrorContains(t, err, "thermalURL is disabled")
+		assert.Empty(t, client.thermal)
+	})
+
+	t.Run("host not allowed", func(t *testing.T) {
+		client := &fakeThermalClient{}
+		_, err := newVectorizer(client, "example.com").VectorizeThermalURL(ctx, server.URL+"/thermal.png", cfg)
+		assert.ErrorContains(t, err, "is not allowed")
+		assert.Empty(t, client.thermal)
+	})
+
+	t.Run("redirect to a host which is not allowed", func(t *testing.T) {
+		client := &fakeThermalClient{}
+		target := "http://localhost:" + serverURL.Port() + "/thermal.png"
+		_, err := newVectorizer(client, serverURL.Hostname()).
+			VectorizeThermalURL(ctx, server.URL+"/redirect?to="+url.QueryEscape(target), cfg)
+		assert.ErrorContains(t, err, `host "localhost" is not allowed`)
+		assert.Empty(t, client.thermal)
+	})
+
+	t.Run("non-public address of an allowed host", func(t *testing.T) {
+		client := &fakeThermalClient{}
+		_, err := New(client).WithThermalURLHosts([]string{serverURL.Hostname()}).
+			VectorizeThermalURL(ctx, server.URL+"/thermal.png", cfg)
+		assert.ErrorContains(t, err, "is not allowed")
+		assert.Empty(t, client.thermal)
+	})
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for _, ip := range []string{
+		"127.0.0.1", "10.0.0.1", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1", "224.0.0.1",
+	} {
+		assert.False(t, isPublicIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"8.8.8.8", "2001:4860:4860::8888"} {
+		assert.True(t, isPublicIP(net.ParseIP(ip)), ip)
+	}
+}
@@ -23,12 +23,14 @@ import (
 )
 
 type Vectorizer struct {
-	client Client
+	client      Client
+	thermalURLs *thermalURLFetcher
 }
 
 func New(client Client) *Vectorizer {
 	return &Vectorizer{
-		client: client,
+		client:      client,
+		thermalURLs: newThermalURLFetcher(nil),
 	}
 }
 
@@ -51,6 +53,7 @@ type ClassSettings interface {
 	IMUFieldsWeights() ([]float32, error)
 	ThermalField(property string) bool
 	ThermalFieldsWeights() ([]float32, error)
+	ThermalExtractor() string
 	DepthField(property string) bool
 	DepthFieldsWeights() ([]float32, error)
 	Properties() ([]string, error)
@@ -95,11 +98,19 @@ func (v *Vectorizer) VectorizeIMU(ctx context.Context, imu string, cfg moduletoo
 }
 
 func (v *Vectorizer) VectorizeThermal(ctx context.Context, thermal string, cfg moduletools.ClassConfig) ([]float32, error) {
-	res, err := v.client.Vectorize(ctx, nil, nil, nil, nil, nil, []string{thermal}, nil)
+	extractor, err := v.thermalExtractor(NewClassSettings(cfg))
 	if err != nil {
 		return nil, err
 	}
-	return v.getVector(res.ThermalVectors)
+	return extractThermal(ctx, extractor, thermal)
+}
+
+func (v *Vectorizer) thermalExtractor(icheck ClassSettings) (ThermalFeatureExtractor, error) {
+	factory, err := thermalExtractorFactory(icheck.ThermalExtractor())
+	if err != nil {
+		return nil, err
+	}
+	return factory(v.client), nil
 }
 
 func (v *Vectorizer) VectorizeDepth(ctx context.Context, depth string, cfg moduletools.ClassConfig) ([]float32, error) {
@@ -161,21 +172,46 @@ func (v *Vectorizer) object(ctx context.Context, object *models.Object, cfg modu
 		}
 	}
 
-	vectors := [][]float32{}
+	// the default extractor is served by the inference container, so thermal
+	// images are vectorized in the same request as all other media. Any other
+	// extractor is called for each thermal image separately.
+	var thermalVectors [][]float32
+	if len(thermal) > 0 && icheck.ThermalExtractor() != DefaultThermalExtractor {
+		extractor, err := v.thermalExtractor(icheck)
+		if err != nil {
+			return nil, err
+		}
+		for i := range thermal {
+			vector, err := extractThermal(ctx, extractor, thermal[i])
+			if err != nil {
+				return nil, err
+			}
+			thermalVectors = append(thermalVectors, vector)
+		}
+		thermal = nil
+	}
+
+	res := &ent.VectorizationResult{ThermalVectors: thermalVectors}
 	if len(texts) > 0 || len(images) > 0 || len(audio) > 0 || len(video) > 0 ||
 		len(imu) > 0 || len(thermal) > 0 || len(depth) > 0 {
-		res, err := v.client.Vectorize(ctx, texts, images, audio, video, imu, thermal, depth)
+		remote, err := v.client.Vectorize(ctx, texts, images, audio, video, imu, thermal, depth)
 		if err != nil {
 			return nil, err
 		}
-		vectors = append(vectors, res.TextVectors...)
-		vectors = append(vectors, res.ImageVectors...)
-		vectors = append(vectors, res.AudioVectors...)
-		vectors = append(vectors, res.VideoVectors...)
-		vectors = append(vectors, res.IMUVectors...)
-		vectors = append(vectors, res.ThermalVectors...)
-		vectors = append(vectors, res.DepthVectors...)
+		res = remote
+		if thermalVectors != nil {
+			res.ThermalVectors = thermalVectors
+		}
 	}
+
+	vectors := [][]float32{}
+	vectors = append(vectors, res.TextVectors...)
+	vectors = append(vectors, res.ImageVectors...)
+	vectors = append(vectors, res.AudioVectors...)
+	vectors = append(vectors, res.VideoVectors...)
+	vectors = append(vectors, res.IMUVectors...)
+	vectors = append(vectors, res.ThermalVectors...)
+	vectors = append(vectors, res.DepthVectors...)
 	weights, err := v.getWeights(icheck)
 	if err != nil {
 		return nil, err
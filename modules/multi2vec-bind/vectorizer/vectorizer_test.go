@@ -28,7 +28,7 @@ func TestVectorizer(t *testing.T) {
 	t.Run("should vectorize image", func(t *testing.T) {
 		// given
 		client := &fakeClient{}
-		vectorizer := &Vectorizer{client}
+		vectorizer := New(client)
 		config := newConfigBuilder().addSetting("imageFields", []interface{}{"image"}).build()
 
 		props := map[string]interface{}{
@@ -49,7 +49,7 @@ func TestVectorizer(t *testing.T) {
 	t.Run("should vectorize 2 image fields", func(t *testing.T) {
 		// given
 		client := &fakeClient{}
-		vectorizer := &Vectorizer{client}
+		vectorizer := New(client)
 		config := newConfigBuilder().addSetting("imageFields", []interface{}{"image1", "image2"}).build()
 
 		props := map[string]interface{}{
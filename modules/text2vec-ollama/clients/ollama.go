@@ -109,9 +109,49 @@ func (v *ollama) vectorize(ctx context.Context, input []string,
 		return nil, errors.Wrap(err, "read response body")
 	}
 
+	if isMissingEndpoint(res.StatusCode, bodyBytes) {
+		// Ollama versions before v0.3 don't support /api/embed, fall back to
+		// embedding every input on its own
+		return v.vectorizeLegacy(ctx, input, settings.ApiEndpoint(), settings.Model())
+	}
+
 	return v.parseEmbeddingsResponse(res.StatusCode, bodyBytes, input)
 }
 
+func (v *ollama) vectorizeLegacy(ctx context.Context, input []string,
+	apiEndpoint, model string,
+) (*modulecomponents.VectorizationResult[[]float32], error) {
+	embedder := NewOllamaEmbedder(v.httpClient, apiEndpoint,
+		DefaultEmbedderConcurrency, v.logger)
+	vectors, err := embedder.VectorizeBatch(ctx, input, model)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, errors.Errorf("empty embeddings response")
+	}
+
+	return &modulecomponents.VectorizationResult[[]float32]{
+		Text:       input,
+		Vector:     vectors,
+		Dimensions: len(vectors[0]),
+	}, nil
+}
+
+// isMissingEndpoint reports whether the server does not know the endpoint at
+// all, as opposed to Ollama reporting an error such as an unknown model, which
+// also comes with a 404 status code.
+func isMissingEndpoint(statusCode int, bodyBytes []byte) bool {
+	if statusCode != http.StatusNotFound {
+		return false
+	}
+	var resBody embeddingsResponse
+	if err := json.Unmarshal(bodyBytes, &resBody); err != nil {
+		return true
+	}
+	return resBody.Error == ""
+}
+
 func (v *ollama) parseEmbeddingsResponse(statusCode int,
 	bodyBytes []byte, input []string,
 ) (*modulecomponents.VectorizationResult[[]float32], error) {
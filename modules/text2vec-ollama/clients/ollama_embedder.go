@@ -0,0 +1,136 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	enterrors "github.com/weaviate/weaviate/entities/errors"
+)
+
+// DefaultEmbedderConcurrency is the number of requests an OllamaEmbedder
+// sends in parallel when vectorizing a batch
+const DefaultEmbedderConcurrency = 4
+
+// OllamaEmbedder vectorizes text using Ollama's /api/embeddings endpoint.
+// Unlike /api/embed, which was added in Ollama v0.3, it embeds a single
+// prompt per request, but is supported by all Ollama versions.
+type OllamaEmbedder struct {
+	httpClient     *http.Client
+	apiEndpoint    string
+	maxConcurrency int
+	logger         logrus.FieldLogger
+}
+
+func NewOllamaEmbedder(httpClient *http.Client, apiEndpoint string,
+	maxConcurrency int, logger logrus.FieldLogger,
+) *OllamaEmbedder {
+	if maxConcurrency < 1 {
+		maxConcurrency = DefaultEmbedderConcurrency
+	}
+	return &OllamaEmbedder{
+		httpClient:     httpClient,
+		apiEndpoint:    apiEndpoint,
+		maxConcurrency: maxConcurrency,
+		logger:         logger,
+	}
+}
+
+func (e *OllamaEmbedder) Vectorize(ctx context.Context, text string, model string) ([]float32, error) {
+	body, err := json.Marshal(legacyEmbeddingsRequest{
+		Model:  model,
+		Prompt: text,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal body")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST",
+		fmt.Sprintf("%s/api/embeddings", e.apiEndpoint), bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "create POST request")
+	}
+
+	res, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "send POST request")
+	}
+	defer res.Body.Close()
+
+	bodyBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read response body")
+	}
+
+	var resBody legacyEmbeddingsResponse
+	if err := json.Unmarshal(bodyBytes, &resBody); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal response body. Got: %v", string(bodyBytes))
+	}
+
+	if resBody.Error != "" {
+		return nil, errors.Errorf("connection to Ollama API failed with error: %s", resBody.Error)
+	}
+
+	if res.StatusCode != 200 {
+		return nil, errors.Errorf("connection to Ollama API failed with status: %d", res.StatusCode)
+	}
+
+	if len(resBody.Embedding) == 0 {
+		return nil, errors.Errorf("empty embeddings response")
+	}
+
+	return resBody.Embedding, nil
+}
+
+// VectorizeBatch vectorizes every text with a separate request, as the
+// endpoint does not support batching. At most maxConcurrency requests are in
+// flight at the same time. The vectors are returned in the order of texts.
+func (e *OllamaEmbedder) VectorizeBatch(ctx context.Context, texts []string, model string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+
+	eg, ctx := enterrors.NewErrorGroupWithContextWrapper(e.logger, ctx)
+	eg.SetLimit(e.maxConcurrency)
+	for i := range texts {
+		i := i
+		eg.Go(func() error {
+			vector, err := e.Vectorize(ctx, texts[i], model)
+			if err != nil {
+				return fmt.Errorf("vectorize text at position %d: %w", i, err)
+			}
+			vectors[i] = vector
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	return vectors, nil
+}
+
+type legacyEmbeddingsRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+type legacyEmbeddingsResponse struct {
+	Embedding []float32 `json:"embedding,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
@@ -0,0 +1,109 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaEmbedder(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/embeddings", r.URL.Path)
+
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var req legacyEmbeddingsRequest
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Prompt == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(legacyEmbeddingsResponse{Error: "nope"})
+			return
+		}
+		json.NewEncoder(w).Encode(legacyEmbeddingsResponse{
+			Embedding: []float32{float32(len(req.Prompt)), 0.5},
+		})
+	}))
+	defer server.Close()
+
+	t.Run("single text", func(t *testing.T) {
+		e := NewOllamaEmbedder(&http.Client{}, server.URL, 0, nullLogger())
+		vector, err := e.Vectorize(context.Background(), "abc", "nomic-embed-text")
+		require.Nil(t, err)
+		assert.Equal(t, []float32{3, 0.5}, vector)
+	})
+
+	t.Run("batch keeps order and bounds concurrency", func(t *testing.T) {
+		maxInFlight.Store(0)
+		e := NewOllamaEmbedder(&http.Client{}, server.URL, 2, nullLogger())
+		texts := []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff"}
+
+		vectors, err := e.VectorizeBatch(context.Background(), texts, "nomic-embed-text")
+		require.Nil(t, err)
+		require.Len(t, vectors, len(texts))
+		for i, text := range texts {
+			assert.Equal(t, float32(len(text)), vectors[i][0])
+		}
+		assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+	})
+
+	t.Run("batch fails if a single text fails", func(t *testing.T) {
+		e := NewOllamaEmbedder(&http.Client{}, server.URL, 2, nullLogger())
+
+		_, err := e.VectorizeBatch(context.Background(), []string{"a", "fail"}, "nomic-embed-text")
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "connection to Ollama API failed with error: nope")
+	})
+}
+
+func TestClientFallsBackToLegacyEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/embeddings":
+			json.NewEncoder(w).Encode(legacyEmbeddingsResponse{Embedding: []float32{0.1, 0.2}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := New(0, nullLogger())
+	cfg := fakeClassConfig{apiEndpoint: server.URL}
+
+	res, _, _, err := c.Vectorize(context.Background(), []string{"one", "two"}, cfg)
+	require.Nil(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.1, 0.2}}, res.Vector)
+	assert.Equal(t, 2, res.Dimensions)
+
+	t.Run("model errors are not treated as a missing endpoint", func(t *testing.T) {
+		assert.False(t, isMissingEndpoint(http.StatusNotFound,
+			[]byte(`{"error":"model \"foo\" not found, try pulling it first"}`)))
+		assert.True(t, isMissingEndpoint(http.StatusNotFound, []byte("404 page not found")))
+	})
+}
@@ -0,0 +1,76 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"context"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/weaviate/weaviate/entities/moduletools"
+)
+
+// TextVectorizer vectorizes thermal images and texts into the same vector
+// space, such as the multi2vec-bind vectorizer. Texts combines all inputs
+// into a single vector.
+type TextVectorizer interface {
+	VectorizeThermal(ctx context.Context, thermal string, cfg moduletools.ClassConfig) ([]float32, error)
+	Texts(ctx context.Context, texts []string, cfg moduletools.ClassConfig) ([]float32, error)
+}
+
+// ClassifyZeroShot assigns the base64 encoded thermal image to the category
+// whose label is nearest to it, e.g. "fire", "human" or "vehicle". Image and
+// labels are vectorized by the same cross-modal vectorizer, without any class
+// config. It returns the nearest category and its cosine distance.
+func ClassifyZeroShot(ctx context.Context, thermalB64 string, categories []string,
+	vectorizer TextVectorizer,
+) (string, float64, error) {
+	return ClassifyZeroShotWithDistance(ctx, thermalB64, categories, vectorizer,
+		DefaultThermalDistance)
+}
+
+// ClassifyZeroShotWithDistance is ClassifyZeroShot using the distance function
+// registered under distanceMetric, see RegisterThermalDistanceFn
+func ClassifyZeroShotWithDistance(ctx context.Context, thermalB64 string, categories []string,
+	vectorizer TextVectorizer, distanceMetric string,
+) (string, float64, error) {
+	distanceFn, err := ThermalDistance(distanceMetric)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(categories) == 0 {
+		return "", 0, errors.New("no categories given")
+	}
+
+	thermal, err := vectorizer.VectorizeThermal(ctx, thermalB64, nil)
+	if err != nil {
+		return "", 0, errors.Errorf("vectorize thermal: %v", err)
+	}
+
+	nearest, nearestDist := "", math.Inf(1)
+	for _, category := range categories {
+		label, err := vectorizer.Texts(ctx, []string{category}, nil)
+		if err != nil {
+			return "", 0, errors.Errorf("vectorize category %q: %v", category, err)
+		}
+
+		dist, err := distanceFn.Distance(thermal, label)
+		if err != nil {
+			return "", 0, errors.Errorf("category %q: %v", category, err)
+		}
+		if float64(dist) < nearestDist {
+			nearest, nearestDist = category, float64(dist)
+		}
+	}
+
+	return nearest, nearestDist, nil
+}
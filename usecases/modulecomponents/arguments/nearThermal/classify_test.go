@@ -0,0 +1,80 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/moduletools"
+)
+
+type fakeTextVectorizer struct {
+	thermal []float32
+	texts   map[string][]float32
+}
+
+func (v *fakeTextVectorizer) VectorizeThermal(ctx context.Context,
+	thermal string, cfg moduletools.ClassConfig,
+) ([]float32, error) {
+	return v.thermal, nil
+}
+
+func (v *fakeTextVectorizer) Texts(ctx context.Context,
+	texts []string, cfg moduletools.ClassConfig,
+) ([]float32, error) {
+	vector, ok := v.texts[texts[0]]
+	if !ok {
+		return nil, errors.New("unknown text")
+	}
+	return vector, nil
+}
+
+func TestClassifyZeroShot(t *testing.T) {
+	vectorizer := &fakeTextVectorizer{
+		thermal: []float32{2, 2, 0},
+		texts: map[string][]float32{
+			"fire":    {1, 0, 0},
+			"human":   {3, 3, 0.1},
+			"vehicle": {0, 0, 1},
+			"broken":  {1, 0},
+		},
+	}
+
+	t.Run("returns the nearest category", func(t *testing.T) {
+		category, dist, err := ClassifyZeroShot(context.Background(), "thermal",
+			[]string{"fire", "human", "vehicle"}, vectorizer)
+		require.NoError(t, err)
+		assert.Equal(t, "human", category)
+		assert.InDelta(t, 0.0003, dist, 0.0001)
+	})
+
+	t.Run("fails without categories", func(t *testing.T) {
+		_, _, err := ClassifyZeroShot(context.Background(), "thermal", nil, vectorizer)
+		require.Error(t, err)
+	})
+
+	t.Run("fails on vectorizer errors", func(t *testing.T) {
+		_, _, err := ClassifyZeroShot(context.Background(), "thermal",
+			[]string{"fire", "unknown"}, vectorizer)
+		require.ErrorContains(t, err, "unknown")
+	})
+
+	t.Run("fails on mismatching dimensions", func(t *testing.T) {
+		_, _, err := ClassifyZeroShot(context.Background(), "thermal",
+			[]string{"broken"}, vectorizer)
+		require.ErrorContains(t, err, "vector lengths don't match")
+	})
+}
@@ -0,0 +1,148 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ThermalDistanceFn computes the distance between two thermal vectors, lower
+// values meaning more similar vectors
+type ThermalDistanceFn interface {
+	Distance(a, b []float32) (float32, error)
+}
+
+// ThermalDistanceFunc adapts a function to a ThermalDistanceFn
+type ThermalDistanceFunc func(a, b []float32) (float32, error)
+
+func (f ThermalDistanceFunc) Distance(a, b []float32) (float32, error) {
+	return f(a, b)
+}
+
+// DefaultThermalDistance is used if no distance metric is given
+const DefaultThermalDistance = "cosine"
+
+var thermalDistances = struct {
+	sync.RWMutex
+	fns map[string]ThermalDistanceFn
+}{fns: map[string]ThermalDistanceFn{}}
+
+func init() {
+	RegisterThermalDistanceFn("cosine", ThermalDistanceFunc(cosineDistance))
+	RegisterThermalDistanceFn("l2", ThermalDistanceFunc(l2Distance))
+	RegisterThermalDistanceFn("manhattan", ThermalDistanceFunc(manhattanDistance))
+	RegisterThermalDistanceFn("chebyshev", ThermalDistanceFunc(chebyshevDistance))
+}
+
+// RegisterThermalDistanceFn makes a distance function available under name.
+// It is meant to be called from init functions, registering the same name
+// twice panics.
+func RegisterThermalDistanceFn(name string, fn ThermalDistanceFn) {
+	thermalDistances.Lock()
+	defer thermalDistances.Unlock()
+
+	if fn == nil {
+		panic("nearThermal: distance function is nil")
+	}
+	if _, ok := thermalDistances.fns[name]; ok {
+		panic(fmt.Sprintf("nearThermal: distance function %q registered twice", name))
+	}
+	thermalDistances.fns[name] = fn
+}
+
+// ThermalDistance returns the distance function registered under name
+func ThermalDistance(name string) (ThermalDistanceFn, error) {
+	thermalDistances.RLock()
+	defer thermalDistances.RUnlock()
+
+	fn, ok := thermalDistances.fns[name]
+	if !ok {
+		names := make([]string, 0, len(thermalDistances.fns))
+		for name := range thermalDistances.fns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, errors.Errorf("unknown distance metric %q, available: %v", name, names)
+	}
+	return fn, nil
+}
+
+func checkLengths(a, b []float32) error {
+	if len(a) != len(b) {
+		return fmt.Errorf("vector lengths don't match: %d vs %d", len(a), len(b))
+	}
+	return nil
+}
+
+// cosineDistance does not expect normalized vectors, as cross-modal
+// vectorizers don't necessarily return them
+func cosineDistance(a, b []float32) (float32, error) {
+	if err := checkLengths(a, b); err != nil {
+		return 0, err
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0, errors.New("cannot compute cosine distance of a zero vector")
+	}
+
+	return float32(1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))), nil
+}
+
+// l2Distance is the euclidean distance
+func l2Distance(a, b []float32) (float32, error) {
+	if err := checkLengths(a, b); err != nil {
+		return 0, err
+	}
+
+	var sum float64
+	for i := range a {
+		diff := float64(a[i]) - float64(b[i])
+		sum += diff * diff
+	}
+	return float32(math.Sqrt(sum)), nil
+}
+
+func manhattanDistance(a, b []float32) (float32, error) {
+	if err := checkLengths(a, b); err != nil {
+		return 0, err
+	}
+
+	var sum float64
+	for i := range a {
+		sum += math.Abs(float64(a[i]) - float64(b[i]))
+	}
+	return float32(sum), nil
+}
+
+// chebyshevDistance is the largest difference in any dimension
+func chebyshevDistance(a, b []float32) (float32, error) {
+	if err := checkLengths(a, b); err != nil {
+		return 0, err
+	}
+
+	var largest float64
+	for i := range a {
+		largest = math.Max(largest, math.Abs(float64(a[i])-float64(b[i])))
+	}
+	return float32(largest), nil
+}
@@ -0,0 +1,92 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThermalDistances(t *testing.T) {
+	a, b := []float32{1, 2, 3}, []float32{4, 0, 3}
+
+	tests := []struct {
+		name     string
+		expected float32
+	}{
+		{name: "cosine", expected: 1 - 13/(3.741657*5)},
+		{name: "l2", expected: 3.605551},
+		{name: "manhattan", expected: 5},
+		{name: "chebyshev", expected: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fn, err := ThermalDistance(test.name)
+			require.NoError(t, err)
+
+			dist, err := fn.Distance(a, b)
+			require.NoError(t, err)
+			assert.InDelta(t, test.expected, dist, 0.0001)
+
+			dist, err = fn.Distance(a, a)
+			require.NoError(t, err)
+			assert.InDelta(t, 0, dist, 0.0001)
+
+			_, err = fn.Distance(a, b[:2])
+			assert.ErrorContains(t, err, "vector lengths don't match")
+		})
+	}
+
+	t.Run("unknown distance metric", func(t *testing.T) {
+		_, err := ThermalDistance("hamming")
+		assert.ErrorContains(t, err, `unknown distance metric "hamming"`)
+	})
+
+	t.Run("custom distance function", func(t *testing.T) {
+		RegisterThermalDistanceFn("test-constant", ThermalDistanceFunc(func(a, b []float32) (float32, error) {
+			return 42, nil
+		}))
+		fn, err := ThermalDistance("test-constant")
+		require.NoError(t, err)
+		dist, err := fn.Distance(a, b)
+		require.NoError(t, err)
+		assert.Equal(t, float32(42), dist)
+
+		assert.Panics(t, func() {
+			RegisterThermalDistanceFn("test-constant", ThermalDistanceFunc(l2Distance))
+		})
+	})
+}
+
+func TestClassifyZeroShotWithDistance(t *testing.T) {
+	vectorizer := &fakeTextVectorizer{
+		thermal: []float32{2, 2, 0},
+		texts: map[string][]float32{
+			"fire":    {1, 0, 0},
+			"human":   {3, 3, 0.1},
+			"vehicle": {0, 0, 1},
+		},
+	}
+
+	category, dist, err := ClassifyZeroShotWithDistance(context.Background(), "thermal",
+		[]string{"fire", "human", "vehicle"}, vectorizer, "l2")
+	require.NoError(t, err)
+	assert.Equal(t, "human", category)
+	assert.InDelta(t, 1.4177, dist, 0.0001)
+
+	_, _, err = ClassifyZeroShotWithDistance(context.Background(), "thermal",
+		[]string{"fire"}, vectorizer, "unknown")
+	assert.ErrorContains(t, err, "unknown distance metric")
+}
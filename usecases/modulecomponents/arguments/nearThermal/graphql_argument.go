@@ -16,50 +16,87 @@ import (
 
 	"github.com/tailor-inc/graphql"
 	"github.com/weaviate/weaviate/adapters/handlers/graphql/descriptions"
+	"github.com/weaviate/weaviate/adapters/handlers/graphql/local/common_filters"
 )
 
 func getNearThermalArgumentFn(classname string) *graphql.ArgumentConfig {
-	return nearThermalArgument("GetObjects", classname)
+	return nearThermalArgument("GetObjects", classname, true)
 }
 
 func exploreNearThermalArgumentFn() *graphql.ArgumentConfig {
-	return nearThermalArgument("Explore", "")
+	return nearThermalArgument("Explore", "", false)
 }
 
 func aggregateNearThermalArgumentFn(classname string) *graphql.ArgumentConfig {
-	return nearThermalArgument("Aggregate", classname)
+	return nearThermalArgument("Aggregate", classname, false)
 }
 
-func nearThermalArgument(prefix, className string) *graphql.ArgumentConfig {
+func nearThermalArgument(prefix, className string, addTarget bool) *graphql.ArgumentConfig {
 	prefixName := fmt.Sprintf("Multi2VecBind%s%s", prefix, className)
 	return &graphql.ArgumentConfig{
 		Type: graphql.NewInputObject(
 			graphql.InputObjectConfig{
 				Name:        fmt.Sprintf("%sNearThermalInpObj", prefixName),
-				Fields:      nearThermalFields(prefixName),
+				Fields:      nearThermalFields(prefixName, addTarget),
 				Description: descriptions.GetWhereInpObj,
 			},
 		),
 	}
 }
 
-func nearThermalFields(prefix string) graphql.InputObjectConfigFieldMap {
-	return graphql.InputObjectConfigFieldMap{
+// nearThermalFields are the input fields of the nearThermal argument. They
+// need to match what extractNearThermalFn expects. The targets subsearch is
+// only supported by Get.
+func nearThermalFields(prefix string, addTarget bool) graphql.InputObjectConfigFieldMap {
+	fields := graphql.InputObjectConfigFieldMap{
 		"thermal": &graphql.InputObjectFieldConfig{
-			Description: "Base64 encoded thermal data",
-			Type:        graphql.NewNonNull(graphql.String),
+			Description: "Base64 encoded thermal data, either thermal or thermalURL needs to be set",
+			Type:        graphql.String,
+		},
+		"thermalURL": &graphql.InputObjectFieldConfig{
+			Description: "URL of thermal data which is fetched by the server if the module allows its host, either thermal or thermalURL needs to be set",
+			Type:        graphql.String,
 		},
 		"certainty": &graphql.InputObjectFieldConfig{
 			Description: descriptions.Certainty,
 			Type:        graphql.Float,
 		},
+		"maxCertainty": &graphql.InputObjectFieldConfig{
+			Description: "Exclude results with a higher certainty, e.g. near-duplicates of the query",
+			Type:        graphql.Float,
+		},
 		"distance": &graphql.InputObjectFieldConfig{
 			Description: descriptions.Distance,
 			Type:        graphql.Float,
 		},
+		"autocut": &graphql.InputObjectFieldConfig{
+			Description: "Cut off number of results after the Nth extrema. Off by default.",
+			Type:        graphql.Int,
+		},
+		"additionalCollections": &graphql.InputObjectFieldConfig{
+			Description: "Collections to search in addition to the queried one, results are merged by distance",
+			Type:        graphql.NewList(graphql.String),
+		},
+		"deduplicateExact": &graphql.InputObjectFieldConfig{
+			Description: "Reuse the vector of an identical thermal image queried before instead of vectorizing it again",
+			Type:        graphql.Boolean,
+		},
+		"filterStrategy": &graphql.InputObjectFieldConfig{
+			Description: "Filter strategy of the vector index if combined with a where filter: sweeping or acorn. Defaults to the one configured on the index.",
+			Type:        graphql.String,
+		},
 		"targetVectors": &graphql.InputObjectFieldConfig{
 			Description: "Target vectors",
 			Type:        graphql.NewList(graphql.String),
 		},
+		"combinationMethod": &graphql.InputObjectFieldConfig{
+			Description: "Combination of the distances of multiple target vectors: minimum, average, sum, manualWeights or relativeScore",
+			Type:        graphql.String,
+		},
+		"weights": &graphql.InputObjectFieldConfig{
+			Description: "Weights of the target vectors for the manualWeights and relativeScore combination methods",
+			Type:        common_filters.WeightsScalar,
+		},
 	}
+	return common_filters.AddTargetArgument(fields, prefix+"nearThermal", addTarget)
 }
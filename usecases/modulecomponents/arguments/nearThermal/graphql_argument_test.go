@@ -24,33 +24,63 @@ func TestNearThermalGraphQLArgument(t *testing.T) {
 		prefix := "Prefix"
 		classname := "Class"
 		// when
-		nearThermal := nearThermalArgument(prefix, classname)
+		nearThermal := nearThermalArgument(prefix, classname, true)
 
 		// then
 		// the built graphQL field needs to support this structure:
 		// nearThermal: {
 		//   thermal: "base64;encoded,thermal_image",
+		//   thermalURL: "https://example.com/thermal.png",
+		//   maxCertainty: 0.95
 		//   distance: 0.9
+		//   autocut: 1
+		//   additionalCollections: ["Collection"]
+		//   deduplicateExact: true
+		//   filterStrategy: "acorn"
 		//   targetVectors: ["targetVector"]
+		//   combinationMethod: "manualWeights"
+		//   weights: {targetVector: 0.5}
+		//   targets: {targetVectors: ["targetVector"], combinationMethod: minimum}
 		// }
 		assert.NotNil(t, nearThermal)
 		assert.Equal(t, "Multi2VecBindPrefixClassNearThermalInpObj", nearThermal.Type.Name())
 		answerFields, ok := nearThermal.Type.(*graphql.InputObject)
 		assert.True(t, ok)
 		assert.NotNil(t, answerFields)
-		assert.Equal(t, 4, len(answerFields.Fields()))
+		assert.Equal(t, 13, len(answerFields.Fields()))
 		fields := answerFields.Fields()
+		// either thermal or thermalURL is set, so neither is required
 		thermal := fields["thermal"]
-		thermalNonNull, thermalNonNullOK := thermal.Type.(*graphql.NonNull)
-		assert.True(t, thermalNonNullOK)
-		assert.Equal(t, "String", thermalNonNull.OfType.Name())
 		assert.NotNil(t, thermal)
+		assert.Equal(t, "String", thermal.Type.Name())
+		assert.Equal(t, "String", fields["thermalURL"].Type.Name())
 		assert.NotNil(t, fields["certainty"])
+		assert.Equal(t, "Float", fields["maxCertainty"].Type.Name())
 		assert.NotNil(t, fields["distance"])
+		assert.Equal(t, "Int", fields["autocut"].Type.Name())
+		additionalCollections, additionalCollectionsOK := fields["additionalCollections"].Type.(*graphql.List)
+		assert.True(t, additionalCollectionsOK)
+		assert.Equal(t, "String", additionalCollections.OfType.Name())
+		assert.Equal(t, "Boolean", fields["deduplicateExact"].Type.Name())
+		assert.Equal(t, "String", fields["filterStrategy"].Type.Name())
 		targetVectors := fields["targetVectors"]
 		targetVectorsList, targetVectorsListOK := targetVectors.Type.(*graphql.List)
 		assert.True(t, targetVectorsListOK)
 		assert.Equal(t, "String", targetVectorsList.OfType.Name())
 		assert.NotNil(t, targetVectors)
+		assert.Equal(t, "String", fields["combinationMethod"].Type.Name())
+		assert.Equal(t, "Weights", fields["weights"].Type.Name())
+		assert.NotNil(t, fields["targets"])
+	})
+
+	t.Run("should not support targets for aggregate and explore", func(t *testing.T) {
+		for _, nearThermal := range []*graphql.ArgumentConfig{
+			aggregateNearThermalArgumentFn("Class"),
+			exploreNearThermalArgumentFn(),
+		} {
+			fields := nearThermal.Type.(*graphql.InputObject).Fields()
+			assert.Equal(t, 12, len(fields))
+			assert.Nil(t, fields["targets"])
+		}
 	})
 }
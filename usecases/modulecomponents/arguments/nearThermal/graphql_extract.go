@@ -12,11 +12,24 @@
 package nearThermal
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+
 	"github.com/weaviate/weaviate/adapters/handlers/graphql/local/common_filters"
 	"github.com/weaviate/weaviate/entities/dto"
 )
 
-// extractNearThermalFn arguments, such as "thermal" and "certainty"
+var combinationMethods = map[string]dto.TargetCombinationType{
+	"sum":           dto.Sum,
+	"average":       dto.Average,
+	"minimum":       dto.Minimum,
+	"manualWeights": dto.ManualWeights,
+	"relativeScore": dto.RelativeScore,
+}
+
+// extractNearThermalFn arguments, such as "thermal", "thermalURL", "certainty",
+// "maxCertainty" and "autocut"
 func extractNearThermalFn(source map[string]interface{}) (interface{}, *dto.TargetCombination, error) {
 	var args NearThermalParams
 
@@ -25,18 +38,84 @@ func extractNearThermalFn(source map[string]interface{}) (interface{}, *dto.Targ
 		args.Thermal = thermal
 	}
 
-	certainty, ok := source["certainty"]
+	thermalURL, ok := source["thermalURL"].(string)
 	if ok {
-		args.Certainty = certainty.(float64)
+		args.ThermalURL = thermalURL
 	}
 
-	distance, ok := source["distance"]
-	if ok {
-		args.Distance = distance.(float64)
+	if certainty, ok := source["certainty"]; ok {
+		value, err := extractNumber(certainty)
+		if err != nil {
+			return nil, nil, fmt.Errorf("certainty: %w", err)
+		}
+		args.Certainty = value
+	}
+
+	if maxCertainty, ok := source["maxCertainty"]; ok {
+		value, err := extractNumber(maxCertainty)
+		if err != nil {
+			return nil, nil, fmt.Errorf("maxCertainty: %w", err)
+		}
+		args.MaxCertainty = &value
+	}
+
+	if distance, ok := source["distance"]; ok {
+		value, err := extractNumber(distance)
+		if err != nil {
+			return nil, nil, fmt.Errorf("distance: %w", err)
+		}
+		args.Distance = value
 		args.WithDistance = true
 	}
 
-	targetVectors, combination, err := common_filters.ExtractTargets(source)
+	if autocut, ok := source["autocut"]; ok {
+		value, err := extractAutocut(autocut)
+		if err != nil {
+			return nil, nil, fmt.Errorf("autocut: %w", err)
+		}
+		args.Autocut = value
+	}
+
+	if collections, ok := source["additionalCollections"]; ok {
+		collectionsList, ok := collections.([]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("additionalCollections is not a list, got %v", collections)
+		}
+		for _, collection := range collectionsList {
+			name, ok := collection.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("additionalCollections must contain strings, got %v", collection)
+			}
+			args.AdditionalCollections = append(args.AdditionalCollections, name)
+		}
+	}
+
+	if deduplicateExact, ok := source["deduplicateExact"]; ok {
+		value, ok := deduplicateExact.(bool)
+		if !ok {
+			return nil, nil, fmt.Errorf("deduplicateExact is not a bool, got %v", deduplicateExact)
+		}
+		args.DeduplicateExact = value
+	}
+
+	if filterStrategy, ok := source["filterStrategy"]; ok {
+		value, ok := filterStrategy.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("filterStrategy is not a string, got %v", filterStrategy)
+		}
+		args.FilterStrategy = value
+	}
+
+	targetsSource := source
+	if combinationMethod, ok := source["combinationMethod"]; ok {
+		combinationType, err := extractCombinationMethod(combinationMethod)
+		if err != nil {
+			return nil, nil, err
+		}
+		targetsSource = withCombinationMethod(source, combinationType)
+	}
+
+	targetVectors, combination, err := common_filters.ExtractTargets(targetsSource)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -44,3 +123,88 @@ func extractNearThermalFn(source map[string]interface{}) (interface{}, *dto.Targ
 
 	return &args, combination, nil
 }
+
+// extractNumber converts the numeric representations used by GraphQL clients
+// and JSON decoders to a float64
+func extractNumber(in interface{}) (float64, error) {
+	switch v := in.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		value, err := v.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q: %w", v.String(), err)
+		}
+		return value, nil
+	default:
+		return 0, fmt.Errorf("expected a number, got %T %v", in, in)
+	}
+}
+
+// extractAutocut accepts the same numeric representations as extractNumber,
+// as long as they are a whole, non-negative number
+func extractAutocut(in interface{}) (int, error) {
+	value, err := extractNumber(in)
+	if err != nil {
+		return 0, err
+	}
+	if value != math.Trunc(value) || value > math.MaxInt32 {
+		return 0, fmt.Errorf("expected a whole number, got %v", in)
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("must not be negative, got %v", in)
+	}
+	return int(value), nil
+}
+
+// extractCombinationMethod validates an explicitly provided combination
+// method, which can be given either as a dto.TargetCombinationType or by name
+func extractCombinationMethod(combinationMethod interface{}) (dto.TargetCombinationType, error) {
+	switch method := combinationMethod.(type) {
+	case dto.TargetCombinationType:
+		for _, supported := range combinationMethods {
+			if method == supported {
+				return method, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown combination method %v", method)
+	case string:
+		if combinationType, ok := combinationMethods[method]; ok {
+			return combinationType, nil
+		}
+		return 0, fmt.Errorf("unknown combination method %q", method)
+	default:
+		return 0, fmt.Errorf("combinationMethod is not a string or TargetCombinationType, got %v", combinationMethod)
+	}
+}
+
+// withCombinationMethod returns a copy of source in which the combination
+// method of the targets is overridden, so that the default combination of
+// common_filters.ExtractTargets is not used
+func withCombinationMethod(source map[string]interface{}, combinationType dto.TargetCombinationType) map[string]interface{} {
+	targets := map[string]interface{}{}
+	if targetsIn, ok := source["targets"].(map[string]interface{}); ok {
+		for k, v := range targetsIn {
+			targets[k] = v
+		}
+	} else {
+		if targetVectors, ok := source["targetVectors"]; ok {
+			targets["targetVectors"] = targetVectors
+		}
+		if weights, ok := source["weights"]; ok {
+			targets["weights"] = weights
+		}
+	}
+	targets["combinationMethod"] = combinationType
+
+	out := make(map[string]interface{}, len(source))
+	for k, v := range source {
+		out[k] = v
+	}
+	out["targets"] = targets
+	return out
+}
@@ -12,6 +12,8 @@
 package nearThermal
 
 import (
+	"encoding/json"
+	"math"
 	"reflect"
 	"testing"
 
@@ -66,6 +68,20 @@ func Test_extractNearThermalFn(t *testing.T) {
 				Thermal: "base64;encoded",
 			},
 		},
+		{
+			name: "should extract properly with only thermalURL set",
+			args: args{
+				source: map[string]interface{}{
+					"thermalURL": "https://example.com/thermal.png",
+					"distance":   float64(0.9),
+				},
+			},
+			want: &NearThermalParams{
+				ThermalURL:   "https://example.com/thermal.png",
+				Distance:     0.9,
+				WithDistance: true,
+			},
+		},
 		{
 			name: "should extract properly with thermal and targetVectors set",
 			args: args{
@@ -98,6 +114,73 @@ func Test_extractNearThermalFn(t *testing.T) {
 			},
 			wantTarget: &dto.TargetCombination{Type: dto.ManualWeights, Weights: []float32{0.5, 0.5}},
 		},
+		{
+			name: "should extract properly with thermal and autocut set",
+			args: args{
+				source: map[string]interface{}{
+					"thermal": "base64;encoded",
+					"autocut": 2,
+				},
+			},
+			want: &NearThermalParams{
+				Thermal: "base64;encoded",
+				Autocut: 2,
+			},
+		},
+		{
+			name: "should extract properly with thermal and additionalCollections set",
+			args: args{
+				source: map[string]interface{}{
+					"thermal":               "base64;encoded",
+					"additionalCollections": []interface{}{"Collection1", "Collection2"},
+				},
+			},
+			want: &NearThermalParams{
+				Thermal:               "base64;encoded",
+				AdditionalCollections: []string{"Collection1", "Collection2"},
+			},
+		},
+		{
+			name: "should extract properly with thermal and deduplicateExact set",
+			args: args{
+				source: map[string]interface{}{
+					"thermal":          "base64;encoded",
+					"deduplicateExact": true,
+				},
+			},
+			want: &NearThermalParams{
+				Thermal:          "base64;encoded",
+				DeduplicateExact: true,
+			},
+		},
+		{
+			name: "should extract properly with thermal and filterStrategy set",
+			args: args{
+				source: map[string]interface{}{
+					"thermal":        "base64;encoded",
+					"filterStrategy": "acorn",
+				},
+			},
+			want: &NearThermalParams{
+				Thermal:        "base64;encoded",
+				FilterStrategy: "acorn",
+			},
+		},
+		{
+			name: "should extract properly with thermal, certainty and maxCertainty set",
+			args: args{
+				source: map[string]interface{}{
+					"thermal":      "base64;encoded",
+					"certainty":    float64(0.7),
+					"maxCertainty": float64(0.95),
+				},
+			},
+			want: &NearThermalParams{
+				Thermal:      "base64;encoded",
+				Certainty:    0.7,
+				MaxCertainty: ptFloat64(0.95),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -108,3 +191,252 @@ func Test_extractNearThermalFn(t *testing.T) {
 		})
 	}
 }
+
+func Test_extractNearThermalFnWithCombinationMethod(t *testing.T) {
+	targetVectors := []interface{}{"targetVector1", "targetVector2"}
+	weights := map[string]interface{}{"targetVector1": 0.3, "targetVector2": 0.7}
+
+	tests := []struct {
+		name       string
+		source     map[string]interface{}
+		wantTarget *dto.TargetCombination
+		wantErr    bool
+	}{
+		{
+			name: "sum",
+			source: map[string]interface{}{
+				"thermal":           "base64;encoded",
+				"targetVectors":     targetVectors,
+				"combinationMethod": "sum",
+			},
+			wantTarget: &dto.TargetCombination{Type: dto.Sum, Weights: []float32{1, 1}},
+		},
+		{
+			name: "average",
+			source: map[string]interface{}{
+				"thermal":           "base64;encoded",
+				"targetVectors":     targetVectors,
+				"combinationMethod": dto.Average,
+			},
+			wantTarget: &dto.TargetCombination{Type: dto.Average, Weights: []float32{0.5, 0.5}},
+		},
+		{
+			name: "minimum",
+			source: map[string]interface{}{
+				"thermal":           "base64;encoded",
+				"targetVectors":     targetVectors,
+				"combinationMethod": "minimum",
+			},
+			wantTarget: &dto.TargetCombination{Type: dto.Minimum, Weights: []float32{0, 0}},
+		},
+		{
+			name: "manual weights",
+			source: map[string]interface{}{
+				"thermal":           "base64;encoded",
+				"targetVectors":     targetVectors,
+				"weights":           weights,
+				"combinationMethod": "manualWeights",
+			},
+			wantTarget: &dto.TargetCombination{Type: dto.ManualWeights, Weights: []float32{0.3, 0.7}},
+		},
+		{
+			name: "relative score",
+			source: map[string]interface{}{
+				"thermal":           "base64;encoded",
+				"targetVectors":     targetVectors,
+				"weights":           weights,
+				"combinationMethod": "relativeScore",
+			},
+			wantTarget: &dto.TargetCombination{Type: dto.RelativeScore, Weights: []float32{0.3, 0.7}},
+		},
+		{
+			name: "overrides combination method of targets",
+			source: map[string]interface{}{
+				"thermal": "base64;encoded",
+				"targets": map[string]interface{}{
+					"targetVectors":     targetVectors,
+					"combinationMethod": dto.Minimum,
+				},
+				"combinationMethod": "sum",
+			},
+			wantTarget: &dto.TargetCombination{Type: dto.Sum, Weights: []float32{1, 1}},
+		},
+		{
+			name: "manual weights without weights",
+			source: map[string]interface{}{
+				"thermal":           "base64;encoded",
+				"targetVectors":     targetVectors,
+				"combinationMethod": "manualWeights",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown combination method",
+			source: map[string]interface{}{
+				"thermal":           "base64;encoded",
+				"targetVectors":     targetVectors,
+				"combinationMethod": "median",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown combination method type",
+			source: map[string]interface{}{
+				"thermal":           "base64;encoded",
+				"targetVectors":     targetVectors,
+				"combinationMethod": dto.TargetCombinationType(42),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, target, err := extractNearThermalFn(tt.source)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("extractNearThermalFn() expected error, got %v", target)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractNearThermalFn() unexpected error: %v", err)
+			}
+			want := &NearThermalParams{
+				Thermal:       "base64;encoded",
+				TargetVectors: []string{"targetVector1", "targetVector2"},
+			}
+			if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(target, tt.wantTarget) {
+				t.Errorf("extractNearThermalFn() = %v, %v, want %v, %v", got, target, want, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func Test_extractNearThermalFnWithInvalidAutocut(t *testing.T) {
+	tests := []struct {
+		name    string
+		autocut interface{}
+	}{
+		{name: "negative", autocut: -1},
+		{name: "negative float64", autocut: float64(-1)},
+		{name: "not a number", autocut: "1"},
+		{name: "fraction", autocut: 1.5},
+		{name: "json.Number fraction", autocut: json.Number("2.5")},
+		{name: "invalid json.Number", autocut: json.Number("abc")},
+		{name: "not finite", autocut: math.Inf(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := extractNearThermalFn(map[string]interface{}{
+				"thermal": "base64;encoded",
+				"autocut": tt.autocut,
+			})
+			if err == nil {
+				t.Errorf("extractNearThermalFn() expected error for autocut %v", tt.autocut)
+			}
+		})
+	}
+}
+
+func Test_extractNearThermalFnWithNumericAutocut(t *testing.T) {
+	tests := []struct {
+		name    string
+		autocut interface{}
+	}{
+		{name: "int", autocut: int(2)},
+		{name: "int64", autocut: int64(2)},
+		{name: "float64", autocut: float64(2)},
+		{name: "json.Number", autocut: json.Number("2")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := extractNearThermalFn(map[string]interface{}{
+				"thermal": "base64;encoded",
+				"autocut": tt.autocut,
+			})
+			if err != nil {
+				t.Fatalf("extractNearThermalFn() unexpected error: %v", err)
+			}
+			if autocut := got.(*NearThermalParams).Autocut; autocut != 2 {
+				t.Errorf("extractNearThermalFn() autocut = %v, want 2", autocut)
+			}
+		})
+	}
+}
+
+func Test_extractNearThermalFnWithInvalidFilterStrategy(t *testing.T) {
+	_, _, err := extractNearThermalFn(map[string]interface{}{
+		"thermal":        "base64;encoded",
+		"filterStrategy": 1,
+	})
+	if err == nil {
+		t.Errorf("extractNearThermalFn() expected error for non-string filterStrategy")
+	}
+}
+
+func Test_extractNearThermalFnWithNumericRepresentations(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  float64
+	}{
+		{name: "float64", value: float64(0.5), want: 0.5},
+		{name: "int", value: int(1), want: 1},
+		{name: "int64", value: int64(1), want: 1},
+		{name: "json.Number", value: json.Number("0.25"), want: 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := extractNearThermalFn(map[string]interface{}{
+				"thermal":   "base64;encoded",
+				"certainty": tt.value,
+			})
+			if err != nil {
+				t.Fatalf("extractNearThermalFn() unexpected error for certainty: %v", err)
+			}
+			if certainty := got.(*NearThermalParams).Certainty; certainty != tt.want {
+				t.Errorf("extractNearThermalFn() certainty = %v, want %v", certainty, tt.want)
+			}
+
+			got, _, err = extractNearThermalFn(map[string]interface{}{
+				"thermal":  "base64;encoded",
+				"distance": tt.value,
+			})
+			if err != nil {
+				t.Fatalf("extractNearThermalFn() unexpected error for distance: %v", err)
+			}
+			params := got.(*NearThermalParams)
+			if params.Distance != tt.want || !params.WithDistance {
+				t.Errorf("extractNearThermalFn() distance = %v, want %v", params.Distance, tt.want)
+			}
+		})
+	}
+}
+
+func Test_extractNearThermalFnWithNonNumericValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "string", value: "0.5"},
+		{name: "bool", value: true},
+		{name: "invalid json.Number", value: json.Number("abc")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, field := range []string{"certainty", "distance"} {
+				_, _, err := extractNearThermalFn(map[string]interface{}{
+					"thermal": "base64;encoded",
+					field:     tt.value,
+				})
+				if err == nil {
+					t.Errorf("extractNearThermalFn() expected error for %s %v", field, tt.value)
+				}
+			}
+		})
+	}
+}
+
+func ptFloat64(f float64) *float64 {
+	return &f
+}
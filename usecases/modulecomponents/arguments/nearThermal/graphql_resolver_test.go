@@ -0,0 +1,113 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tailor-inc/graphql"
+	"github.com/weaviate/weaviate/entities/dto"
+)
+
+// TestNearThermalResolver parses full queries with the nearThermal argument
+// of Get and passes the parsed argument on to extractNearThermalFn, just like
+// the Get resolver does
+func TestNearThermalResolver(t *testing.T) {
+	var params *NearThermalParams
+	var combination *dto.TargetCombination
+	var extractErr error
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"Thermal": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{
+						Name: getNearThermalArgumentFn("Thermal"),
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						source, ok := p.Args[Name].(map[string]interface{})
+						require.True(t, ok)
+						var extracted interface{}
+						extracted, combination, extractErr = extractNearThermalFn(source)
+						params, _ = extracted.(*NearThermalParams)
+						return "ok", nil
+					},
+				},
+			},
+		}),
+	})
+	require.NoError(t, err)
+
+	do := func(t *testing.T, query string) {
+		params, combination, extractErr = nil, nil, nil
+		res := graphql.Do(graphql.Params{Schema: schema, RequestString: query})
+		require.Empty(t, res.Errors)
+		require.NoError(t, extractErr)
+	}
+
+	t.Run("all fields", func(t *testing.T) {
+		do(t, `{ Thermal(nearThermal: {
+			thermal: "iVBORw0KGgo="
+			certainty: 0.7
+			distance: 0.3
+			autocut: 2
+			additionalCollections: ["Other"]
+			deduplicateExact: true
+			targetVectors: ["first", "second"]
+			combinationMethod: "manualWeights"
+			weights: {first: 0.25, second: 0.75}
+		}) }`)
+
+		assert.Equal(t, &NearThermalParams{
+			Thermal:               "iVBORw0KGgo=",
+			Certainty:             0.7,
+			Distance:              0.3,
+			WithDistance:          true,
+			Autocut:               2,
+			AdditionalCollections: []string{"Other"},
+			DeduplicateExact:      true,
+			TargetVectors:         []string{"first", "second"},
+		}, params)
+		assert.Equal(t, &dto.TargetCombination{
+			Type: dto.ManualWeights, Weights: []float32{0.25, 0.75},
+		}, combination)
+	})
+
+	t.Run("thermal URL", func(t *testing.T) {
+		do(t, `{ Thermal(nearThermal: {thermalURL: "https://example.com/thermal.png"}) }`)
+
+		assert.Equal(t, "https://example.com/thermal.png", params.ThermalURL)
+		assert.Empty(t, params.Thermal)
+	})
+
+	t.Run("targets", func(t *testing.T) {
+		do(t, `{ Thermal(nearThermal: {
+			thermal: "iVBORw0KGgo="
+			targets: {targetVectors: ["first", "second"], combinationMethod: minimum}
+		}) }`)
+
+		assert.Equal(t, []string{"first", "second"}, params.TargetVectors)
+		assert.Equal(t, dto.Minimum, combination.Type)
+	})
+
+	t.Run("invalid field type", func(t *testing.T) {
+		res := graphql.Do(graphql.Params{
+			Schema:        schema,
+			RequestString: `{ Thermal(nearThermal: {thermal: "iVBORw0KGgo=", autocut: "two"}) }`,
+		})
+		assert.NotEmpty(t, res.Errors)
+	})
+}
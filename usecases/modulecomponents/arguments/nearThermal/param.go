@@ -13,14 +13,37 @@ package nearThermal
 
 import (
 	"errors"
+	"net/url"
+
+	"github.com/weaviate/weaviate/entities/vectorindex/hnsw"
 )
 
 type NearThermalParams struct {
-	Thermal       string
+	Thermal string
+	// ThermalURL references a thermal image which is fetched server-side
+	// instead of being passed inline in Thermal, see ThermalURLVectorizer.
+	// Exactly one of both needs to be set.
+	ThermalURL    string
 	Certainty     float64
 	Distance      float64
 	WithDistance  bool
 	TargetVectors []string
+	// Autocut limits the results to the first Autocut clusters of similar
+	// scores, 0 disables it
+	Autocut int
+	// AdditionalCollections are searched alongside the queried collection,
+	// the results of all collections are merged by distance
+	AdditionalCollections []string
+	// DeduplicateExact reuses the vector of a previous query with the exact
+	// same thermal image instead of vectorizing it again
+	DeduplicateExact bool
+	// MaxCertainty excludes results which are more similar than this, e.g.
+	// near-duplicates of the query. nil disables the upper bound.
+	MaxCertainty *float64
+	// FilterStrategy overrides the filter strategy of the vector index for
+	// this query if it is combined with a where filter, empty uses the one
+	// configured on the index
+	FilterStrategy string
 }
 
 func (n NearThermalParams) GetCertainty() float64 {
@@ -39,14 +62,41 @@ func (n NearThermalParams) GetTargetVectors() []string {
 	return n.TargetVectors
 }
 
+func (n NearThermalParams) GetAutocut() int {
+	return n.Autocut
+}
+
+func (n NearThermalParams) GetAdditionalCollections() []string {
+	return n.AdditionalCollections
+}
+
+func (n NearThermalParams) GetMaxCertainty() *float64 {
+	return n.MaxCertainty
+}
+
+func (n NearThermalParams) GetFilterStrategy() string {
+	return n.FilterStrategy
+}
+
 func validateNearThermalFn(param interface{}) error {
 	nearThermal, ok := param.(*NearThermalParams)
 	if !ok {
 		return errors.New("'nearThermal' invalid parameter")
 	}
 
-	if len(nearThermal.Thermal) == 0 {
-		return errors.New("'nearThermal.thermal' needs to be defined")
+	if len(nearThermal.Thermal) == 0 && len(nearThermal.ThermalURL) == 0 {
+		return errors.New("'nearThermal.thermal' or 'nearThermal.thermalURL' needs to be defined")
+	}
+
+	if len(nearThermal.Thermal) > 0 && len(nearThermal.ThermalURL) > 0 {
+		return errors.New("nearThermal cannot provide both thermal and thermalURL")
+	}
+
+	if len(nearThermal.ThermalURL) > 0 {
+		u, err := url.Parse(nearThermal.ThermalURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("'nearThermal.thermalURL' needs to be an absolute http or https URL")
+		}
 	}
 
 	if nearThermal.Certainty != 0 && nearThermal.WithDistance {
@@ -54,6 +104,27 @@ func validateNearThermalFn(param interface{}) error {
 			"nearThermal cannot provide both distance and certainty")
 	}
 
+	if maxCertainty := nearThermal.MaxCertainty; maxCertainty != nil {
+		if *maxCertainty <= 0 || *maxCertainty > 1 {
+			return errors.New("'nearThermal.maxCertainty' needs to be greater than 0 and at most 1")
+		}
+		if nearThermal.Certainty != 0 && nearThermal.Certainty >= *maxCertainty {
+			return errors.New("'nearThermal.maxCertainty' needs to be greater than 'nearThermal.certainty'")
+		}
+	}
+
+	switch nearThermal.FilterStrategy {
+	case "", hnsw.FilterStrategySweeping, hnsw.FilterStrategyAcorn:
+	default:
+		return errors.New("'nearThermal.filterStrategy' must be either 'sweeping' or 'acorn'")
+	}
+
+	for _, collection := range nearThermal.AdditionalCollections {
+		if collection == "" {
+			return errors.New("'nearThermal.additionalCollections' must not contain empty collection names")
+		}
+	}
+
 	if len(nearThermal.TargetVectors) > 1 {
 		return errors.New(
 			"nearThermal.targetVectors cannot provide more than 1 target vector value")
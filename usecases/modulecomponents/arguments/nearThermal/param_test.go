@@ -46,6 +46,42 @@ func Test_validateNearThermalFn(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "should pass with thermalURL",
+			args: args{
+				param: &NearThermalParams{
+					ThermalURL: "https://example.com/thermal.png",
+				},
+			},
+		},
+		{
+			name: "should not pass with both thermal and thermalURL",
+			args: args{
+				param: &NearThermalParams{
+					Thermal:    "base64;enncoded",
+					ThermalURL: "https://example.com/thermal.png",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "should not pass with relative thermalURL",
+			args: args{
+				param: &NearThermalParams{
+					ThermalURL: "/thermal.png",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "should not pass with non http thermalURL",
+			args: args{
+				param: &NearThermalParams{
+					ThermalURL: "file:///etc/passwd",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "should not pass with struct param, not a pointer to struct",
 			args: args{
@@ -67,6 +103,84 @@ func Test_validateNearThermalFn(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "should pass with additional collections",
+			args: args{
+				param: &NearThermalParams{
+					Thermal:               "thermal",
+					AdditionalCollections: []string{"Collection1", "Collection2"},
+				},
+			},
+		},
+		{
+			name: "should not pass with empty additional collection",
+			args: args{
+				param: &NearThermalParams{
+					Thermal:               "thermal",
+					AdditionalCollections: []string{"Collection1", ""},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "should pass with certainty below maxCertainty",
+			args: args{
+				param: &NearThermalParams{
+					Thermal:      "thermal",
+					Certainty:    0.7,
+					MaxCertainty: ptFloat64(0.95),
+				},
+			},
+		},
+		{
+			name: "should not pass with certainty above maxCertainty",
+			args: args{
+				param: &NearThermalParams{
+					Thermal:      "thermal",
+					Certainty:    0.95,
+					MaxCertainty: ptFloat64(0.7),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "should not pass with maxCertainty out of range",
+			args: args{
+				param: &NearThermalParams{
+					Thermal:      "thermal",
+					MaxCertainty: ptFloat64(1.5),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "should pass with sweeping filterStrategy",
+			args: args{
+				param: &NearThermalParams{
+					Thermal:        "thermal",
+					FilterStrategy: "sweeping",
+				},
+			},
+		},
+		{
+			name: "should pass with acorn filterStrategy",
+			args: args{
+				param: &NearThermalParams{
+					Thermal:        "thermal",
+					FilterStrategy: "acorn",
+				},
+			},
+		},
+		{
+			name: "should not pass with unsupported filterStrategy",
+			args: args{
+				param: &NearThermalParams{
+					Thermal:        "thermal",
+					FilterStrategy: "postFilter",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "should not pass with more then 1 target vector",
 			args: args{
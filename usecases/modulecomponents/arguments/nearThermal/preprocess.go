@@ -0,0 +1,79 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// PreprocessFn transforms base64 encoded thermal data before it is passed to
+// the vectorizer, e.g. to correct fixed offsets or the gain of a camera
+type PreprocessFn func(rawBase64 string) (processedBase64 string, err error)
+
+// ChainPreprocessors combines fns into a single PreprocessFn which applies
+// them in the given order. It stops at the first error.
+func ChainPreprocessors(fns ...PreprocessFn) PreprocessFn {
+	return func(rawBase64 string) (string, error) {
+		processed := rawBase64
+		for i, fn := range fns {
+			var err error
+			if processed, err = fn(processed); err != nil {
+				return "", fmt.Errorf("preprocessing step %d: %w", i, err)
+			}
+		}
+		return processed, nil
+	}
+}
+
+// NormalizeTemperatureRange returns a PreprocessFn for radiometric images,
+// whose gray values are temperatures in Kelvin. The values are clamped to
+// [minK, maxK] and scaled linearly to [0, 255]. The result is an 8-bit
+// grayscale PNG.
+func NormalizeTemperatureRange(minK, maxK float64) PreprocessFn {
+	return func(rawBase64 string) (string, error) {
+		if minK < 0 || maxK <= minK {
+			return "", fmt.Errorf("invalid temperature range [%v, %v]", minK, maxK)
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(rawBase64)
+		if err != nil {
+			return "", fmt.Errorf("decode base64: %w", err)
+		}
+		img, _, err := image.Decode(bytes.NewReader(raw))
+		if err != nil {
+			return "", fmt.Errorf("decode image: %w", err)
+		}
+
+		bounds := img.Bounds()
+		out := image.NewGray(bounds)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				kelvin := float64(color.Gray16Model.Convert(img.At(x, y)).(color.Gray16).Y)
+				kelvin = math.Max(minK, math.Min(maxK, kelvin))
+				scaled := (kelvin - minK) / (maxK - minK) * 255
+				out.SetGray(x, y, color.Gray{Y: uint8(math.Round(scaled))})
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, out); err != nil {
+			return "", fmt.Errorf("encode image: %w", err)
+		}
+		return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+	}
+}
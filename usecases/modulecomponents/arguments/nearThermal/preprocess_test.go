@@ -0,0 +1,113 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/moduletools"
+)
+
+func encodeGray16(t *testing.T, kelvins ...uint16) string {
+	img := image.NewGray16(image.Rect(0, 0, len(kelvins), 1))
+	for x, k := range kelvins {
+		img.SetGray16(x, 0, color.Gray16{Y: k})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func decodeGray(t *testing.T, in string) []uint8 {
+	raw, err := base64.StdEncoding.DecodeString(in)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(raw))
+	require.NoError(t, err)
+
+	out := make([]uint8, img.Bounds().Dx())
+	for x := range out {
+		out[x] = color.GrayModel.Convert(img.At(x, 0)).(color.Gray).Y
+	}
+	return out
+}
+
+func TestNormalizeTemperatureRange(t *testing.T) {
+	t.Run("scales and clamps to the range", func(t *testing.T) {
+		normalize := NormalizeTemperatureRange(273, 373)
+		out, err := normalize(encodeGray16(t, 200, 273, 323, 373, 500))
+		require.NoError(t, err)
+		assert.Equal(t, []uint8{0, 0, 128, 255, 255}, decodeGray(t, out))
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := NormalizeTemperatureRange(373, 273)(encodeGray16(t, 300))
+		assert.ErrorContains(t, err, "invalid temperature range")
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := NormalizeTemperatureRange(273, 373)("not base64!")
+		assert.ErrorContains(t, err, "decode base64")
+
+		_, err = NormalizeTemperatureRange(273, 373)(base64.StdEncoding.EncodeToString([]byte("no image")))
+		assert.ErrorContains(t, err, "decode image")
+	})
+}
+
+func TestChainPreprocessors(t *testing.T) {
+	appendFn := func(suffix string) PreprocessFn {
+		return func(in string) (string, error) { return in + suffix, nil }
+	}
+
+	out, err := ChainPreprocessors(appendFn("a"), appendFn("b"))("x")
+	require.NoError(t, err)
+	assert.Equal(t, "xab", out)
+
+	out, err = ChainPreprocessors()("x")
+	require.NoError(t, err)
+	assert.Equal(t, "x", out)
+
+	failing := func(string) (string, error) { return "", errors.New("boom") }
+	_, err = ChainPreprocessors(appendFn("a"), failing, appendFn("b"))("x")
+	assert.ErrorContains(t, err, "preprocessing step 1: boom")
+}
+
+type recordingVectorizer struct {
+	inputs []string
+}
+
+func (v *recordingVectorizer) VectorizeThermal(ctx context.Context,
+	thermal string, cfg moduletools.ClassConfig,
+) ([]float32, error) {
+	v.inputs = append(v.inputs, thermal)
+	return []float32{1}, nil
+}
+
+func TestVectorForParamsPreprocessor(t *testing.T) {
+	vectorizer := &recordingVectorizer{}
+	s := NewSearcher[[]float32](vectorizer).WithPreprocessor(func(in string) (string, error) {
+		return "processed:" + in, nil
+	})
+
+	_, err := s.VectorSearches()["nearThermal"].VectorForParams(context.Background(),
+		&NearThermalParams{Thermal: "aW1hZ2U="}, "Class", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"processed:aW1hZ2U="}, vectorizer.inputs)
+}
@@ -12,7 +12,12 @@
 package nearThermal
 
 import (
+	"container/list"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/weaviate/weaviate/entities/dto"
@@ -20,36 +25,223 @@ import (
 	"github.com/weaviate/weaviate/entities/moduletools"
 )
 
+// maxCachedVectors bounds the number of vectors cached for deduplicateExact
+// queries. Once it is reached, the least recently used vector is evicted.
+const maxCachedVectors = 1000
+
 type Searcher[T dto.Embedding] struct {
-	vectorizer bindVectorizer[T]
+	vectorizer  bindVectorizer[T]
+	cache       *vectorCache[T]
+	preprocess  PreprocessFn
+	vectorSpace *VectorSpaceValidator
 }
 
 func NewSearcher[T dto.Embedding](vectorizer bindVectorizer[T]) *Searcher[T] {
-	return &Searcher[T]{vectorizer}
+	return &Searcher[T]{vectorizer: vectorizer, cache: newVectorCache[T](maxCachedVectors)}
+}
+
+// WithPreprocessor applies fn to inline thermal data before it is vectorized.
+// Use ChainPreprocessors to apply multiple steps. Thermal data referenced by a
+// thermalURL is fetched by the vectorizer and is not preprocessed.
+func (s *Searcher[T]) WithPreprocessor(fn PreprocessFn) *Searcher[T] {
+	s.preprocess = fn
+	return s
+}
+
+// WithVectorSpaceValidator makes nearThermal queries against a vector space
+// of another vectorizer fail with an error which names the vectorizers that
+// are accepted by v.
+func (s *Searcher[T]) WithVectorSpaceValidator(v *VectorSpaceValidator) *Searcher[T] {
+	s.vectorSpace = v
+	return s
 }
 
 type bindVectorizer[T dto.Embedding] interface {
 	VectorizeThermal(ctx context.Context, thermal string, cfg moduletools.ClassConfig) (T, error)
 }
 
+// ThermalURLVectorizer is implemented by vectorizers which support nearThermal
+// queries with a thermalURL. The vectorizer is responsible for fetching the
+// thermal image from the URL and decoding it, the URL is passed on unchanged.
+// As the URL is user supplied, implementations need to restrict what they
+// fetch, e.g. to an allow-list of hosts which is empty by default. Queries
+// with a thermalURL fail for vectorizers which don't implement it.
+type ThermalURLVectorizer[T dto.Embedding] interface {
+	VectorizeThermalURL(ctx context.Context, thermalURL string, cfg moduletools.ClassConfig) (T, error)
+}
+
 func (s *Searcher[T]) VectorSearches() map[string]modulecapabilities.VectorForParams[T] {
 	vectorSearches := map[string]modulecapabilities.VectorForParams[T]{}
-	vectorSearches["nearThermal"] = &vectorForParams[T]{s.vectorizer}
+	vectorSearches["nearThermal"] = &vectorForParams[T]{s.vectorizer, s.cache, s.preprocess, s.vectorSpace}
 	return vectorSearches
 }
 
 type vectorForParams[T dto.Embedding] struct {
-	vectorizer bindVectorizer[T]
+	vectorizer  bindVectorizer[T]
+	cache       *vectorCache[T]
+	preprocess  PreprocessFn
+	vectorSpace *VectorSpaceValidator
+}
+
+// ValidateVectorSpace implements modulecapabilities.VectorSpaceValidator
+func (v *vectorForParams[T]) ValidateVectorSpace(className, targetVector, vectorizer string) error {
+	if v.vectorSpace == nil {
+		return nil
+	}
+	return v.vectorSpace.Validate(className, targetVector, vectorizer)
 }
 
 func (v *vectorForParams[T]) VectorForParams(ctx context.Context, params interface{}, className string,
 	findVectorFn modulecapabilities.FindVectorFn[T],
 	cfg moduletools.ClassConfig,
 ) (T, error) {
+	nearThermal := params.(*NearThermalParams)
+
+	if nearThermal.ThermalURL != "" {
+		// the image behind a URL can change, so it is never deduplicated
+		urlVectorizer, ok := v.vectorizer.(ThermalURLVectorizer[T])
+		if !ok {
+			return nil, errors.New("vectorizer does not support nearThermal.thermalURL")
+		}
+		vector, err := callWithTimeout(ctx, cfg, func(ctx context.Context) (T, error) {
+			return urlVectorizer.VectorizeThermalURL(ctx, nearThermal.ThermalURL, cfg)
+		})
+		if err != nil {
+			// wrapped, so callers can detect a *VectorizerTimeoutError
+			return nil, errors.Wrap(err, "vectorize thermal URL")
+		}
+		return vector, nil
+	}
+
+	var key vectorCacheKey
+	if nearThermal.DeduplicateExact {
+		key = newVectorCacheKey(nearThermal.Thermal, className, cfg)
+		if vector, ok := v.cache.get(key); ok {
+			return vector, nil
+		}
+	}
+
+	// the cache is keyed by the raw input, preprocessing is deterministic
+	thermal := nearThermal.Thermal
+	if v.preprocess != nil {
+		processed, err := v.preprocess(thermal)
+		if err != nil {
+			return nil, errors.Errorf("preprocess thermal: %v", err)
+		}
+		thermal = processed
+	}
+
 	// find vector for given search query
-	vector, err := v.vectorizer.VectorizeThermal(ctx, params.(*NearThermalParams).Thermal, cfg)
+	vector, err := callWithTimeout(ctx, cfg, func(ctx context.Context) (T, error) {
+		return v.vectorizer.VectorizeThermal(ctx, thermal, cfg)
+	})
 	if err != nil {
-		return nil, errors.Errorf("vectorize thermal: %v", err)
+		return nil, errors.Wrap(err, "vectorize thermal")
+	}
+
+	if nearThermal.DeduplicateExact {
+		v.cache.put(key, vector)
 	}
 	return vector, nil
 }
+
+// vectorCacheKey identifies a thermal image by the hash of its raw bytes.
+// The same image is vectorized differently depending on the collection, its
+// target vector and the module config, e.g. the model, so they are part of
+// the key. A changed config therefore never hits vectors of the previous one.
+type vectorCacheKey struct {
+	hash         [sha256.Size]byte
+	className    string
+	targetVector string
+	configHash   [sha256.Size]byte
+}
+
+func newVectorCacheKey(thermal, className string, cfg moduletools.ClassConfig) vectorCacheKey {
+	// hash the decoded image rather than its encoding. Input which is not
+	// valid standard base64 is hashed as is.
+	raw, err := base64.StdEncoding.DecodeString(thermal)
+	if err != nil {
+		raw = []byte(thermal)
+	}
+
+	key := vectorCacheKey{hash: sha256.Sum256(raw), className: className}
+	if cfg != nil {
+		key.targetVector = cfg.TargetVector()
+		// maps are marshalled with sorted keys, so equal configs produce equal
+		// hashes. A config which can't be marshalled is hashed as empty, which
+		// at worst shares entries between such configs of the same collection.
+		config, _ := json.Marshal(cfg.Class())
+		key.configHash = sha256.Sum256(config)
+	}
+	return key
+}
+
+// vectorCache is a fixed size LRU cache of query vectors
+type vectorCache[T dto.Embedding] struct {
+	lock       sync.Mutex
+	entries    map[vectorCacheKey]*list.Element
+	lru        *list.List // front is the most recently used entry
+	maxEntries int
+}
+
+type vectorCacheEntry[T dto.Embedding] struct {
+	key    vectorCacheKey
+	vector T
+}
+
+func newVectorCache[T dto.Embedding](maxEntries int) *vectorCache[T] {
+	return &vectorCache[T]{
+		entries:    map[vectorCacheKey]*list.Element{},
+		lru:        list.New(),
+		maxEntries: maxEntries,
+	}
+}
+
+func (c *vectorCache[T]) get(key vectorCacheKey) (T, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return copyVector(elem.Value.(*vectorCacheEntry[T]).vector), true
+}
+
+func (c *vectorCache[T]) put(key vectorCacheKey, vector T) {
+	vector = copyVector(vector)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*vectorCacheEntry[T]).vector = vector
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&vectorCacheEntry[T]{key: key, vector: vector})
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*vectorCacheEntry[T]).key)
+	}
+}
+
+// copyVector returns a deep copy of vector, so that callers of the cache can
+// modify the vectors they put or get without affecting each other
+func copyVector[T dto.Embedding](vector T) T {
+	switch v := any(vector).(type) {
+	case []float32:
+		return any(append([]float32(nil), v...)).(T)
+	case [][]float32:
+		out := make([][]float32, len(v))
+		for i := range v {
+			out[i] = append([]float32(nil), v[i]...)
+		}
+		return any(out).(T)
+	default:
+		return vector
+	}
+}
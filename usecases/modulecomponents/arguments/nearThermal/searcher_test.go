@@ -0,0 +1,204 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/modulecapabilities"
+	"github.com/weaviate/weaviate/entities/moduletools"
+)
+
+type countingVectorizer struct {
+	calls int
+}
+
+func (v *countingVectorizer) VectorizeThermal(ctx context.Context,
+	thermal string, cfg moduletools.ClassConfig,
+) ([]float32, error) {
+	v.calls++
+	return []float32{float32(len(thermal)), float32(v.calls)}, nil
+}
+
+type urlVectorizer struct {
+	countingVectorizer
+	urls []string
+}
+
+func (v *urlVectorizer) VectorizeThermalURL(ctx context.Context,
+	thermalURL string, cfg moduletools.ClassConfig,
+) ([]float32, error) {
+	v.urls = append(v.urls, thermalURL)
+	return []float32{1, 2}, nil
+}
+
+func TestVectorForParamsThermalURL(t *testing.T) {
+	params := &NearThermalParams{ThermalURL: "https://example.com/thermal.png", DeduplicateExact: true}
+
+	t.Run("URL is passed on to the vectorizer", func(t *testing.T) {
+		vectorizer := &urlVectorizer{}
+		s := NewSearcher[[]float32](vectorizer)
+
+		for i := 0; i < 2; i++ {
+			vector, err := s.VectorSearches()["nearThermal"].VectorForParams(context.Background(),
+				params, "Class", nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, []float32{1, 2}, vector)
+		}
+		// never deduplicated, the image behind the URL may change
+		assert.Equal(t, []string{params.ThermalURL, params.ThermalURL}, vectorizer.urls)
+		assert.Equal(t, 0, vectorizer.calls)
+	})
+
+	t.Run("vectorizer without URL support", func(t *testing.T) {
+		s := NewSearcher[[]float32](&countingVectorizer{})
+		_, err := s.VectorSearches()["nearThermal"].VectorForParams(context.Background(),
+			params, "Class", nil, nil)
+		assert.ErrorContains(t, err, "does not support nearThermal.thermalURL")
+	})
+}
+
+func TestVectorForParamsValidateVectorSpace(t *testing.T) {
+	t.Run("without validator every vector space is accepted", func(t *testing.T) {
+		s := NewSearcher[[]float32](&countingVectorizer{})
+		validator, ok := s.VectorSearches()["nearThermal"].(modulecapabilities.VectorSpaceValidator)
+		require.True(t, ok)
+		assert.NoError(t, validator.ValidateVectorSpace("Inspection", "notes", "text2vec-openai"))
+	})
+
+	t.Run("with validator", func(t *testing.T) {
+		s := NewSearcher[[]float32](&countingVectorizer{}).
+			WithVectorSpaceValidator(NewVectorSpaceValidator([]string{"multi2vec-bind"}))
+		validator, ok := s.VectorSearches()["nearThermal"].(modulecapabilities.VectorSpaceValidator)
+		require.True(t, ok)
+		assert.NoError(t, validator.ValidateVectorSpace("Inspection", "flir", "multi2vec-bind"))
+		assert.EqualError(t, validator.ValidateVectorSpace("Inspection", "notes", "text2vec-openai"),
+			`target vector "notes" of collection Inspection is vectorized by text2vec-openai, `+
+				`nearThermal requires one of: multi2vec-bind`)
+	})
+}
+
+func TestVectorForParamsDeduplicateExact(t *testing.T) {
+	vectorFor := func(s *Searcher[[]float32], params *NearThermalParams, className string) []float32 {
+		vector, err := s.VectorSearches()["nearThermal"].VectorForParams(context.Background(),
+			params, className, nil, nil)
+		require.NoError(t, err)
+		return vector
+	}
+
+	t.Run("identical images are vectorized once", func(t *testing.T) {
+		vectorizer := &countingVectorizer{}
+		s := NewSearcher[[]float32](vectorizer)
+		params := &NearThermalParams{Thermal: "aW1hZ2U=", DeduplicateExact: true}
+
+		first := vectorFor(s, params, "Class")
+		second := vectorFor(s, params, "Class")
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, vectorizer.calls)
+	})
+
+	t.Run("different images or collections are vectorized separately", func(t *testing.T) {
+		vectorizer := &countingVectorizer{}
+		s := NewSearcher[[]float32](vectorizer)
+
+		vectorFor(s, &NearThermalParams{Thermal: "aW1hZ2U=", DeduplicateExact: true}, "Class")
+		vectorFor(s, &NearThermalParams{Thermal: "b3RoZXI=", DeduplicateExact: true}, "Class")
+		vectorFor(s, &NearThermalParams{Thermal: "aW1hZ2U=", DeduplicateExact: true}, "OtherClass")
+		assert.Equal(t, 3, vectorizer.calls)
+	})
+
+	t.Run("without deduplicateExact every query is vectorized", func(t *testing.T) {
+		vectorizer := &countingVectorizer{}
+		s := NewSearcher[[]float32](vectorizer)
+		params := &NearThermalParams{Thermal: "aW1hZ2U="}
+
+		vectorFor(s, params, "Class")
+		vectorFor(s, params, "Class")
+		assert.Equal(t, 2, vectorizer.calls)
+	})
+
+	t.Run("changed module config is vectorized again", func(t *testing.T) {
+		vectorizer := &countingVectorizer{}
+		s := NewSearcher[[]float32](vectorizer)
+		params := &NearThermalParams{Thermal: "aW1hZ2U=", DeduplicateExact: true}
+		vectorForConfig := func(cfg moduletools.ClassConfig) {
+			_, err := s.VectorSearches()["nearThermal"].VectorForParams(context.Background(),
+				params, "Class", nil, cfg)
+			require.NoError(t, err)
+		}
+
+		vectorForConfig(fakeClassConfig{classConfig: map[string]interface{}{"model": "a"}})
+		vectorForConfig(fakeClassConfig{classConfig: map[string]interface{}{"model": "a"}})
+		vectorForConfig(fakeClassConfig{classConfig: map[string]interface{}{"model": "b"}})
+		assert.Equal(t, 2, vectorizer.calls)
+	})
+
+	t.Run("least recently used vector is evicted", func(t *testing.T) {
+		cache := newVectorCache[[]float32](2)
+		first := newVectorCacheKey("aW1hZ2U=", "Class", nil)
+		second := newVectorCacheKey("b3RoZXI=", "Class", nil)
+		third := newVectorCacheKey("dGhpcmQ=", "Class", nil)
+
+		cache.put(first, []float32{1})
+		cache.put(second, []float32{2})
+		_, ok := cache.get(first)
+		require.True(t, ok)
+		cache.put(third, []float32{3})
+
+		_, ok = cache.get(first)
+		assert.True(t, ok)
+		_, ok = cache.get(second)
+		assert.False(t, ok)
+		vector, ok := cache.get(third)
+		assert.True(t, ok)
+		assert.Equal(t, []float32{3}, vector)
+	})
+
+	t.Run("cached vectors are not shared", func(t *testing.T) {
+		cache := newVectorCache[[]float32](2)
+		key := newVectorCacheKey("aW1hZ2U=", "Class", nil)
+
+		vector := []float32{1, 2}
+		cache.put(key, vector)
+		vector[0] = 10
+
+		got, ok := cache.get(key)
+		require.True(t, ok)
+		assert.Equal(t, []float32{1, 2}, got)
+		got[1] = 20
+
+		got, ok = cache.get(key)
+		require.True(t, ok)
+		assert.Equal(t, []float32{1, 2}, got)
+	})
+
+	t.Run("cached multi vectors are not shared", func(t *testing.T) {
+		cache := newVectorCache[[][]float32](2)
+		key := newVectorCacheKey("aW1hZ2U=", "Class", nil)
+
+		vector := [][]float32{{1, 2}, {3}}
+		cache.put(key, vector)
+		vector[0][0] = 10
+
+		got, ok := cache.get(key)
+		require.True(t, ok)
+		assert.Equal(t, [][]float32{{1, 2}, {3}}, got)
+		got[1][0] = 30
+
+		got, ok = cache.get(key)
+		require.True(t, ok)
+		assert.Equal(t, [][]float32{{1, 2}, {3}}, got)
+	})
+}
@@ -0,0 +1,107 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/weaviate/weaviate/entities/moduletools"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+// vectorizerCallTimeoutProperty is the class config option bounding the time
+// a single vectorizer call of a nearThermal search may take. It is either a
+// duration string such as "5s" or a number of seconds. Without it, the call
+// is only bounded by the context of the request.
+const vectorizerCallTimeoutProperty = "vectorizerCallTimeout"
+
+// VectorizerTimeoutError is returned if the vectorizer did not answer within
+// the vectorizerCallTimeout. errors.Is reports true for any
+// *VectorizerTimeoutError target and for context.DeadlineExceeded.
+type VectorizerTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *VectorizerTimeoutError) Error() string {
+	return fmt.Sprintf("vectorizer did not respond within %s", e.Timeout)
+}
+
+func (e *VectorizerTimeoutError) Is(target error) bool {
+	_, ok := target.(*VectorizerTimeoutError)
+	return ok
+}
+
+func (e *VectorizerTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// vectorizerCallTimeout returns the vectorizerCallTimeout of the class config,
+// 0 if it is not set
+func vectorizerCallTimeout(cfg moduletools.ClassConfig) (time.Duration, error) {
+	if cfg == nil {
+		return 0, nil
+	}
+	value, ok := cfg.Class()[vectorizerCallTimeoutProperty]
+	if !ok {
+		return 0, nil
+	}
+
+	var timeout time.Duration
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", vectorizerCallTimeoutProperty, err)
+		}
+		timeout = parsed
+	case float64:
+		timeout = time.Duration(v * float64(time.Second))
+	case int:
+		timeout = time.Duration(v) * time.Second
+	default:
+		return 0, fmt.Errorf("%s must be a duration string or a number of seconds, got %T",
+			vectorizerCallTimeoutProperty, value)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("%s must not be negative, got %s", vectorizerCallTimeoutProperty, timeout)
+	}
+	return timeout, nil
+}
+
+// callWithTimeout calls vectorize with a context bounded by the
+// vectorizerCallTimeout of cfg. If the timeout expires, a
+// *VectorizerTimeoutError is returned instead of the error of vectorize.
+func callWithTimeout[T any](ctx context.Context, cfg moduletools.ClassConfig,
+	vectorize func(ctx context.Context) (T, error),
+) (T, error) {
+	timeout, err := vectorizerCallTimeout(cfg)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if timeout == 0 {
+		return vectorize(ctx)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	vector, err := vectorize(callCtx)
+	if err != nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		monitoring.GetMetrics().VectorizerTimeoutCount.WithLabelValues("nearThermal").Inc()
+		return vector, &VectorizerTimeoutError{Timeout: timeout}
+	}
+	return vector, err
+}
@@ -0,0 +1,117 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/moduletools"
+	"github.com/weaviate/weaviate/usecases/monitoring"
+)
+
+// slowVectorizer answers after delay, unless ctx expires first
+type slowVectorizer struct {
+	delay time.Duration
+}
+
+func (v *slowVectorizer) VectorizeThermal(ctx context.Context,
+	thermal string, cfg moduletools.ClassConfig,
+) ([]float32, error) {
+	select {
+	case <-time.After(v.delay):
+		return []float32{1, 2}, nil
+	case <-ctx.Done():
+		return nil, errors.New("send POST request: " + ctx.Err().Error())
+	}
+}
+
+type fakeClassConfig struct {
+	classConfig map[string]interface{}
+}
+
+func (f fakeClassConfig) Class() map[string]interface{}                   { return f.classConfig }
+func (f fakeClassConfig) ClassByModuleName(string) map[string]interface{} { return f.classConfig }
+func (f fakeClassConfig) Property(string) map[string]interface{}          { return nil }
+func (f fakeClassConfig) Tenant() string                                  { return "" }
+func (f fakeClassConfig) TargetVector() string                            { return "" }
+
+func TestVectorForParamsTimeout(t *testing.T) {
+	params := &NearThermalParams{Thermal: "thermal"}
+	timeouts := func() float64 {
+		return testutil.ToFloat64(monitoring.GetMetrics().VectorizerTimeoutCount.WithLabelValues("nearThermal"))
+	}
+
+	vectorFor := func(ctx context.Context, delay time.Duration, timeout interface{}) ([]float32, error) {
+		cfg := fakeClassConfig{classConfig: map[string]interface{}{}}
+		if timeout != nil {
+			cfg.classConfig["vectorizerCallTimeout"] = timeout
+		}
+		s := NewSearcher[[]float32](&slowVectorizer{delay: delay})
+		return s.VectorSearches()["nearThermal"].VectorForParams(ctx, params, "Class", nil, cfg)
+	}
+
+	t.Run("vectorizer times out", func(t *testing.T) {
+		before := timeouts()
+		start := time.Now()
+
+		_, err := vectorFor(context.Background(), time.Minute, "50ms")
+		require.Error(t, err)
+		assert.Less(t, time.Since(start), 10*time.Second)
+
+		assert.ErrorIs(t, err, &VectorizerTimeoutError{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		var timeoutErr *VectorizerTimeoutError
+		require.True(t, errors.As(err, &timeoutErr))
+		assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+		assert.Equal(t, 1.0, timeouts()-before)
+	})
+
+	t.Run("vectorizer answers in time", func(t *testing.T) {
+		before := timeouts()
+
+		vector, err := vectorFor(context.Background(), 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []float32{1, 2}, vector)
+		assert.Equal(t, 0.0, timeouts()-before)
+	})
+
+	t.Run("request context expires first", func(t *testing.T) {
+		before := timeouts()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := vectorFor(ctx, time.Minute, "1m")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, &VectorizerTimeoutError{})
+		assert.Equal(t, 0.0, timeouts()-before)
+	})
+
+	t.Run("no timeout configured", func(t *testing.T) {
+		vector, err := vectorFor(context.Background(), 0, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []float32{1, 2}, vector)
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		_, err := vectorFor(context.Background(), 0, "soon")
+		assert.ErrorContains(t, err, "vectorizerCallTimeout")
+
+		_, err = vectorFor(context.Background(), 0, "-1s")
+		assert.ErrorContains(t, err, "must not be negative")
+	})
+}
@@ -0,0 +1,68 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VectorSpaceValidator verifies that the vector space searched by nearThermal
+// was produced by a vectorizer which supports thermal images. Otherwise a
+// query against e.g. a text2vec-openai vector space would compare the thermal
+// image with vectors of an unrelated embedding space.
+type VectorSpaceValidator struct {
+	thermalModules map[string]struct{}
+}
+
+// NewVectorSpaceValidator creates a validator which accepts vector spaces
+// configured with one of the given vectorizer modules
+func NewVectorSpaceValidator(thermalModules []string) *VectorSpaceValidator {
+	modules := make(map[string]struct{}, len(thermalModules))
+	for _, module := range thermalModules {
+		modules[module] = struct{}{}
+	}
+	return &VectorSpaceValidator{thermalModules: modules}
+}
+
+// Validate returns an error if vectorizer, the module the vector space of
+// targetVector is configured with, is not thermal compatible. An empty
+// targetVector refers to the class level vectorizer.
+func (v *VectorSpaceValidator) Validate(className, targetVector, vectorizer string) error {
+	if _, ok := v.thermalModules[vectorizer]; ok {
+		return nil
+	}
+
+	space := "collection " + className
+	if targetVector != "" {
+		space = fmt.Sprintf("target vector %q of collection %s", targetVector, className)
+	}
+	if vectorizer == "" || vectorizer == "none" {
+		return fmt.Errorf("%s has no vectorizer configured, %s requires one of: %s",
+			space, Name, v.moduleNames())
+	}
+	return fmt.Errorf("%s is vectorized by %s, %s requires one of: %s",
+		space, vectorizer, Name, v.moduleNames())
+}
+
+func (v *VectorSpaceValidator) moduleNames() string {
+	if len(v.thermalModules) == 0 {
+		return "a thermal vectorizer"
+	}
+	names := make([]string, 0, len(v.thermalModules))
+	for name := range v.thermalModules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
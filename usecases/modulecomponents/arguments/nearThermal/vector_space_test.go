@@ -0,0 +1,50 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package nearThermal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVectorSpaceValidator(t *testing.T) {
+	validator := NewVectorSpaceValidator([]string{"multi2vec-bind"})
+
+	t.Run("thermal vector spaces", func(t *testing.T) {
+		assert.NoError(t, validator.Validate("Inspection", "flir", "multi2vec-bind"))
+		assert.NoError(t, validator.Validate("Inspection", "borescope", "multi2vec-bind"))
+	})
+
+	t.Run("vector space of another vectorizer", func(t *testing.T) {
+		err := validator.Validate("Inspection", "notes", "text2vec-openai")
+		require.Error(t, err)
+		assert.Equal(t, `target vector "notes" of collection Inspection is vectorized by text2vec-openai, `+
+			`nearThermal requires one of: multi2vec-bind`, err.Error())
+	})
+
+	t.Run("vector space without vectorizer", func(t *testing.T) {
+		err := validator.Validate("Inspection", "custom", "none")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has no vectorizer configured")
+	})
+
+	t.Run("class level vectorizer", func(t *testing.T) {
+		assert.NoError(t, validator.Validate("Legacy", "", "multi2vec-bind"))
+
+		err := validator.Validate("Legacy", "", "text2vec-openai")
+		require.Error(t, err)
+		assert.Equal(t, "collection Legacy is vectorized by text2vec-openai, "+
+			"nearThermal requires one of: multi2vec-bind", err.Error())
+	})
+}
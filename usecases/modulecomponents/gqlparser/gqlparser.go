@@ -65,6 +65,17 @@ func GetValueAsStringArray(f *ast.ObjectField) []string {
 	return stopSequences
 }
 
+func GetValueAsIntArray(f *ast.ObjectField) []int {
+	vals := f.Value.GetValue().([]ast.Value)
+	var ints []int
+	for _, val := range vals {
+		if asInt, err := strconv.Atoi(val.GetValue().(string)); err == nil {
+			ints = append(ints, asInt)
+		}
+	}
+	return ints
+}
+
 func GetValueAsBool(f *ast.ObjectField) *bool {
 	asBool, ok := f.Value.GetValue().(bool)
 	if ok {
@@ -24,6 +24,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/tailor-inc/graphql"
 	"github.com/tailor-inc/graphql/language/ast"
+	"github.com/weaviate/weaviate/entities/errorcompounder"
 	"github.com/weaviate/weaviate/entities/models"
 	"github.com/weaviate/weaviate/entities/modulecapabilities"
 	"github.com/weaviate/weaviate/entities/moduletools"
@@ -107,6 +108,20 @@ func (p *Provider) SetSchemaGetter(sg schemaGetter) {
 	p.schemaGetter = sg
 }
 
+// Close releases the resources of all modules which implement
+// modulecapabilities.ModuleWithClose
+func (p *Provider) Close() error {
+	ec := errorcompounder.New()
+	for _, mod := range p.GetAll() {
+		if modClose, ok := mod.(modulecapabilities.ModuleWithClose); ok {
+			if err := modClose.Close(); err != nil {
+				ec.Add(errors.Wrapf(err, "close module %q", mod.Name()))
+			}
+		}
+	}
+	return ec.ToError()
+}
+
 func (p *Provider) Init(ctx context.Context,
 	params moduletools.ModuleInitParams, logger logrus.FieldLogger,
 ) error {
@@ -865,6 +880,9 @@ func (p *Provider) VectorFromSearchParam(ctx context.Context, className, targetV
 		}
 	}
 
+	if err := p.validateVectorSpace(class.Class, targetModule, targetVector, param); err != nil {
+		return nil, err
+	}
 	return nil, fmt.Errorf("could not vectorize input for collection %v with search-type %v, targetVector %v and parameters %v. Make sure a vectorizer module is configured for this class", className, param, targetVector, params)
 }
 
@@ -886,9 +904,34 @@ func (p *Provider) MultiVectorFromSearchParam(ctx context.Context, className, ta
 		}
 	}
 
+	if err := p.validateVectorSpace(class.Class, targetModule, targetVector, param); err != nil {
+		return nil, err
+	}
 	return nil, fmt.Errorf("could not vectorize input for collection %v with search-type %v, targetVector %v and parameters %v. Make sure a vectorizer module is configured for this class", className, param, targetVector, params)
 }
 
+// validateVectorSpace asks the modules which provide a vector search for param
+// whether the vector space of targetVector, which is configured with
+// targetModule, can be searched with it
+func (p *Provider) validateVectorSpace(className, targetModule, targetVector, param string) error {
+	var vectorSearches []interface{}
+	for _, mod := range p.GetAll() {
+		if searcher, ok := mod.(modulecapabilities.Searcher[[]float32]); ok {
+			vectorSearches = append(vectorSearches, searcher.VectorSearches()[param])
+		} else if searcher, ok := mod.(modulecapabilities.Searcher[[][]float32]); ok {
+			vectorSearches = append(vectorSearches, searcher.VectorSearches()[param])
+		}
+	}
+	for _, vectorSearch := range vectorSearches {
+		if validator, ok := vectorSearch.(modulecapabilities.VectorSpaceValidator); ok {
+			if err := validator.ValidateVectorSpace(className, targetVector, targetModule); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // CrossClassVectorFromSearchParam gets a vector for a given argument without
 // being specific to any one class and it's configuration. This is used in
 // Explore() { } for example
@@ -1058,6 +1101,19 @@ func (p *Provider) GetMeta() (map[string]interface{}, error) {
 	return metaInfos, nil
 }
 
+// Health checks all modules which implement modulecapabilities.HealthChecker
+// and returns the first error encountered
+func (p *Provider) Health(ctx context.Context) error {
+	for _, module := range p.GetAll() {
+		if c, ok := module.(modulecapabilities.HealthChecker); ok {
+			if err := c.Health(ctx); err != nil {
+				return errors.Wrapf(err, "module %q", module.Name())
+			}
+		}
+	}
+	return nil
+}
+
 func (p *Provider) getClass(className string) (*models.Class, error) {
 	class := p.schemaGetter.ReadOnlyClass(className)
 	if class == nil {
@@ -551,3 +551,29 @@ func (m *dummyBackupModuleWithAltNames) PutFile(ctx context.Context, backupID, k
 func (m *dummyBackupModuleWithAltNames) Initialize(ctx context.Context, backupID, overrideBucket, overridePath string) error {
 	return nil
 }
+
+type dummyClosingModule struct {
+	dummyNonVectorizerModule
+	closed *bool
+	err    error
+}
+
+func (m dummyClosingModule) Close() error {
+	*m.closed = true
+	return m.err
+}
+
+func TestModulesProviderClose(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	p := NewProvider(logger)
+
+	var closed, failedClosed bool
+	p.Register(dummyClosingModule{newDummyNonVectorizerModule("closing"), &closed, nil})
+	p.Register(dummyClosingModule{newDummyNonVectorizerModule("failing"), &failedClosed, fmt.Errorf("boom")})
+	p.Register(newDummyNonVectorizerModule("plain"))
+
+	err := p.Close()
+	assert.ErrorContains(t, err, "failing")
+	assert.True(t, closed)
+	assert.True(t, failedClosed)
+}
@@ -13,6 +13,7 @@ package modules
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/go-openapi/strfmt"
@@ -249,6 +250,78 @@ func TestModulesWithSearchers(t *testing.T) {
 		assert.Equal(t, [][]float32{{0.1, 0.2, 0.3}, {0.11, 0.22, 0.33}}, res)
 		assert.Equal(t, "", targetVector)
 	})
+
+	t.Run("search param validates the vector space", func(t *testing.T) {
+		p := NewProvider(logger)
+		p.SetSchemaGetter(&fakeSchemaGetter{
+			schema: schema.Schema{
+				Objects: &models.Schema{
+					Classes: []*models.Class{
+						{
+							Class: "Inspection",
+							VectorConfig: map[string]models.VectorConfig{
+								"flir": {
+									Vectorizer: map[string]interface{}{"thermal-mod": map[string]interface{}{}},
+								},
+								"notes": {
+									Vectorizer: map[string]interface{}{"text-mod": map[string]interface{}{}},
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+		p.Register(newSearcherModule[[]float32]("thermal-mod").
+			withArg("nearThermal").
+			withSearcher("nearThermal", &thermalVectorForParams{generictypes.VectorForParams(func(ctx context.Context, params interface{},
+				className string,
+				findVectorFn modulecapabilities.FindVectorFn[[]float32],
+				cfg moduletools.ClassConfig,
+			) ([]float32, error) {
+				return []float32{1, 2, 3}, nil
+			})}),
+		)
+		p.Register(newSearcherModule[[]float32]("text-mod").
+			withArg("nearText").
+			withSearcher("nearText", generictypes.VectorForParams(func(ctx context.Context, params interface{},
+				className string,
+				findVectorFn modulecapabilities.FindVectorFn[[]float32],
+				cfg moduletools.ClassConfig,
+			) ([]float32, error) {
+				return []float32{4, 5, 6}, nil
+			})),
+		)
+		p.Init(context.Background(), nil, logger)
+
+		res, err := p.VectorFromSearchParam(context.Background(), "Inspection", "flir", "",
+			"nearThermal", nil, generictypes.FindVectorFn(fakeFindVector))
+		require.Nil(t, err)
+		assert.Equal(t, []float32{1, 2, 3}, res)
+
+		_, err = p.VectorFromSearchParam(context.Background(), "Inspection", "notes", "",
+			"nearThermal", nil, generictypes.FindVectorFn(fakeFindVector))
+		require.NotNil(t, err)
+		assert.Equal(t, `target vector "notes" of collection Inspection is vectorized by text-mod`, err.Error())
+	})
+}
+
+// thermalVectorForParams only accepts vector spaces of thermal-mod
+type thermalVectorForParams struct {
+	vectorForParams modulecapabilities.VectorForParams[[]float32]
+}
+
+func (v *thermalVectorForParams) VectorForParams(ctx context.Context, params interface{},
+	className string, findVectorFn modulecapabilities.FindVectorFn[[]float32], cfg moduletools.ClassConfig,
+) ([]float32, error) {
+	return v.vectorForParams.VectorForParams(ctx, params, className, findVectorFn, cfg)
+}
+
+func (v *thermalVectorForParams) ValidateVectorSpace(className, targetVector, vectorizer string) error {
+	if vectorizer != "thermal-mod" {
+		return fmt.Errorf("target vector %q of collection %s is vectorized by %s", targetVector, className, vectorizer)
+	}
+	return nil
 }
 
 func fakeFindVector(ctx context.Context, className string, id strfmt.UUID, tenant, targetVector string) ([]float32, string, error) {
@@ -50,8 +50,19 @@ type PrometheusMetrics struct {
 	LSMObjectsBucketSegmentCount        *prometheus.GaugeVec
 	LSMCompressedVecsBucketSegmentCount *prometheus.GaugeVec
 	LSMSegmentCountByLevel              *prometheus.GaugeVec
+	LSMTierAmplification                *prometheus.GaugeVec
+	LSMSegmentLevel                     *prometheus.HistogramVec
 	LSMSegmentObjects                   *prometheus.GaugeVec
 	LSMSegmentSize                      *prometheus.GaugeVec
+	LSMSegmentReadRetries               *prometheus.CounterVec
+	LSMMaintenanceLockTimeouts          *prometheus.CounterVec
+	LSMWALReplayErrors                  *prometheus.CounterVec
+	LSMMaintenanceLockWaitDurations     *prometheus.SummaryVec
+	LSMMaintenanceLockWaitTime          *prometheus.HistogramVec
+	LSMMaintenanceLockHeldDuration      *prometheus.HistogramVec
+	LSMSegmentReadDurations             *prometheus.SummaryVec
+	LSMSegmentProbes                    *prometheus.CounterVec
+	LSMSegmentProbeSeconds              *prometheus.CounterVec
 	LSMMemtableSize                     *prometheus.GaugeVec
 	LSMMemtableDurations                *prometheus.SummaryVec
 	ObjectCount                         *prometheus.GaugeVec
@@ -137,6 +148,15 @@ type PrometheusMetrics struct {
 	T2VTokensInRequest    *prometheus.HistogramVec
 	T2VRateLimitStats     *prometheus.GaugeVec
 	T2VRequestsPerBatch   *prometheus.HistogramVec
+	VectorizerLastError   *prometheus.GaugeVec
+
+	// Generative
+	GenerativeResponseCache      *prometheus.CounterVec
+	GenerativeModelWarmUpLatency *prometheus.HistogramVec
+	GenerativePrimaryFailed      *prometheus.CounterVec
+	GenerativeFallbackUsed       *prometheus.CounterVec
+	GenerativePromptTruncated    *prometheus.CounterVec
+	VectorizerTimeoutCount       *prometheus.CounterVec
 }
 
 func NewTenantOffloadMetrics(cfg Config, reg prometheus.Registerer) *TenantOffloadMetrics {
@@ -241,6 +261,17 @@ func (pm *PrometheusMetrics) DeleteShard(className, shardName string) error {
 	pm.LSMSegmentCount.DeletePartialMatch(labels)
 	pm.LSMSegmentSize.DeletePartialMatch(labels)
 	pm.LSMSegmentCountByLevel.DeletePartialMatch(labels)
+	pm.LSMTierAmplification.DeletePartialMatch(labels)
+	pm.LSMSegmentLevel.DeletePartialMatch(labels)
+	pm.LSMSegmentReadRetries.DeletePartialMatch(labels)
+	pm.LSMMaintenanceLockTimeouts.DeletePartialMatch(labels)
+	pm.LSMWALReplayErrors.DeletePartialMatch(labels)
+	pm.LSMMaintenanceLockWaitDurations.DeletePartialMatch(labels)
+	pm.LSMMaintenanceLockWaitTime.DeletePartialMatch(labels)
+	pm.LSMMaintenanceLockHeldDuration.DeletePartialMatch(labels)
+	pm.LSMSegmentReadDurations.DeletePartialMatch(labels)
+	pm.LSMSegmentProbes.DeletePartialMatch(labels)
+	pm.LSMSegmentProbeSeconds.DeletePartialMatch(labels)
 	pm.QueueSize.DeletePartialMatch(labels)
 	pm.QueueDiskUsage.DeletePartialMatch(labels)
 	pm.QueuePaused.DeletePartialMatch(labels)
@@ -425,6 +456,55 @@ func newPrometheusMetrics() *PrometheusMetrics {
 			Name: "lsm_segment_count",
 			Help: "Number of segments by level",
 		}, []string{"strategy", "class_name", "shard_name", "path", "level"}),
+		LSMTierAmplification: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "lsm_tier_amplification_ratio",
+			Help: "Size ratio of consecutive segment levels (upper/lower), expected to be 1.5-10, above 20 compaction falls behind",
+		}, []string{"strategy", "class_name", "shard_name", "path", "tier_pair"}),
+		LSMSegmentLevel: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "lsm_segment_level",
+			Help:    "Compaction level of segments as they are added to a segment group by loading, flushing or compacting",
+			Buckets: prometheus.LinearBuckets(0, 1, 20),
+		}, []string{"strategy", "class_name", "shard_name"}),
+		LSMSegmentReadRetries: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "lsm_segment_read_retries_total",
+			Help: "Number of segment reads retried after a (transient) I/O error",
+		}, []string{"class_name", "shard_name"}),
+		LSMMaintenanceLockTimeouts: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "lsm_maintenance_lock_timeouts_total",
+			Help: "Number of reads which gave up waiting for the segment group maintenance lock",
+		}, []string{"class_name", "shard_name"}),
+		LSMWALReplayErrors: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "lsm_wal_replay_errors_total",
+			Help: "Number of write-ahead-log entries which failed their checksum on recovery",
+		}, []string{"class_name", "shard_name"}),
+		LSMMaintenanceLockWaitDurations: promauto.NewSummaryVec(prometheus.SummaryOpts{
+			Name:       "lsm_maintenance_lock_wait_duration_ms",
+			Help:       "Rolling percentiles of the time spent waiting for the segment group maintenance lock on reads",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		}, []string{"class_name", "shard_name", "key_prefix"}),
+		LSMMaintenanceLockWaitTime: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "lsm_maintenance_lock_wait_seconds",
+			Help:    "Time spent waiting to acquire the segment group maintenance lock by operation",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}, []string{"operation", "class_name", "shard_name", "key_prefix"}),
+		LSMMaintenanceLockHeldDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "lsm_maintenance_lock_held_seconds",
+			Help:    "Time the segment group maintenance lock was held exclusively by operation",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}, []string{"operation", "class_name", "shard_name"}),
+		LSMSegmentReadDurations: promauto.NewSummaryVec(prometheus.SummaryOpts{
+			Name:       "lsm_segment_read_duration_ms",
+			Help:       "Rolling percentiles of the time spent reading a key from an individual segment",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		}, []string{"class_name", "shard_name", "key_prefix"}),
+		LSMSegmentProbes: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "lsm_segment_probes_total",
+			Help: "Number of segments probed by reads, by depth below the newest segment and whether the key was found",
+		}, []string{"class_name", "shard_name", "depth", "result"}),
+		LSMSegmentProbeSeconds: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "lsm_segment_probe_seconds_total",
+			Help: "Time spent probing segments on reads, by depth below the newest segment",
+		}, []string{"class_name", "shard_name", "depth"}),
 		LSMMemtableSize: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "lsm_memtable_size",
 			Help: "Size of memtable by path",
@@ -684,6 +764,36 @@ func newPrometheusMetrics() *PrometheusMetrics {
 			Help:    "Number of requests required to process an entire (user) batch",
 			Buckets: []float64{1, 2, 5, 10, 100, 1000},
 		}, []string{"vectorizer"}),
+		VectorizerLastError: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "vectorizer_last_error",
+			Help: "Whether the last health probe of the vectorizer failed (1) or succeeded (0)",
+		}, []string{"vectorizer"}),
+
+		GenerativeResponseCache: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "generative_response_cache_requests_total",
+			Help: "Number of lookups in the response cache of a generative module by result (hit or miss)",
+		}, []string{"module", "result"}),
+		GenerativeModelWarmUpLatency: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "generative_model_warm_up_duration_seconds",
+			Help:    "Duration of loading a model of a generative module at startup by result (success or failure)",
+			Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		}, []string{"module", "model", "result"}),
+		GenerativePrimaryFailed: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "generative_primary_failed_total",
+			Help: "Number of requests of a generative module failing on the primary provider with an error eligible for a fallback",
+		}, []string{"module"}),
+		GenerativeFallbackUsed: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "generative_fallback_used_total",
+			Help: "Number of requests of a generative module served by a fallback provider",
+		}, []string{"module"}),
+		GenerativePromptTruncated: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "generative_prompt_truncated_total",
+			Help: "Number of prompts of a generative module whose property values were truncated to fit the context window of the model",
+		}, []string{"module"}),
+		VectorizerTimeoutCount: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "vectorizer_timeouts_total",
+			Help: "Number of vectorizer calls of a near<Media> search which exceeded the configured vectorizerCallTimeout",
+		}, []string{"search"}),
 	}
 }
 
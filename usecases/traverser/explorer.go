@@ -35,6 +35,7 @@ import (
 	"github.com/weaviate/weaviate/entities/search"
 	"github.com/weaviate/weaviate/entities/searchparams"
 	"github.com/weaviate/weaviate/entities/storobj"
+	enthnsw "github.com/weaviate/weaviate/entities/vectorindex/hnsw"
 	"github.com/weaviate/weaviate/usecases/config"
 	"github.com/weaviate/weaviate/usecases/floatcomp"
 	"github.com/weaviate/weaviate/usecases/modulecomponents/generictypes"
@@ -54,6 +55,7 @@ type Explorer struct {
 	schemaGetter      uc.SchemaGetter
 	nearParamsVector  *nearParamsVector
 	targetParamHelper *TargetVectorParamHelper
+	crossCollection   *CrossCollectionNearThermalSearcher
 	metrics           explorerMetrics
 	config            config.Config
 }
@@ -111,6 +113,7 @@ type hybridSearcher interface {
 
 // NewExplorer with search and connector repo
 func NewExplorer(searcher objectsSearcher, logger logrus.FieldLogger, modulesProvider ModulesProvider, metrics explorerMetrics, conf config.Config) *Explorer {
+	sizer, _ := searcher.(collectionSizer)
 	return &Explorer{
 		searcher:          searcher,
 		logger:            logger,
@@ -119,12 +122,14 @@ func NewExplorer(searcher objectsSearcher, logger logrus.FieldLogger, modulesPro
 		schemaGetter:      nil, // schemaGetter is set later
 		nearParamsVector:  newNearParamsVector(modulesProvider, searcher),
 		targetParamHelper: NewTargetParamHelper(),
+		crossCollection:   NewCrossCollectionNearThermalSearcher(searcher, sizer, logger),
 		config:            conf,
 	}
 }
 
 func (e *Explorer) SetSchemaGetter(sg uc.SchemaGetter) {
 	e.schemaGetter = sg
+	e.crossCollection.SetClassReader(sg)
 }
 
 // GetClass from search and connector repo
@@ -272,17 +277,38 @@ func (e *Explorer) searchForTargets(ctx context.Context, params dto.GetParams, t
 		params.AdditionalProperties.Vector = true
 	}
 
-	res, err := e.searcher.VectorSearch(ctx, params, targetVectors, searchVectors)
-	if err != nil {
-		return nil, nil, errors.Errorf("explorer: get class: vector search: %v", err)
+	ctx = enthnsw.ContextWithFilterStrategy(ctx, extractFilterStrategyFromModuleParams(params.ModuleParams))
+
+	var res []search.Result
+	if additionalCollections := extractAdditionalCollectionsFromModuleParams(params.ModuleParams); len(additionalCollections) > 0 {
+		if params.Filters != nil {
+			return nil, nil, errors.Errorf("explorer: get class: additional collections cannot be combined with a where filter")
+		}
+		res, err = e.crossCollection.Search(ctx, params, targetVectors, searchVectors, additionalCollections)
+		if err != nil {
+			return nil, nil, errors.Errorf("explorer: get class: cross collection vector search: %v", err)
+		}
+	} else {
+		res, err = e.searcher.VectorSearch(ctx, params, targetVectors, searchVectors)
+		if err != nil {
+			return nil, nil, errors.Errorf("explorer: get class: vector search: %v", err)
+		}
 	}
 
-	if params.Pagination.Autocut > 0 {
+	if maxCertainty := extractMaxCertaintyFromModuleParams(params.ModuleParams); maxCertainty != nil {
+		res = excludeAboveMaxCertainty(res, *maxCertainty)
+	}
+
+	autocutValue := params.Pagination.Autocut
+	if autocutValue <= 0 {
+		autocutValue = extractAutocutFromModuleParams(params.ModuleParams)
+	}
+	if autocutValue > 0 {
 		scores := make([]float32, len(res))
 		for i := range res {
 			scores[i] = res[i].Dist
 		}
-		cutOff := autocut.Autocut(scores, params.Pagination.Autocut)
+		cutOff := autocut.Autocut(scores, autocutValue)
 		res = res[:cutOff]
 	}
 
@@ -871,6 +897,78 @@ func extractDistanceFromModuleParams(moduleParams map[string]interface{}) (dista
 	return
 }
 
+// extractAutocutFromModuleParams returns the autocut of a near<Media> module
+// argument, it is only used if none is set on the pagination
+func extractAutocutFromModuleParams(moduleParams map[string]interface{}) int {
+	for _, param := range moduleParams {
+		if nearParam, ok := param.(modulecapabilities.NearParamWithAutocut); ok {
+			if autocut := nearParam.GetAutocut(); autocut > 0 {
+				return autocut
+			}
+		}
+	}
+
+	return 0
+}
+
+// extractAdditionalCollectionsFromModuleParams returns the collections a
+// near<Media> module argument wants to search in addition to the queried one
+func extractAdditionalCollectionsFromModuleParams(moduleParams map[string]interface{}) []string {
+	for _, param := range moduleParams {
+		if nearParam, ok := param.(modulecapabilities.NearParamWithAdditionalCollections); ok {
+			if collections := nearParam.GetAdditionalCollections(); len(collections) > 0 {
+				return collections
+			}
+		}
+	}
+
+	return nil
+}
+
+// extractMaxCertaintyFromModuleParams returns the upper certainty bound of a
+// near<Media> module argument, nil if there is none
+func extractMaxCertaintyFromModuleParams(moduleParams map[string]interface{}) *float64 {
+	for _, param := range moduleParams {
+		if nearParam, ok := param.(modulecapabilities.NearParamWithMaxCertainty); ok {
+			if maxCertainty := nearParam.GetMaxCertainty(); maxCertainty != nil {
+				return maxCertainty
+			}
+		}
+	}
+
+	return nil
+}
+
+// extractFilterStrategyFromModuleParams returns the filter strategy a
+// near<Media> module argument wants the vector index to use, empty if it
+// keeps the one configured on the index
+func extractFilterStrategyFromModuleParams(moduleParams map[string]interface{}) string {
+	for _, param := range moduleParams {
+		if nearParam, ok := param.(modulecapabilities.NearParamWithFilterStrategy); ok {
+			if strategy := nearParam.GetFilterStrategy(); strategy != "" {
+				return strategy
+			}
+		}
+	}
+
+	return ""
+}
+
+// excludeAboveMaxCertainty drops the results which are closer than the
+// distance corresponding to maxCertainty. The order of the remaining results
+// is kept.
+func excludeAboveMaxCertainty(res []search.Result, maxCertainty float64) []search.Result {
+	minDistance := float32(additional.CertaintyToDist(maxCertainty))
+
+	filtered := res[:0]
+	for _, r := range res {
+		if r.Dist >= minDistance {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
 func (e *Explorer) trackUsageGet(res search.Results, params dto.GetParams) {
 	if len(res) == 0 {
 		return
@@ -0,0 +1,319 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package traverser
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/entities/dto"
+	enterrors "github.com/weaviate/weaviate/entities/errors"
+	"github.com/weaviate/weaviate/entities/filters"
+	"github.com/weaviate/weaviate/entities/models"
+	"github.com/weaviate/weaviate/entities/schema"
+	schemaConfig "github.com/weaviate/weaviate/entities/schema/config"
+	"github.com/weaviate/weaviate/entities/search"
+)
+
+// classReader resolves the schema of the searched collections
+type classReader interface {
+	ReadOnlyClass(className string) *models.Class
+}
+
+// collectionSizer provides an estimate of the number of objects in a
+// collection, which is used to split the limit between collections
+type collectionSizer interface {
+	CollectionObjectCountAsync(className string) int64
+}
+
+// CrossCollectionNearThermalSearcher runs a nearThermal search in the queried
+// collection as well as in its additional collections and merges the results
+// by distance. The limit is split between the collections proportionally to
+// their size, every collection contributes at least one candidate.
+//
+// Distances are only comparable if all collections are vectorized by the same
+// module and use the same distance metric, collections which don't are
+// rejected. Authorizing the additional collections is up to the caller.
+type CrossCollectionNearThermalSearcher struct {
+	searcher objectsSearcher
+	sizer    collectionSizer
+	classes  classReader
+	logger   logrus.FieldLogger
+}
+
+// NewCrossCollectionNearThermalSearcher creates a searcher for the given
+// collections. If sizer is nil, the limit is split evenly.
+func NewCrossCollectionNearThermalSearcher(searcher objectsSearcher, sizer collectionSizer,
+	logger logrus.FieldLogger,
+) *CrossCollectionNearThermalSearcher {
+	return &CrossCollectionNearThermalSearcher{
+		searcher: searcher,
+		sizer:    sizer,
+		logger:   logger,
+	}
+}
+
+// SetClassReader sets the schema source, which is only available after the
+// searcher is created, see Explorer.SetSchemaGetter
+func (s *CrossCollectionNearThermalSearcher) SetClassReader(classes classReader) {
+	s.classes = classes
+}
+
+// Search returns the merged results of all collections, ordered by distance.
+// Every result carries the collection it originates from in the "collection"
+// additional property. targetVectors are the resolved target vectors of the
+// queried collection, the ones of the additional collections are resolved
+// from the target vectors requested in params.
+func (s *CrossCollectionNearThermalSearcher) Search(ctx context.Context, params dto.GetParams,
+	targetVectors []string, searchVectors []models.Vector, additionalCollections []string,
+) ([]search.Result, error) {
+	collections := uniqueCollections(params.ClassName, additionalCollections)
+
+	paramsPerCollection := make([]dto.GetParams, len(collections))
+	targetVectorsPerCollection := make([][]string, len(collections))
+	paramsPerCollection[0], targetVectorsPerCollection[0] = params, targetVectors
+	if len(collections) > 1 {
+		queried, err := s.readClass(params.ClassName)
+		if err != nil {
+			return nil, err
+		}
+		requested := NewTargetParamHelper().GetTargetVectorsFromParams(params)
+		for i := 1; i < len(collections); i++ {
+			class, err := s.readClass(collections[i])
+			if err != nil {
+				return nil, err
+			}
+			paramsPerCollection[i], targetVectorsPerCollection[i], err = paramsForCollection(
+				params, queried, targetVectors, class, requested)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	offset, limit := params.Pagination.Offset, params.Pagination.Limit
+	total := limit
+	if limit >= 0 {
+		total = offset + limit
+	}
+	limits := s.limitsPerCollection(collections, total)
+
+	resultsPerCollection := make([][]search.Result, len(collections))
+	eg := enterrors.NewErrorGroupWrapper(s.logger)
+	for i := range collections {
+		i := i
+		eg.Go(func() error {
+			collectionParams := paramsPerCollection[i]
+			collectionParams.Pagination = &filters.Pagination{Limit: limits[i]}
+
+			res, err := s.searcher.VectorSearch(ctx, collectionParams, targetVectorsPerCollection[i], searchVectors)
+			if err != nil {
+				return fmt.Errorf("collection %s: %w", collections[i], err)
+			}
+			for j := range res {
+				if res[j].AdditionalProperties == nil {
+					res[j].AdditionalProperties = models.AdditionalProperties{}
+				}
+				res[j].AdditionalProperties["collection"] = collections[i]
+			}
+			resultsPerCollection[i] = res
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	var merged []search.Result
+	for _, res := range resultsPerCollection {
+		merged = append(merged, res...)
+	}
+	sort.SliceStable(merged, func(a, b int) bool {
+		return merged[a].Dist < merged[b].Dist
+	})
+
+	if offset >= len(merged) {
+		return []search.Result{}, nil
+	}
+	merged = merged[offset:]
+	if limit >= 0 && limit < len(merged) {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+func (s *CrossCollectionNearThermalSearcher) readClass(className string) (*models.Class, error) {
+	if s.classes == nil {
+		return nil, fmt.Errorf("schema not set")
+	}
+	class := s.classes.ReadOnlyClass(className)
+	if class == nil {
+		return nil, fmt.Errorf("collection %s not found", className)
+	}
+	return class, nil
+}
+
+// paramsForCollection derives the search params of an additional collection
+// from the ones of the queried collection. Selected properties which don't
+// exist in the collection are dropped, the tenant is only passed on to
+// multi-tenant collections. Target vectors are resolved against the
+// collection and have to be vectorized by the same modules and use the same
+// distance metrics as the ones of the queried collection.
+func paramsForCollection(params dto.GetParams, queried *models.Class, queriedTargetVectors []string,
+	class *models.Class, requestedTargetVectors []string,
+) (dto.GetParams, []string, error) {
+	targetVectors, err := targetVectorsOfClass(class, requestedTargetVectors)
+	if err != nil {
+		return dto.GetParams{}, nil, fmt.Errorf("collection %s: %w", class.Class, err)
+	}
+	if len(targetVectors) != len(queriedTargetVectors) {
+		return dto.GetParams{}, nil, fmt.Errorf("collection %s: resolved %d target vectors, collection %s %d",
+			class.Class, len(targetVectors), queried.Class, len(queriedTargetVectors))
+	}
+	for i := range targetVectors {
+		if err := compatibleVectorSpaces(queried, queriedTargetVectors[i], class, targetVectors[i]); err != nil {
+			return dto.GetParams{}, nil, err
+		}
+	}
+
+	collectionParams := params
+	collectionParams.ClassName = class.Class
+	collectionParams.Properties = selectExistingProperties(class, params.Properties)
+	if !schema.MultiTenancyEnabled(class) {
+		collectionParams.Tenant = ""
+	}
+	return collectionParams, targetVectors, nil
+}
+
+// targetVectorsOfClass resolves the requested target vectors the same way as
+// TargetVectorParamHelper.GetTargetVectorOrDefault
+func targetVectorsOfClass(class *models.Class, requested []string) ([]string, error) {
+	if len(requested) > 0 {
+		return requested, nil
+	}
+	if len(class.VectorConfig) > 1 {
+		return nil, fmt.Errorf("multiple vectorizers configuration found, please specify target vector name")
+	}
+	for name := range class.VectorConfig {
+		return []string{name}, nil
+	}
+	return []string{""}, nil
+}
+
+// compatibleVectorSpaces returns an error unless the distances of both vector
+// spaces can be compared, i.e. both are vectorized by the same module and use
+// the same distance metric
+func compatibleVectorSpaces(queried *models.Class, queriedTargetVector string,
+	class *models.Class, targetVector string,
+) error {
+	queriedVectorizer := vectorizerOfTargetVector(queried, queriedTargetVector)
+	if vectorizer := vectorizerOfTargetVector(class, targetVector); vectorizer != queriedVectorizer {
+		return fmt.Errorf("collection %s is vectorized by %q, collection %s by %q",
+			class.Class, vectorizer, queried.Class, queriedVectorizer)
+	}
+
+	queriedDistance, err := distanceOfTargetVector(queried, queriedTargetVector)
+	if err != nil {
+		return err
+	}
+	distance, err := distanceOfTargetVector(class, targetVector)
+	if err != nil {
+		return err
+	}
+	if distance != queriedDistance {
+		return fmt.Errorf("collection %s uses distance metric %q, collection %s %q",
+			class.Class, distance, queried.Class, queriedDistance)
+	}
+	return nil
+}
+
+func vectorizerOfTargetVector(class *models.Class, targetVector string) string {
+	if vectorConfig, ok := class.VectorConfig[targetVector]; ok {
+		if vectorizer, ok := vectorConfig.Vectorizer.(map[string]interface{}); ok && len(vectorizer) == 1 {
+			for moduleName := range vectorizer {
+				return moduleName
+			}
+		}
+	}
+	return class.Vectorizer
+}
+
+func distanceOfTargetVector(class *models.Class, targetVector string) (string, error) {
+	vectorConfigs, err := schemaConfig.TypeAssertVectorIndex(class, []string{targetVector})
+	if err != nil {
+		return "", err
+	}
+	return vectorConfigs[0].DistanceName(), nil
+}
+
+// selectExistingProperties drops the selected properties which don't exist in
+// class, so the queried collection's selection can be applied to others
+func selectExistingProperties(class *models.Class, props search.SelectProperties) search.SelectProperties {
+	if len(props) == 0 {
+		return props
+	}
+
+	existing := make(search.SelectProperties, 0, len(props))
+	for _, prop := range props {
+		if _, err := schema.GetPropertyByName(class, prop.Name); err == nil {
+			existing = append(existing, prop)
+		}
+	}
+	return existing
+}
+
+// limitsPerCollection splits total between the collections proportionally to
+// their size. A negative total, i.e. no limit, is passed to every collection
+// as is.
+func (s *CrossCollectionNearThermalSearcher) limitsPerCollection(collections []string, total int) []int {
+	limits := make([]int, len(collections))
+	if total < 0 {
+		for i := range limits {
+			limits[i] = total
+		}
+		return limits
+	}
+
+	sizes := make([]int64, len(collections))
+	var sum int64
+	if s.sizer != nil {
+		for i, collection := range collections {
+			sizes[i] = s.sizer.CollectionObjectCountAsync(collection)
+			sum += sizes[i]
+		}
+	}
+
+	for i := range collections {
+		share := (total + len(collections) - 1) / len(collections)
+		if sum > 0 {
+			// round up, so that rounding never leaves the merged results short
+			share = int((int64(total)*sizes[i] + sum - 1) / sum)
+		}
+		limits[i] = MaxInt(MinInt(share, total), 1)
+	}
+	return limits
+}
+
+func uniqueCollections(className string, additionalCollections []string) []string {
+	collections := []string{className}
+	seen := map[string]struct{}{className: {}}
+	for _, collection := range additionalCollections {
+		if _, ok := seen[collection]; ok {
+			continue
+		}
+		seen[collection] = struct{}{}
+		collections = append(collections, collection)
+	}
+	return collections
+}
@@ -0,0 +1,270 @@
+//                           _       _
+// __      _____  __ ___   ___  __ _| |_ ___
+// \ \ /\ / / _ \/ _` \ \ / / |/ _` | __/ _ \
+//  \ V  V /  __/ (_| |\ V /| | (_| | ||  __/
+//   \_/\_/ \___|\__,_| \_/ |_|\__,_|\__\___|
+//
+//  Copyright © 2016 - 2024 Weaviate B.V. All rights reserved.
+//
+//  CONTACT: hello@weaviate.io
+//
+
+package traverser
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/dto"
+	"github.com/weaviate/weaviate/entities/filters"
+	"github.com/weaviate/weaviate/entities/models"
+	"github.com/weaviate/weaviate/entities/schema"
+	"github.com/weaviate/weaviate/entities/search"
+	"github.com/weaviate/weaviate/entities/vectorindex/hnsw"
+	"github.com/weaviate/weaviate/usecases/auth/authorization/mocks"
+	"github.com/weaviate/weaviate/usecases/modulecomponents/arguments/nearThermal"
+)
+
+type fakeAdditionalCollectionsParam []string
+
+func (f fakeAdditionalCollectionsParam) GetAdditionalCollections() []string {
+	return f
+}
+
+func thermalClass(name, vectorizer, distance string) *models.Class {
+	return &models.Class{
+		Class:             name,
+		Vectorizer:        vectorizer,
+		VectorIndexConfig: hnsw.UserConfig{Distance: distance},
+		Properties:        []*models.Property{{Name: "name", DataType: schema.DataTypeText.PropString()}},
+	}
+}
+
+func thermalSchemaGetter(classes ...*models.Class) *fakeSchemaGetter {
+	return &fakeSchemaGetter{schema: schema.Schema{Objects: &models.Schema{Classes: classes}}}
+}
+
+type fakeCollectionSizer map[string]int64
+
+func (f fakeCollectionSizer) CollectionObjectCountAsync(className string) int64 {
+	return f[className]
+}
+
+func TestCrossCollectionNearThermalSearcher(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	searchVectors := []models.Vector{[]float32{1, 2, 3}}
+
+	forClass := func(className string, limit int) interface{} {
+		return mock.MatchedBy(func(p dto.GetParams) bool {
+			return p.ClassName == className && p.Pagination.Limit == limit
+		})
+	}
+
+	classes := thermalSchemaGetter(thermalClass("Main", "multi2vec-bind", "cosine"),
+		thermalClass("Other", "multi2vec-bind", "cosine"))
+
+	t.Run("results are merged by distance", func(t *testing.T) {
+		searcher := &fakeVectorSearcher{}
+		searcher.On("VectorSearch", forClass("Main", 2), searchVectors).
+			Return([]search.Result{{ClassName: "Main", Dist: 0.1}, {ClassName: "Main", Dist: 0.4}}, nil)
+		searcher.On("VectorSearch", forClass("Other", 2), searchVectors).
+			Return([]search.Result{{ClassName: "Other", Dist: 0.2}, {ClassName: "Other", Dist: 0.3}}, nil)
+
+		s := NewCrossCollectionNearThermalSearcher(searcher, nil, logger)
+		s.SetClassReader(classes)
+		res, err := s.Search(context.Background(), dto.GetParams{
+			ClassName:  "Main",
+			Pagination: &filters.Pagination{Limit: 3},
+		}, []string{""}, searchVectors, []string{"Other", "Main"})
+		require.Nil(t, err)
+
+		require.Len(t, res, 3)
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, []float32{res[0].Dist, res[1].Dist, res[2].Dist})
+		assert.Equal(t, "Main", res[0].AdditionalProperties["collection"])
+		assert.Equal(t, "Other", res[1].AdditionalProperties["collection"])
+		assert.Equal(t, "Other", res[2].AdditionalProperties["collection"])
+	})
+
+	t.Run("offset is applied to the merged results", func(t *testing.T) {
+		searcher := &fakeVectorSearcher{}
+		searcher.On("VectorSearch", forClass("Main", 2), searchVectors).
+			Return([]search.Result{{Dist: 0.1}, {Dist: 0.4}}, nil)
+		searcher.On("VectorSearch", forClass("Other", 2), searchVectors).
+			Return([]search.Result{{Dist: 0.2}, {Dist: 0.3}}, nil)
+
+		s := NewCrossCollectionNearThermalSearcher(searcher, nil, logger)
+		s.SetClassReader(classes)
+		res, err := s.Search(context.Background(), dto.GetParams{
+			ClassName:  "Main",
+			Pagination: &filters.Pagination{Offset: 2, Limit: 2},
+		}, []string{""}, searchVectors, []string{"Other"})
+		require.Nil(t, err)
+
+		require.Len(t, res, 2)
+		assert.Equal(t, []float32{0.3, 0.4}, []float32{res[0].Dist, res[1].Dist})
+	})
+
+	t.Run("properties and tenant are resolved per collection", func(t *testing.T) {
+		tenantClass := thermalClass("Tenanted", "multi2vec-bind", "cosine")
+		tenantClass.MultiTenancyConfig = &models.MultiTenancyConfig{Enabled: true}
+		classes := thermalSchemaGetter(thermalClass("Main", "multi2vec-bind", "cosine"),
+			thermalClass("Other", "multi2vec-bind", "cosine"), tenantClass)
+		classes.schema.Objects.Classes[0].Properties = append(classes.schema.Objects.Classes[0].Properties,
+			&models.Property{Name: "onlyInMain", DataType: schema.DataTypeText.PropString()})
+
+		props := search.SelectProperties{{Name: "name", IsPrimitive: true}, {Name: "onlyInMain", IsPrimitive: true}}
+		searcher := &fakeVectorSearcher{}
+		searcher.On("VectorSearch", mock.MatchedBy(func(p dto.GetParams) bool {
+			return p.ClassName == "Main" && len(p.Properties) == 2 && p.Tenant == "t1"
+		}), searchVectors).Return([]search.Result{}, nil)
+		searcher.On("VectorSearch", mock.MatchedBy(func(p dto.GetParams) bool {
+			return p.ClassName == "Other" && len(p.Properties) == 1 && p.Tenant == ""
+		}), searchVectors).Return([]search.Result{}, nil)
+		searcher.On("VectorSearch", mock.MatchedBy(func(p dto.GetParams) bool {
+			return p.ClassName == "Tenanted" && len(p.Properties) == 1 && p.Tenant == "t1"
+		}), searchVectors).Return([]search.Result{}, nil)
+
+		s := NewCrossCollectionNearThermalSearcher(searcher, nil, logger)
+		s.SetClassReader(classes)
+		_, err := s.Search(context.Background(), dto.GetParams{
+			ClassName:  "Main",
+			Properties: props,
+			Tenant:     "t1",
+			Pagination: &filters.Pagination{Limit: 3},
+		}, []string{""}, searchVectors, []string{"Other", "Tenanted"})
+		require.Nil(t, err)
+		searcher.AssertExpectations(t)
+	})
+
+	t.Run("incompatible collections are rejected", func(t *testing.T) {
+		classes := thermalSchemaGetter(thermalClass("Main", "multi2vec-bind", "cosine"),
+			thermalClass("OtherVectorizer", "multi2vec-clip", "cosine"),
+			thermalClass("OtherDistance", "multi2vec-bind", "l2-squared"))
+
+		for _, collection := range []string{"OtherVectorizer", "OtherDistance", "Missing"} {
+			s := NewCrossCollectionNearThermalSearcher(&fakeVectorSearcher{}, nil, logger)
+			s.SetClassReader(classes)
+			_, err := s.Search(context.Background(), dto.GetParams{
+				ClassName:  "Main",
+				Pagination: &filters.Pagination{Limit: 3},
+			}, []string{""}, searchVectors, []string{collection})
+			require.NotNil(t, err, collection)
+			assert.Contains(t, err.Error(), collection)
+		}
+	})
+}
+
+func TestTraverser_AuthorizeAdditionalCollections(t *testing.T) {
+	tenantClass := thermalClass("Tenanted", "multi2vec-bind", "cosine")
+	tenantClass.MultiTenancyConfig = &models.MultiTenancyConfig{Enabled: true}
+	classes := thermalSchemaGetter(thermalClass("Main", "multi2vec-bind", "cosine"),
+		thermalClass("Other", "multi2vec-bind", "cosine"), tenantClass)
+	params := dto.GetParams{
+		ClassName:    "Main",
+		Tenant:       "t1",
+		ModuleParams: map[string]interface{}{"nearThermal": fakeAdditionalCollectionsParam{"Other", "Tenanted"}},
+	}
+
+	t.Run("every additional collection is authorized", func(t *testing.T) {
+		authorizer := mocks.NewMockAuthorizer()
+		tr := &Traverser{authorizer: authorizer, schemaGetter: classes}
+		require.Nil(t, tr.authorizeAdditionalCollections(nil, params))
+
+		calls := authorizer.Calls()
+		require.Len(t, calls, 2)
+		assert.Contains(t, calls[0].Resources[0], "Other")
+		assert.NotContains(t, calls[0].Resources[0], "t1")
+		assert.Contains(t, calls[1].Resources[0], "Tenanted")
+		assert.Contains(t, calls[1].Resources[0], "t1")
+	})
+
+	t.Run("unauthorized collections are rejected", func(t *testing.T) {
+		authorizer := mocks.NewMockAuthorizer()
+		authorizer.SetErr(errors.New("forbidden"))
+		tr := &Traverser{authorizer: authorizer, schemaGetter: classes}
+		assert.EqualError(t, tr.authorizeAdditionalCollections(nil, params), "forbidden")
+	})
+}
+
+func TestCrossCollectionNearThermalSearcher_LimitsPerCollection(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+
+	tests := []struct {
+		name     string
+		sizer    collectionSizer
+		total    int
+		expected []int
+	}{
+		{
+			name:     "without sizer the limit is split evenly",
+			total:    10,
+			expected: []int{4, 4, 4},
+		},
+		{
+			name:     "limit is proportional to the collection size",
+			sizer:    fakeCollectionSizer{"A": 700, "B": 200, "C": 100},
+			total:    10,
+			expected: []int{7, 2, 1},
+		},
+		{
+			name:     "empty collections still contribute a candidate",
+			sizer:    fakeCollectionSizer{"A": 1000},
+			total:    10,
+			expected: []int{10, 1, 1},
+		},
+		{
+			name:     "empty collections only",
+			sizer:    fakeCollectionSizer{},
+			total:    3,
+			expected: []int{1, 1, 1},
+		},
+		{
+			name:     "no limit",
+			sizer:    fakeCollectionSizer{"A": 700, "B": 200, "C": 100},
+			total:    -1,
+			expected: []int{-1, -1, -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewCrossCollectionNearThermalSearcher(nil, tt.sizer, logger)
+			assert.Equal(t, tt.expected, s.limitsPerCollection([]string{"A", "B", "C"}, tt.total))
+		})
+	}
+}
+
+func TestTraverser_ValidateGetCertaintyParams(t *testing.T) {
+	classes := thermalSchemaGetter(thermalClass("Cosine", "multi2vec-bind", "cosine"),
+		thermalClass("Dot", "multi2vec-bind", "dot"))
+	tr := &Traverser{schemaGetter: classes, targetVectorParamHelper: NewTargetParamHelper()}
+	maxCertainty := 0.9
+	withMaxCertainty := map[string]interface{}{
+		"nearThermal": &nearThermal.NearThermalParams{Thermal: "base64;encoded", MaxCertainty: &maxCertainty},
+	}
+
+	t.Run("maxCertainty with cosine distance", func(t *testing.T) {
+		assert.Nil(t, tr.validateGetCertaintyParams(dto.GetParams{
+			ClassName: "Cosine", ModuleParams: withMaxCertainty,
+		}))
+	})
+
+	t.Run("maxCertainty with other distance", func(t *testing.T) {
+		err := tr.validateGetCertaintyParams(dto.GetParams{
+			ClassName: "Dot", ModuleParams: withMaxCertainty,
+		})
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "dot")
+	})
+
+	t.Run("without certainty", func(t *testing.T) {
+		assert.Nil(t, tr.validateGetCertaintyParams(dto.GetParams{
+			ClassName:    "Dot",
+			ModuleParams: map[string]interface{}{"nearThermal": &nearThermal.NearThermalParams{Thermal: "base64;encoded"}},
+		}))
+	})
+}
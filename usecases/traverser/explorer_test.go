@@ -31,6 +31,7 @@ import (
 	"github.com/weaviate/weaviate/entities/searchparams"
 	"github.com/weaviate/weaviate/entities/vectorindex/hnsw"
 	"github.com/weaviate/weaviate/usecases/config"
+	"github.com/weaviate/weaviate/usecases/modulecomponents/arguments/nearThermal"
 )
 
 var defaultConfig = config.Config{
@@ -2906,3 +2907,67 @@ func getFakeModulesProviderWithCustomExtenders(
 func getFakeModulesProvider() ModulesProvider {
 	return &fakeModulesProvider{}
 }
+
+func Test_Explorer_ExtractAutocutFromModuleParams(t *testing.T) {
+	t.Run("without module params", func(t *testing.T) {
+		assert.Equal(t, 0, extractAutocutFromModuleParams(nil))
+	})
+
+	t.Run("with nearThermal autocut", func(t *testing.T) {
+		moduleParams := map[string]interface{}{
+			"nearThermal": &nearThermal.NearThermalParams{Thermal: "base64;encoded", Autocut: 2},
+		}
+		assert.Equal(t, 2, extractAutocutFromModuleParams(moduleParams))
+	})
+
+	t.Run("with nearThermal autocut disabled", func(t *testing.T) {
+		moduleParams := map[string]interface{}{
+			"nearThermal": &nearThermal.NearThermalParams{Thermal: "base64;encoded"},
+		}
+		assert.Equal(t, 0, extractAutocutFromModuleParams(moduleParams))
+	})
+}
+
+func Test_Explorer_ExtractFilterStrategyFromModuleParams(t *testing.T) {
+	t.Run("without module params", func(t *testing.T) {
+		assert.Equal(t, "", extractFilterStrategyFromModuleParams(nil))
+	})
+
+	t.Run("with nearThermal filterStrategy", func(t *testing.T) {
+		moduleParams := map[string]interface{}{
+			"nearThermal": &nearThermal.NearThermalParams{Thermal: "base64;encoded", FilterStrategy: "acorn"},
+		}
+		assert.Equal(t, "acorn", extractFilterStrategyFromModuleParams(moduleParams))
+	})
+
+	t.Run("with nearThermal without filterStrategy", func(t *testing.T) {
+		moduleParams := map[string]interface{}{
+			"nearThermal": &nearThermal.NearThermalParams{Thermal: "base64;encoded"},
+		}
+		assert.Equal(t, "", extractFilterStrategyFromModuleParams(moduleParams))
+	})
+}
+
+func Test_Explorer_ExcludeAboveMaxCertainty(t *testing.T) {
+	t.Run("without module params", func(t *testing.T) {
+		assert.Nil(t, extractMaxCertaintyFromModuleParams(nil))
+	})
+
+	t.Run("with nearThermal maxCertainty", func(t *testing.T) {
+		maxCertainty := 0.9
+		moduleParams := map[string]interface{}{
+			"nearThermal": &nearThermal.NearThermalParams{Thermal: "base64;encoded", MaxCertainty: &maxCertainty},
+		}
+		assert.Equal(t, &maxCertainty, extractMaxCertaintyFromModuleParams(moduleParams))
+	})
+
+	t.Run("results closer than maxCertainty are excluded", func(t *testing.T) {
+		// a certainty of 0.9 corresponds to a distance of 0.2
+		res := []search.Result{{ID: "1", Dist: 0.05}, {ID: "2", Dist: 0.2}, {ID: "3", Dist: 0.1}, {ID: "4", Dist: 0.5}}
+
+		filtered := excludeAboveMaxCertainty(res, 0.9)
+		require.Len(t, filtered, 2)
+		assert.Equal(t, strfmt.UUID("2"), filtered[0].ID)
+		assert.Equal(t, strfmt.UUID("4"), filtered[1].ID)
+	})
+}
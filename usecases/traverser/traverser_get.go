@@ -22,6 +22,7 @@ import (
 	"github.com/weaviate/weaviate/entities/dto"
 	enterrors "github.com/weaviate/weaviate/entities/errors"
 	"github.com/weaviate/weaviate/entities/models"
+	"github.com/weaviate/weaviate/entities/schema"
 	"github.com/weaviate/weaviate/entities/search"
 	"github.com/weaviate/weaviate/usecases/auth/authorization"
 )
@@ -60,19 +61,42 @@ func (t *Traverser) GetClass(ctx context.Context, principal *models.Principal,
 		return nil, errors.Wrap(err, "invalid 'where' filter")
 	}
 
-	certainty := ExtractCertaintyFromParams(params)
-	if certainty != 0 || params.AdditionalProperties.Certainty {
-		// if certainty is provided as input, we must ensure
-		// that the vector index is configured to use cosine
-		// distance
-		if err := t.validateGetDistanceParams(params); err != nil {
-			return nil, err
-		}
+	if err := t.authorizeAdditionalCollections(principal, params); err != nil {
+		return nil, err
+	}
+
+	if err := t.validateGetCertaintyParams(params); err != nil {
+		return nil, err
 	}
 
 	return t.explorer.GetClass(ctx, params)
 }
 
+// authorizeAdditionalCollections authorizes reading the additional collections
+// of a cross collection near<Media> search. The queried collection itself is
+// authorized by the caller.
+func (t *Traverser) authorizeAdditionalCollections(principal *models.Principal, params dto.GetParams) error {
+	for _, collection := range extractAdditionalCollectionsFromModuleParams(params.ModuleParams) {
+		class := t.schemaGetter.ReadOnlyClass(collection)
+
+		// the tenant is only passed on to multi-tenant collections, see
+		// paramsForCollection
+		var tenant string
+		if class != nil && schema.MultiTenancyEnabled(class) {
+			tenant = params.Tenant
+		}
+		if err := t.authorizer.Authorize(principal, authorization.READ,
+			authorization.ShardsData(collection, tenant)...); err != nil {
+			return err
+		}
+
+		if class == nil {
+			return fmt.Errorf("additional collection %s not found", collection)
+		}
+	}
+	return nil
+}
+
 // probeForRefDepthLimit checks to ensure reference nesting depth doesn't exceed the limit
 // provided by QUERY_CROSS_REFERENCE_DEPTH_LIMIT
 func (t *Traverser) probeForRefDepthLimit(props search.SelectProperties) error {
@@ -102,6 +102,18 @@ func (t *Traverser) validateExploreDistanceParams(params ExploreParams, distType
 	return nil
 }
 
+// validateGetCertaintyParams ensures that the vector index is configured to
+// use cosine distance if a certainty is provided as input, used as the
+// maxCertainty bound of a near<Media> argument, or requested as an additional
+// property. Certainties can't be derived from other distances.
+func (t *Traverser) validateGetCertaintyParams(params dto.GetParams) error {
+	if ExtractCertaintyFromParams(params) == 0 && !params.AdditionalProperties.Certainty &&
+		extractMaxCertaintyFromModuleParams(params.ModuleParams) == nil {
+		return nil
+	}
+	return t.validateGetDistanceParams(params)
+}
+
 func (t *Traverser) validateGetDistanceParams(params dto.GetParams) error {
 	class := t.schemaGetter.ReadOnlyClass(params.ClassName)
 	if class == nil {